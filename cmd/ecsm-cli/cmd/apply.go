@@ -0,0 +1,176 @@
+// file: cmd/ecsm-cli/cmd/apply.go
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/fx147/ecsm-operator/internal/ecsm-cli/util"
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/registry"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/retry"
+)
+
+// manifestOptions 是 apply/create/delete 共用的、与清单文件相关的标志。
+type manifestOptions struct {
+	filenames []string
+	recursive bool
+	namespace string
+}
+
+func (o *manifestOptions) addFlags(cmd *cobra.Command, usage string) {
+	cmd.Flags().StringSliceVarP(&o.filenames, "filename", "f", nil, usage)
+	cmd.Flags().BoolVarP(&o.recursive, "recursive", "R", false, "Process the directory used in -f, --filename recursively")
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", "", "The namespace for objects that do not specify one (default \"default\")")
+	cmd.MarkFlagRequired("filename")
+}
+
+// load 读取所有清单，并为每个对象确定命名空间。
+// 显式指定的 --namespace 与清单中的命名空间不一致时返回错误。
+func (o *manifestOptions) load(stdin io.Reader) ([]util.Manifest, error) {
+	manifests, err := util.ReadManifests(o.filenames, o.recursive, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range manifests {
+		svc := m.Service
+		switch {
+		case svc.Namespace == "" && o.namespace != "":
+			svc.Namespace = o.namespace
+		case svc.Namespace == "":
+			svc.Namespace = metav1.NamespaceDefault
+		case o.namespace != "" && svc.Namespace != o.namespace:
+			return nil, fmt.Errorf("the namespace from the provided object %q does not match the namespace %q. You must pass '--namespace=%s' to perform this operation",
+				svc.Namespace, o.namespace, svc.Namespace)
+		}
+	}
+	return manifests, nil
+}
+
+// newApplyCmd 创建 apply 命令
+func newApplyCmd() *cobra.Command {
+	o := &manifestOptions{}
+	w := &waitOptions{}
+	cmd := &cobra.Command{
+		Use:   "apply -f FILENAME",
+		Short: "Apply a configuration to an ECSMService by file name or stdin",
+		Long: `Apply a configuration to an ECSMService by file name or stdin.
+The ECSMService will be created in the ECSM Registry if it doesn't exist yet,
+and its spec, labels and annotations will be replaced otherwise.
+The ecsm-operator then reconciles the ECSM platform towards the new state.
+ECSMServices that are being deleted cannot be applied until the deletion finishes.
+With --wait, the command watches the ECSMService status the ecsm-operator writes back
+to the Registry, so it only returns once the ecsm-operator has observed the rollout.
+
+JSON and YAML formats are accepted, multiple YAML documents may be separated by '---'.`,
+		Example: `  # Apply the ECSMService in service.yaml
+  ecsm-cli apply -f service.yaml
+
+  # Apply all manifests in a directory
+  ecsm-cli apply -f ./services/
+
+  # Apply a manifest from stdin
+  cat service.yaml | ecsm-cli apply -f -
+
+  # Apply a manifest and wait until all instances are online
+  ecsm-cli apply -f service.yaml --wait --timeout=2m`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manifests, err := o.load(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+
+			reg, closeRegistry, err := util.NewRegistryFromFlags()
+			if err != nil {
+				return err
+			}
+			defer closeRegistry()
+
+			ctx := context.Background()
+			var errs []error
+			// applied 记录写入成功的对象，--wait 时等待它们
+			var applied []util.Manifest
+			for _, m := range manifests {
+				result, action, err := applyService(ctx, reg, m.Service)
+				if err != nil {
+					errs = append(errs, objectError(m, err))
+					continue
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", util.ObjectName(m.Service), action)
+				applied = append(applied, util.Manifest{Source: m.Source, Service: result})
+			}
+			if w.wait {
+				errs = append(errs, w.waitForRollouts(ctx, reg, applied, cmd.OutOrStdout())...)
+			}
+			// 某个对象出错不影响其余对象，所有错误在最后一并返回
+			return utilerrors.NewAggregate(errs)
+		},
+	}
+
+	o.addFlags(cmd, "Filename, directory, or '-' (stdin) of the ECSMService manifests to apply")
+	w.addFlags(cmd, "If true, wait for the ecsm-operator to roll out the applied ECSMServices before returning")
+	cmd.SilenceUsage = true
+	return cmd
+}
+
+// applyService 创建或更新 Registry 中的 svc，返回写入后的对象和 "created"、"configured" 或 "unchanged"。
+//
+// 清单中没有 resourceVersion 时，更新基于刚刚读取到的版本，读取之后有其他写入（例如 ecsm-operator
+// 添加 finalizer 或开始删除）时重新读取再试；清单中指定了 resourceVersion 时，版本不一致直接返回 Conflict。
+// 已经在删除中的对象不能被 apply，否则删除完成后变更会悄悄丢失。
+func applyService(ctx context.Context, reg *registry.Registry, svc *ecsmv1.ECSMService) (*ecsmv1.ECSMService, string, error) {
+	var result *ecsmv1.ECSMService
+	var action string
+	apply := func() error {
+		existing, err := reg.GetService(ctx, svc.Namespace, svc.Name)
+		if errors.IsNotFound(err) {
+			result, err = reg.CreateService(ctx, svc)
+			action = "created"
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if existing.DeletionTimestamp != nil {
+			return fmt.Errorf("the object is being deleted, wait for the deletion to finish before applying it again")
+		}
+
+		toUpdate := svc.DeepCopy()
+		if toUpdate.ResourceVersion == "" {
+			toUpdate.ResourceVersion = existing.ResourceVersion
+		}
+		result, err = reg.UpdateService(ctx, toUpdate)
+		if err != nil {
+			return err
+		}
+		action = "configured"
+		if result.ResourceVersion == existing.ResourceVersion {
+			action = "unchanged"
+		}
+		return nil
+	}
+
+	var err error
+	if svc.ResourceVersion != "" {
+		err = apply()
+	} else {
+		err = retry.RetryOnConflict(retry.DefaultRetry, apply)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return result, action, nil
+}
+
+// objectError 为处理某个对象时发生的错误附上对象名称及其来源。
+func objectError(m util.Manifest, err error) error {
+	return fmt.Errorf("%s (from %s): %w", util.ObjectName(m.Service), m.Source, err)
+}
@@ -0,0 +1,102 @@
+// file: cmd/ecsm-cli/cmd/apply_test.go
+
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/registry"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newTestRegistry(t *testing.T) *registry.Registry {
+	scheme := runtime.NewScheme()
+	if err := ecsmv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	store, err := registry.NewFileStore(t.TempDir(), scheme)
+	if err != nil {
+		t.Fatalf("Failed to create FileStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return registry.NewRegistry(store)
+}
+
+// newManifestService 返回一个和从清单中读取到的一样、没有 resourceVersion 的 ECSMService。
+func newManifestService(name, image string) *ecsmv1.ECSMService {
+	replicas := int32(1)
+	return &ecsmv1.ECSMService{
+		TypeMeta:   metav1.TypeMeta{APIVersion: ecsmv1.SchemeGroupVersion.String(), Kind: "ECSMService"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: name},
+		Spec: ecsmv1.ECSMServiceSpec{
+			DeploymentStrategy: ecsmv1.DeploymentStrategy{
+				Type:     ecsmv1.DeploymentStrategyTypeDynamic,
+				Replicas: &replicas,
+				NodePool: []string{"node-1"},
+			},
+			Template: ecsmv1.ContainerTemplateSpec{Image: image},
+		},
+	}
+}
+
+func TestApplyService(t *testing.T) {
+	ctx := context.Background()
+	reg := newTestRegistry(t)
+
+	created, action, err := applyService(ctx, reg, newManifestService("web", "nginx@1.0"))
+	if err != nil || action != "created" {
+		t.Fatalf("Expected the first apply to create the object, but got action %q, error: %v", action, err)
+	}
+
+	_, action, err = applyService(ctx, reg, newManifestService("web", "nginx@1.0"))
+	if err != nil || action != "unchanged" {
+		t.Errorf("Expected applying the same manifest to be unchanged, but got action %q, error: %v", action, err)
+	}
+
+	configured, action, err := applyService(ctx, reg, newManifestService("web", "nginx@2.0"))
+	if err != nil || action != "configured" {
+		t.Fatalf("Expected a changed manifest to be configured, but got action %q, error: %v", action, err)
+	}
+	if configured.Generation != created.Generation+1 {
+		t.Errorf("Expected generation %d, but got %d", created.Generation+1, configured.Generation)
+	}
+
+	// 清单中显式指定的过期 resourceVersion 不会被替换成存储中的版本
+	stale := newManifestService("web", "nginx@3.0")
+	stale.ResourceVersion = created.ResourceVersion
+	if _, _, err := applyService(ctx, reg, stale); !errors.IsConflict(err) {
+		t.Errorf("Expected a Conflict error for a stale resourceVersion, but got: %v", err)
+	}
+}
+
+func TestApplyService_RefusesObjectBeingDeleted(t *testing.T) {
+	ctx := context.Background()
+	reg := newTestRegistry(t)
+
+	if _, _, err := applyService(ctx, reg, newManifestService("web", "nginx@1.0")); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if _, err := reg.AddServiceFinalizer(ctx, metav1.NamespaceDefault, "web", ecsmv1.ECSMServiceFinalizer); err != nil {
+		t.Fatalf("AddServiceFinalizer failed: %v", err)
+	}
+	if err := reg.DeleteService(ctx, metav1.NamespaceDefault, "web"); err != nil {
+		t.Fatalf("DeleteService failed: %v", err)
+	}
+
+	_, _, err := applyService(ctx, reg, newManifestService("web", "nginx@2.0"))
+	if err == nil || !strings.Contains(err.Error(), "being deleted") {
+		t.Fatalf("Expected apply to refuse an object being deleted, but got: %v", err)
+	}
+	got, err := reg.GetService(ctx, metav1.NamespaceDefault, "web")
+	if err != nil {
+		t.Fatalf("GetService failed: %v", err)
+	}
+	if got.Spec.Template.Image != "nginx@1.0" {
+		t.Errorf("Expected the object being deleted to keep its spec, but image is %q", got.Spec.Template.Image)
+	}
+}
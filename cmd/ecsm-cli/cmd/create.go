@@ -0,0 +1,63 @@
+// file: cmd/ecsm-cli/cmd/create.go
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fx147/ecsm-operator/internal/ecsm-cli/util"
+	"github.com/spf13/cobra"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// newCreateCmd 创建 create 命令
+func newCreateCmd() *cobra.Command {
+	o := &manifestOptions{}
+	w := &waitOptions{}
+	cmd := &cobra.Command{
+		Use:   "create -f FILENAME",
+		Short: "Create ECSMServices from a file or from stdin",
+		Long: `Create ECSMServices in the ECSM Registry from a file or from stdin.
+Unlike apply, create fails for objects that already exist.
+
+JSON and YAML formats are accepted, multiple YAML documents may be separated by '---'.`,
+		Example: `  # Create the ECSMService in service.yaml
+  ecsm-cli create -f service.yaml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manifests, err := o.load(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+
+			reg, closeRegistry, err := util.NewRegistryFromFlags()
+			if err != nil {
+				return err
+			}
+			defer closeRegistry()
+
+			ctx := context.Background()
+			var errs []error
+			var created []util.Manifest
+			for _, m := range manifests {
+				svc, err := reg.CreateService(ctx, m.Service)
+				if err != nil {
+					errs = append(errs, objectError(m, err))
+					continue
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s created\n", util.ObjectName(m.Service))
+				created = append(created, util.Manifest{Source: m.Source, Service: svc})
+			}
+			if w.wait {
+				errs = append(errs, w.waitForRollouts(ctx, reg, created, cmd.OutOrStdout())...)
+			}
+			return utilerrors.NewAggregate(errs)
+		},
+	}
+
+	o.addFlags(cmd, "Filename, directory, or '-' (stdin) of the ECSMService manifests to create")
+	w.addFlags(cmd, "If true, wait for the ecsm-operator to deploy the created ECSMServices before returning")
+	cmd.SilenceUsage = true
+	return cmd
+}
@@ -0,0 +1,79 @@
+// file: cmd/ecsm-cli/cmd/delete.go
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fx147/ecsm-operator/internal/ecsm-cli/util"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// newDeleteCmd 创建 delete 命令
+func newDeleteCmd() *cobra.Command {
+	o := &manifestOptions{}
+	w := &waitOptions{}
+	var ignoreNotFound bool
+	cmd := &cobra.Command{
+		Use:   "delete -f FILENAME",
+		Short: "Delete ECSMServices by file name or stdin",
+		Long: `Delete the ECSMServices described in the given manifests from the ECSM Registry.
+Only the namespace and name of each object are used.
+The ecsm-operator then removes the corresponding services from the ECSM platform,
+use --wait to return only after it has done so.
+With --wait, the command also follows the ECSM delete transaction the ecsm-operator
+records on the ECSMService, and reports a failed deletion without waiting for --timeout.`,
+		Example: `  # Delete the ECSMService described in service.yaml
+  ecsm-cli delete -f service.yaml
+
+  # Delete the ECSMService and wait until it is removed from the ECSM platform
+  ecsm-cli delete -f service.yaml --wait`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manifests, err := o.load(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+
+			reg, closeRegistry, err := util.NewRegistryFromFlags()
+			if err != nil {
+				return err
+			}
+			defer closeRegistry()
+
+			ctx := context.Background()
+			var errs []error
+			var deleted []util.Manifest
+			for _, m := range manifests {
+				svc := m.Service
+				// Store 的 Delete 对不存在的对象是幂等的，这里先检查一次以便告诉用户
+				existing, err := reg.GetService(ctx, svc.Namespace, svc.Name)
+				if err != nil {
+					if !errors.IsNotFound(err) || !ignoreNotFound {
+						errs = append(errs, objectError(m, err))
+					}
+					continue
+				}
+				if err := reg.DeleteService(ctx, svc.Namespace, svc.Name); err != nil {
+					errs = append(errs, objectError(m, err))
+					continue
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s deleted\n", util.ObjectName(svc))
+				deleted = append(deleted, util.Manifest{Source: m.Source, Service: existing})
+			}
+			if w.wait {
+				errs = append(errs, w.waitForDeletions(ctx, reg, deleted)...)
+			}
+			return utilerrors.NewAggregate(errs)
+		},
+	}
+
+	o.addFlags(cmd, "Filename, directory, or '-' (stdin) of the ECSMService manifests to delete")
+	w.addFlags(cmd, "If true, wait for the ecsm-operator to remove the services from the ECSM platform before returning")
+	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", false, "Treat \"resource not found\" as a successful delete")
+	cmd.SilenceUsage = true
+	return cmd
+}
@@ -5,7 +5,7 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
+	"strings"
 
 	"github.com/fx147/ecsm-operator/internal/ecsm-cli/util"
 	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
@@ -18,7 +18,9 @@ func newDescribeCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "describe [resource] [name]",
 		Short: "Show detailed information about a resource",
-		Long:  `Prints a detailed description of the specified resource.`,
+		Long: `Prints a detailed description of the specified resource.
+Use -o json|yaml|name|jsonpath=...|go-template=... to print the aggregated
+object instead, e.g. for use in scripts.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmd.Help()
 		},
@@ -29,18 +31,27 @@ func newDescribeCmd() *cobra.Command {
 	cmd.AddCommand(newDescribeNodeCmd()) // 未来在这里添加
 	cmd.AddCommand(newDescribeServiceCmd())
 	cmd.AddCommand(newDescribeContainerCmd())
+	cmd.AddCommand(newDescribeTemplateCmd())
+	cmd.AddCommand(newDescribeConfigCmd())
+	cmd.AddCommand(newDescribeRecordCmd())
+	cmd.AddCommand(newDescribeMicroServiceCmd())
 
 	return cmd
 }
 
 // newDescribeNodeCmd 创建 describe node 子命令
 func newDescribeNodeCmd() *cobra.Command {
+	var printFlags util.PrintFlags
 	cmd := &cobra.Command{
 		Use:   "node <NODE_NAME_OR_ID>",
 		Short: "Show detailed information about a specific node",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cs, err := util.NewClientsetFromFlags()
+			printer, err := newDescribePrinter(&printFlags, util.NodeDescriptionTable)
+			if err != nil {
+				return err
+			}
+			cs, err := newClientset()
 			if err != nil {
 				return err
 			}
@@ -49,42 +60,17 @@ func newDescribeNodeCmd() *cobra.Command {
 			ctx := context.Background()
 
 			// --- 核心逻辑：智能查找 Node ID ---
-			var targetNodeID string
-
-			// 1. 获取所有节点，以便查找
 			allNodes, err := cs.Nodes().ListAll(ctx, clientset.NodeListOptions{})
 			if err != nil {
 				return fmt.Errorf("failed to list nodes to find identifier: %w", err)
 			}
-
-			// 2. 尝试将 identifier 作为 ID 直接匹配
-			var foundByName []*clientset.NodeInfo
-			for i, node := range allNodes {
-				if node.ID == identifier {
-					targetNodeID = identifier
-					break
-				}
-				if node.Name == identifier {
-					foundByName = append(foundByName, &allNodes[i])
-				}
-			}
-
-			// 3. 如果通过 ID 没找到，则检查按名称查找的结果
-			if targetNodeID == "" {
-				if len(foundByName) == 0 {
-					return fmt.Errorf("node '%s' not found", identifier)
-				}
-				if len(foundByName) > 1 {
-					// --- 关键的用户友好提示 ---
-					var ids []string
-					for _, n := range foundByName {
-						ids = append(ids, n.ID)
-					}
-					return fmt.Errorf("multiple nodes found with name '%s', please use one of the following IDs: %v", identifier, ids)
-				}
-				// 名称唯一，查找成功
-				targetNodeID = foundByName[0].ID
+			node, err := resolveByIDOrName("node", identifier, allNodes,
+				func(n *clientset.NodeInfo) string { return n.ID },
+				func(n *clientset.NodeInfo) string { return n.Name })
+			if err != nil {
+				return err
 			}
+			targetNodeID := node.ID
 
 			// --- 数据聚合 ---
 			// 4. 现在我们有了唯一的 targetNodeID，可以进行所有查询
@@ -103,16 +89,21 @@ func newDescribeNodeCmd() *cobra.Command {
 
 			// --- 打印 ---
 			// 5. 将聚合后的数据传递给打印机
-			util.PrintNodeDetails(os.Stdout, nodeView, &metricsList[0])
+			if printer != nil {
+				return printer.PrintObject(cmd.OutOrStdout(), &util.NodeDescription{Node: nodeView, Metrics: &metricsList[0]})
+			}
+			util.PrintNodeDetails(cmd.OutOrStdout(), nodeView, &metricsList[0])
 			return nil
 		},
 	}
+	printFlags.AddOutputFlag(cmd)
 	return cmd
 }
 
 // newDescribeImageCmd 创建 "describe image" 子命令
 func newDescribeImageCmd() *cobra.Command {
 	var registryID string
+	var printFlags util.PrintFlags
 
 	cmd := &cobra.Command{
 		Use:     "image <NAME@TAG[#OS]>",
@@ -121,8 +112,13 @@ func newDescribeImageCmd() *cobra.Command {
 		// 确保用户必须提供且只提供一个参数
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := newDescribePrinter(&printFlags, util.ImageDescriptionTable)
+			if err != nil {
+				return err
+			}
+
 			// 1. 获取客户端
-			cs, err := util.NewClientsetFromFlags()
+			cs, err := newClientset()
 			if err != nil {
 				return err
 			}
@@ -137,24 +133,33 @@ func newDescribeImageCmd() *cobra.Command {
 			}
 
 			// 4. 将获取到的详情对象传递给专门的打印机
-			util.PrintImageDetails(os.Stdout, details)
+			if printer != nil {
+				return printer.PrintObject(cmd.OutOrStdout(), details)
+			}
+			util.PrintImageDetails(cmd.OutOrStdout(), details)
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVar(&registryID, "registry-id", "local", "The ID of the registry to query")
+	printFlags.AddOutputFlag(cmd)
 	return cmd
 }
 
 // newDescribeServiceCmd 创建 "describe service" 子命令
 func newDescribeServiceCmd() *cobra.Command {
+	var printFlags util.PrintFlags
 	cmd := &cobra.Command{
 		Use:     "service <SERVICE_NAME_OR_ID>",
 		Short:   "Show detailed information about a specific service",
 		Aliases: []string{"svc"},
 		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cs, err := util.NewClientsetFromFlags()
+			printer, err := newDescribePrinter(&printFlags, util.ServiceDescriptionTable)
+			if err != nil {
+				return err
+			}
+			cs, err := newClientset()
 			if err != nil {
 				return err
 			}
@@ -163,36 +168,11 @@ func newDescribeServiceCmd() *cobra.Command {
 			ctx := context.Background()
 
 			// --- 1. 智能查找 Service ID ---
-			allServices, err := cs.Services().ListAll(ctx, clientset.ListServicesOptions{})
+			svc, err := resolveService(ctx, cs, identifier)
 			if err != nil {
-				return fmt.Errorf("failed to list services: %w", err)
-			}
-
-			var targetServiceID string
-			var foundByName []*clientset.ProvisionListRow
-			for i, svc := range allServices {
-				if svc.ID == identifier {
-					targetServiceID = identifier
-					break
-				}
-				if svc.Name == identifier {
-					foundByName = append(foundByName, &allServices[i])
-				}
-			}
-
-			if targetServiceID == "" {
-				if len(foundByName) == 0 {
-					return fmt.Errorf("service '%s' not found", identifier)
-				}
-				if len(foundByName) > 1 {
-					var ids []string
-					for _, s := range foundByName {
-						ids = append(ids, s.ID)
-					}
-					return fmt.Errorf("multiple services found with name '%s', please use one of the following IDs: %v", identifier, ids)
-				}
-				targetServiceID = foundByName[0].ID
+				return err
 			}
+			targetServiceID := svc.ID
 
 			// --- 2. 数据聚合 ---
 			// 主调用: 获取服务详情
@@ -212,22 +192,31 @@ func newDescribeServiceCmd() *cobra.Command {
 			}
 
 			// --- 3. 打印 ---
-			util.PrintServiceDetails(os.Stdout, serviceDetails, containerList.Items)
+			if printer != nil {
+				return printer.PrintObject(cmd.OutOrStdout(), &util.ServiceDescription{Service: serviceDetails, Containers: containerList.Items})
+			}
+			util.PrintServiceDetails(cmd.OutOrStdout(), serviceDetails, containerList.Items)
 			return nil
 		},
 	}
+	printFlags.AddOutputFlag(cmd)
 	return cmd
 }
 
 // newDescribeContainerCmd 创建 "describe container" 子命令
 func newDescribeContainerCmd() *cobra.Command {
+	var printFlags util.PrintFlags
 	cmd := &cobra.Command{
 		Use:     "container <CONTAINER_NAME>",
 		Short:   "Show detailed information about a specific container",
 		Aliases: []string{"co"},
 		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cs, err := util.NewClientsetFromFlags()
+			printer, err := newDescribePrinter(&printFlags, util.ContainerDescriptionTable)
+			if err != nil {
+				return err
+			}
+			cs, err := newClientset()
 			if err != nil {
 				return err
 			}
@@ -254,9 +243,201 @@ func newDescribeContainerCmd() *cobra.Command {
 			}
 
 			// 3. 打印聚合后的信息
-			util.PrintContainerDetails(os.Stdout, containerInfo, historyList)
+			if printer != nil {
+				description := &util.ContainerDescription{Container: containerInfo}
+				if historyList != nil {
+					description.History = historyList.Items
+				}
+				return printer.PrintObject(cmd.OutOrStdout(), description)
+			}
+			util.PrintContainerDetails(cmd.OutOrStdout(), containerInfo, historyList)
 			return nil
 		},
 	}
+	printFlags.AddOutputFlag(cmd)
 	return cmd
 }
+
+// newDescribeTemplateCmd 创建 "describe template" 子命令
+func newDescribeTemplateCmd() *cobra.Command {
+	var printFlags util.PrintFlags
+	cmd := &cobra.Command{
+		Use:     "template <TEMPLATE_PATH_OR_ID>",
+		Short:   "Show detailed information about a specific template",
+		Long:    `Shows a template and its full spec. An argument starting with "/" is treated as the template path, anything else as the template ID.`,
+		Aliases: []string{"tmpl"},
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := newDescribePrinter(&printFlags, util.TemplateDescriptionTable)
+			if err != nil {
+				return err
+			}
+			cs, err := newClientset()
+			if err != nil {
+				return err
+			}
+
+			identifier := args[0]
+			ctx := context.Background()
+
+			// 模板的路径总是以 "/" 开头，据此区分路径和 ID
+			var template *clientset.TemplateGet
+			if strings.HasPrefix(identifier, "/") {
+				template, err = cs.Templates().GetTemplateByPath(ctx, identifier)
+			} else {
+				template, err = cs.Templates().GetTemplateByID(ctx, identifier)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get template '%s': %w", identifier, err)
+			}
+
+			if printer != nil {
+				return printer.PrintObject(cmd.OutOrStdout(), template)
+			}
+			util.PrintTemplateDetails(cmd.OutOrStdout(), template)
+			return nil
+		},
+	}
+	printFlags.AddOutputFlag(cmd)
+	return cmd
+}
+
+// newDescribeConfigCmd 创建 "describe config" 子命令
+func newDescribeConfigCmd() *cobra.Command {
+	var printFlags util.PrintFlags
+	cmd := &cobra.Command{
+		Use:     "config <KEY>",
+		Short:   "Show detailed information about a specific config item",
+		Aliases: []string{"configs", "cm"},
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := newDescribePrinter(&printFlags, util.ConfigDescriptionTable)
+			if err != nil {
+				return err
+			}
+			cs, err := newClientset()
+			if err != nil {
+				return err
+			}
+
+			key := args[0]
+			// 按 key 查询的接口只返回值，这里通过 List 获取包括 ID 和类型在内的完整配置项。
+			// List 的 key 是模糊匹配，需要再精确匹配一次。
+			configs, err := cs.Configs().ListAllConfig(context.Background(), clientset.ListConfigsOptions{Key: key})
+			if err != nil {
+				return fmt.Errorf("failed to list configs: %w", err)
+			}
+			var config *clientset.ConfigItem
+			for i := range configs {
+				if configs[i].Key == key {
+					config = &configs[i]
+					break
+				}
+			}
+			if config == nil {
+				return fmt.Errorf("config '%s' not found", key)
+			}
+
+			if printer != nil {
+				return printer.PrintObject(cmd.OutOrStdout(), config)
+			}
+			util.PrintConfigDetails(cmd.OutOrStdout(), config)
+			return nil
+		},
+	}
+	printFlags.AddOutputFlag(cmd)
+	return cmd
+}
+
+// newDescribeRecordCmd 创建 "describe record" 子命令
+func newDescribeRecordCmd() *cobra.Command {
+	var printFlags util.PrintFlags
+	cmd := &cobra.Command{
+		Use:     "record <RECORD_ID>",
+		Short:   "Show detailed information about a specific deploy record",
+		Long:    `Shows a deploy record, including the image configuration used by that deployment. Use 'ecsm-cli get records --service <SERVICE>' to find the record IDs.`,
+		Aliases: []string{"records", "rec"},
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := newDescribePrinter(&printFlags, util.RecordDescriptionTable)
+			if err != nil {
+				return err
+			}
+			cs, err := newClientset()
+			if err != nil {
+				return err
+			}
+
+			recordID := args[0]
+			record, err := cs.Records().GetRecord(context.Background(), recordID)
+			if err != nil {
+				return fmt.Errorf("failed to get record '%s': %w", recordID, err)
+			}
+
+			description := &util.RecordDescription{ID: recordID, RecordGet: *record}
+			if printer != nil {
+				return printer.PrintObject(cmd.OutOrStdout(), description)
+			}
+			util.PrintRecordDetails(cmd.OutOrStdout(), description)
+			return nil
+		},
+	}
+	printFlags.AddOutputFlag(cmd)
+	return cmd
+}
+
+// newDescribeMicroServiceCmd 创建 "describe microservice" 子命令
+func newDescribeMicroServiceCmd() *cobra.Command {
+	var printFlags util.PrintFlags
+	cmd := &cobra.Command{
+		Use:     "microservice <MICROSERVICE_NAME_OR_ID>",
+		Short:   "Show detailed information about a specific microservice",
+		Aliases: []string{"microservices", "ms"},
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := newDescribePrinter(&printFlags, util.MicroServiceDescriptionTable)
+			if err != nil {
+				return err
+			}
+			cs, err := newClientset()
+			if err != nil {
+				return err
+			}
+
+			identifier := args[0]
+			ctx := context.Background()
+
+			allMicroServices, err := cs.MicroServices().ListAllMicroService(ctx, clientset.ListMicroServicesOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to list microservices: %w", err)
+			}
+			row, err := resolveByIDOrName("microservice", identifier, allMicroServices,
+				func(m *clientset.MicroServiceListRow) string { return m.ID },
+				func(m *clientset.MicroServiceListRow) string { return m.Name })
+			if err != nil {
+				return err
+			}
+
+			microService, err := cs.MicroServices().GetMicroService(ctx, row.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get microservice details: %w", err)
+			}
+
+			if printer != nil {
+				return printer.PrintObject(cmd.OutOrStdout(), microService)
+			}
+			util.PrintMicroServiceDetails(cmd.OutOrStdout(), microService)
+			return nil
+		},
+	}
+	printFlags.AddOutputFlag(cmd)
+	return cmd
+}
+
+// newDescribePrinter 在指定了 -o 时返回打印聚合对象的 Printer，否则返回 nil，由命令打印人类可读的描述。
+func newDescribePrinter[T any](printFlags *util.PrintFlags, table *util.Table[T]) (util.Printer[T], error) {
+	if printFlags.OutputFormat == "" {
+		return nil, nil
+	}
+	return util.NewPrinter(printFlags, table)
+}
@@ -0,0 +1,183 @@
+// file: cmd/ecsm-cli/cmd/describe_test.go
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fx147/ecsm-operator/internal/ecsm-cli/util"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestDescribeRecord(t *testing.T) {
+	cs := newFakeClientset(t)
+	cs.Simulator.SetClock(testingclock.NewFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)))
+	cs.Simulator.AddNode("worker1", "10.0.0.1")
+
+	highest, lowest, port := 150, 250, 3000
+	config := &clientset.EcsImageConfig{
+		Hostname: "web-host",
+		Root:     &clientset.Root{Path: "/apps/web", Readonly: true},
+		Process: &clientset.Process{
+			Args: []string{"/apps/web/nginx", "-c", "/etc/nginx.conf"},
+			Env:  []string{"PATH=/bin", "MODE=prod"},
+			Cwd:  "/apps/web",
+		},
+		Mounts: []clientset.Mount{{Destination: "/data", Source: "/media/data", Options: []string{"rw", "bind"}}},
+		SylixOS: &clientset.SylixOS{
+			Commands: []string{"ifconfig", "ps"},
+			Devices:  []clientset.Device{{Path: "/dev/can0", Access: "rw"}},
+			Network:  &clientset.Network{FtpdEnable: true},
+			Resources: &clientset.Resources{
+				CPU:    &clientset.CPU{HighestPrio: &highest, LowestPrio: &lowest},
+				Memory: &clientset.Memory{KheapLimit: 2097152, MemoryLimitMB: 512},
+				Disk:   &clientset.Disk{LimitMB: 2048},
+				KernelObject: &clientset.KernelObject{
+					ThreadLimit: 300, ThreadPoolLimit: 1, EventLimit: 800, EventSetLimit: 50,
+					PartitionLimit: 5, RegionLimit: 5, MsgQueueLimit: 50, TimerLimit: 5, SocketLimit: 100,
+				},
+			},
+		},
+	}
+	serviceID := createService(t, cs, "web", clientset.ImageSpec{
+		Ref:        "nginx@1.0",
+		Action:     "run",
+		Config:     config,
+		VSOA:       &clientset.ImageVSOA{Port: &port, HealthPath: "/healthz"},
+		PullPolicy: "IfNotPresent",
+	})
+	records, err := cs.Records().ListAllRecord(context.Background(), clientset.ListRecordOptions{ServiceID: serviceID})
+	if err != nil || len(records) != 1 {
+		t.Fatalf("Expected one record, got %d (err: %v)", len(records), err)
+	}
+	recordID := records[0].ID
+
+	t.Run("Details", func(t *testing.T) {
+		got, err := runCommand(t, newDescribeCmd(), "record", recordID)
+		if err != nil {
+			t.Fatalf("describe record failed: %v", err)
+		}
+		want := `ID:             ` + recordID + `
+Name:           web
+Created:        2024-05-01T12:00:00Z
+Action:         run
+Command:        /apps/web/nginx -c /etc/nginx.conf
+
+Deployment:
+  Policy:       dynamic
+  Replicas:     1
+Image:
+  Reference:    nginx@1.0
+  Pull Policy:  IfNotPresent
+  Auto Upgrade: <none>
+  VSOA:
+    Port:                3000
+    Health Path:         /healthz
+
+Configuration:
+  Hostname:     web-host
+  Root Filesystem:
+    Path:       /apps/web
+    Read Only:  true
+  Process:
+    Command:      /apps/web/nginx -c /etc/nginx.conf
+    Working Dir:  /apps/web
+    Environment:
+      PATH=/bin
+      MODE=prod
+  Mounts:
+    - Destination: /data
+      Source:      /media/data
+      Options:     rw,bind
+  SylixOS:
+    Commands: (ifconfig, ps)
+    Devices:
+      /dev/can0 (access: rw)
+    Network:
+      FTPD Enabled:    true
+      TelnetD Enabled: false
+    Resources:
+      Memory Limit:  512 MB
+      KHeap Limit:   2097152
+      Disk Limit:    2048 MB
+      CPU Priority (High/Low): 150/250
+      Kernel Objects:
+        Thread Limit:         300
+        Thread Pool Limit:    1
+        Event Limit:          800
+        Event Set Limit:      50
+        Partition Limit:      5
+        Region Limit:         5
+        Msg Queue Limit:      50
+        Timer Limit:          5
+        Socket Limit:         100
+`
+		if got != want {
+			t.Errorf("describe record output:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		got, err := runCommand(t, newDescribeCmd(), "record", recordID, "-o", "json")
+		if err != nil {
+			t.Fatalf("describe record -o json failed: %v", err)
+		}
+		var description util.RecordDescription
+		if err := json.Unmarshal([]byte(got), &description); err != nil {
+			t.Fatalf("Failed to decode output: %v\n%s", err, got)
+		}
+		if description.ID != recordID {
+			t.Errorf("id = %q, want %q", description.ID, recordID)
+		}
+		if description.Config == nil || description.Config.SylixOS == nil || description.Config.SylixOS.Resources.Memory.MemoryLimitMB != 512 {
+			t.Errorf("EcsImageConfig was not printed in full: %s", got)
+		}
+	})
+
+	t.Run("JSONPath", func(t *testing.T) {
+		got, err := runCommand(t, newDescribeCmd(), "record", recordID, "-o", "jsonpath={.config.process.args[0]} {.config.sylixos.resources.cpu.highestPrio}")
+		if err != nil {
+			t.Fatalf("describe record -o jsonpath failed: %v", err)
+		}
+		if want := "/apps/web/nginx 150"; got != want {
+			t.Errorf("describe record -o jsonpath = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Name", func(t *testing.T) {
+		got, err := runCommand(t, newDescribeCmd(), "record", recordID, "-o", "name")
+		if err != nil {
+			t.Fatalf("describe record -o name failed: %v", err)
+		}
+		if want := "record/" + recordID + "\n"; got != want {
+			t.Errorf("describe record -o name = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("NoConfig", func(t *testing.T) {
+		dbID := createService(t, cs, "db", clientset.ImageSpec{Ref: "redis@7.0", Action: "run"})
+		records, err := cs.Records().ListAllRecord(context.Background(), clientset.ListRecordOptions{ServiceID: dbID})
+		if err != nil || len(records) != 1 {
+			t.Fatalf("Expected one record, got %d (err: %v)", len(records), err)
+		}
+		got, err := runCommand(t, newDescribeCmd(), "record", records[0].ID)
+		if err != nil {
+			t.Fatalf("describe record failed: %v", err)
+		}
+		if !strings.HasSuffix(got, "\nConfiguration: Not available\n") {
+			t.Errorf("Expected the missing configuration to be reported, got:\n%s", got)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := runCommand(t, newDescribeCmd(), "record", "missing")
+		if err == nil || !strings.Contains(err.Error(), "failed to get record 'missing'") {
+			t.Errorf("Expected a not found error, got %v", err)
+		}
+	})
+}
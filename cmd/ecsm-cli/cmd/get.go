@@ -5,11 +5,12 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
 
 	"github.com/fx147/ecsm-operator/internal/ecsm-cli/util"
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
 	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // newGetCmd 创建 get 命令
@@ -17,7 +18,9 @@ func newGetCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "get [resource]",
 		Short: "Display one or many resources",
-		Long:  `Prints a table of the most important information about the specified resources.`,
+		Long: `Prints a table of the most important information about the specified resources.
+Use -o to print the resources as json, yaml, names, custom columns or through a
+jsonpath or go template instead.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			// 如果只输入 "ecsm-cli get"，就打印帮助信息
 			cmd.Help()
@@ -29,6 +32,11 @@ func newGetCmd() *cobra.Command {
 	cmd.AddCommand(newGetImagesCmd())
 	cmd.AddCommand(newGetServicesCmd())
 	cmd.AddCommand(newGetContainersCmd())
+	cmd.AddCommand(newGetECSMServicesCmd())
+	cmd.AddCommand(newGetTemplatesCmd())
+	cmd.AddCommand(newGetConfigsCmd())
+	cmd.AddCommand(newGetRecordsCmd())
+	cmd.AddCommand(newGetMicroServicesCmd())
 
 	return cmd
 }
@@ -39,12 +47,17 @@ func newGetNodesCmd() *cobra.Command {
 	var pageNum int
 	var nameFilter string
 	var basicInfo bool
+	var printFlags util.PrintFlags
 	cmd := &cobra.Command{
 		Use:     "nodes",
 		Short:   "Display a list of nodes",
 		Aliases: []string{"node", "no"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cs, err := util.NewClientsetFromFlags()
+			printer, err := util.NewPrinter(&printFlags, util.NodeTable)
+			if err != nil {
+				return err
+			}
+			cs, err := newClientset()
 			if err != nil {
 				return err
 			}
@@ -77,12 +90,11 @@ func newGetNodesCmd() *cobra.Command {
 				nodesToPrint = allNodes
 			}
 
-			if len(nodesToPrint) > 0 {
-				util.PrintNodesTable(os.Stdout, nodesToPrint)
-			} else {
-				fmt.Println("No nodes found.")
+			if len(nodesToPrint) == 0 && printFlags.IsHumanReadable() {
+				fmt.Fprintln(cmd.OutOrStdout(), "No nodes found.")
+				return nil
 			}
-			return nil
+			return printer.PrintList(cmd.OutOrStdout(), nodesToPrint)
 		},
 	}
 
@@ -91,6 +103,7 @@ func newGetNodesCmd() *cobra.Command {
 	cmd.Flags().IntVarP(&pageSize, "page-size", "s", 100, "Number of items per page (used for both single and all-page listing)")
 	cmd.Flags().StringVarP(&nameFilter, "name", "n", "", "Filter nodes by name (fuzzy match)")
 	cmd.Flags().BoolVar(&basicInfo, "basic", false, "Display basic information only")
+	printFlags.AddFlags(cmd)
 
 	return cmd
 }
@@ -101,6 +114,7 @@ func newGetImagesCmd() *cobra.Command {
 	var registryID, nameFilter, osFilter, authorFilter string
 	var pageNum, pageSize int
 	var listAll bool
+	var printFlags util.PrintFlags
 
 	cmd := &cobra.Command{
 		Use:     "images",
@@ -109,8 +123,13 @@ func newGetImagesCmd() *cobra.Command {
 		// 我们不希望 get images 后面跟任何参数
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := util.NewPrinter(&printFlags, util.ImageTable)
+			if err != nil {
+				return err
+			}
+
 			// 1. 创建客户端
-			cs, err := util.NewClientsetFromFlags()
+			cs, err := newClientset()
 			if err != nil {
 				return fmt.Errorf("failed to create clientset: %w", err)
 			}
@@ -143,13 +162,11 @@ func newGetImagesCmd() *cobra.Command {
 			}
 
 			// 4. 使用 printer 打印结果
-			if len(imagesToPrint) > 0 {
-				util.PrintImagesTable(os.Stdout, imagesToPrint)
-			} else {
-				fmt.Println("No images found.")
+			if len(imagesToPrint) == 0 && printFlags.IsHumanReadable() {
+				fmt.Fprintln(cmd.OutOrStdout(), "No images found.")
+				return nil
 			}
-
-			return nil
+			return printer.PrintList(cmd.OutOrStdout(), imagesToPrint)
 		},
 	}
 
@@ -162,6 +179,7 @@ func newGetImagesCmd() *cobra.Command {
 	cmd.Flags().BoolVarP(&listAll, "all", "A", true, "List all pages of images (default behavior)")
 	cmd.Flags().IntVar(&pageNum, "page", 1, "Page number to retrieve (if --all=false)")
 	cmd.Flags().IntVar(&pageSize, "page-size", 100, "Number of items per page")
+	printFlags.AddFlags(cmd)
 
 	return cmd
 }
@@ -172,6 +190,7 @@ func newGetServicesCmd() *cobra.Command {
 	var pageNum, pageSize int
 	var nameFilter, imageID, nodeID, labelFilter string
 	var listAll bool
+	var printFlags util.PrintFlags
 
 	cmd := &cobra.Command{
 		Use:     "services",
@@ -179,7 +198,11 @@ func newGetServicesCmd() *cobra.Command {
 		Aliases: []string{"service", "svc"},
 		Args:    cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cs, err := util.NewClientsetFromFlags()
+			printer, err := util.NewPrinter(&printFlags, util.ServiceTable)
+			if err != nil {
+				return err
+			}
+			cs, err := newClientset()
 			if err != nil {
 				return err
 			}
@@ -209,13 +232,11 @@ func newGetServicesCmd() *cobra.Command {
 				servicesToPrint = serviceList.Items
 			}
 
-			if len(servicesToPrint) > 0 {
-				util.PrintServicesTable(os.Stdout, servicesToPrint)
-			} else {
-				fmt.Println("No services found.")
+			if len(servicesToPrint) == 0 && printFlags.IsHumanReadable() {
+				fmt.Fprintln(cmd.OutOrStdout(), "No services found.")
+				return nil
 			}
-
-			return nil
+			return printer.PrintList(cmd.OutOrStdout(), servicesToPrint)
 		},
 	}
 
@@ -228,6 +249,7 @@ func newGetServicesCmd() *cobra.Command {
 	cmd.Flags().BoolVarP(&listAll, "all", "A", true, "List all pages of services (default behavior)")
 	cmd.Flags().IntVar(&pageNum, "page", 1, "Page number to retrieve (if --all=false)")
 	cmd.Flags().IntVar(&pageSize, "page-size", 100, "Number of items per page")
+	printFlags.AddFlags(cmd)
 
 	return cmd
 }
@@ -238,6 +260,7 @@ func newGetContainersCmd() *cobra.Command {
 	var serviceFilter string
 	var nodeFilter string
 	var listAll bool
+	var printFlags util.PrintFlags
 
 	cmd := &cobra.Command{
 		Use:     "containers",
@@ -245,7 +268,11 @@ func newGetContainersCmd() *cobra.Command {
 		Aliases: []string{"container", "co"},
 		Args:    cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cs, err := util.NewClientsetFromFlags()
+			printer, err := util.NewPrinter(&printFlags, util.ContainerTable)
+			if err != nil {
+				return err
+			}
+			cs, err := newClientset()
 			if err != nil {
 				return err
 			}
@@ -329,13 +356,11 @@ func newGetContainersCmd() *cobra.Command {
 			}
 
 			// 打印结果
-			if len(containersToPrint) > 0 {
-				util.PrintContainersTable(os.Stdout, containersToPrint)
-			} else {
-				fmt.Println("No containers found.")
+			if len(containersToPrint) == 0 && printFlags.IsHumanReadable() {
+				fmt.Fprintln(cmd.OutOrStdout(), "No containers found.")
+				return nil
 			}
-
-			return nil
+			return printer.PrintList(cmd.OutOrStdout(), containersToPrint)
 		},
 	}
 
@@ -344,6 +369,273 @@ func newGetContainersCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&nodeFilter, "node", "n", "", "Filter containers by node name or ID")
 
 	cmd.Flags().BoolVarP(&listAll, "all", "A", true, "List all pages of containers (default behavior)")
+	printFlags.AddFlags(cmd)
+
+	return cmd
+}
+
+// newGetTemplatesCmd 创建 "get templates" 子命令
+func newGetTemplatesCmd() *cobra.Command {
+	var templatePath string
+	var level int
+	var printFlags util.PrintFlags
+
+	cmd := &cobra.Command{
+		Use:     "templates",
+		Short:   "Display the template tree",
+		Long:    `Prints the folders and service templates under --path as a tree. With -o, the tree is flattened into a list of entries, e.g. -o wide prints a table with the template IDs.`,
+		Aliases: []string{"template", "tmpl"},
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := util.NewPrinter(&printFlags, util.TemplateTable)
+			if err != nil {
+				return err
+			}
+			cs, err := newClientset()
+			if err != nil {
+				return err
+			}
+
+			// model=full 时每个节点都带有 data，这样才能区分空文件夹和服务模板
+			tree, err := cs.Templates().GetTemplateTree(context.Background(), clientset.GetTemplateTreeOptions{
+				Path:  templatePath,
+				Level: level,
+				Model: "full",
+			})
+			if err != nil {
+				return err
+			}
+
+			if printFlags.OutputFormat == "" {
+				util.PrintTemplateTree(cmd.OutOrStdout(), tree)
+				return nil
+			}
+			return printer.PrintList(cmd.OutOrStdout(), util.FlattenTemplateTree(tree))
+		},
+	}
+
+	cmd.Flags().StringVar(&templatePath, "path", "/", "The folder (or template) to display")
+	cmd.Flags().IntVar(&level, "level", 0, "The number of levels to display (0 means all levels)")
+	printFlags.AddFlags(cmd)
+
+	return cmd
+}
+
+// newGetConfigsCmd 创建 "get configs" 子命令
+func newGetConfigsCmd() *cobra.Command {
+	var keyFilter string
+	var printFlags util.PrintFlags
+
+	cmd := &cobra.Command{
+		Use:     "configs",
+		Short:   "Display a list of config items",
+		Aliases: []string{"config", "cm"},
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := util.NewPrinter(&printFlags, util.ConfigTable)
+			if err != nil {
+				return err
+			}
+			cs, err := newClientset()
+			if err != nil {
+				return err
+			}
+
+			configs, err := cs.Configs().ListAllConfig(context.Background(), clientset.ListConfigsOptions{Key: keyFilter})
+			if err != nil {
+				return err
+			}
+
+			if len(configs) == 0 && printFlags.IsHumanReadable() {
+				fmt.Fprintln(cmd.OutOrStdout(), "No configs found.")
+				return nil
+			}
+			return printer.PrintList(cmd.OutOrStdout(), configs)
+		},
+	}
+
+	cmd.Flags().StringVarP(&keyFilter, "key", "k", "", "Filter config items by key")
+	printFlags.AddFlags(cmd)
+
+	return cmd
+}
+
+// newGetRecordsCmd 创建 "get records" 子命令
+func newGetRecordsCmd() *cobra.Command {
+	var serviceFilter string
+	var printFlags util.PrintFlags
+
+	cmd := &cobra.Command{
+		Use:     "records",
+		Short:   "Display the deploy records of a service",
+		Aliases: []string{"record", "rec"},
+		Args:    cobra.NoArgs,
+		Example: `  # List the deploy records of service "web", newest first
+  ecsm-cli get records --service web
+
+  # Show the image configuration of the latest deployment
+  ecsm-cli describe record $(ecsm-cli get records -s web -o jsonpath='{.items[0].id}')`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := util.NewPrinter(&printFlags, util.RecordTable)
+			if err != nil {
+				return err
+			}
+			cs, err := newClientset()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			svc, err := resolveService(ctx, cs, serviceFilter)
+			if err != nil {
+				return err
+			}
+			records, err := cs.Records().ListAllRecord(ctx, clientset.ListRecordOptions{ServiceID: svc.ID})
+			if err != nil {
+				return fmt.Errorf("failed to list records for service '%s': %w", serviceFilter, err)
+			}
+
+			if len(records) == 0 && printFlags.IsHumanReadable() {
+				fmt.Fprintln(cmd.OutOrStdout(), "No records found.")
+				return nil
+			}
+			return printer.PrintList(cmd.OutOrStdout(), records)
+		},
+	}
+
+	cmd.Flags().StringVarP(&serviceFilter, "service", "s", "", "The name or ID of the service (required)")
+	cmd.MarkFlagRequired("service")
+	printFlags.AddFlags(cmd)
+
+	return cmd
+}
+
+// newGetMicroServicesCmd 创建 "get microservices" 子命令
+func newGetMicroServicesCmd() *cobra.Command {
+	var nameFilter, nodeID, labelFilter string
+	var printFlags util.PrintFlags
+
+	cmd := &cobra.Command{
+		Use:     "microservices",
+		Short:   "Display a list of microservices",
+		Aliases: []string{"microservice", "ms"},
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := util.NewPrinter(&printFlags, util.MicroServiceTable)
+			if err != nil {
+				return err
+			}
+			cs, err := newClientset()
+			if err != nil {
+				return err
+			}
+
+			opts := clientset.ListMicroServicesOptions{
+				KeyWord: nameFilter,
+				NodeID:  nodeID,
+				Label:   labelFilter,
+			}
+			microServices, err := cs.MicroServices().ListAllMicroService(context.Background(), opts)
+			if err != nil {
+				return err
+			}
+
+			if len(microServices) == 0 && printFlags.IsHumanReadable() {
+				fmt.Fprintln(cmd.OutOrStdout(), "No microservices found.")
+				return nil
+			}
+			return printer.PrintList(cmd.OutOrStdout(), microServices)
+		},
+	}
+
+	cmd.Flags().StringVarP(&nameFilter, "name", "n", "", "Filter microservices by name (fuzzy match)")
+	cmd.Flags().StringVar(&nodeID, "node-id", "", "Filter microservices by node ID")
+	cmd.Flags().StringVarP(&labelFilter, "label", "l", "", "Filter microservices by path label (fuzzy match)")
+	printFlags.AddFlags(cmd)
+
+	return cmd
+}
+
+// newGetECSMServicesCmd 创建 "get ecsmservices" 子命令。
+// 与 "get services" 不同，它读取的是 ECSM Registry 中的期望状态以及 ecsm-operator 写回的 status。
+func newGetECSMServicesCmd() *cobra.Command {
+	var namespace string
+	var allNamespaces bool
+	var printFlags util.PrintFlags
+
+	cmd := &cobra.Command{
+		Use:     "ecsmservices [NAME...]",
+		Short:   "Display ECSMServices and their conditions",
+		Aliases: []string{"ecsmservice", "esvc"},
+		Example: `  # List all ECSMServices in the default namespace
+  ecsm-cli get ecsmservices
+
+  # List ECSMServices in all namespaces
+  ecsm-cli get esvc -A
+
+  # List ECSMServices with the ID of the underlying ECSM service, sorted by creation time
+  ecsm-cli get esvc -o custom-columns=NAME:.metadata.name,ECSM_ID:.status.underlyingServiceID --sort-by=.metadata.creationTimestamp`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if allNamespaces && len(args) > 0 {
+				return fmt.Errorf("a resource cannot be retrieved by name across all namespaces")
+			}
+
+			printer, err := util.NewPrinter(&printFlags, util.ECSMServiceTable(allNamespaces))
+			if err != nil {
+				return err
+			}
+
+			reg, closeRegistry, err := util.NewRegistryFromFlags()
+			if err != nil {
+				return err
+			}
+			defer closeRegistry()
+
+			ctx := context.Background()
+			var servicesToPrint []ecsmv1.ECSMService
+			if len(args) > 0 {
+				for _, name := range args {
+					svc, err := reg.GetService(ctx, namespace, name)
+					if err != nil {
+						return err
+					}
+					servicesToPrint = append(servicesToPrint, *svc)
+				}
+			} else {
+				ns := namespace
+				if allNamespaces {
+					ns = metav1.NamespaceAll
+				}
+				list, err := reg.ListServices(ctx, ns)
+				if err != nil {
+					return err
+				}
+				servicesToPrint = list.Items
+			}
+
+			if len(servicesToPrint) == 0 && printFlags.IsHumanReadable() {
+				if allNamespaces {
+					fmt.Fprintln(cmd.OutOrStdout(), "No ECSMServices found.")
+				} else {
+					fmt.Fprintf(cmd.OutOrStdout(), "No ECSMServices found in %s namespace.\n", namespace)
+				}
+				return nil
+			}
+			// 存储中的对象可能没有 apiVersion/kind，补上之后 -o json/yaml 的输出可以直接 apply
+			for i := range servicesToPrint {
+				servicesToPrint[i].SetGroupVersionKind(ecsmv1.SchemeGroupVersion.WithKind("ECSMService"))
+			}
+			// 与 kubectl 一样，按名字获取单个对象时输出对象本身而不是 List
+			if len(args) == 1 {
+				return printer.PrintObject(cmd.OutOrStdout(), &servicesToPrint[0])
+			}
+			return printer.PrintList(cmd.OutOrStdout(), servicesToPrint)
+		},
+	}
 
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", metav1.NamespaceDefault, "The namespace of the ECSMServices")
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "List ECSMServices across all namespaces")
+	printFlags.AddFlags(cmd)
+	cmd.SilenceUsage = true
 	return cmd
 }
@@ -0,0 +1,189 @@
+// file: cmd/ecsm-cli/cmd/get_test.go
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset/fake"
+	"github.com/spf13/cobra"
+)
+
+// newFakeClientset 返回一个 fake clientset，并让 get/describe 等子命令在测试结束之前使用它。
+func newFakeClientset(t *testing.T) *fake.Clientset {
+	cs := fake.NewSimpleClientset()
+	old := newClientset
+	newClientset = func() (*clientset.Clientset, error) { return cs.Clientset, nil }
+	t.Cleanup(func() { newClientset = old })
+	return cs
+}
+
+// runCommand 以 args 执行 cmd，返回它的标准输出。
+func runCommand(t *testing.T, cmd *cobra.Command, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+// createService 在 cs 上创建一个服务，并等待它部署完成。
+func createService(t *testing.T, cs *fake.Clientset, name string, image clientset.ImageSpec) string {
+	t.Helper()
+	resp, err := cs.Services().Create(context.Background(), &clientset.CreateServiceRequest{Name: name, Image: image})
+	if err != nil {
+		t.Fatalf("Failed to create service %s: %v", name, err)
+	}
+	cs.Simulator.Settle()
+	return resp.ID
+}
+
+func TestGetTemplates(t *testing.T) {
+	cs := newFakeClientset(t)
+	for _, p := range []string{"/web", "/edge/gateway", "/edge/camera"} {
+		if _, err := cs.Simulator.AddTemplate(p, clientset.TemplateSpec{Image: clientset.ImageForTmpl{Ref: "nginx@1.0"}}); err != nil {
+			t.Fatalf("Failed to add template %s: %v", p, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "Tree",
+			args: []string{"templates"},
+			want: `/
+├── edge/
+│   ├── camera
+│   └── gateway
+└── web
+`,
+		},
+		{
+			// 更深的层级没有返回时只提示子节点的数量
+			name: "LevelOne",
+			args: []string{"templates", "--level", "1"},
+			want: "/\n└── ... (2 more)\n",
+		},
+		{
+			name: "Level",
+			args: []string{"templates", "--level", "2"},
+			want: `/
+├── edge/
+│   └── ... (2 more)
+└── web
+`,
+		},
+		{
+			name: "Path",
+			args: []string{"templates", "--path", "/edge"},
+			want: `/edge
+├── camera
+└── gateway
+`,
+		},
+		{
+			// 指定 -o 时输出按路径展开的列表
+			name: "Name",
+			args: []string{"templates", "-o", "name"},
+			want: "template/edge\ntemplate/edge/camera\ntemplate/edge/gateway\ntemplate/web\n",
+		},
+		{
+			name: "CustomColumns",
+			args: []string{"templates", "-o", "custom-columns=PATH:.path,KIND:.kind,CHILDREN:.childCount"},
+			want: `PATH          KIND    CHILDREN
+/edge         folder  2
+/edge/camera  service 0
+/edge/gateway service 0
+/web          service 0
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runCommand(t, newGetCmd(), tt.args...)
+			if err != nil {
+				t.Fatalf("get %v failed: %v", tt.args, err)
+			}
+			if got != tt.want {
+				t.Errorf("get %v output:\n%s\nwant:\n%s", tt.args, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := runCommand(t, newGetCmd(), "templates", "--path", "/missing"); err == nil {
+		t.Error("Expected an error for a missing template path")
+	}
+}
+
+func TestGetRecords(t *testing.T) {
+	ctx := context.Background()
+	cs := newFakeClientset(t)
+	cs.Simulator.AddNode("worker1", "10.0.0.1")
+
+	webID := createService(t, cs, "web", clientset.ImageSpec{Ref: "nginx@1.0", Action: "run"})
+	factor := 1
+	if _, err := cs.Services().Update(ctx, webID, &clientset.UpdateServiceRequest{
+		ID:     webID,
+		Name:   "web",
+		Image:  clientset.ImageSpec{Ref: "nginx@1.1", Action: "run"},
+		Factor: &factor,
+	}); err != nil {
+		t.Fatalf("Failed to update service web: %v", err)
+	}
+	cs.Simulator.Settle()
+	createService(t, cs, "db", clientset.ImageSpec{Ref: "redis@7.0", Action: "run"})
+
+	records, err := cs.Records().ListAllRecord(ctx, clientset.ListRecordOptions{ServiceID: webID})
+	if err != nil {
+		t.Fatalf("Failed to list records: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records for web, got %d", len(records))
+	}
+	// 最新的记录在前
+	want := "record/" + records[0].ID + "\nrecord/" + records[1].ID + "\n"
+
+	for _, tt := range []struct{ name, service string }{{"ByName", "web"}, {"ByID", webID}} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runCommand(t, newGetCmd(), "records", "--service", tt.service, "-o", "name")
+			if err != nil {
+				t.Fatalf("get records --service %s failed: %v", tt.service, err)
+			}
+			if got != want {
+				t.Errorf("get records --service %s output:\n%s\nwant:\n%s", tt.service, got, want)
+			}
+		})
+	}
+
+	t.Run("Table", func(t *testing.T) {
+		got, err := runCommand(t, newGetCmd(), "records", "-s", "web", "--no-headers", "-o", "custom-columns=NAME:.name,IMAGE:.image")
+		if err != nil {
+			t.Fatalf("get records failed: %v", err)
+		}
+		if want := "web  nginx@1.1\nweb  nginx@1.0\n"; got != want {
+			t.Errorf("get records output:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := runCommand(t, newGetCmd(), "records", "--service", "missing")
+		if err == nil || !strings.Contains(err.Error(), "service 'missing' not found") {
+			t.Errorf("Expected a not found error, got %v", err)
+		}
+	})
+
+	t.Run("ServiceRequired", func(t *testing.T) {
+		if _, err := runCommand(t, newGetCmd(), "records"); err == nil {
+			t.Error("Expected an error without --service")
+		}
+	})
+}
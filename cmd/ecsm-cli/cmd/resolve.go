@@ -0,0 +1,48 @@
+// file: cmd/ecsm-cli/cmd/resolve.go
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+)
+
+// resolveByIDOrName 在 items 中查找 identifier：优先按 ID 精确匹配，其次按名称匹配。
+// ECSM 允许重名，名称对应多个对象时返回的错误中列出它们的 ID，提示用户改用 ID。
+func resolveByIDOrName[T any](kind, identifier string, items []T, id, name func(*T) string) (*T, error) {
+	var foundByName []*T
+	for i := range items {
+		if id(&items[i]) == identifier {
+			return &items[i], nil
+		}
+		if name(&items[i]) == identifier {
+			foundByName = append(foundByName, &items[i])
+		}
+	}
+
+	if len(foundByName) == 0 {
+		return nil, fmt.Errorf("%s '%s' not found", kind, identifier)
+	}
+	if len(foundByName) > 1 {
+		// --- 关键的用户友好提示 ---
+		var ids []string
+		for _, item := range foundByName {
+			ids = append(ids, id(item))
+		}
+		return nil, fmt.Errorf("multiple %ss found with name '%s', please use one of the following IDs: %v", kind, identifier, ids)
+	}
+	return foundByName[0], nil
+}
+
+// resolveService 按名称或 ID 查找 ECSM 服务。
+func resolveService(ctx context.Context, cs clientset.Interface, identifier string) (*clientset.ProvisionListRow, error) {
+	allServices, err := cs.Services().ListAll(ctx, clientset.ListServicesOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	return resolveByIDOrName("service", identifier, allServices,
+		func(svc *clientset.ProvisionListRow) string { return svc.ID },
+		func(svc *clientset.ProvisionListRow) string { return svc.Name })
+}
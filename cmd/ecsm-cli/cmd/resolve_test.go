@@ -0,0 +1,92 @@
+// file: cmd/ecsm-cli/cmd/resolve_test.go
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/simulator"
+)
+
+// duplicateNameClientset 在服务列表中为每个服务追加一个同名、ID 不同的副本。
+// 真实的 ECSM 允许服务重名，模拟器不允许，所以用它来构造按名称查找到多个服务的情况。
+type duplicateNameClientset struct {
+	clientset.Interface
+}
+
+func (c duplicateNameClientset) Services() clientset.ServiceInterface {
+	return duplicateNameServices{ServiceInterface: c.Interface.Services()}
+}
+
+type duplicateNameServices struct {
+	clientset.ServiceInterface
+}
+
+func (s duplicateNameServices) ListAll(ctx context.Context, opts clientset.ListServicesOptions) ([]clientset.ProvisionListRow, error) {
+	rows, err := s.ServiceInterface.ListAll(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		row.ID += "-copy"
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func TestResolveService(t *testing.T) {
+	cs := newFakeClientset(t)
+	cs.Simulator.AddNode("worker1", "10.0.0.1")
+	webID := createService(t, cs, "web", clientset.ImageSpec{Ref: "nginx@1.0", Action: "run"})
+	dbID := createService(t, cs, "db", clientset.ImageSpec{Ref: "redis@7.0", Action: "run"})
+
+	tests := []struct {
+		name       string
+		cs         clientset.Interface
+		identifier string
+		wantID     string
+		wantErr    string
+	}{
+		{name: "ByName", cs: cs, identifier: "web", wantID: webID},
+		{name: "ByID", cs: cs, identifier: dbID, wantID: dbID},
+		{name: "NotFound", cs: cs, identifier: "missing", wantErr: "service 'missing' not found"},
+		{
+			name:       "Ambiguous",
+			cs:         duplicateNameClientset{cs},
+			identifier: "web",
+			wantErr:    fmt.Sprintf("multiple services found with name 'web', please use one of the following IDs: [%s %s-copy]", webID, webID),
+		},
+		// 重名时仍然可以用 ID 精确指定其中一个
+		{name: "AmbiguousByID", cs: duplicateNameClientset{cs}, identifier: webID + "-copy", wantID: webID + "-copy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, err := resolveService(context.Background(), tt.cs, tt.identifier)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("resolveService(%q) error = %v, want %q", tt.identifier, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveService(%q) failed: %v", tt.identifier, err)
+			}
+			if svc.ID != tt.wantID {
+				t.Errorf("resolveService(%q) = %s, want %s", tt.identifier, svc.ID, tt.wantID)
+			}
+		})
+	}
+
+	t.Run("ListFailure", func(t *testing.T) {
+		cs.Simulator.InjectFault(simulator.Fault{Method: http.MethodGet, Path: "service", StatusCode: http.StatusServiceUnavailable, Times: 1})
+		_, err := resolveService(context.Background(), cs, "web")
+		if err == nil || !strings.Contains(err.Error(), "failed to list services") {
+			t.Errorf("Expected a list error, got %v", err)
+		}
+	})
+}
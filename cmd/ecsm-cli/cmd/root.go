@@ -7,6 +7,9 @@ import (
 	"os"
 	"strings"
 
+	"github.com/fx147/ecsm-operator/internal/clientflags"
+	"github.com/fx147/ecsm-operator/internal/ecsm-cli/util"
+	"github.com/fx147/ecsm-operator/pkg/registry"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"k8s.io/klog/v2"
@@ -24,7 +27,10 @@ var (
 interact with the ECSM (Edge Container Service Mesh) platform.
 
 You can use it to manage resources like nodes, services, and containers
-without going through the ecsm-operator's declarative layer.`,
+without going through the ecsm-operator's declarative layer, or use
+apply/create/delete to manage ECSMService manifests in the ECSM Registry.`,
+		// 错误由 Execute 统一打印，避免 cobra 再打印一次
+		SilenceErrors: true,
 		// 如果用户只输入 ecsm-cli 而没有子命令，就打印帮助信息
 		Run: func(cmd *cobra.Command, args []string) {
 			cmd.Help()
@@ -32,6 +38,9 @@ without going through the ecsm-operator's declarative layer.`,
 	}
 )
 
+// newClientset 创建 get/describe 等子命令访问 ECSM 使用的 clientset，测试中替换为 fake clientset。
+var newClientset = util.NewClientsetFromFlags
+
 // Execute 将所有子命令添加到根命令中，并设置标志。
 // 这是 main.go 将调用的主函数。
 func Execute() {
@@ -51,21 +60,27 @@ func init() {
 	// --config 标志
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.ecsm-cli.yaml)")
 
-	// ECSM Server 连接相关的标志
-	rootCmd.PersistentFlags().String("host", "localhost", "The host of the ECSM API server")
-	rootCmd.PersistentFlags().String("port", "3001", "The port of the ECSM API server")
-	rootCmd.PersistentFlags().String("protocol", "http", "The protocol to use (http or https)")
+	// ECSM Server 连接、认证和 TLS 相关的标志，与 ecsm-operator 共用
+	clientflags.AddFlags(rootCmd.PersistentFlags(), clientflags.Options{EnvPrefix: "ECSMCLI"})
+
+	// ECSM Registry 相关的标志，apply/create/delete 通过它们读写声明式的 ECSMService
+	// 必须与 ecsm-operator 使用同一个后端和路径
+	rootCmd.PersistentFlags().String("store-backend", registry.StoreBackendFile, "The storage backend of the ECSM Registry (file or bolt), must match the one used by ecsm-operator")
+	rootCmd.PersistentFlags().String("store-path", "", fmt.Sprintf("The base directory (file) or database file (bolt) of the ECSM Registry (default %q or %q)",
+		registry.DefaultFileStorePath, registry.DefaultBoltStorePath))
 
 	// --- 将标志与 Viper 绑定 ---
 	// 这使得我们可以通过配置文件或环境变量来设置这些值
-	viper.BindPFlag("host", rootCmd.PersistentFlags().Lookup("host"))
-	viper.BindPFlag("port", rootCmd.PersistentFlags().Lookup("port"))
-	viper.BindPFlag("protocol", rootCmd.PersistentFlags().Lookup("protocol"))
+	viper.BindPFlag("store-backend", rootCmd.PersistentFlags().Lookup("store-backend"))
+	viper.BindPFlag("store-path", rootCmd.PersistentFlags().Lookup("store-path"))
 
 	// --- 添加子命令 ---
 	// 我们将在这里添加 get, describe 等命令
 	rootCmd.AddCommand(newGetCmd())
 	rootCmd.AddCommand(newDescribeCmd())
+	rootCmd.AddCommand(newApplyCmd())
+	rootCmd.AddCommand(newCreateCmd())
+	rootCmd.AddCommand(newDeleteCmd())
 }
 
 // initConfig 读取配置文件和环境变量（如果设置了的话）。
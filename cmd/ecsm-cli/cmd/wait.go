@@ -0,0 +1,168 @@
+// file: cmd/ecsm-cli/cmd/wait.go
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fx147/ecsm-operator/internal/ecsm-cli/util"
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/rest"
+	"github.com/fx147/ecsm-operator/pkg/registry"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// waitPollInterval 是等待 ecsm-operator 处理完对象时查询 Registry 和 ECSM 事务的间隔
+var waitPollInterval = time.Second
+
+// waitOptions 是 apply/create/delete 共用的、等待操作真正完成的标志。
+// 这些命令只修改 Registry，ECSM 上的服务由 ecsm-operator 异步地创建、更新和删除。
+// ECSM 的创建和更新接口不返回事务，所以 apply/create 等待的是 ecsm-operator 写回 status 的 conditions；
+// 删除接口返回事务，delete 会通过 ecsm-operator 记录的事务 ID 直接向 ECSM 查询删除是否成功。
+type waitOptions struct {
+	wait    bool
+	timeout time.Duration
+}
+
+func (o *waitOptions) addFlags(cmd *cobra.Command, usage string) {
+	cmd.Flags().BoolVar(&o.wait, "wait", false, usage)
+	cmd.Flags().DurationVar(&o.timeout, "timeout", 5*time.Minute, "The length of time to wait before giving up, only used with --wait (0 means wait forever)")
+}
+
+// context 返回等待使用的 context，--timeout 是等待所有对象的总时间，为 0 时不设置超时。
+func (o *waitOptions) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, o.timeout)
+}
+
+// waitForRollouts 依次等待 manifests 中的对象部署完成，返回每个没有完成的对象的错误。
+func (o *waitOptions) waitForRollouts(ctx context.Context, reg *registry.Registry, manifests []util.Manifest, out io.Writer) []error {
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	var errs []error
+	for _, m := range manifests {
+		if err := waitForRollout(ctx, reg, m.Service); err != nil {
+			errs = append(errs, objectError(m, err))
+			continue
+		}
+		fmt.Fprintf(out, "%s available\n", util.ObjectName(m.Service))
+	}
+	return errs
+}
+
+// waitForDeletions 依次等待 manifests 中的对象被删除，返回每个没有删除完成的对象的错误。
+func (o *waitOptions) waitForDeletions(ctx context.Context, reg *registry.Registry, manifests []util.Manifest) []error {
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	// 只有在 ecsm-operator 记录了删除事务时才需要连接 ECSM
+	var txs clientset.TransactionInterface
+	transactions := func() (clientset.TransactionInterface, error) {
+		if txs == nil {
+			cs, err := newClientset()
+			if err != nil {
+				return nil, err
+			}
+			txs = cs.Transactions()
+		}
+		return txs, nil
+	}
+
+	var errs []error
+	for _, m := range manifests {
+		if err := waitForDeletion(ctx, reg, transactions, m.Service); err != nil {
+			errs = append(errs, objectError(m, err))
+		}
+	}
+	return errs
+}
+
+// waitForRollout 等待 ecsm-operator 处理完 svc 的当前 generation，并且 ECSM 上的部署不再进行中。
+// 所有实例都在线时返回 nil；部署停滞（例如有实例失败）时返回带有 Degraded 信息的错误。
+func waitForRollout(ctx context.Context, reg *registry.Registry, svc *ecsmv1.ECSMService) error {
+	var current *ecsmv1.ECSMService
+	err := wait.PollUntilContextCancel(ctx, waitPollInterval, true, func(ctx context.Context) (bool, error) {
+		var err error
+		current, err = reg.GetService(ctx, svc.Namespace, svc.Name)
+		if err != nil {
+			return false, err
+		}
+		if current.UID != svc.UID {
+			return false, fmt.Errorf("the object has been deleted and recreated while waiting")
+		}
+		if current.Status.ObservedGeneration < svc.Generation {
+			return false, nil
+		}
+		progressing := meta.FindStatusCondition(current.Status.Conditions, ecsmv1.ECSMServiceProgressing)
+		return progressing != nil && progressing.ObservedGeneration >= svc.Generation &&
+			progressing.Status != metav1.ConditionTrue, nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("timed out waiting for the rollout to finish: %w", err)
+		}
+		return err
+	}
+
+	if meta.IsStatusConditionTrue(current.Status.Conditions, ecsmv1.ECSMServiceAvailable) {
+		return nil
+	}
+	if degraded := meta.FindStatusCondition(current.Status.Conditions, ecsmv1.ECSMServiceDegraded); degraded != nil && degraded.Message != "" {
+		return fmt.Errorf("rollout stalled: %s", degraded.Message)
+	}
+	return fmt.Errorf("rollout stalled: %d/%d replicas ready", current.Status.ReadyReplicas, current.Status.Replicas)
+}
+
+// waitForDeletion 等待 svc 从 Registry 中消失，即 ecsm-operator 已经删除了 ECSM 上的服务并移除了 finalizer。
+// ecsm-operator 在 ECSMServiceDeleteTransactionAnnotation 中记录了删除事务后，等待这个事务结束，
+// 事务失败时返回 *clientset.TransactionFailedError，而不是一直等到超时。
+func waitForDeletion(ctx context.Context, reg *registry.Registry, transactions func() (clientset.TransactionInterface, error), svc *ecsmv1.ECSMService) error {
+	var waited string
+	err := wait.PollUntilContextCancel(ctx, waitPollInterval, true, func(ctx context.Context) (bool, error) {
+		current, err := reg.GetService(ctx, svc.Namespace, svc.Name)
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if current.UID != svc.UID {
+			return true, nil
+		}
+
+		id := current.Annotations[ecsmv1.ECSMServiceDeleteTransactionAnnotation]
+		if id == "" || id == waited {
+			return false, nil
+		}
+		txs, err := transactions()
+		if err != nil {
+			return false, err
+		}
+		_, err = txs.WaitForTransaction(ctx, id, waitPollInterval)
+		switch {
+		case clientset.IsTransactionFailed(err):
+			return false, fmt.Errorf("the ecsm-operator failed to delete the ECSM service and will retry: %w", err)
+		case rest.IsNotFound(err):
+			// ECSM 已经不再保留这个事务，只能等待 ecsm-operator 移除 finalizer
+		case err != nil:
+			return false, fmt.Errorf("failed to get delete transaction %s: %w", id, err)
+		}
+		waited = id
+		return false, nil
+	})
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("timed out waiting for the deletion to finish: %w", err)
+	}
+	return err
+}
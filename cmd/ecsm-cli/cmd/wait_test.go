@@ -0,0 +1,110 @@
+// file: cmd/ecsm-cli/cmd/wait_test.go
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fx147/ecsm-operator/internal/ecsm-cli/util"
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset/fake"
+	"github.com/fx147/ecsm-operator/pkg/registry"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// deleteLikeOperator 像 ecsm-operator 一样处理一个正在被删除的 ECSMService：
+// 删除 ECSM 上的服务 serviceID，并把返回的删除事务记录到 ECSMService 上。
+func deleteLikeOperator(t *testing.T, reg *registry.Registry, cs *fake.Clientset, serviceID string) {
+	t.Helper()
+	resp, err := cs.Services().Delete(context.Background(), serviceID)
+	if err != nil {
+		t.Fatalf("Failed to delete service %s: %v", serviceID, err)
+	}
+	cs.Simulator.Settle()
+	if _, err := reg.SetServiceAnnotation(context.Background(), metav1.NamespaceDefault, "web", ecsmv1.ECSMServiceDeleteTransactionAnnotation, resp.ID); err != nil {
+		t.Fatalf("SetServiceAnnotation failed: %v", err)
+	}
+}
+
+func TestWaitForDeletions(t *testing.T) {
+	old := waitPollInterval
+	waitPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { waitPollInterval = old })
+
+	// setup 创建一个带有 finalizer 的 ECSMService 和 ECSM 上对应的服务，然后删除 ECSMService
+	setup := func(t *testing.T) (*registry.Registry, *fake.Clientset, string, util.Manifest) {
+		ctx := context.Background()
+		reg := newTestRegistry(t)
+		cs := newFakeClientset(t)
+		cs.Simulator.AddNode("worker1", "10.0.0.1")
+		serviceID := createService(t, cs, "web", clientset.ImageSpec{Ref: "nginx@1.0", Action: "run"})
+
+		if _, err := reg.CreateService(ctx, newManifestService("web", "nginx@1.0")); err != nil {
+			t.Fatalf("CreateService failed: %v", err)
+		}
+		existing, err := reg.AddServiceFinalizer(ctx, metav1.NamespaceDefault, "web", ecsmv1.ECSMServiceFinalizer)
+		if err != nil {
+			t.Fatalf("AddServiceFinalizer failed: %v", err)
+		}
+		if err := reg.DeleteService(ctx, metav1.NamespaceDefault, "web"); err != nil {
+			t.Fatalf("DeleteService failed: %v", err)
+		}
+		return reg, cs, serviceID, util.Manifest{Source: "service.yaml", Service: existing}
+	}
+
+	t.Run("TransactionFailed", func(t *testing.T) {
+		reg, cs, serviceID, m := setup(t)
+		cs.Simulator.FailNextTransaction("service is locked")
+		deleteLikeOperator(t, reg, cs, serviceID)
+
+		// finalizer 一直保留，但事务失败后不需要等到超时
+		w := &waitOptions{wait: true, timeout: time.Minute}
+		start := time.Now()
+		errs := w.waitForDeletions(context.Background(), reg, []util.Manifest{m})
+		if len(errs) != 1 || !clientset.IsTransactionFailed(errs[0]) || !strings.Contains(errs[0].Error(), "service is locked") {
+			t.Fatalf("Expected a failed delete transaction, got %v", errs)
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("Expected the failure to be reported before the timeout, took %v", elapsed)
+		}
+	})
+
+	t.Run("Succeeded", func(t *testing.T) {
+		reg, cs, serviceID, m := setup(t)
+		deleteLikeOperator(t, reg, cs, serviceID)
+
+		// 事务成功后仍然要等 ecsm-operator 移除 finalizer
+		time.AfterFunc(100*time.Millisecond, func() {
+			if err := reg.RemoveServiceFinalizer(context.Background(), metav1.NamespaceDefault, "web", ecsmv1.ECSMServiceFinalizer); err != nil {
+				t.Errorf("RemoveServiceFinalizer failed: %v", err)
+			}
+		})
+		w := &waitOptions{wait: true, timeout: 10 * time.Second}
+		if errs := w.waitForDeletions(context.Background(), reg, []util.Manifest{m}); len(errs) != 0 {
+			t.Fatalf("Expected the deletion to finish, got %v", errs)
+		}
+		if _, err := reg.GetService(context.Background(), metav1.NamespaceDefault, "web"); !errors.IsNotFound(err) {
+			t.Errorf("Expected the ECSMService to be gone, got %v", err)
+		}
+	})
+
+	t.Run("TimeoutWithoutOperator", func(t *testing.T) {
+		// 没有 ecsm-operator 记录删除事务时，只能等到超时，也不需要连接 ECSM
+		reg, _, _, m := setup(t)
+		newClientset = func() (*clientset.Clientset, error) {
+			t.Error("Expected no ECSM client to be created")
+			return nil, fmt.Errorf("no client")
+		}
+		w := &waitOptions{wait: true, timeout: 50 * time.Millisecond}
+		errs := w.waitForDeletions(context.Background(), reg, []util.Manifest{m})
+		if len(errs) != 1 || !strings.Contains(errs[0].Error(), "timed out waiting for the deletion to finish") {
+			t.Fatalf("Expected a timeout, got %v", errs)
+		}
+	})
+}
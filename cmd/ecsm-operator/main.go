@@ -0,0 +1,198 @@
+// file: cmd/ecsm-operator/main.go
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fx147/ecsm-operator/internal/clientflags"
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/controller"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/informers"
+	"github.com/fx147/ecsm-operator/pkg/registry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+)
+
+// cfgFile 用于存储配置文件的路径
+var cfgFile string
+
+func main() {
+	cmd := newOperatorCmd()
+
+	// 将 klog 的标志 (-v, --logtostderr 等) 添加到命令上
+	fs := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(fs)
+	cmd.PersistentFlags().AddGoFlagSet(fs)
+
+	if err := cmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// newOperatorCmd 创建 ecsm-operator 的根命令。
+// 所有参数都可以通过命令行标志、环境变量 (ECSM_OPERATOR_*) 或配置文件设置，优先级依次降低。
+func newOperatorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ecsm-operator",
+		Short: "Reconcile ECSMService objects against the ECSM platform",
+		Long: `ecsm-operator is a long-running daemon that watches the declarative
+ECSM Registry and keeps the services on the ECSM platform in sync with
+the ECSMService objects stored there.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return initConfig()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run()
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&cfgFile, "config", "", "config file (default is ./.ecsm-operator.yaml or $HOME/.ecsm-operator.yaml)")
+
+	// ECSM Server 连接、认证和 TLS 相关的标志，与 ecsm-cli 共用
+	clientflags.AddFlags(flags, clientflags.Options{DefaultRequestTimeout: 30 * time.Second, EnvPrefix: "ECSM_OPERATOR"})
+
+	// Registry 与控制器相关的标志
+	flags.String("store-backend", registry.StoreBackendFile, "The storage backend of the ECSM Registry (file or bolt), ecsm-cli must use the same one")
+	flags.String("store-path", "", fmt.Sprintf("The base directory (file) or database file (bolt) of the ECSM Registry (default %q or %q)",
+		registry.DefaultFileStorePath, registry.DefaultBoltStorePath))
+	flags.String("namespace", "", "Only reconcile ECSMServices in this namespace (default is all namespaces)")
+	flags.Duration("resync-period", 30*time.Second, "How often every ECSMService is reconciled")
+	flags.Duration("shutdown-timeout", 60*time.Second, "How long to wait for in-flight reconciles to finish after SIGTERM")
+	flags.Int("workers", 2, "The number of ECSMServices that are reconciled concurrently")
+	flags.Duration("retry-base-delay", controller.DefaultRetryBaseDelay, "The initial delay before a failed reconcile is retried, doubled on every further failure")
+	flags.Duration("retry-max-delay", controller.DefaultRetryMaxDelay, "The maximum delay before a failed reconcile is retried")
+	flags.Float64("retry-qps", controller.DefaultRetryQPS, "The maximum rate at which failed reconciles are requeued, across all ECSMServices (this does not limit calls to the ECSM API, see --api-qps)")
+	flags.Int("retry-burst", controller.DefaultRetryBurst, "The maximum burst of requeued failed reconciles, across all ECSMServices (this does not limit calls to the ECSM API, see --api-burst)")
+
+	for _, name := range []string{"store-backend", "store-path", "namespace", "resync-period", "shutdown-timeout",
+		"workers", "retry-base-delay", "retry-max-delay", "retry-qps", "retry-burst"} {
+		viper.BindPFlag(name, flags.Lookup(name))
+	}
+
+	return cmd
+}
+
+// initConfig 读取配置文件和环境变量（如果设置了的话）。
+func initConfig() error {
+	if cfgFile != "" {
+		viper.SetConfigFile(cfgFile)
+	} else {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			viper.AddConfigPath(home)
+		}
+		viper.AddConfigPath(".")
+		viper.SetConfigName(".ecsm-operator")
+		viper.SetConfigType("yaml")
+	}
+
+	// 设置环境变量前缀，例如 ECSM_OPERATOR_HOST, ECSM_OPERATOR_RESYNC_PERIOD
+	viper.SetEnvPrefix("ECSM_OPERATOR")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.AutomaticEnv()
+
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return fmt.Errorf("error reading config file: %w", err)
+		}
+	}
+	return nil
+}
+
+// run 初始化 Registry、ECSM 客户端和控制器，并运行调谐循环直到收到退出信号。
+func run() error {
+	resyncPeriod := viper.GetDuration("resync-period")
+	if resyncPeriod <= 0 {
+		return fmt.Errorf("--resync-period must be positive, got %s", resyncPeriod)
+	}
+	shutdownTimeout := viper.GetDuration("shutdown-timeout")
+	workers := viper.GetInt("workers")
+	if workers <= 0 {
+		return fmt.Errorf("--workers must be positive, got %d", workers)
+	}
+	retryQPS, retryBurst := viper.GetFloat64("retry-qps"), viper.GetInt("retry-burst")
+	if retryQPS <= 0 || retryBurst <= 0 {
+		return fmt.Errorf("--retry-qps and --retry-burst must be positive, got %v and %d", retryQPS, retryBurst)
+	}
+
+	// 1. 创建 Registry (世界一)
+	scheme := runtime.NewScheme()
+	if err := ecsmv1.AddToScheme(scheme); err != nil {
+		return fmt.Errorf("failed to build scheme: %w", err)
+	}
+	store, err := registry.NewStore(viper.GetString("store-backend"), viper.GetString("store-path"), scheme)
+	if err != nil {
+		return err
+	}
+	if closer, ok := store.(io.Closer); ok {
+		defer closer.Close()
+	}
+	reg := registry.NewRegistry(store)
+
+	// 2. 创建 ECSM 客户端 (世界二)
+	restConfig, err := clientflags.RESTConfig()
+	if err != nil {
+		return err
+	}
+	cs, err := clientset.NewClientsetForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	// 3. 创建控制器，并在收到 SIGINT/SIGTERM 时优雅退出
+	rateLimiter := controller.NewRequeueRateLimiter(viper.GetDuration("retry-base-delay"), viper.GetDuration("retry-max-delay"), retryQPS, retryBurst)
+	ctrl := controller.NewControllerWithRateLimiter(reg, cs, viper.GetString("namespace"), rateLimiter)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// 服务列表由共享的 informer 每个 resync 周期 list 一次，而不是每次调谐都查询
+	informerFactory := informers.NewSharedInformerFactory(cs, resyncPeriod)
+	ctrl.SetServiceInformer(informerFactory.Services())
+	informerFactory.Start(ctx)
+
+	klog.InfoS("Starting ecsm-operator",
+		"server", restConfig.Host,
+		"storeBackend", viper.GetString("store-backend"),
+		"storePath", viper.GetString("store-path"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ctrl.Run(ctx, resyncPeriod, workers)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	// 恢复默认的信号处理，这样再次发送 SIGTERM 可以立即终止进程
+	stop()
+	klog.InfoS("Received shutdown signal, waiting for in-flight reconciles to finish", "timeout", shutdownTimeout)
+
+	select {
+	case <-done:
+		klog.InfoS("ecsm-operator stopped")
+		return nil
+	case <-time.After(shutdownTimeout):
+		return fmt.Errorf("timed out after %s waiting for in-flight reconciles to finish", shutdownTimeout)
+	}
+}
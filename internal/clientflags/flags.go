@@ -0,0 +1,93 @@
+// file: internal/clientflags/flags.go
+
+// Package clientflags 定义了 ecsm-cli 和 ecsm-operator 共用的连接 ECSM API Server 的标志，
+// 并从这些标志（以及 viper 中绑定的配置文件字段和环境变量）构造 rest.Config。
+package clientflags
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/rest"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Options 描述了不同二进制之间有差异的标志默认值。
+type Options struct {
+	// DefaultRequestTimeout 是 --request-timeout 的默认值，0 表示不超时。
+	DefaultRequestTimeout time.Duration
+	// EnvPrefix 是 viper 使用的环境变量前缀，例如 "ECSMCLI"，只用于帮助信息。
+	EnvPrefix string
+}
+
+// flagNames 是 AddFlags 注册的所有标志，它们以同名的键绑定到 viper。
+var flagNames = []string{
+	"host", "port", "protocol", "api-path", "request-timeout", "api-qps", "api-burst",
+	"token", "token-file", "username", "password", "login-path",
+	"certificate-authority", "client-certificate", "client-key", "tls-server-name", "insecure-skip-tls-verify",
+}
+
+// AddFlags 在 flags 中注册连接 ECSM API Server 所需的连接、认证和 TLS 标志，并把它们绑定到全局的 viper。
+func AddFlags(flags *pflag.FlagSet, opts Options) {
+	// ECSM Server 连接相关的标志
+	flags.String("host", "localhost", "The host of the ECSM API server")
+	flags.String("port", "3001", "The port of the ECSM API server")
+	flags.String("protocol", "http", "The protocol to use (http or https)")
+	flags.String("api-path", "/api", "The path prefix of the ECSM API, e.g. /ecsm/api when ECSM is served under a reverse proxy sub-path")
+	flags.Duration("request-timeout", opts.DefaultRequestTimeout, "The timeout of a single request to the ECSM API server (0 means no timeout)")
+	flags.Float32("api-qps", rest.DefaultQPS, "The maximum queries per second to the ECSM API server (a negative value disables client-side throttling)")
+	flags.Int("api-burst", rest.DefaultBurst, "The maximum burst of queries to the ECSM API server")
+
+	// ECSM Server 认证相关的标志，token 与 username/password 只能二选一
+	flags.String("token", "", "Bearer token for authentication to the ECSM API server")
+	flags.String("token-file", "", "File containing the bearer token for authentication to the ECSM API server")
+	flags.String("username", "", "Username for authentication to the ECSM API server")
+	flags.String("password", "", fmt.Sprintf("Password for authentication to the ECSM API server (prefer %s_PASSWORD or the config file)", opts.EnvPrefix))
+	flags.String("login-path", "", "Path of the ECSM login endpoint, e.g. /api/v1/login. If set, username and password are exchanged for a session token instead of being sent as basic auth")
+
+	// ECSM Server TLS 相关的标志，仅在 --protocol=https 时生效
+	flags.String("certificate-authority", "", "Path to a PEM encoded CA certificate used to verify the ECSM API server certificate")
+	flags.String("client-certificate", "", "Path to a PEM encoded client certificate for TLS")
+	flags.String("client-key", "", "Path to a PEM encoded client key for TLS")
+	flags.String("tls-server-name", "", "Server name used to verify the ECSM API server certificate. If empty, the host is used")
+	flags.Bool("insecure-skip-tls-verify", false, "If true, the ECSM API server certificate will not be checked. This makes your HTTPS connections insecure")
+
+	for _, name := range flagNames {
+		viper.BindPFlag(name, flags.Lookup(name))
+	}
+}
+
+// RESTConfig 从全局的 viper 中读取 AddFlags 注册的标志，构造连接 ECSM API Server 的 rest.Config。
+// 认证和 TLS 设置可以来自命令行标志、配置文件中的同名字段或环境变量。
+func RESTConfig() (*rest.Config, error) {
+	host := viper.GetString("host")
+	port := viper.GetString("port")
+	protocol := viper.GetString("protocol")
+
+	if host == "" || port == "" || protocol == "" {
+		return nil, fmt.Errorf("host, port, and protocol must be specified")
+	}
+
+	return &rest.Config{
+		Host:    fmt.Sprintf("%s://%s:%s", protocol, host, port),
+		APIPath: viper.GetString("api-path"),
+		Timeout: viper.GetDuration("request-timeout"),
+		QPS:     float32(viper.GetFloat64("api-qps")),
+		Burst:   viper.GetInt("api-burst"),
+		AuthConfig: rest.AuthConfig{
+			BearerToken:     viper.GetString("token"),
+			BearerTokenFile: viper.GetString("token-file"),
+			Username:        viper.GetString("username"),
+			Password:        viper.GetString("password"),
+			LoginPath:       viper.GetString("login-path"),
+		},
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure:   viper.GetBool("insecure-skip-tls-verify"),
+			ServerName: viper.GetString("tls-server-name"),
+			CAFile:     viper.GetString("certificate-authority"),
+			CertFile:   viper.GetString("client-certificate"),
+			KeyFile:    viper.GetString("client-key"),
+		},
+	}, nil
+}
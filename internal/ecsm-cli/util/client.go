@@ -5,19 +5,26 @@ package util
 import (
 	"fmt"
 
+	"github.com/fx147/ecsm-operator/internal/clientflags"
 	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
-	"github.com/spf13/viper"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/rest"
 )
 
+// RESTConfigFromFlags 从 viper 中读取全局标志，构造连接 ECSM API Server 的 rest.Config。
+// 认证和 TLS 设置可以来自命令行标志、配置文件中的同名字段或 ECSMCLI_* 环境变量。
+func RESTConfigFromFlags() (*rest.Config, error) {
+	return clientflags.RESTConfig()
+}
+
 // NewClientsetFromFlags 从 viper 中读取全局标志，并创建一个新的 ecsm-client Clientset。
 func NewClientsetFromFlags() (*clientset.Clientset, error) {
-	host := viper.GetString("host")
-	port := viper.GetString("port")
-	protocol := viper.GetString("protocol")
-
-	if host == "" || port == "" || protocol == "" {
-		return nil, fmt.Errorf("host, port, and protocol must be specified")
+	config, err := RESTConfigFromFlags()
+	if err != nil {
+		return nil, err
 	}
-
-	return clientset.NewClientset(protocol, host, port) // http.Client 先用 nil
+	cs, err := clientset.NewClientsetForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client configuration: %w", err)
+	}
+	return cs, nil
 }
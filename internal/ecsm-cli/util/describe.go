@@ -0,0 +1,80 @@
+// file: internal/ecsm-cli/util/describe.go
+
+package util
+
+import "github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+
+// describe 命令聚合了多个接口的返回值。指定 -o 时打印的是下面这些聚合后的对象，
+// 它们的字段与人类可读的输出一一对应。describe 的 Table 没有列，不支持默认和 wide 表格。
+
+// NodeDescription 是 describe node 的输出对象。
+type NodeDescription struct {
+	Node    *clientset.NodeView    `json:"node"`
+	Metrics *clientset.NodeMetrics `json:"metrics"`
+}
+
+// NodeDescriptionTable 用于 describe node 的 -o name 输出。
+var NodeDescriptionTable = &Table[NodeDescription]{
+	Resource: "node",
+	Name:     func(d *NodeDescription) string { return d.Node.Name },
+}
+
+// ImageDescriptionTable 用于 describe image 的 -o name 输出，输出对象就是 ImageDetails。
+var ImageDescriptionTable = &Table[clientset.ImageDetails]{
+	Resource: "image",
+	Name:     func(d *clientset.ImageDetails) string { return d.Name + "@" + d.Tag },
+}
+
+// ServiceDescription 是 describe service 的输出对象。
+type ServiceDescription struct {
+	Service    *clientset.ServiceGet     `json:"service"`
+	Containers []clientset.ContainerInfo `json:"containers"`
+}
+
+// ServiceDescriptionTable 用于 describe service 的 -o name 输出。
+var ServiceDescriptionTable = &Table[ServiceDescription]{
+	Resource: "service",
+	Name:     func(d *ServiceDescription) string { return d.Service.Name },
+}
+
+// ContainerDescription 是 describe container 的输出对象，获取操作历史失败时 History 为空。
+type ContainerDescription struct {
+	Container *clientset.ContainerInfo     `json:"container"`
+	History   []clientset.ContainerHistory `json:"history"`
+}
+
+// ContainerDescriptionTable 用于 describe container 的 -o name 输出。
+var ContainerDescriptionTable = &Table[ContainerDescription]{
+	Resource: "container",
+	Name:     func(d *ContainerDescription) string { return d.Container.Name },
+}
+
+// TemplateDescriptionTable 用于 describe template 的 -o name 输出，输出对象就是 TemplateGet。
+var TemplateDescriptionTable = &Table[clientset.TemplateGet]{
+	Resource: "template",
+	Name:     func(t *clientset.TemplateGet) string { return t.Name },
+}
+
+// ConfigDescriptionTable 用于 describe config 的 -o name 输出，输出对象就是 ConfigItem。
+var ConfigDescriptionTable = &Table[clientset.ConfigItem]{
+	Resource: "config",
+	Name:     func(c *clientset.ConfigItem) string { return c.Key },
+}
+
+// RecordDescription 是 describe record 的输出对象。RecordGet 本身不包含记录的 ID，这里补上。
+type RecordDescription struct {
+	ID string `json:"id"`
+	clientset.RecordGet
+}
+
+// RecordDescriptionTable 用于 describe record 的 -o name 输出。
+var RecordDescriptionTable = &Table[RecordDescription]{
+	Resource: "record",
+	Name:     func(r *RecordDescription) string { return r.ID },
+}
+
+// MicroServiceDescriptionTable 用于 describe microservice 的 -o name 输出，输出对象就是 MicroServiceGet。
+var MicroServiceDescriptionTable = &Table[clientset.MicroServiceGet]{
+	Resource: "microservice",
+	Name:     func(m *clientset.MicroServiceGet) string { return m.Name },
+}
@@ -0,0 +1,152 @@
+// file: internal/ecsm-cli/util/manifest.go
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// manifestExtensions 是读取目录时会被当作清单文件的扩展名
+var manifestExtensions = []string{".yaml", ".yml", ".json"}
+
+// Manifest 是从清单文件中读取到的一个 ECSMService，Source 用于在错误信息中指明出处。
+type Manifest struct {
+	Source  string
+	Service *ecsmv1.ECSMService
+}
+
+// ObjectName 返回 kubectl 风格的对象名称，例如 "ecsmservice.ecsm.sh/web"。
+func ObjectName(service *ecsmv1.ECSMService) string {
+	return fmt.Sprintf("ecsmservice.%s/%s", ecsmv1.SchemeGroupVersion.Group, service.Name)
+}
+
+// ReadManifests 读取 filenames 中的所有清单。filename 可以是文件、目录或 "-"（从 stdin 读取）。
+// 一个文件中可以包含多个以 "---" 分隔的 YAML 文档，也可以是 JSON。
+// 读取目录时只处理 .yaml/.yml/.json 文件，recursive 为 true 时会进入子目录。
+func ReadManifests(filenames []string, recursive bool, stdin io.Reader) ([]Manifest, error) {
+	if len(filenames) == 0 {
+		return nil, fmt.Errorf("must specify one of -f or --filename")
+	}
+
+	var manifests []Manifest
+	for _, filename := range filenames {
+		if filename == "-" {
+			ms, err := decodeManifests("STDIN", stdin)
+			if err != nil {
+				return nil, err
+			}
+			manifests = append(manifests, ms...)
+			continue
+		}
+
+		paths, err := expandPath(filename, recursive)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			ms, err := decodeManifests(path, f)
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+			manifests = append(manifests, ms...)
+		}
+	}
+
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("no objects found in %s", strings.Join(filenames, ", "))
+	}
+	return manifests, nil
+}
+
+// expandPath 把目录展开为其中的清单文件，普通文件原样返回。
+func expandPath(path string, recursive bool) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var paths []string
+	err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != path && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(p))
+		for _, want := range manifestExtensions {
+			if ext == want {
+				paths = append(paths, p)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("error reading %s: no manifest files (%s) found", path, strings.Join(manifestExtensions, ", "))
+	}
+	return paths, nil
+}
+
+// decodeManifests 解码 r 中的所有文档，每个文档都必须是 ecsm.sh/v1 的 ECSMService。
+// 未知字段被视为错误，这样拼写错误不会被悄悄忽略。
+func decodeManifests(source string, r io.Reader) ([]Manifest, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+
+	var manifests []Manifest
+	for i := 0; ; i++ {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("error parsing %s: %w", source, err)
+		}
+		// 空文档（例如文件末尾多余的 "---"）
+		if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+			continue
+		}
+
+		var typeMeta metav1.TypeMeta
+		if err := json.Unmarshal(raw, &typeMeta); err != nil {
+			return nil, fmt.Errorf("error parsing %s (document %d): %w", source, i+1, err)
+		}
+		if typeMeta.APIVersion != ecsmv1.SchemeGroupVersion.String() || typeMeta.Kind != "ECSMService" {
+			return nil, fmt.Errorf("error parsing %s (document %d): unsupported object %q, kind %q (only %s ECSMService is supported)",
+				source, i+1, typeMeta.APIVersion, typeMeta.Kind, ecsmv1.SchemeGroupVersion.String())
+		}
+
+		service := &ecsmv1.ECSMService{}
+		strict := json.NewDecoder(bytes.NewReader(raw))
+		strict.DisallowUnknownFields()
+		if err := strict.Decode(service); err != nil {
+			return nil, fmt.Errorf("error parsing %s (document %d): %w", source, i+1, err)
+		}
+		manifests = append(manifests, Manifest{Source: source, Service: service})
+	}
+	return manifests, nil
+}
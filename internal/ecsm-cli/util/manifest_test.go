@@ -0,0 +1,231 @@
+// file: internal/ecsm-cli/util/manifest_test.go
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const webYAML = `apiVersion: ecsm.sh/v1
+kind: ECSMService
+metadata:
+  name: web
+  namespace: prod
+spec:
+  template:
+    image: nginx@1.0
+`
+
+const apiYAML = `apiVersion: ecsm.sh/v1
+kind: ECSMService
+metadata:
+  name: api
+spec:
+  template:
+    image: api@2.0
+`
+
+const webJSON = `{
+  "apiVersion": "ecsm.sh/v1",
+  "kind": "ECSMService",
+  "metadata": {"name": "web"},
+  "spec": {"template": {"image": "nginx@1.0"}}
+}`
+
+func TestDecodeManifests(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantNames []string
+		wantErr   string
+	}{
+		{
+			name:      "single YAML document",
+			input:     webYAML,
+			wantNames: []string{"web"},
+		},
+		{
+			name:      "multiple YAML documents",
+			input:     webYAML + "---\n" + apiYAML,
+			wantNames: []string{"web", "api"},
+		},
+		{
+			name:      "JSON",
+			input:     webJSON,
+			wantNames: []string{"web"},
+		},
+		{
+			name:      "empty documents are skipped",
+			input:     "---\n" + webYAML + "---\n---\n" + apiYAML + "---\n",
+			wantNames: []string{"web", "api"},
+		},
+		{
+			name:  "empty input",
+			input: "",
+		},
+		{
+			name:    "wrong kind",
+			input:   strings.Replace(webYAML, "kind: ECSMService", "kind: Deployment", 1),
+			wantErr: `(document 1): unsupported object "ecsm.sh/v1", kind "Deployment"`,
+		},
+		{
+			name:    "wrong apiVersion",
+			input:   webYAML + "---\n" + strings.Replace(apiYAML, "ecsm.sh/v1", "ecsm.sh/v2", 1),
+			wantErr: `(document 2): unsupported object "ecsm.sh/v2", kind "ECSMService"`,
+		},
+		{
+			name:    "missing apiVersion and kind",
+			input:   "metadata:\n  name: web\n",
+			wantErr: `unsupported object "", kind ""`,
+		},
+		{
+			name:    "unknown field",
+			input:   strings.Replace(webYAML, "image: nginx@1.0", "image: nginx@1.0\n    imagee: typo", 1),
+			wantErr: `unknown field "imagee"`,
+		},
+		{
+			name:    "malformed YAML",
+			input:   "apiVersion: [ecsm.sh/v1\n",
+			wantErr: "error parsing test",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifests, err := decodeManifests("test", strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected an error containing %q, but got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeManifests failed: %v", err)
+			}
+			var names []string
+			for _, m := range manifests {
+				if m.Source != "test" {
+					t.Errorf("Expected source %q, but got %q", "test", m.Source)
+				}
+				names = append(names, m.Service.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("Expected objects %v, but got %v", tt.wantNames, names)
+			}
+		})
+	}
+}
+
+func TestDecodeManifests_Fields(t *testing.T) {
+	manifests, err := decodeManifests("test", strings.NewReader(webYAML))
+	if err != nil {
+		t.Fatalf("decodeManifests failed: %v", err)
+	}
+	svc := manifests[0].Service
+	if svc.Namespace != "prod" || svc.Spec.Template.Image != "nginx@1.0" {
+		t.Errorf("Expected namespace prod and image nginx@1.0, but got %q and %q", svc.Namespace, svc.Spec.Template.Image)
+	}
+}
+
+func TestReadManifests(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(rel, content string) string {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	webFile := writeFile("services/web.yaml", webYAML)
+	writeFile("services/api.json", strings.Replace(webJSON, `"web"`, `"api"`, 1))
+	writeFile("services/README.md", "not a manifest")
+	writeFile("services/nested/db.yml", strings.Replace(apiYAML, "name: api", "name: db", 1))
+	writeFile("empty/notes.txt", "")
+
+	tests := []struct {
+		name      string
+		filenames []string
+		recursive bool
+		stdin     string
+		wantNames []string
+		wantErr   string
+	}{
+		{
+			name:      "file",
+			filenames: []string{webFile},
+			wantNames: []string{"web"},
+		},
+		{
+			name:      "stdin",
+			filenames: []string{"-"},
+			stdin:     webYAML + "---\n" + apiYAML,
+			wantNames: []string{"web", "api"},
+		},
+		{
+			name:      "stdin and file",
+			filenames: []string{"-", webFile},
+			stdin:     apiYAML,
+			wantNames: []string{"api", "web"},
+		},
+		{
+			name:      "directory skips non-manifest files and subdirectories",
+			filenames: []string{filepath.Join(dir, "services")},
+			wantNames: []string{"api", "web"},
+		},
+		{
+			name:      "recursive directory",
+			filenames: []string{filepath.Join(dir, "services")},
+			recursive: true,
+			wantNames: []string{"api", "db", "web"},
+		},
+		{
+			name:      "no filenames",
+			filenames: nil,
+			wantErr:   "must specify one of -f or --filename",
+		},
+		{
+			name:      "empty stdin",
+			filenames: []string{"-"},
+			stdin:     "---\n",
+			wantErr:   "no objects found in -",
+		},
+		{
+			name:      "directory without manifests",
+			filenames: []string{filepath.Join(dir, "empty")},
+			wantErr:   "no manifest files",
+		},
+		{
+			name:      "missing file",
+			filenames: []string{filepath.Join(dir, "missing.yaml")},
+			wantErr:   "no such file or directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifests, err := ReadManifests(tt.filenames, tt.recursive, strings.NewReader(tt.stdin))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected an error containing %q, but got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadManifests failed: %v", err)
+			}
+			var names []string
+			for _, m := range manifests {
+				names = append(names, m.Service.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("Expected objects %v, but got %v", tt.wantNames, names)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// file: internal/ecsm-cli/util/print_flags.go
+
+package util
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// 支持的输出格式，与 kubectl 的 -o 保持一致。
+const (
+	OutputFormatJSON          = "json"
+	OutputFormatYAML          = "yaml"
+	OutputFormatWide          = "wide"
+	OutputFormatName          = "name"
+	OutputFormatJSONPath      = "jsonpath"
+	OutputFormatGoTemplate    = "go-template"
+	OutputFormatCustomColumns = "custom-columns"
+)
+
+var allowedFormats = []string{
+	OutputFormatJSON,
+	OutputFormatYAML,
+	OutputFormatWide,
+	OutputFormatName,
+	OutputFormatJSONPath + "=...",
+	OutputFormatGoTemplate + "=...",
+	OutputFormatCustomColumns + "=...",
+}
+
+// PrintFlags 是 get/describe 命令共用的输出标志。
+type PrintFlags struct {
+	// OutputFormat 是 -o 的值，例如 "json" 或 "jsonpath={.items[*].name}"，为空时打印表格。
+	OutputFormat string
+	// NoHeaders 为 true 时表格不打印表头。
+	NoHeaders bool
+	// SortBy 是排序使用的 JSONPath 表达式，例如 ".name" 或 "{.status.replicas}"。
+	SortBy string
+}
+
+// AddFlags 为列表命令注册 -o、--no-headers 和 --sort-by。
+func (f *PrintFlags) AddFlags(cmd *cobra.Command) {
+	f.AddOutputFlag(cmd)
+	cmd.Flags().BoolVar(&f.NoHeaders, "no-headers", false, "When using the default, wide or custom-columns output format, don't print headers")
+	cmd.Flags().StringVar(&f.SortBy, "sort-by", "", "If non-empty, sort list types using this field specification. The field specification is expressed as a JSONPath expression (e.g. '{.name}')")
+}
+
+// AddOutputFlag 只注册 -o，用于只输出单个对象的 describe 命令。
+func (f *PrintFlags) AddOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.OutputFormat, "output", "o", "", "Output format. One of: "+strings.Join(allowedFormats, "|"))
+}
+
+// IsHumanReadable 判断输出是否是默认或 wide 表格。
+// 这两种格式在没有对象时由命令打印 "No xxx found."，其他格式打印一个空的列表。
+func (f *PrintFlags) IsHumanReadable() bool {
+	return f.OutputFormat == "" || f.OutputFormat == OutputFormatWide
+}
+
+// NewPrinter 根据标志创建打印 T 的 Printer，table 描述了 T 的表格列和 -o name 的输出。
+// 标志的值有误（例如未知的格式、无法解析的模板）时返回错误。
+func NewPrinter[T any](f *PrintFlags, table *Table[T]) (Printer[T], error) {
+	format, arg, hasArg := strings.Cut(f.OutputFormat, "=")
+	p := &printer[T]{format: format, noHeaders: f.NoHeaders, table: table}
+
+	switch format {
+	case "", OutputFormatWide:
+		if len(table.Columns) == 0 {
+			if format == "" {
+				return nil, fmt.Errorf("an output format must be specified")
+			}
+			return nil, fmt.Errorf("output format %q is not supported for this resource", format)
+		}
+	case OutputFormatJSON, OutputFormatYAML, OutputFormatName:
+	case OutputFormatJSONPath, OutputFormatGoTemplate, OutputFormatCustomColumns:
+		if !hasArg || arg == "" {
+			return nil, fmt.Errorf("template format specified but no template given")
+		}
+	default:
+		return nil, fmt.Errorf("unable to match a printer suitable for the output format %q, allowed formats are: %s",
+			f.OutputFormat, strings.Join(allowedFormats, ","))
+	}
+	if hasArg && format != OutputFormatJSONPath && format != OutputFormatGoTemplate && format != OutputFormatCustomColumns {
+		return nil, fmt.Errorf("output format %q does not take an argument", format)
+	}
+
+	var err error
+	switch format {
+	case OutputFormatJSONPath:
+		if p.jsonPath, err = parseJSONPath("output", arg); err != nil {
+			return nil, err
+		}
+	case OutputFormatGoTemplate:
+		if p.template, err = template.New("output").Parse(arg); err != nil {
+			return nil, fmt.Errorf("error parsing template %s: %w", arg, err)
+		}
+	case OutputFormatCustomColumns:
+		if p.customColumns, err = parseCustomColumns(arg); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.SortBy != "" {
+		expr, err := relaxedJSONPathExpression(f.SortBy)
+		if err != nil {
+			return nil, err
+		}
+		if p.sortBy, err = parseJSONPath("sorting", expr); err != nil {
+			return nil, err
+		}
+		p.sortByExpr = expr
+	}
+	return p, nil
+}
+
+// parseJSONPath 解析 JSONPath 模板，缺少的字段输出为空而不是报错。
+func parseJSONPath(name, text string) (*jsonpath.JSONPath, error) {
+	j := jsonpath.New(name).AllowMissingKeys(true)
+	if err := j.Parse(text); err != nil {
+		return nil, fmt.Errorf("error parsing jsonpath %s: %w", text, err)
+	}
+	return j, nil
+}
+
+// relaxedJSONPathExpression 像 kubectl 一样接受 "name"、".name" 和 "{.name}" 三种写法，
+// 统一转换为 "{.name}"。
+func relaxedJSONPathExpression(expr string) (string, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return "", fmt.Errorf("jsonpath expression cannot be empty")
+	}
+	if strings.HasPrefix(expr, "{") {
+		if !strings.HasSuffix(expr, "}") {
+			return "", fmt.Errorf("unexpected path string, expected a 'name1.name2' or '.name1.name2' or '{name1.name2}' or '{.name1.name2}'")
+		}
+		expr = strings.TrimSuffix(strings.TrimPrefix(expr, "{"), "}")
+	}
+	if !strings.HasPrefix(expr, ".") {
+		expr = "." + expr
+	}
+	return "{" + expr + "}", nil
+}
+
+// parseCustomColumns 解析 "HEADER1:.path1,HEADER2:.path2" 形式的列定义。
+func parseCustomColumns(spec string) ([]customColumn, error) {
+	var columns []customColumn
+	for _, part := range strings.Split(spec, ",") {
+		header, path, ok := strings.Cut(part, ":")
+		if !ok || header == "" {
+			return nil, fmt.Errorf("unexpected custom-columns spec: %s, expected <header>:<json-path-expr>", part)
+		}
+		expr, err := relaxedJSONPathExpression(path)
+		if err != nil {
+			return nil, err
+		}
+		j, err := parseJSONPath(header, expr)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, customColumn{header: header, path: j})
+	}
+	return columns, nil
+}
@@ -3,43 +3,46 @@
 package util
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
 	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
 )
 
-// PrintNodesTable 将节点列表以表格形式打印到指定的 writer。
-func PrintNodesTable(out io.Writer, nodes []clientset.NodeInfo) {
-	// 初始化 tabwriter
-	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
-	defer w.Flush()
-
-	// 打印表头
-	fmt.Fprintln(w, "NAME\tSTATUS\tADDRESS\tTYPE\tARCH\tCONTAINERS\tCREATED\tUPTIME\tID")
-
-	// 打印每一行
-	for _, node := range nodes {
-		containerInfo := fmt.Sprintf("%d/%d", node.ContainerEcsmRunning, node.ContainerEcsmTotal)
-		uptimeDuration := time.Duration(node.UpTime) * time.Second
-		uptimeStr := formatUptime(uptimeDuration)
-
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-			node.Name,
-			node.Status,
-			node.Address,
-			node.Type,
-			node.Arch,
-			containerInfo,
-			node.CreatedTime,
-			uptimeStr,
-			node.ID,
-		)
-	}
+// NodeTable 描述了节点列表的表格。
+var NodeTable = &Table[clientset.NodeInfo]{
+	Resource: "node",
+	Name:     func(node *clientset.NodeInfo) string { return node.Name },
+	Columns: []Column[clientset.NodeInfo]{
+		{Header: "NAME", Value: func(node *clientset.NodeInfo) string { return node.Name }},
+		{Header: "STATUS", Value: func(node *clientset.NodeInfo) string { return node.Status }},
+		{Header: "ADDRESS", Value: func(node *clientset.NodeInfo) string { return node.Address }},
+		{Header: "TYPE", Value: func(node *clientset.NodeInfo) string { return node.Type }},
+		{Header: "ARCH", Value: func(node *clientset.NodeInfo) string { return node.Arch }},
+		{Header: "CONTAINERS", Value: func(node *clientset.NodeInfo) string {
+			return fmt.Sprintf("%d/%d", node.ContainerEcsmRunning, node.ContainerEcsmTotal)
+		}},
+		{Header: "CREATED", Value: func(node *clientset.NodeInfo) string { return node.CreatedTime }},
+		{Header: "UPTIME", Value: func(node *clientset.NodeInfo) string {
+			return formatUptime(time.Duration(node.UpTime) * time.Second)
+		}},
+		{Header: "ID", Value: func(node *clientset.NodeInfo) string { return node.ID }},
+		// ALL_CONTAINERS 包括不是由 ECSM 管理的容器
+		{Header: "ALL_CONTAINERS", Wide: true, Value: func(node *clientset.NodeInfo) string {
+			return fmt.Sprintf("%d/%d", node.ContainerRunning, node.ContainerTotal)
+		}},
+		{Header: "TLS", Wide: true, Value: func(node *clientset.NodeInfo) string { return strconv.FormatBool(node.TLS) }},
+	},
 }
 
 // formatUptime 是一个新的辅助函数，用于将时长格式化为 "XdYhZm" 的形式
@@ -50,34 +53,34 @@ func formatUptime(d time.Duration) string {
 	return fmt.Sprintf("%dd%dh%dm", days, hours, minutes)
 }
 
-// PrintImagesTable 将镜像列表以表格形式打印到指定的 writer。
-func PrintImagesTable(out io.Writer, images []clientset.ImageListItem) {
-	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
-	defer w.Flush()
-
-	// 打印表头
-	fmt.Fprintln(w, "NAME\tTAG\tOS\tARCH\tSIZE(MB)\tCREATED")
-
-	for _, img := range images {
-		// 解析并格式化创建时间
-		createdTime, err := time.Parse(time.RFC3339Nano, img.CreatedTime)
-		var createdStr string
-		if err == nil {
+// ImageTable 描述了镜像列表的表格。
+var ImageTable = &Table[clientset.ImageListItem]{
+	Resource: "image",
+	Name:     func(img *clientset.ImageListItem) string { return img.Name + "@" + img.Tag },
+	Columns: []Column[clientset.ImageListItem]{
+		{Header: "NAME", Value: func(img *clientset.ImageListItem) string { return img.Name }},
+		{Header: "TAG", Value: func(img *clientset.ImageListItem) string { return img.Tag }},
+		{Header: "OS", Value: func(img *clientset.ImageListItem) string { return img.OS }},
+		{Header: "ARCH", Value: func(img *clientset.ImageListItem) string { return img.Arch }},
+		{Header: "SIZE(MB)", Value: func(img *clientset.ImageListItem) string { return fmt.Sprintf("%.2f", img.Size) }},
+		{Header: "CREATED", Value: func(img *clientset.ImageListItem) string {
+			// 解析并格式化创建时间
+			createdTime, err := time.Parse(time.RFC3339Nano, img.CreatedTime)
+			if err != nil {
+				return "N/A" // 如果时间格式解析失败，则优雅地处理
+			}
 			// 使用一个更友好的格式，例如 "2023-11-17"
-			createdStr = createdTime.Format("2006-01-02")
-		} else {
-			createdStr = "N/A" // 如果时间格式解析失败，则优雅地处理
-		}
-
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.2f\t%s\n",
-			img.Name,
-			img.Tag,
-			img.OS,
-			img.Arch,
-			img.Size,
-			createdStr,
-		)
-	}
+			return createdTime.Format("2006-01-02")
+		}},
+		{Header: "AUTHOR", Wide: true, Value: func(img *clientset.ImageListItem) string {
+			if img.Author == nil {
+				return "<none>"
+			}
+			return valueOrNone(*img.Author)
+		}},
+		{Header: "PULLED", Wide: true, Value: func(img *clientset.ImageListItem) string { return strconv.FormatBool(img.Pulled) }},
+		{Header: "ID", Wide: true, Value: func(img *clientset.ImageListItem) string { return img.ID }},
+	},
 }
 
 // PrintImageDetails 将单个镜像的详细信息以分层、人类可读的格式打印出来。
@@ -97,123 +100,127 @@ func PrintImageDetails(out io.Writer, details *clientset.ImageDetails) {
 	fmt.Fprintf(out, "Pulled:       %s\n", strconv.FormatBool(details.Pulled))
 
 	// --- 打印 Config 部分 (严格按照 EcsImageConfig 结构) ---
-	if details.Config != nil {
-		config := details.Config
-		fmt.Fprintf(out, "Configuration:\n")
+	printImageConfig(out, details.Config)
+}
 
-		if config.Hostname != "" {
-			fmt.Fprintf(out, "  Hostname:     %s\n", config.Hostname)
-		}
+// printImageConfig 打印镜像、部署记录和模板中的 EcsImageConfig，config 为 nil 时说明配置不可用。
+func printImageConfig(out io.Writer, config *clientset.EcsImageConfig) {
+	if config == nil {
+		fmt.Fprintf(out, "Configuration: Not available\n")
+		return
+	}
+	fmt.Fprintf(out, "Configuration:\n")
 
-		// --- 核心修复 1: 打印 Root 信息 ---
-		if config.Root != nil {
-			fmt.Fprintf(out, "  Root Filesystem:\n")
-			fmt.Fprintf(out, "    Path:       %s\n", config.Root.Path)
-			fmt.Fprintf(out, "    Read Only:  %t\n", config.Root.Readonly)
-		}
+	if config.Hostname != "" {
+		fmt.Fprintf(out, "  Hostname:     %s\n", config.Hostname)
+	}
 
-		// 打印 Process 信息
-		if config.Process != nil {
-			fmt.Fprintf(out, "  Process:\n")
-			if len(config.Process.Args) > 0 {
-				// 使用 Args 作为 Command
-				fmt.Fprintf(out, "    Command:      %s\n", strings.Join(config.Process.Args, " "))
-			}
-			if config.Process.Cwd != "" {
-				fmt.Fprintf(out, "    Working Dir:  %s\n", config.Process.Cwd)
-			}
-			if len(config.Process.Env) > 0 {
-				fmt.Fprintf(out, "    Environment:\n")
-				for _, env := range config.Process.Env {
-					fmt.Fprintf(out, "      %s\n", env)
-				}
+	// --- 核心修复 1: 打印 Root 信息 ---
+	if config.Root != nil {
+		fmt.Fprintf(out, "  Root Filesystem:\n")
+		fmt.Fprintf(out, "    Path:       %s\n", config.Root.Path)
+		fmt.Fprintf(out, "    Read Only:  %t\n", config.Root.Readonly)
+	}
+
+	// 打印 Process 信息
+	if config.Process != nil {
+		fmt.Fprintf(out, "  Process:\n")
+		if len(config.Process.Args) > 0 {
+			// 使用 Args 作为 Command
+			fmt.Fprintf(out, "    Command:      %s\n", strings.Join(config.Process.Args, " "))
+		}
+		if config.Process.Cwd != "" {
+			fmt.Fprintf(out, "    Working Dir:  %s\n", config.Process.Cwd)
+		}
+		if len(config.Process.Env) > 0 {
+			fmt.Fprintf(out, "    Environment:\n")
+			for _, env := range config.Process.Env {
+				fmt.Fprintf(out, "      %s\n", env)
 			}
 		}
+	}
 
-		// 打印 Mounts
-		if len(config.Mounts) > 0 {
-			fmt.Fprintf(out, "  Mounts:\n")
-			for _, mount := range config.Mounts {
-				fmt.Fprintf(out, "    - Destination: %s\n", mount.Destination)
-				fmt.Fprintf(out, "      Source:      %s\n", mount.Source)
-				fmt.Fprintf(out, "      Options:     %s\n", strings.Join(mount.Options, ","))
-			}
+	// 打印 Mounts
+	if len(config.Mounts) > 0 {
+		fmt.Fprintf(out, "  Mounts:\n")
+		for _, mount := range config.Mounts {
+			fmt.Fprintf(out, "    - Destination: %s\n", mount.Destination)
+			fmt.Fprintf(out, "      Source:      %s\n", mount.Source)
+			fmt.Fprintf(out, "      Options:     %s\n", strings.Join(mount.Options, ","))
 		}
+	}
 
-		// 打印 SylixOS 特有配置
-		if config.SylixOS != nil {
-			s := config.SylixOS
-			fmt.Fprintf(out, "  SylixOS:\n")
-			// 1. Commands
-			if len(s.Commands) > 0 {
-				fmt.Fprintf(out, "    Commands: (%s)\n", strings.Join(s.Commands, ", "))
+	// 打印 SylixOS 特有配置
+	if config.SylixOS != nil {
+		s := config.SylixOS
+		fmt.Fprintf(out, "  SylixOS:\n")
+		// 1. Commands
+		if len(s.Commands) > 0 {
+			fmt.Fprintf(out, "    Commands: (%s)\n", strings.Join(s.Commands, ", "))
+		}
+		// 2. Devices
+		if len(s.Devices) > 0 {
+			fmt.Fprintf(out, "    Devices:\n")
+			for _, device := range s.Devices {
+				fmt.Fprintf(out, "      %s (access: %s)\n", device.Path, device.Access)
 			}
-			// 2. Devices
-			if len(s.Devices) > 0 {
-				fmt.Fprintf(out, "    Devices:\n")
-				for _, device := range s.Devices {
-					fmt.Fprintf(out, "      %s (access: %s)\n", device.Path, device.Access)
-				}
+		}
+		// 3. Network
+		if s.Network != nil {
+			fmt.Fprintf(out, "    Network:\n")
+			fmt.Fprintf(out, "      FTPD Enabled:    %t\n", s.Network.FtpdEnable)
+			fmt.Fprintf(out, "      TelnetD Enabled: %t\n", s.Network.TelnetdEnable)
+		}
+		// 4. Resources
+		if s.Resources != nil {
+			fmt.Fprintf(out, "    Resources:\n")
+			if s.Resources.Memory != nil {
+				fmt.Fprintf(out, "      Memory Limit:  %d MB\n", s.Resources.Memory.MemoryLimitMB)
+				fmt.Fprintf(out, "      KHeap Limit:   %d\n", s.Resources.Memory.KheapLimit)
 			}
-			// 3. Network
-			if s.Network != nil {
-				fmt.Fprintf(out, "    Network:\n")
-				fmt.Fprintf(out, "      FTPD Enabled:    %t\n", s.Network.FtpdEnable)
-				fmt.Fprintf(out, "      TelnetD Enabled: %t\n", s.Network.TelnetdEnable)
+			if s.Resources.Disk != nil {
+				fmt.Fprintf(out, "      Disk Limit:    %d MB\n", s.Resources.Disk.LimitMB)
 			}
-			// 4. Resources
-			if s.Resources != nil {
-				fmt.Fprintf(out, "    Resources:\n")
-				if s.Resources.Memory != nil {
-					fmt.Fprintf(out, "      Memory Limit:  %d MB\n", s.Resources.Memory.MemoryLimitMB)
-					fmt.Fprintf(out, "      KHeap Limit:   %d\n", s.Resources.Memory.KheapLimit)
+			if s.Resources.CPU != nil {
+				fmt.Fprintf(out, "      CPU Priority (High/Low): %s/%s\n", intOrNone(s.Resources.CPU.HighestPrio), intOrNone(s.Resources.CPU.LowestPrio))
+			}
+			if s.Resources.KernelObject != nil {
+				ko := s.Resources.KernelObject
+				fmt.Fprintf(out, "      Kernel Objects:\n")
+				fmt.Fprintf(out, "        Thread Limit:         %d\n", ko.ThreadLimit)
+				fmt.Fprintf(out, "        Thread Pool Limit:    %d\n", ko.ThreadPoolLimit)
+				fmt.Fprintf(out, "        Event Limit:          %d\n", ko.EventLimit)
+				fmt.Fprintf(out, "        Event Set Limit:      %d\n", ko.EventSetLimit)
+				fmt.Fprintf(out, "        Partition Limit:      %d\n", ko.PartitionLimit)
+				fmt.Fprintf(out, "        Region Limit:         %d\n", ko.RegionLimit)
+				fmt.Fprintf(out, "        Msg Queue Limit:      %d\n", ko.MsgQueueLimit)
+				fmt.Fprintf(out, "        Timer Limit:          %d\n", ko.TimerLimit)
+				if ko.RMSLimit > 0 {
+					fmt.Fprintf(out, "        RMS Limit:            %d\n", ko.RMSLimit)
+				}
+				if ko.ThreadVarLimit > 0 {
+					fmt.Fprintf(out, "        Thread Var Limit:     %d\n", ko.ThreadVarLimit)
+				}
+				if ko.PosixMqueueLimit > 0 {
+					fmt.Fprintf(out, "        Posix Mqueue Limit:   %d\n", ko.PosixMqueueLimit)
+				}
+				if ko.DlopenLibraryLimit > 0 {
+					fmt.Fprintf(out, "        Dlopen Library Limit: %d\n", ko.DlopenLibraryLimit)
+				}
+				if ko.XSIIPCLimit > 0 {
+					fmt.Fprintf(out, "        XSIIPC Limit:         %d\n", ko.XSIIPCLimit)
 				}
-				if s.Resources.Disk != nil {
-					fmt.Fprintf(out, "      Disk Limit:    %d MB\n", s.Resources.Disk.LimitMB)
+				if ko.SocketLimit > 0 {
+					fmt.Fprintf(out, "        Socket Limit:         %d\n", ko.SocketLimit)
 				}
-				if s.Resources.CPU != nil {
-					fmt.Fprintf(out, "      CPU Priority (High/Low): %d/%d\n", s.Resources.CPU.HighestPrio, s.Resources.CPU.LowestPrio)
+				if ko.SRTPLimit > 0 {
+					fmt.Fprintf(out, "        SRTP Limit:           %d\n", ko.SRTPLimit)
 				}
-				if s.Resources.KernelObject != nil {
-					ko := s.Resources.KernelObject
-					fmt.Fprintf(out, "      Kernel Objects:\n")
-					fmt.Fprintf(out, "        Thread Limit:         %d\n", ko.ThreadLimit)
-					fmt.Fprintf(out, "        Thread Pool Limit:    %d\n", ko.ThreadPoolLimit)
-					fmt.Fprintf(out, "        Event Limit:          %d\n", ko.EventLimit)
-					fmt.Fprintf(out, "        Event Set Limit:      %d\n", ko.EventSetLimit)
-					fmt.Fprintf(out, "        Partition Limit:      %d\n", ko.PartitionLimit)
-					fmt.Fprintf(out, "        Region Limit:         %d\n", ko.RegionLimit)
-					fmt.Fprintf(out, "        Msg Queue Limit:      %d\n", ko.MsgQueueLimit)
-					fmt.Fprintf(out, "        Timer Limit:          %d\n", ko.TimerLimit)
-					if ko.RMSLimit > 0 {
-						fmt.Fprintf(out, "        RMS Limit:            %d\n", ko.RMSLimit)
-					}
-					if ko.ThreadVarLimit > 0 {
-						fmt.Fprintf(out, "        Thread Var Limit:     %d\n", ko.ThreadVarLimit)
-					}
-					if ko.PosixMqueueLimit > 0 {
-						fmt.Fprintf(out, "        Posix Mqueue Limit:   %d\n", ko.PosixMqueueLimit)
-					}
-					if ko.DlopenLibraryLimit > 0 {
-						fmt.Fprintf(out, "        Dlopen Library Limit: %d\n", ko.DlopenLibraryLimit)
-					}
-					if ko.XSIIPCLimit > 0 {
-						fmt.Fprintf(out, "        XSIIPC Limit:         %d\n", ko.XSIIPCLimit)
-					}
-					if ko.SocketLimit > 0 {
-						fmt.Fprintf(out, "        Socket Limit:         %d\n", ko.SocketLimit)
-					}
-					if ko.SRTPLimit > 0 {
-						fmt.Fprintf(out, "        SRTP Limit:           %d\n", ko.SRTPLimit)
-					}
-					if ko.DeviceLimit > 0 {
-						fmt.Fprintf(out, "        Device Limit:         %d\n", ko.DeviceLimit)
-					}
+				if ko.DeviceLimit > 0 {
+					fmt.Fprintf(out, "        Device Limit:         %d\n", ko.DeviceLimit)
 				}
 			}
 		}
-	} else {
-		fmt.Fprintf(out, "Configuration: Not available\n")
 	}
 }
 
@@ -279,32 +286,36 @@ func PrintNodeDetails(out io.Writer, view *clientset.NodeView, metrics *clientse
 	}
 }
 
-// PrintServicesTable 将服务列表以表格形式打印到指定的 writer。
-func PrintServicesTable(out io.Writer, services []clientset.ProvisionListRow) {
-	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
-	defer w.Flush()
-
-	// 打印表头
-	fmt.Fprintln(w, "NAME\tDEPLOY_STATUS\tPOLICY\tONLINE\tDESIRED\tIMAGE\tID")
-
-	for _, svc := range services {
-		// 组合一个易于阅读的镜像名
-		imageName := "N/A"
-		if len(svc.ImageList) > 0 {
-			img := svc.ImageList[0]
-			imageName = fmt.Sprintf("%s:%s", img.Name, img.Tag)
-		}
-
-		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
-			svc.Name,
-			svc.Status,
-			svc.Policy,
-			svc.InstanceOnline,
-			svc.Factor, // Factor 代表期望的副本数
-			imageName,
-			svc.ID,
-		)
-	}
+// ServiceTable 描述了 ECSM 服务列表的表格。
+var ServiceTable = &Table[clientset.ProvisionListRow]{
+	Resource: "service",
+	Name:     func(svc *clientset.ProvisionListRow) string { return svc.Name },
+	Padding:  2,
+	Columns: []Column[clientset.ProvisionListRow]{
+		{Header: "NAME", Value: func(svc *clientset.ProvisionListRow) string { return svc.Name }},
+		{Header: "DEPLOY_STATUS", Value: func(svc *clientset.ProvisionListRow) string { return svc.Status }},
+		{Header: "POLICY", Value: func(svc *clientset.ProvisionListRow) string { return svc.Policy }},
+		{Header: "ONLINE", Value: func(svc *clientset.ProvisionListRow) string { return strconv.Itoa(svc.InstanceOnline) }},
+		// Factor 代表期望的副本数
+		{Header: "DESIRED", Value: func(svc *clientset.ProvisionListRow) string { return strconv.Itoa(svc.Factor) }},
+		{Header: "IMAGE", Value: func(svc *clientset.ProvisionListRow) string {
+			// 组合一个易于阅读的镜像名
+			if len(svc.ImageList) == 0 {
+				return "N/A"
+			}
+			return fmt.Sprintf("%s:%s", svc.ImageList[0].Name, svc.ImageList[0].Tag)
+		}},
+		{Header: "ID", Value: func(svc *clientset.ProvisionListRow) string { return svc.ID }},
+		{Header: "NODES", Wide: true, Value: func(svc *clientset.ProvisionListRow) string {
+			var names []string
+			for _, node := range svc.NodeList {
+				names = append(names, node.NodeName)
+			}
+			return valueOrNone(strings.Join(names, ","))
+		}},
+		{Header: "LABEL", Wide: true, Value: func(svc *clientset.ProvisionListRow) string { return valueOrNone(svc.PathLabel) }},
+		{Header: "UPDATED", Wide: true, Value: func(svc *clientset.ProvisionListRow) string { return svc.UpdatedTime }},
+	},
 }
 
 // PrintServiceDetails 打印聚合后的服务详细信息。
@@ -358,27 +369,25 @@ func PrintServiceDetails(out io.Writer, details *clientset.ServiceGet, container
 	}
 }
 
-// PrintContainersTable 将容器列表以表格形式打印到指定的 writer。
-func PrintContainersTable(out io.Writer, containers []clientset.ContainerInfo) {
-	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
-	defer w.Flush()
-
-	// 打印表头
-	fmt.Fprintln(w, "NAME\tSTATUS\tRESTARTS\tIMAGE\tSERVICE\tNODE")
-
-	for _, c := range containers {
+// ContainerTable 描述了容器列表的表格。
+var ContainerTable = &Table[clientset.ContainerInfo]{
+	Resource: "container",
+	Name:     func(c *clientset.ContainerInfo) string { return c.Name },
+	Padding:  2,
+	Columns: []Column[clientset.ContainerInfo]{
+		{Header: "NAME", Value: func(c *clientset.ContainerInfo) string { return c.Name }},
+		{Header: "STATUS", Value: func(c *clientset.ContainerInfo) string { return c.Status }},
+		{Header: "RESTARTS", Value: func(c *clientset.ContainerInfo) string { return strconv.Itoa(c.RestartCount) }},
 		// 组合一个易于阅读的镜像名
-		imageRef := fmt.Sprintf("%s:%s", c.ImageName, c.ImageVersion)
-
-		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n",
-			c.Name,
-			c.Status,
-			c.RestartCount,
-			imageRef,
-			c.ServiceName,
-			c.NodeName,
-		)
-	}
+		{Header: "IMAGE", Value: func(c *clientset.ContainerInfo) string { return fmt.Sprintf("%s:%s", c.ImageName, c.ImageVersion) }},
+		{Header: "SERVICE", Value: func(c *clientset.ContainerInfo) string { return c.ServiceName }},
+		{Header: "NODE", Value: func(c *clientset.ContainerInfo) string { return c.NodeName }},
+		{Header: "DEPLOY_STATUS", Wide: true, Value: func(c *clientset.ContainerInfo) string { return c.DeployStatus }},
+		{Header: "UPTIME", Wide: true, Value: func(c *clientset.ContainerInfo) string {
+			return formatUptime(time.Duration(c.Uptime) * time.Second)
+		}},
+		{Header: "ID", Wide: true, Value: func(c *clientset.ContainerInfo) string { return c.ID }},
+	},
 }
 
 // PrintContainerDetails 打印聚合后的容器详细信息。
@@ -433,3 +442,379 @@ func PrintContainerDetails(out io.Writer, details *clientset.ContainerInfo, hist
 		fmt.Fprintf(out, "No action history found.\n")
 	}
 }
+
+// now 返回计算 AGE 列使用的当前时间，测试中替换它以得到固定的输出。
+var now = time.Now
+
+// ECSMServiceTable 返回 Registry 中 ECSMService 列表的表格，withNamespace 为 true 时第一列是 NAMESPACE。
+// REASON 列给出服务不正常时最主要的原因：Degraded 的 reason 优先，其次是 Available 的 reason。
+func ECSMServiceTable(withNamespace bool) *Table[ecsmv1.ECSMService] {
+	columns := []Column[ecsmv1.ECSMService]{
+		{Header: "NAME", Value: func(svc *ecsmv1.ECSMService) string { return svc.Name }},
+		{Header: "READY", Value: func(svc *ecsmv1.ECSMService) string {
+			return fmt.Sprintf("%d/%d", svc.Status.ReadyReplicas, svc.Status.Replicas)
+		}},
+		{Header: "AVAILABLE", Value: func(svc *ecsmv1.ECSMService) string {
+			return conditionStatus(svc.Status.Conditions, ecsmv1.ECSMServiceAvailable)
+		}},
+		{Header: "PROGRESSING", Value: func(svc *ecsmv1.ECSMService) string {
+			return conditionStatus(svc.Status.Conditions, ecsmv1.ECSMServiceProgressing)
+		}},
+		{Header: "DEGRADED", Value: func(svc *ecsmv1.ECSMService) string {
+			return conditionStatus(svc.Status.Conditions, ecsmv1.ECSMServiceDegraded)
+		}},
+		{Header: "REASON", Value: ecsmServiceReason},
+		{Header: "AGE", Value: func(svc *ecsmv1.ECSMService) string {
+			return duration.HumanDuration(now().Sub(svc.CreationTimestamp.Time))
+		}},
+		{Header: "IMAGE", Wide: true, Value: func(svc *ecsmv1.ECSMService) string { return svc.Spec.Template.Image }},
+		{Header: "ECSM_ID", Wide: true, Value: func(svc *ecsmv1.ECSMService) string { return valueOrNone(svc.Status.UnderlyingServiceID) }},
+	}
+	if withNamespace {
+		namespace := Column[ecsmv1.ECSMService]{Header: "NAMESPACE", Value: func(svc *ecsmv1.ECSMService) string { return svc.Namespace }}
+		columns = append([]Column[ecsmv1.ECSMService]{namespace}, columns...)
+	}
+	return &Table[ecsmv1.ECSMService]{
+		Resource: "ecsmservice." + ecsmv1.SchemeGroupVersion.Group,
+		Name:     func(svc *ecsmv1.ECSMService) string { return svc.Name },
+		Columns:  columns,
+	}
+}
+
+func ecsmServiceReason(svc *ecsmv1.ECSMService) string {
+	if svc.DeletionTimestamp != nil {
+		return "Terminating"
+	}
+	conditions := svc.Status.Conditions
+	if c := meta.FindStatusCondition(conditions, ecsmv1.ECSMServiceDegraded); c != nil && c.Status == metav1.ConditionTrue {
+		return valueOrNone(c.Reason)
+	}
+	if c := meta.FindStatusCondition(conditions, ecsmv1.ECSMServiceAvailable); c != nil && c.Status != metav1.ConditionTrue {
+		return valueOrNone(c.Reason)
+	}
+	return "<none>"
+}
+
+// conditionStatus 返回 condition 的状态，控制器还没有设置它时返回 "Unknown"。
+func conditionStatus(conditions []metav1.Condition, conditionType string) string {
+	if c := meta.FindStatusCondition(conditions, conditionType); c != nil {
+		return string(c.Status)
+	}
+	return string(metav1.ConditionUnknown)
+}
+
+func valueOrNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+// TemplateTreeEntry 是模板树中的一个文件夹或服务模板。
+// get templates 默认打印树形视图，指定 -o 时输出的是按路径展开的所有节点。
+type TemplateTreeEntry struct {
+	Path       string                         `json:"path"`
+	Name       string                         `json:"name"`
+	Kind       string                         `json:"kind"`
+	ChildCount int                            `json:"childCount"`
+	Data       *clientset.ProvisionTmplDetail `json:"data,omitempty"`
+}
+
+// intOrNone 返回 *int 的字符串形式，nil 时返回 "<none>"。
+func intOrNone(i *int) string {
+	if i == nil {
+		return valueOrNone("")
+	}
+	return strconv.Itoa(*i)
+}
+
+// FlattenTemplateTree 按深度优先、同一层按名称排序的顺序展开模板树，根目录 "/" 本身不包括在内。
+func FlattenTemplateTree(tree *clientset.ProvisionTmplTree) []TemplateTreeEntry {
+	var entries []TemplateTreeEntry
+	if tree.RealPath != "/" {
+		entries = append(entries, TemplateTreeEntry{
+			Path:       tree.RealPath,
+			Name:       tree.Name,
+			Kind:       templateKind(tree),
+			ChildCount: tree.ChildCount,
+			Data:       tree.Data,
+		})
+	}
+	for _, child := range sortedTemplateChildren(tree) {
+		entries = append(entries, FlattenTemplateTree(child)...)
+	}
+	return entries
+}
+
+// templateKind 返回模板树节点的类型，没有 data 时（model 不是 full）根据是否有子节点推断。
+func templateKind(tree *clientset.ProvisionTmplTree) string {
+	if tree.Data != nil && tree.Data.Kind != "" {
+		return tree.Data.Kind
+	}
+	if tree.ChildCount > 0 || len(tree.Children) > 0 {
+		return "folder"
+	}
+	return "service"
+}
+
+func sortedTemplateChildren(tree *clientset.ProvisionTmplTree) []*clientset.ProvisionTmplTree {
+	names := make([]string, 0, len(tree.Children))
+	for name := range tree.Children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	children := make([]*clientset.ProvisionTmplTree, 0, len(names))
+	for _, name := range names {
+		children = append(children, tree.Children[name])
+	}
+	return children
+}
+
+// TemplateTable 描述了展开后的模板树的表格，用于 get templates -o wide。
+var TemplateTable = &Table[TemplateTreeEntry]{
+	Resource: "template",
+	Name:     func(e *TemplateTreeEntry) string { return strings.TrimPrefix(e.Path, "/") },
+	Columns: []Column[TemplateTreeEntry]{
+		{Header: "PATH", Value: func(e *TemplateTreeEntry) string { return e.Path }},
+		{Header: "KIND", Value: func(e *TemplateTreeEntry) string { return e.Kind }},
+		{Header: "CHILDREN", Value: func(e *TemplateTreeEntry) string { return strconv.Itoa(e.ChildCount) }},
+		{Header: "NODES", Value: func(e *TemplateTreeEntry) string {
+			if e.Data == nil {
+				return "<none>"
+			}
+			return valueOrNone(strings.Join(e.Data.Node.Names, ","))
+		}},
+		{Header: "ID", Value: func(e *TemplateTreeEntry) string {
+			if e.Data == nil {
+				return "<none>"
+			}
+			return e.Data.ID
+		}},
+		{Header: "HOSTNAME", Wide: true, Value: func(e *TemplateTreeEntry) string {
+			if e.Data == nil {
+				return "<none>"
+			}
+			return valueOrNone(e.Data.Hostname)
+		}},
+		{Header: "UPDATED", Wide: true, Value: func(e *TemplateTreeEntry) string {
+			if e.Data == nil {
+				return "<none>"
+			}
+			return e.Data.UpdatedTime
+		}},
+	},
+}
+
+// PrintTemplateTree 以树形打印模板树，文件夹以 "/" 结尾。
+func PrintTemplateTree(out io.Writer, tree *clientset.ProvisionTmplTree) {
+	fmt.Fprintln(out, tree.RealPath)
+	printTemplateChildren(out, tree, "")
+}
+
+func printTemplateChildren(out io.Writer, tree *clientset.ProvisionTmplTree, prefix string) {
+	children := sortedTemplateChildren(tree)
+	for i, child := range children {
+		branch, indent := "├── ", "│   "
+		if i == len(children)-1 {
+			branch, indent = "└── ", "    "
+		}
+		name := child.Name
+		if templateKind(child) == "folder" {
+			name += "/"
+		}
+		fmt.Fprintf(out, "%s%s%s\n", prefix, branch, name)
+		printTemplateChildren(out, child, prefix+indent)
+	}
+	// 指定了 level 时更深的层级没有返回，提示还有多少个子节点
+	if len(children) == 0 && tree.ChildCount > 0 {
+		fmt.Fprintf(out, "%s└── ... (%d more)\n", prefix, tree.ChildCount)
+	}
+}
+
+// PrintTemplateDetails 打印模板的详细信息，包括完整的 TemplateSpec。
+func PrintTemplateDetails(out io.Writer, tmpl *clientset.TemplateGet) {
+	fmt.Fprintf(out, "Name:         %s\n", tmpl.Name)
+	fmt.Fprintf(out, "ID:           %s\n", tmpl.ID)
+	fmt.Fprintf(out, "Kind:         %s\n", tmpl.Kind)
+	fmt.Fprintf(out, "Created:      %s\n", tmpl.CreatedTime)
+	fmt.Fprintf(out, "Updated:      %s\n", tmpl.UpdatedTime)
+	if tmpl.Kind == "folder" {
+		return
+	}
+	fmt.Fprintf(out, "\n")
+
+	spec := tmpl.Spec
+	fmt.Fprintf(out, "Deployment:\n")
+	fmt.Fprintf(out, "  Policy:       %s\n", valueOrNone(spec.Policy))
+	if spec.Factor != nil {
+		fmt.Fprintf(out, "  Replicas:     %d\n", *spec.Factor)
+	}
+	fmt.Fprintf(out, "  Nodes:        %s\n", valueOrNone(strings.Join(spec.Node.Names, ", ")))
+	if spec.Prepull != nil {
+		fmt.Fprintf(out, "  Prepull:      %t\n", *spec.Prepull)
+	}
+
+	fmt.Fprintf(out, "Image:\n")
+	fmt.Fprintf(out, "  Reference:    %s\n", spec.Image.Ref)
+	fmt.Fprintf(out, "  Pull Policy:  %s\n", valueOrNone(spec.Image.PullPolicy))
+	printVSOA(out, spec.Image.VSOA)
+	fmt.Fprintf(out, "\n")
+
+	printImageConfig(out, spec.Image.Config)
+}
+
+// printVSOA 打印镜像的 VSOA 配置，只有微服务才有这一部分。
+func printVSOA(out io.Writer, vsoa *clientset.ImageVSOA) {
+	if vsoa == nil {
+		return
+	}
+	fmt.Fprintf(out, "  VSOA:\n")
+	if vsoa.Port != nil {
+		fmt.Fprintf(out, "    Port:                %d\n", *vsoa.Port)
+	}
+	if vsoa.HealthPath != "" {
+		fmt.Fprintf(out, "    Health Path:         %s\n", vsoa.HealthPath)
+	}
+	if vsoa.HealthInterval != nil {
+		fmt.Fprintf(out, "    Health Interval:     %ds\n", *vsoa.HealthInterval)
+	}
+	if vsoa.HealthTimeout != nil {
+		fmt.Fprintf(out, "    Health Timeout:      %ds\n", *vsoa.HealthTimeout)
+	}
+	if vsoa.HealthRetries != nil {
+		fmt.Fprintf(out, "    Health Retries:      %d\n", *vsoa.HealthRetries)
+	}
+	if vsoa.HealthStartPeriod != nil {
+		fmt.Fprintf(out, "    Health Start Period: %ds\n", *vsoa.HealthStartPeriod)
+	}
+}
+
+// ConfigTable 描述了配置项列表的表格。
+var ConfigTable = &Table[clientset.ConfigItem]{
+	Resource: "config",
+	Name:     func(c *clientset.ConfigItem) string { return c.Key },
+	Columns: []Column[clientset.ConfigItem]{
+		{Header: "KEY", Value: func(c *clientset.ConfigItem) string { return c.Key }},
+		{Header: "TYPE", Value: func(c *clientset.ConfigItem) string { return string(c.Type) }},
+		{Header: "VALUE", Value: func(c *clientset.ConfigItem) string { return formatConfigValue(c.Value, "") }},
+		{Header: "ID", Value: func(c *clientset.ConfigItem) string { return c.ID }},
+	},
+}
+
+// formatConfigValue 把配置项的值格式化为字符串：字符串原样返回，其他类型输出为 JSON，
+// indent 不为空时 JSON 按 indent 缩进。
+func formatConfigValue(value interface{}, indent string) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	var b []byte
+	var err error
+	if indent == "" {
+		b, err = json.Marshal(value)
+	} else {
+		b, err = json.MarshalIndent(value, indent, "  ")
+	}
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
+
+// PrintConfigDetails 打印配置项的详细信息，JSON 类型的值会缩进打印。
+func PrintConfigDetails(out io.Writer, config *clientset.ConfigItem) {
+	fmt.Fprintf(out, "Key:          %s\n", config.Key)
+	fmt.Fprintf(out, "ID:           %s\n", config.ID)
+	fmt.Fprintf(out, "Type:         %s\n", config.Type)
+	if config.Type == clientset.ConfigItemTypeJSON {
+		fmt.Fprintf(out, "Value:\n  %s\n", formatConfigValue(config.Value, "  "))
+	} else {
+		fmt.Fprintf(out, "Value:        %s\n", formatConfigValue(config.Value, ""))
+	}
+}
+
+// RecordTable 描述了部署记录列表的表格。
+var RecordTable = &Table[clientset.DeployRecord]{
+	Resource: "record",
+	Name:     func(r *clientset.DeployRecord) string { return r.ID },
+	Padding:  2,
+	Columns: []Column[clientset.DeployRecord]{
+		{Header: "ID", Value: func(r *clientset.DeployRecord) string { return r.ID }},
+		{Header: "NAME", Value: func(r *clientset.DeployRecord) string { return r.Name }},
+		{Header: "IMAGE", Value: func(r *clientset.DeployRecord) string { return r.Image }},
+		{Header: "NODES", Value: func(r *clientset.DeployRecord) string {
+			if r.Node == nil {
+				return "<none>"
+			}
+			return valueOrNone(strings.Join(r.Node.Names, ","))
+		}},
+		{Header: "CREATED", Value: func(r *clientset.DeployRecord) string { return r.CreatedTime }},
+		{Header: "CMD", Wide: true, Value: func(r *clientset.DeployRecord) string { return valueOrNone(r.CMD) }},
+	},
+}
+
+// PrintRecordDetails 打印部署记录的详细信息，包括部署时使用的 EcsImageConfig。
+func PrintRecordDetails(out io.Writer, record *RecordDescription) {
+	fmt.Fprintf(out, "ID:             %s\n", record.ID)
+	fmt.Fprintf(out, "Name:           %s\n", record.Name)
+	fmt.Fprintf(out, "Created:        %s\n", record.CreatedTime)
+	fmt.Fprintf(out, "Action:         %s\n", valueOrNone(record.Action))
+	if len(record.Cmd) > 0 {
+		fmt.Fprintf(out, "Command:        %s\n", strings.Join(record.Cmd, " "))
+	}
+	fmt.Fprintf(out, "\n")
+
+	fmt.Fprintf(out, "Deployment:\n")
+	fmt.Fprintf(out, "  Policy:       %s\n", valueOrNone(record.Policy))
+	if record.Factor != nil {
+		fmt.Fprintf(out, "  Replicas:     %d\n", *record.Factor)
+	}
+
+	fmt.Fprintf(out, "Image:\n")
+	fmt.Fprintf(out, "  Reference:    %s\n", record.Image.Ref)
+	if record.Image.Path != "" {
+		fmt.Fprintf(out, "  Path:         %s\n", record.Image.Path)
+	}
+	fmt.Fprintf(out, "  Pull Policy:  %s\n", valueOrNone(record.Image.PullPolicy))
+	fmt.Fprintf(out, "  Auto Upgrade: %s\n", valueOrNone(record.Image.AutoUpgrade))
+	printVSOA(out, record.VSOA)
+	fmt.Fprintf(out, "\n")
+
+	printImageConfig(out, record.Config)
+}
+
+// MicroServiceTable 描述了微服务列表的表格。
+var MicroServiceTable = &Table[clientset.MicroServiceListRow]{
+	Resource: "microservice",
+	Name:     func(m *clientset.MicroServiceListRow) string { return m.Name },
+	Padding:  2,
+	Columns: []Column[clientset.MicroServiceListRow]{
+		{Header: "NAME", Value: func(m *clientset.MicroServiceListRow) string { return m.Name }},
+		{Header: "IMAGE", Value: func(m *clientset.MicroServiceListRow) string { return m.ImageName }},
+		{Header: "HEALTHY", Value: func(m *clientset.MicroServiceListRow) string {
+			return fmt.Sprintf("%d/%d", m.HealthInstance, m.Instance)
+		}},
+		{Header: "LOAD_BALANCE", Value: func(m *clientset.MicroServiceListRow) string { return m.LoadBalance }},
+		{Header: "ID", Value: func(m *clientset.MicroServiceListRow) string { return m.ID }},
+	},
+}
+
+// PrintMicroServiceDetails 打印微服务的详细信息，主备模式下同时打印每个实例的备份关系。
+func PrintMicroServiceDetails(out io.Writer, ms *clientset.MicroServiceGet) {
+	fmt.Fprintf(out, "Name:           %s\n", ms.Name)
+	fmt.Fprintf(out, "ID:             %s\n", ms.ID)
+	fmt.Fprintf(out, "Image:          %s\n", ms.ImageName)
+	fmt.Fprintf(out, "Dynamic:        %t\n", ms.BoDYnamic)
+	fmt.Fprintf(out, "Instances:      %d healthy / %d total\n", ms.HealthInstance, ms.Instance)
+	fmt.Fprintf(out, "Load Balance:   %s\n", ms.LoadBalance)
+
+	if len(ms.LoadBalanceDetail) > 0 {
+		fmt.Fprintf(out, "\n")
+		fmt.Fprintf(out, "Master/Slave (%d):\n", len(ms.LoadBalanceDetail))
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  TASK ID\tMASTER")
+		for _, d := range ms.LoadBalanceDetail {
+			fmt.Fprintf(w, "  %s\t%s\n", d.TaskID, d.Master)
+		}
+		w.Flush()
+	}
+}
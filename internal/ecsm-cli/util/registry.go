@@ -0,0 +1,32 @@
+// file: internal/ecsm-cli/util/registry.go
+
+package util
+
+import (
+	"io"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/registry"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// NewRegistryFromFlags 从 viper 中读取存储相关的全局标志，并打开 ECSM Registry。
+// 调用者用完之后必须调用返回的 close 函数，释放数据库文件等资源。
+func NewRegistryFromFlags() (reg *registry.Registry, close func(), err error) {
+	scheme := runtime.NewScheme()
+	if err := ecsmv1.AddToScheme(scheme); err != nil {
+		return nil, nil, err
+	}
+
+	store, err := registry.NewStore(viper.GetString("store-backend"), viper.GetString("store-path"), scheme)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	close = func() {}
+	if closer, ok := store.(io.Closer); ok {
+		close = func() { closer.Close() }
+	}
+	return registry.NewRegistry(store), close, nil
+}
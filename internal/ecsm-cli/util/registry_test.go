@@ -0,0 +1,60 @@
+// file: internal/ecsm-cli/util/registry_test.go
+
+package util
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/registry"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newRegistryTestScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := ecsmv1.AddToScheme(s); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	return s
+}
+
+func TestNewRegistryFromFlags_BoltBackend(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "registry.db")
+	viper.Set("store-backend", registry.StoreBackendBolt)
+	viper.Set("store-path", dbPath)
+	t.Cleanup(viper.Reset)
+
+	// 数据库文件只在每次操作时打开，ecsm-operator 运行时 ecsm-cli 同样可以使用它
+	operator, err := registry.NewBoltStore(dbPath, newRegistryTestScheme(t))
+	if err != nil {
+		t.Fatalf("Failed to open BoltStore: %v", err)
+	}
+	defer operator.Close()
+
+	reg, closeFn, err := NewRegistryFromFlags()
+	if err != nil {
+		t.Fatalf("NewRegistryFromFlags failed: %v", err)
+	}
+	defer closeFn()
+	if _, err := reg.ListServices(context.Background(), "default"); err != nil {
+		t.Errorf("ListServices failed: %v", err)
+	}
+}
+
+func TestNewRegistryFromFlags_FileBackend(t *testing.T) {
+	viper.Set("store-backend", registry.StoreBackendFile)
+	viper.Set("store-path", t.TempDir())
+	t.Cleanup(viper.Reset)
+
+	reg, closeFn, err := NewRegistryFromFlags()
+	if err != nil {
+		t.Fatalf("NewRegistryFromFlags failed: %v", err)
+	}
+	defer closeFn()
+	if reg == nil {
+		t.Fatalf("Expected a registry, but got nil")
+	}
+}
@@ -0,0 +1,304 @@
+// file: internal/ecsm-cli/util/resource_printer.go
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// Printer 按照 PrintFlags 指定的格式打印 T。
+type Printer[T any] interface {
+	// PrintList 打印一组对象。json/yaml/jsonpath/go-template 的输入是
+	// {"apiVersion": "v1", "kind": "List", "items": [...]}，与 kubectl 一致。
+	PrintList(out io.Writer, objs []T) error
+	// PrintObject 打印单个对象，json/yaml/jsonpath/go-template 的输入是对象本身。
+	PrintObject(out io.Writer, obj *T) error
+}
+
+// Column 是表格中的一列。
+type Column[T any] struct {
+	Header string
+	// Wide 为 true 的列只在 -o wide 时打印。
+	Wide  bool
+	Value func(obj *T) string
+}
+
+// Table 描述了一种资源的表格输出和 -o name 输出。
+type Table[T any] struct {
+	// Resource 是 -o name 输出中 "/" 之前的资源名，例如 "node"。
+	Resource string
+	// Name 返回对象的名字。
+	Name func(obj *T) string
+	// Columns 是表格的列，为空时不支持默认和 wide 输出。
+	Columns []Column[T]
+	// Padding 是列之间的空格数，为 0 时使用 3。
+	Padding int
+}
+
+// customColumn 是 -o custom-columns 中的一列。
+type customColumn struct {
+	header string
+	path   *jsonpath.JSONPath
+}
+
+type printer[T any] struct {
+	format        string
+	noHeaders     bool
+	table         *Table[T]
+	jsonPath      *jsonpath.JSONPath
+	template      *template.Template
+	customColumns []customColumn
+	sortBy        *jsonpath.JSONPath
+	sortByExpr    string
+}
+
+// errSortFieldNotFound 表示所有对象都没有 --sort-by 指定的字段。
+var errSortFieldNotFound = errors.New("sort field not found")
+
+func (p *printer[T]) PrintList(out io.Writer, objs []T) error {
+	items := make([]interface{}, len(objs))
+	for i := range objs {
+		item, err := toGeneric(&objs[i])
+		if err != nil {
+			return err
+		}
+		items[i] = item
+	}
+
+	if p.sortBy != nil {
+		order, err := sortOrder(p.sortBy, items)
+		if err == errSortFieldNotFound {
+			return fmt.Errorf("couldn't find any field with path %q in the list of objects", p.sortByExpr)
+		}
+		if err != nil {
+			return err
+		}
+		sortedObjs := make([]T, len(objs))
+		sortedItems := make([]interface{}, len(items))
+		for i, j := range order {
+			sortedObjs[i], sortedItems[i] = objs[j], items[j]
+		}
+		objs, items = sortedObjs, sortedItems
+	}
+
+	switch p.format {
+	case "", OutputFormatWide:
+		return p.printTable(out, objs)
+	case OutputFormatName:
+		for i := range objs {
+			p.printName(out, &objs[i])
+		}
+		return nil
+	case OutputFormatCustomColumns:
+		return p.printCustomColumns(out, items)
+	default:
+		return p.printData(out, map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "List",
+			"items":      items,
+		})
+	}
+}
+
+func (p *printer[T]) PrintObject(out io.Writer, obj *T) error {
+	switch p.format {
+	case "", OutputFormatWide:
+		return p.printTable(out, []T{*obj})
+	case OutputFormatName:
+		p.printName(out, obj)
+		return nil
+	}
+
+	data, err := toGeneric(obj)
+	if err != nil {
+		return err
+	}
+	if p.format == OutputFormatCustomColumns {
+		return p.printCustomColumns(out, []interface{}{data})
+	}
+	return p.printData(out, data)
+}
+
+// printData 以 json/yaml/jsonpath/go-template 格式打印通用的 JSON 数据。
+func (p *printer[T]) printData(out io.Writer, data interface{}) error {
+	switch p.format {
+	case OutputFormatJSON:
+		b, err := json.MarshalIndent(data, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "%s\n", b)
+		return err
+	case OutputFormatYAML:
+		b, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(b)
+		return err
+	case OutputFormatJSONPath:
+		if err := p.jsonPath.Execute(out, data); err != nil {
+			return fmt.Errorf("error executing jsonpath: %w", err)
+		}
+		return nil
+	case OutputFormatGoTemplate:
+		if err := p.template.Execute(out, data); err != nil {
+			return fmt.Errorf("error executing template: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported output format %q", p.format)
+}
+
+func (p *printer[T]) printName(out io.Writer, obj *T) {
+	fmt.Fprintf(out, "%s/%s\n", p.table.Resource, p.table.Name(obj))
+}
+
+func (p *printer[T]) printTable(out io.Writer, objs []T) error {
+	padding := p.table.Padding
+	if padding == 0 {
+		padding = 3
+	}
+	w := tabwriter.NewWriter(out, 0, 0, padding, ' ', 0)
+
+	var columns []Column[T]
+	for _, c := range p.table.Columns {
+		if !c.Wide || p.format == OutputFormatWide {
+			columns = append(columns, c)
+		}
+	}
+
+	cells := make([]string, len(columns))
+	if !p.noHeaders {
+		for i, c := range columns {
+			cells[i] = c.Header
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	for i := range objs {
+		for j, c := range columns {
+			cells[j] = c.Value(&objs[i])
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	return w.Flush()
+}
+
+func (p *printer[T]) printCustomColumns(out io.Writer, items []interface{}) error {
+	w := tabwriter.NewWriter(out, 5, 8, 1, ' ', 0)
+
+	cells := make([]string, len(p.customColumns))
+	if !p.noHeaders {
+		for i, c := range p.customColumns {
+			cells[i] = c.header
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	for _, item := range items {
+		for i, c := range p.customColumns {
+			values, err := findValues(c.path, item)
+			if err != nil {
+				return err
+			}
+			cells[i] = valueOrNone(strings.Join(values, ","))
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	return w.Flush()
+}
+
+// toGeneric 把对象转换为 encoding/json 解码出的通用数据，作为 jsonpath 和模板的输入。
+// 数字解码为 json.Number，这样大的整数不会以科学计数法输出。
+func toGeneric(obj interface{}) (interface{}, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var data interface{}
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// findValues 返回 item 中 path 匹配到的所有值的字符串形式。
+func findValues(path *jsonpath.JSONPath, item interface{}) ([]string, error) {
+	results, err := path.FindResults(item)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, result := range results {
+		for _, v := range result {
+			if !v.IsValid() {
+				// 值为 null
+				continue
+			}
+			values = append(values, fmt.Sprint(v.Interface()))
+		}
+	}
+	return values, nil
+}
+
+// sortOrder 返回按 sortBy 的值对 items 稳定排序之后的下标顺序。
+// 两个值都是数字时按数值比较，否则按字符串比较；没有该字段的对象排在最前面。
+func sortOrder(sortBy *jsonpath.JSONPath, items []interface{}) ([]int, error) {
+	keys := make([]interface{}, len(items))
+	found := false
+	for i, item := range items {
+		results, err := sortBy.FindResults(item)
+		if err != nil {
+			return nil, err
+		}
+		if len(results) > 0 && len(results[0]) > 0 && results[0][0].IsValid() {
+			keys[i] = results[0][0].Interface()
+			found = true
+		}
+	}
+	if len(items) > 0 && !found {
+		return nil, errSortFieldNotFound
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return lessValue(keys[order[i]], keys[order[j]])
+	})
+	return order, nil
+}
+
+func lessValue(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b != nil
+	}
+	if x, ok := a.(json.Number); ok {
+		if y, ok := b.(json.Number); ok {
+			xf, errX := x.Float64()
+			yf, errY := y.Float64()
+			if errX == nil && errY == nil {
+				return xf < yf
+			}
+		}
+	}
+	if x, ok := a.(bool); ok {
+		if y, ok := b.(bool); ok {
+			return !x && y
+		}
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
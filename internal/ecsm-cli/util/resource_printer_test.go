@@ -0,0 +1,233 @@
+// file: internal/ecsm-cli/util/resource_printer_test.go
+
+package util
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 修改了打印格式之后，用 go test ./internal/ecsm-cli/util -run TestPrinter -update 重新生成 testdata 中的输出。
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// printerTestTime 是表格测试使用的固定时间，ECSMService 的 AGE 列相对它计算。
+var printerTestTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// printerCase 是一种输出格式，nameExpr 和 numberExpr 分别是被测类型中一个字符串字段和一个数字字段的 JSONPath，
+// 用于构造 jsonpath、go-template、custom-columns 和 --sort-by 的参数。
+type printerCase struct {
+	name  string
+	flags func(nameExpr, numberExpr string) PrintFlags
+	// object 为 true 时用 PrintObject 打印第一个对象，否则用 PrintList 打印所有对象
+	object bool
+}
+
+var printerCases = []printerCase{
+	{name: "table", flags: func(_, _ string) PrintFlags { return PrintFlags{} }},
+	{name: "wide", flags: func(_, _ string) PrintFlags { return PrintFlags{OutputFormat: OutputFormatWide} }},
+	{name: "no-headers", flags: func(_, _ string) PrintFlags { return PrintFlags{NoHeaders: true} }},
+	{name: "wide-no-headers", flags: func(_, _ string) PrintFlags {
+		return PrintFlags{OutputFormat: OutputFormatWide, NoHeaders: true}
+	}},
+	{name: "name", flags: func(_, _ string) PrintFlags { return PrintFlags{OutputFormat: OutputFormatName} }},
+	{name: "json", flags: func(_, _ string) PrintFlags { return PrintFlags{OutputFormat: OutputFormatJSON} }},
+	{name: "yaml", flags: func(_, _ string) PrintFlags { return PrintFlags{OutputFormat: OutputFormatYAML} }},
+	{name: "jsonpath", flags: func(nameExpr, numberExpr string) PrintFlags {
+		return PrintFlags{OutputFormat: fmt.Sprintf(`jsonpath={range .items[*]}{%s}{"\t"}{%s}{"\n"}{end}`, nameExpr, numberExpr)}
+	}},
+	{name: "go-template", flags: func(nameExpr, numberExpr string) PrintFlags {
+		return PrintFlags{OutputFormat: fmt.Sprintf(`go-template={{range .items}}{{%s}} {{%s}}{{"\n"}}{{end}}`, nameExpr, numberExpr)}
+	}},
+	{name: "custom-columns", flags: func(nameExpr, numberExpr string) PrintFlags {
+		return PrintFlags{OutputFormat: fmt.Sprintf("custom-columns=NAME:%s,VALUE:%s", nameExpr, numberExpr)}
+	}},
+	{name: "custom-columns-no-headers", flags: func(nameExpr, numberExpr string) PrintFlags {
+		return PrintFlags{OutputFormat: fmt.Sprintf("custom-columns=NAME:%s,VALUE:%s", nameExpr, numberExpr), NoHeaders: true}
+	}},
+	// 数字字段按数值排序（9 在 10 之前），字符串字段按字典序排序。
+	// 每个测试的对象按名字、按数值和按数字的字典序排列的顺序都不相同。
+	{name: "sort-by-number", flags: func(nameExpr, numberExpr string) PrintFlags {
+		return PrintFlags{OutputFormat: fmt.Sprintf("custom-columns=NAME:%s,VALUE:%s", nameExpr, numberExpr), SortBy: numberExpr}
+	}},
+	{name: "sort-by-string", flags: func(nameExpr, numberExpr string) PrintFlags {
+		return PrintFlags{OutputFormat: fmt.Sprintf("custom-columns=NAME:%s,VALUE:%s", nameExpr, numberExpr), SortBy: "{" + nameExpr + "}"}
+	}},
+	{name: "sort-by-table", flags: func(_, numberExpr string) PrintFlags { return PrintFlags{SortBy: numberExpr} }},
+	{name: "object-json", object: true, flags: func(_, _ string) PrintFlags { return PrintFlags{OutputFormat: OutputFormatJSON} }},
+	{name: "object-jsonpath", object: true, flags: func(nameExpr, _ string) PrintFlags {
+		return PrintFlags{OutputFormat: "jsonpath={" + nameExpr + "}"}
+	}},
+	{name: "object-table", object: true, flags: func(_, _ string) PrintFlags { return PrintFlags{} }},
+}
+
+// testPrinterGolden 用 printerCases 中的每种格式打印 objs，并与 testdata/<测试名>/<格式>.golden 比较。
+func testPrinterGolden[T any](t *testing.T, table *Table[T], objs []T, nameExpr, numberExpr string) {
+	t.Helper()
+	for _, tc := range printerCases {
+		t.Run(tc.name, func(t *testing.T) {
+			flags := tc.flags(nameExpr, numberExpr)
+			p, err := NewPrinter(&flags, table)
+			if err != nil {
+				t.Fatalf("NewPrinter() error = %v", err)
+			}
+			var out bytes.Buffer
+			if tc.object {
+				err = p.PrintObject(&out, &objs[0])
+			} else {
+				err = p.PrintList(&out, objs)
+			}
+			if err != nil {
+				t.Fatalf("print error = %v", err)
+			}
+			checkGolden(t, filepath.Join("testdata", filepath.Dir(t.Name()), tc.name+".golden"), out.String())
+		})
+	}
+}
+
+func checkGolden(t *testing.T, path, got string) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file, run with -update to create it: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("output does not match %s:\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+func TestPrinter_Node(t *testing.T) {
+	nodes := []clientset.NodeInfo{
+		{ID: "n-2", Name: "worker-b", Address: "10.0.0.2", Status: "online", Type: "sylixos", Arch: "arm64",
+			ContainerEcsmTotal: 10, ContainerEcsmRunning: 8, ContainerTotal: 12, ContainerRunning: 9,
+			UpTime: 90061, CreatedTime: "2024-01-02 10:00:00", TLS: true},
+		{ID: "n-1", Name: "worker-a", Address: "10.0.0.1", Status: "offline", Type: "linux", Arch: "x86_64",
+			ContainerEcsmTotal: 9, ContainerEcsmRunning: 0, ContainerTotal: 9, ContainerRunning: 0,
+			UpTime: 0, CreatedTime: "2024-01-01 09:00:00"},
+		{ID: "n-3", Name: "worker-c", Address: "10.0.0.3", Status: "online", Type: "sylixos", Arch: "arm64",
+			ContainerEcsmTotal: 1, ContainerEcsmRunning: 1, ContainerTotal: 3, ContainerRunning: 2,
+			UpTime: 3600, CreatedTime: "2024-01-03 11:00:00"},
+	}
+	testPrinterGolden(t, NodeTable, nodes, ".name", ".containerEcsmTotal")
+}
+
+func TestPrinter_Image(t *testing.T) {
+	author := "alice"
+	images := []clientset.ImageListItem{
+		{ID: "img-2", Name: "nginx", Tag: "1.2", OS: "sylixos", Arch: "arm64", Size: 10.5,
+			CreatedTime: "2024-03-04T05:06:07.123Z", Author: &author, Pulled: true},
+		{ID: "img-1", Name: "api", Tag: "2.0", OS: "linux", Arch: "x86_64", Size: 9.25,
+			CreatedTime: "not a time"},
+		{ID: "img-3", Name: "redis", Tag: "7.0", OS: "sylixos", Arch: "arm64", Size: 1,
+			CreatedTime: "2023-11-17T00:00:00Z"},
+	}
+	testPrinterGolden(t, ImageTable, images, ".name", ".size")
+}
+
+func TestPrinter_Service(t *testing.T) {
+	services := []clientset.ProvisionListRow{
+		{ID: "svc-2", Name: "web", Status: "running", Policy: "dynamic", Factor: 10, InstanceOnline: 10,
+			ImageList: []clientset.ImageListEntry{{Name: "nginx", Tag: "1.2", OS: "sylixos"}},
+			NodeList:  []clientset.ServiceNodeInfo{{NodeName: "worker-a"}, {NodeName: "worker-b"}},
+			PathLabel: "prod/web", UpdatedTime: "2024-01-02 10:00:00"},
+		{ID: "svc-1", Name: "api", Status: "deploying", Policy: "static", Factor: 9, InstanceOnline: 3,
+			UpdatedTime: "2024-01-01 09:00:00"},
+		{ID: "svc-3", Name: "cache", Status: "failed", Policy: "dynamic", Factor: 1, InstanceOnline: 0,
+			ImageList:   []clientset.ImageListEntry{{Name: "redis", Tag: "7.0", OS: "sylixos"}},
+			NodeList:    []clientset.ServiceNodeInfo{{NodeName: "worker-c"}},
+			UpdatedTime: "2024-01-03 11:00:00"},
+	}
+	testPrinterGolden(t, ServiceTable, services, ".name", ".factor")
+}
+
+func TestPrinter_Container(t *testing.T) {
+	containers := []clientset.ContainerInfo{
+		{ID: "c-2", TaskID: "t-2", Name: "web-1", Status: "running", RestartCount: 10, ImageName: "nginx", ImageVersion: "1.2",
+			ServiceName: "web", NodeName: "worker-a", DeployStatus: "success", Uptime: 90061},
+		{ID: "c-1", TaskID: "t-1", Name: "api-1", Status: "stop", RestartCount: 9, ImageName: "api", ImageVersion: "2.0",
+			ServiceName: "api", NodeName: "worker-b", DeployStatus: "failed"},
+		{ID: "c-3", TaskID: "t-3", Name: "cache-1", Status: "running", RestartCount: 100, ImageName: "redis", ImageVersion: "7.0",
+			ServiceName: "cache", NodeName: "worker-c", DeployStatus: "success", Uptime: 3600},
+	}
+	testPrinterGolden(t, ContainerTable, containers, ".name", ".restartCnt")
+}
+
+func TestPrinter_ECSMService(t *testing.T) {
+	oldNow := now
+	now = func() time.Time { return printerTestTime }
+	t.Cleanup(func() { now = oldNow })
+
+	replicas := func(n int32) *int32 { return &n }
+	newService := func(namespace, name string, age time.Duration, desired, ready int32, conditions ...metav1.Condition) ecsmv1.ECSMService {
+		return ecsmv1.ECSMService{
+			TypeMeta: metav1.TypeMeta{APIVersion: ecsmv1.SchemeGroupVersion.String(), Kind: "ECSMService"},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         namespace,
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(printerTestTime.Add(-age)),
+			},
+			Spec: ecsmv1.ECSMServiceSpec{
+				DeploymentStrategy: ecsmv1.DeploymentStrategy{Type: ecsmv1.DeploymentStrategyTypeDynamic, Replicas: replicas(desired)},
+				Template:           ecsmv1.ContainerTemplateSpec{Image: name + "@1.0"},
+			},
+			Status: ecsmv1.ECSMServiceStatus{
+				Replicas:            desired,
+				ReadyReplicas:       ready,
+				Conditions:          conditions,
+				UnderlyingServiceID: "ecsm-" + name,
+			},
+		}
+	}
+	condition := func(conditionType string, status metav1.ConditionStatus, reason string) metav1.Condition {
+		return metav1.Condition{
+			Type:               conditionType,
+			Status:             status,
+			Reason:             reason,
+			LastTransitionTime: metav1.NewTime(printerTestTime.Add(-time.Minute)),
+		}
+	}
+
+	terminating := newService("prod", "cache", 3*time.Hour, 1, 1,
+		condition(ecsmv1.ECSMServiceAvailable, metav1.ConditionTrue, "AllInstancesOnline"))
+	deletedAt := metav1.NewTime(printerTestTime.Add(-time.Second))
+	terminating.DeletionTimestamp = &deletedAt
+	// 还没有被控制器处理过的对象没有 conditions 和 ECSM 服务 ID
+	pending := newService("default", "batch", 5*24*time.Hour, 2, 0)
+	pending.Status.UnderlyingServiceID = ""
+
+	services := []ecsmv1.ECSMService{
+		newService("prod", "web", 2*time.Hour, 10, 10,
+			condition(ecsmv1.ECSMServiceAvailable, metav1.ConditionTrue, "AllInstancesOnline"),
+			condition(ecsmv1.ECSMServiceProgressing, metav1.ConditionFalse, "DeploymentComplete"),
+			condition(ecsmv1.ECSMServiceDegraded, metav1.ConditionFalse, "AsExpected")),
+		newService("default", "api", 45*time.Second, 9, 0,
+			condition(ecsmv1.ECSMServiceAvailable, metav1.ConditionFalse, "InstancesOffline"),
+			condition(ecsmv1.ECSMServiceDegraded, metav1.ConditionTrue, "InstanceErrors")),
+		terminating,
+		pending,
+	}
+
+	t.Run("Namespaced", func(t *testing.T) {
+		testPrinterGolden(t, ECSMServiceTable(false), services, ".metadata.name", ".status.replicas")
+	})
+	t.Run("AllNamespaces", func(t *testing.T) {
+		testPrinterGolden(t, ECSMServiceTable(true), services, ".metadata.name", ".status.replicas")
+	})
+}
@@ -2,6 +2,19 @@ package v1
 
 import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+// ECSMServiceFinalizer 由控制器添加到 ECSMService 上。
+// 只有当 ECSM 平台上对应的服务被删除之后，控制器才会移除它，对象随后才会从 Registry 中真正删除。
+const ECSMServiceFinalizer = "ecsm.sh/ecsm-service-cleanup"
+
+// ECSMServiceIDAnnotation 记录了控制器为 ECSMService 在 ECSM 平台上创建的服务的 ID，由控制器维护。
+// 值为空表示控制器已经发出了创建请求，但还没有拿到服务 ID。
+// 控制器只会删除这个注解指向的服务，按名称接管的、不是由它创建的服务在 ECSMService 被删除时会被保留。
+const ECSMServiceIDAnnotation = "ecsm.sh/ecsm-service-id"
+
+// ECSMServiceDeleteTransactionAnnotation 记录了控制器删除 ECSM 上的服务时得到的异步事务 ID，由控制器维护。
+// ecsm-cli delete --wait 通过它查询删除是否成功，而不必等到超时才知道删除失败了。
+const ECSMServiceDeleteTransactionAnnotation = "ecsm.sh/ecsm-delete-transaction-id"
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -65,6 +78,16 @@ type ECSMServiceStatus struct {
 	UnderlyingServiceID string `json:"underlyingServiceID,omitempty"`
 }
 
+// ECSMService 的 status.conditions 中使用的条件类型
+const (
+	// ECSMServiceAvailable 表示服务的所有实例都在线，服务可以正常提供服务。
+	ECSMServiceAvailable = "Available"
+	// ECSMServiceProgressing 表示 ECSM 仍在部署或更新服务的实例。
+	ECSMServiceProgressing = "Progressing"
+	// ECSMServiceDegraded 表示服务不健康，或者有实例部署失败。
+	ECSMServiceDegraded = "Degraded"
+)
+
 type DeploymentStrategyType string
 
 const (
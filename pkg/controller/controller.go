@@ -0,0 +1,281 @@
+// file: pkg/controller/controller.go
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/informers"
+	"github.com/fx147/ecsm-operator/pkg/registry"
+	"golang.org/x/time/rate"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// defaultDeletePollInterval 是等待 ECSM 服务删除完成时的轮询间隔
+	defaultDeletePollInterval = 2 * time.Second
+	// defaultDeleteTimeout 是单次调谐中等待 ECSM 服务删除完成的最长时间，超时后在下一次调谐中继续
+	defaultDeleteTimeout = 2 * time.Minute
+
+	// DefaultRetryBaseDelay 和 DefaultRetryMaxDelay 是调谐失败后重新入队的退避时间的下限和上限
+	DefaultRetryBaseDelay = 1 * time.Second
+	DefaultRetryMaxDelay  = 5 * time.Minute
+	// DefaultRetryQPS 和 DefaultRetryBurst 限制了所有失败的 key 重新入队的总速率，见 NewRequeueRateLimiter
+	DefaultRetryQPS   = 10
+	DefaultRetryBurst = 100
+)
+
+// Controller 是 ECSMService 的调谐控制器。
+// 它从 Registry 中读取期望状态（世界一），通过 clientset 与 ECSM 平台（世界二）交互，
+// 使 ECSM 上的服务与 ECSMService 的 spec 保持一致，并把观测到的结果写回 status。
+// 控制器通过 ECSMServiceFinalizer 保证 ECSMService 被删除时，ECSM 上对应的服务也会被删除。
+//
+// 需要调谐的对象以 namespace/name 为 key 放入工作队列，由多个 worker 并发处理。
+// 队列会合并重复的 key，并保证同一个 key 不会同时被两个 worker 处理；
+// 调谐失败的 key 按指数退避重新入队，从而在 ECSM 出现问题时不会被重试请求压垮；
+// 对 ECSM API 的请求速率则由 clientset 中 RESTClient 的令牌桶统一限制。
+type Controller struct {
+	registry  *registry.Registry
+	clientset clientset.Interface
+
+	// namespace 是控制器负责的命名空间，为空表示所有命名空间。
+	namespace string
+
+	// serviceInformer 缓存了 ECSM 的服务列表，用于读取服务详情接口不返回的 errorInstance，为 nil 时直接查询 ECSM。
+	serviceInformer *informers.Informer[clientset.ProvisionListRow]
+
+	queue workqueue.TypedRateLimitingInterface[string]
+
+	deletePollInterval time.Duration
+	deleteTimeout      time.Duration
+}
+
+// NewController 使用默认的重试限速器创建一个新的 Controller 实例。
+// namespace 为空 (metav1.NamespaceAll) 时，控制器会调谐所有命名空间中的 ECSMService。
+func NewController(reg *registry.Registry, cs clientset.Interface, namespace string) *Controller {
+	return NewControllerWithRateLimiter(reg, cs, namespace,
+		NewRequeueRateLimiter(DefaultRetryBaseDelay, DefaultRetryMaxDelay, DefaultRetryQPS, DefaultRetryBurst))
+}
+
+// NewControllerWithRateLimiter 创建一个新的 Controller 实例，rateLimiter 决定调谐失败的 key 何时重新入队。
+func NewControllerWithRateLimiter(reg *registry.Registry, cs clientset.Interface, namespace string, rateLimiter workqueue.TypedRateLimiter[string]) *Controller {
+	return &Controller{
+		registry:  reg,
+		clientset: cs,
+		namespace: namespace,
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(rateLimiter, workqueue.TypedRateLimitingQueueConfig[string]{
+			Name: "ecsmservice",
+		}),
+
+		deletePollInterval: defaultDeletePollInterval,
+		deleteTimeout:      defaultDeleteTimeout,
+	}
+}
+
+// SetServiceInformer 让控制器从 informer 的缓存中读取服务列表中的信息，而不是每次调谐都查询 ECSM。
+// informer 由调用者启动，必须在 Run 之前调用。
+func (c *Controller) SetServiceInformer(informer *informers.Informer[clientset.ProvisionListRow]) {
+	c.serviceInformer = informer
+}
+
+// NewRequeueRateLimiter 返回调谐失败后重新入队所用的限速器：
+// 每个 key 的等待时间从 baseDelay 开始逐次翻倍，最长为 maxDelay；
+// 同时所有 key 共享一个 qps/burst 的令牌桶，两者取较长的等待时间。
+// 它限制的是失败的 key 重新入队的速率，不是对 ECSM API 的调用：一次调谐可能发出多个请求，
+// 首次入队和周期性的全量入队也不经过它。对 ECSM API 的总请求速率由 RESTClient 的 QPS/Burst 限制。
+func NewRequeueRateLimiter(baseDelay, maxDelay time.Duration, qps float64, burst int) workqueue.TypedRateLimiter[string] {
+	return workqueue.NewTypedMaxOfRateLimiter(
+		workqueue.NewTypedItemExponentialFailureRateLimiter[string](baseDelay, maxDelay),
+		&workqueue.TypedBucketRateLimiter[string]{Limiter: rate.NewLimiter(rate.Limit(qps), burst)},
+	)
+}
+
+// Run 启动 workers 个 worker 处理工作队列，它会阻塞直到 ctx 被取消且所有 worker 退出。
+// 控制器每隔 resyncPeriod 把所有 ECSMService 放入队列，
+// 在两次全量入队之间通过 Watch 把发生变更的对象放入队列。Watch 不可用时退化为纯轮询。
+// ctx 被取消后不会再开始新的调谐，但正在进行中的调谐会使用一个不随 ctx 取消的 context 执行完毕，
+// 以免 ECSM 上的操作做到一半、status 却没有写回。Run 只能被调用一次。
+func (c *Controller) Run(ctx context.Context, resyncPeriod time.Duration, workers int) {
+	klog.InfoS("Starting ECSMService controller", "namespace", c.namespace, "resyncPeriod", resyncPeriod, "workers", workers)
+	defer klog.InfoS("Shutting down ECSMService controller")
+
+	workCtx := context.WithoutCancel(ctx)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c.processNextWorkItem(workCtx, ctx.Done()) {
+			}
+		}()
+	}
+
+	c.enqueueLoop(ctx, resyncPeriod)
+
+	// 关闭队列会唤醒所有空闲的 worker，正在调谐的 worker 处理完当前的 key 后退出
+	c.queue.ShutDown()
+	wg.Wait()
+}
+
+// enqueueLoop 周期性地把所有 ECSMService 放入队列，并把 watch 事件转化为入队操作，直到 ctx 被取消。
+func (c *Controller) enqueueLoop(ctx context.Context, resyncPeriod time.Duration) {
+	ticker := time.NewTicker(resyncPeriod)
+	defer ticker.Stop()
+
+	var w watch.Interface
+	defer func() {
+		if w != nil {
+			w.Stop()
+		}
+	}()
+
+	for {
+		resourceVersion, err := c.enqueueAll(ctx)
+		if err != nil {
+			klog.ErrorS(err, "Failed to enqueue ECSMServices for resync")
+		}
+		if w == nil && resourceVersion != "" && !stopped(ctx.Done()) {
+			w = c.watchServices(ctx, resourceVersion)
+		}
+
+	waitForEvents:
+		for {
+			var events <-chan watch.Event
+			if w != nil {
+				events = w.ResultChan()
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				break waitForEvents
+			case event, ok := <-events:
+				if !ok {
+					// watch 被关闭，在下一次全量入队后重新建立
+					klog.V(2).InfoS("Watch on ECSMServices closed, falling back to resync until it is re-established")
+					w = nil
+					continue
+				}
+				c.handleEvent(event)
+			}
+		}
+	}
+}
+
+// enqueueAll 把负责范围内的每一个 ECSMService 放入队列，返回所基于的列表的 resourceVersion。
+func (c *Controller) enqueueAll(ctx context.Context) (string, error) {
+	services, err := c.registry.ListServices(ctx, c.namespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to list ECSMServices: %w", err)
+	}
+	for i := range services.Items {
+		c.queue.Add(keyFor(services.Items[i].Namespace, services.Items[i].Name))
+	}
+	return services.ResourceVersion, nil
+}
+
+// watchServices 从 resourceVersion 开始监听 ECSMService 的变更，失败时返回 nil。
+func (c *Controller) watchServices(ctx context.Context, resourceVersion string) watch.Interface {
+	w, err := c.registry.WatchServices(ctx, c.namespace, resourceVersion)
+	if err != nil {
+		klog.ErrorS(err, "Failed to watch ECSMServices, relying on periodic resync", "resourceVersion", resourceVersion)
+		return nil
+	}
+	return w
+}
+
+// handleEvent 把 watch 事件对应的 ECSMService 放入队列。
+func (c *Controller) handleEvent(event watch.Event) {
+	svc, ok := event.Object.(*ecsmv1.ECSMService)
+	if !ok {
+		klog.V(4).InfoS("Ignoring unexpected watch event", "type", event.Type, "object", fmt.Sprintf("%T", event.Object))
+		return
+	}
+	key := keyFor(svc.Namespace, svc.Name)
+	klog.V(4).InfoS("Received ECSMService event", "type", event.Type, "key", key)
+
+	// DELETED 事件只会在 finalizer 被移除之后出现，此时 ECSM 上的服务已经被删除
+	switch event.Type {
+	case watch.Added, watch.Modified:
+		c.queue.Add(key)
+	}
+}
+
+// processNextWorkItem 从队列中取出一个 key 并调谐，队列被关闭或 stopCh 关闭时返回 false。
+func (c *Controller) processNextWorkItem(ctx context.Context, stopCh <-chan struct{}) bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	// 队列关闭后其中剩余的 key 仍然会被取出，这里丢弃它们，下次启动时的全量入队会重新处理
+	if stopped(stopCh) {
+		return false
+	}
+
+	namespace, name := splitKey(key)
+	if err := c.Reconcile(ctx, namespace, name); err != nil {
+		klog.ErrorS(err, "Failed to reconcile ECSMService, requeuing with backoff", "key", key, "retries", c.queue.NumRequeues(key))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+// ReconcileAll 在当前 goroutine 中对负责范围内的每一个 ECSMService 执行一次调谐，包括完成正在被删除的对象的清理。
+// 它不经过工作队列，单个对象的失败不会中断整个循环，所有错误会被聚合后返回。
+func (c *Controller) ReconcileAll(ctx context.Context) error {
+	services, err := c.registry.ListServices(ctx, c.namespace)
+	if err != nil {
+		return fmt.Errorf("failed to list ECSMServices: %w", err)
+	}
+
+	var errs []error
+	for i := range services.Items {
+		svc := &services.Items[i]
+		key := keyFor(svc.Namespace, svc.Name)
+		if err := c.Reconcile(ctx, svc.Namespace, svc.Name); err != nil {
+			klog.ErrorS(err, "Failed to reconcile ECSMService", "key", key)
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// stopped 非阻塞地检查 stopCh 是否已关闭。
+func stopped(stopCh <-chan struct{}) bool {
+	select {
+	case <-stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// keyFor 返回对象的 namespace/name 形式的 key。
+func keyFor(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// splitKey 是 keyFor 的逆操作。
+func splitKey(key string) (namespace, name string) {
+	if namespace, name, ok := strings.Cut(key, "/"); ok {
+		return namespace, name
+	}
+	return "", key
+}
@@ -0,0 +1,627 @@
+// file: pkg/controller/controller_test.go
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/conversion"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset/fake"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/rest"
+	"github.com/fx147/ecsm-operator/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// fakeClientset 只实现控制器用到的 Services()、Containers() 和 Transactions()，其余方法通过内嵌的 nil 接口满足 clientset.Interface。
+type fakeClientset struct {
+	clientset.Interface
+	services   clientset.ServiceInterface
+	containers fakeContainers
+}
+
+func (f *fakeClientset) Services() clientset.ServiceInterface {
+	return f.services
+}
+
+func (f *fakeClientset) Containers() clientset.ContainerInterface {
+	return f.containers
+}
+
+func (f *fakeClientset) Transactions() clientset.TransactionInterface {
+	return fakeTransactions{services: f.services}
+}
+
+// fakeTransactions 只认识 fakeServices.Delete 返回的 "tx-<服务 ID>" 事务：服务不存在时事务成功，否则仍在执行。
+// 其他事务一律返回 NotFound。
+type fakeTransactions struct {
+	services clientset.ServiceInterface
+}
+
+func (f fakeTransactions) Get(ctx context.Context, id string) (*clientset.Transaction, error) {
+	serviceID, ok := strings.CutPrefix(id, "tx-")
+	if !ok {
+		return nil, &rest.Aerror{Status: http.StatusNotFound, Message: fmt.Sprintf("transaction %s not found", id)}
+	}
+	tx := &clientset.Transaction{ID: id, Status: clientset.TransactionStatusSuccess}
+	if _, err := f.services.Get(ctx, serviceID); err == nil {
+		tx.Status = clientset.TransactionStatusRunning
+	}
+	return tx, nil
+}
+
+func (f fakeTransactions) WaitForTransaction(ctx context.Context, id string, pollInterval time.Duration) (*clientset.Transaction, error) {
+	var tx *clientset.Transaction
+	err := wait.PollUntilContextCancel(ctx, pollInterval, true, func(ctx context.Context) (bool, error) {
+		var err error
+		tx, err = f.Get(ctx, id)
+		return err == nil && tx.Status != clientset.TransactionStatusRunning, err
+	})
+	return tx, err
+}
+
+// fakeContainers 对任何服务都返回同一组容器。
+type fakeContainers struct {
+	clientset.ContainerInterface
+	items []clientset.ContainerInfo
+}
+
+func (f fakeContainers) ListAllByService(ctx context.Context, opts clientset.ListContainersByServiceOptions) ([]clientset.ContainerInfo, error) {
+	return f.items, nil
+}
+
+// fakeServices 是一个内存中的 ECSM 服务集合。
+type fakeServices struct {
+	clientset.ServiceInterface
+	mu     sync.Mutex
+	nextID int
+	items  map[string]*clientset.ServiceGet
+	// errorInstances 是服务列表中每个服务的 errorInstance，key 为服务 ID
+	errorInstances map[string][]clientset.ErrorInstance
+	// lists 是 ListAll 被调用的次数
+	lists   int
+	created int
+	updated int
+	deleted int
+}
+
+func newFakeServices() *fakeServices {
+	return &fakeServices{
+		items:          make(map[string]*clientset.ServiceGet),
+		errorInstances: make(map[string][]clientset.ErrorInstance),
+	}
+}
+
+func (f *fakeServices) Create(ctx context.Context, req *clientset.CreateServiceRequest) (*clientset.ServiceCreateResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	f.created++
+	id := fmt.Sprintf("svc-%d", f.nextID)
+	image := req.Image
+	f.items[id] = &clientset.ServiceGet{
+		ID:             id,
+		Name:           req.Name,
+		Policy:         req.Policy,
+		Factor:         *req.Factor,
+		InstanceOnline: *req.Factor,
+		Healthy:        true,
+		Image:          &image,
+		Node:           &clientset.NodeSpec{Names: req.Node.Names},
+	}
+	return &clientset.ServiceCreateResponse{ID: id}, nil
+}
+
+func (f *fakeServices) Get(ctx context.Context, id string) (*clientset.ServiceGet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	svc, ok := f.items[id]
+	if !ok {
+		return nil, &rest.Aerror{Status: http.StatusNotFound, Message: fmt.Sprintf("service %s not found", id)}
+	}
+	copied := *svc
+	return &copied, nil
+}
+
+func (f *fakeServices) ListAll(ctx context.Context, opts clientset.ListServicesOptions) ([]clientset.ProvisionListRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lists++
+	var rows []clientset.ProvisionListRow
+	for _, svc := range f.items {
+		if opts.Name != "" && !strings.Contains(svc.Name, opts.Name) {
+			continue
+		}
+		rows = append(rows, clientset.ProvisionListRow{ID: svc.ID, Name: svc.Name, ErrorInstances: f.errorInstances[svc.ID]})
+	}
+	return rows, nil
+}
+
+func (f *fakeServices) Update(ctx context.Context, id string, req *clientset.UpdateServiceRequest) (*clientset.ServiceCreateResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	svc, ok := f.items[id]
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", id)
+	}
+	f.updated++
+	image := req.Image
+	svc.Policy = req.Policy
+	svc.Factor = *req.Factor
+	svc.InstanceOnline = *req.Factor
+	svc.Image = &image
+	svc.Node = &clientset.NodeSpec{Names: req.Node.Names}
+	return &clientset.ServiceCreateResponse{ID: id}, nil
+}
+
+func (f *fakeServices) Delete(ctx context.Context, id string) (*clientset.ServiceDeleteResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.items, id)
+	f.deleted++
+	return &clientset.ServiceDeleteResponse{ID: "tx-" + id}, nil
+}
+
+// counts 返回 Create/Update/Delete 的调用次数。
+func (f *fakeServices) counts() (created, updated, deleted int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.created, f.updated, f.deleted
+}
+
+func newTestRegistry(t *testing.T) *registry.Registry {
+	s := runtime.NewScheme()
+	require.NoError(t, ecsmv1.AddToScheme(s))
+	store, err := registry.NewFileStore(t.TempDir(), s)
+	require.NoError(t, err)
+	return registry.NewRegistry(store)
+}
+
+func newDynamicService(namespace, name string, replicas int32) *ecsmv1.ECSMService {
+	return &ecsmv1.ECSMService{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: ecsmv1.SchemeGroupVersion.String(),
+			Kind:       "ECSMService",
+		},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: ecsmv1.ECSMServiceSpec{
+			DeploymentStrategy: ecsmv1.DeploymentStrategy{
+				Type:     ecsmv1.DeploymentStrategyTypeDynamic,
+				Replicas: &replicas,
+				NodePool: []string{"worker1", "worker2"},
+			},
+			Template: ecsmv1.ContainerTemplateSpec{Image: "nginx@1.0#sylixos"},
+		},
+	}
+}
+
+func TestController_ReconcileLifecycle(t *testing.T) {
+	ctx := context.Background()
+	reg := newTestRegistry(t)
+	services := newFakeServices()
+	c := NewController(reg, &fakeClientset{services: services}, "")
+
+	_, err := reg.CreateService(ctx, newDynamicService("default", "web", 2))
+	require.NoError(t, err)
+
+	t.Run("Create", func(t *testing.T) {
+		require.NoError(t, c.ReconcileAll(ctx))
+		assert.Equal(t, 1, services.created)
+
+		svc, err := reg.GetService(ctx, "default", "web")
+		require.NoError(t, err)
+		assert.Equal(t, "svc-1", svc.Status.UnderlyingServiceID)
+		assert.Equal(t, int32(2), svc.Status.Replicas)
+		assert.Equal(t, int32(2), svc.Status.ReadyReplicas)
+		assert.True(t, meta.IsStatusConditionTrue(svc.Status.Conditions, ecsmv1.ECSMServiceAvailable))
+		assert.True(t, meta.IsStatusConditionFalse(svc.Status.Conditions, ecsmv1.ECSMServiceDegraded))
+	})
+
+	t.Run("NoDrift", func(t *testing.T) {
+		require.NoError(t, c.ReconcileAll(ctx))
+		assert.Equal(t, 1, services.created)
+		assert.Equal(t, 0, services.updated)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		svc, err := reg.GetService(ctx, "default", "web")
+		require.NoError(t, err)
+		replicas := int32(3)
+		svc.Spec.DeploymentStrategy.Replicas = &replicas
+		_, err = reg.UpdateService(ctx, svc)
+		require.NoError(t, err)
+
+		require.NoError(t, c.ReconcileAll(ctx))
+		assert.Equal(t, 1, services.updated)
+
+		svc, err = reg.GetService(ctx, "default", "web")
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), svc.Status.Replicas)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		// finalizer 存在时，删除只会设置 DeletionTimestamp
+		require.NoError(t, reg.DeleteService(ctx, "default", "web"))
+		svc, err := reg.GetService(ctx, "default", "web")
+		require.NoError(t, err)
+		assert.NotNil(t, svc.DeletionTimestamp)
+		assert.Equal(t, 0, services.deleted)
+
+		require.NoError(t, c.ReconcileAll(ctx))
+		assert.Equal(t, 1, services.deleted)
+		assert.Empty(t, services.items)
+
+		// ECSM 上的服务被删除之后，对象才会从 Registry 中移除
+		_, err = reg.GetService(ctx, "default", "web")
+		assert.True(t, errors.IsNotFound(err), "expected NotFound, got %v", err)
+	})
+}
+
+func TestController_AddsFinalizerBeforeCreating(t *testing.T) {
+	ctx := context.Background()
+	reg := newTestRegistry(t)
+	services := newFakeServices()
+	c := NewController(reg, &fakeClientset{services: services}, "default")
+
+	_, err := reg.CreateService(ctx, newDynamicService("default", "web", 1))
+	require.NoError(t, err)
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+
+	svc, err := reg.GetService(ctx, "default", "web")
+	require.NoError(t, err)
+	assert.Contains(t, svc.Finalizers, ecsmv1.ECSMServiceFinalizer)
+	assert.Equal(t, 1, services.created)
+}
+
+func TestController_FinalizeKeepsObjectUntilServiceIsGone(t *testing.T) {
+	ctx := context.Background()
+	reg := newTestRegistry(t)
+	services := &stickyServices{fakeServices: newFakeServices()}
+	c := NewController(reg, &fakeClientset{services: services.fakeServices}, "default")
+	c.deletePollInterval = time.Millisecond
+	c.deleteTimeout = 20 * time.Millisecond
+
+	_, err := reg.CreateService(ctx, newDynamicService("default", "web", 1))
+	require.NoError(t, err)
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+	require.NoError(t, reg.DeleteService(ctx, "default", "web"))
+
+	// ECSM 接受了删除请求，但服务迟迟没有消失：finalizer 必须保留
+	c.clientset = &fakeClientset{services: services}
+	assert.Error(t, c.Reconcile(ctx, "default", "web"))
+	svc, err := reg.GetService(ctx, "default", "web")
+	require.NoError(t, err)
+	assert.Contains(t, svc.Finalizers, ecsmv1.ECSMServiceFinalizer)
+
+	// 删除最终完成后，下一次调谐移除 finalizer，对象被真正删除
+	c.clientset = &fakeClientset{services: services.fakeServices}
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+	_, err = reg.GetService(ctx, "default", "web")
+	assert.True(t, errors.IsNotFound(err), "expected NotFound, got %v", err)
+}
+
+func TestController_FinalizeGetsServiceWhenTransactionIsUnknown(t *testing.T) {
+	ctx := context.Background()
+	reg := newTestRegistry(t)
+	services := newFakeServices()
+	c := NewController(reg, &fakeClientset{services: services}, "default")
+	c.deletePollInterval = time.Millisecond
+	c.deleteTimeout = time.Second
+
+	_, err := reg.CreateService(ctx, newDynamicService("default", "web", 1))
+	require.NoError(t, err)
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+	require.NoError(t, reg.DeleteService(ctx, "default", "web"))
+
+	// ECSM 查不到删除事务时按 ID 确认服务已经不存在，而不是按名称列出所有服务
+	c.clientset = &fakeClientset{services: &untrackedDeleteServices{fakeServices: services}}
+	services.mu.Lock()
+	lists := services.lists
+	services.mu.Unlock()
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+	_, err = reg.GetService(ctx, "default", "web")
+	assert.True(t, errors.IsNotFound(err), "expected NotFound, got %v", err)
+	services.mu.Lock()
+	assert.Equal(t, lists, services.lists, "expected no ListAll while waiting for the deletion")
+	services.mu.Unlock()
+}
+
+// untrackedDeleteServices 删除服务，但返回一个 ECSM 查不到的事务 ID。
+type untrackedDeleteServices struct {
+	*fakeServices
+}
+
+func (s *untrackedDeleteServices) Delete(ctx context.Context, id string) (*clientset.ServiceDeleteResponse, error) {
+	if _, err := s.fakeServices.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	return &clientset.ServiceDeleteResponse{ID: "pruned-" + id}, nil
+}
+
+// stickyServices 接受删除请求，但不真正删除服务，模拟一个尚未完成的异步删除事务。
+type stickyServices struct {
+	*fakeServices
+}
+
+func (s *stickyServices) Delete(ctx context.Context, id string) (*clientset.ServiceDeleteResponse, error) {
+	return &clientset.ServiceDeleteResponse{ID: "tx-" + id}, nil
+}
+
+func TestController_AdoptsExistingServiceByName(t *testing.T) {
+	ctx := context.Background()
+	reg := newTestRegistry(t)
+	services := newFakeServices()
+	c := NewController(reg, &fakeClientset{services: services}, "default")
+
+	// ECSM 上已经存在同名服务，控制器应该接管它而不是重复创建
+	// 先写入 registry，ECSM 上的服务按照填充过默认值的 spec 创建
+	want, err := reg.CreateService(ctx, newDynamicService("default", "api", 1))
+	require.NoError(t, err)
+	req, err := conversion.SpecToCreateRequest(want.Name, &want.Spec)
+	require.NoError(t, err)
+	_, err = services.Create(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Reconcile(ctx, "default", "api"))
+	assert.Equal(t, 1, services.created)
+	assert.Equal(t, 0, services.updated)
+
+	svc, err := reg.GetService(ctx, "default", "api")
+	require.NoError(t, err)
+	assert.Equal(t, "svc-1", svc.Status.UnderlyingServiceID)
+	assert.NotContains(t, svc.Annotations, ecsmv1.ECSMServiceIDAnnotation)
+
+	// 接管的服务不是控制器创建的，删除 ECSMService 时不能删除它
+	require.NoError(t, reg.DeleteService(ctx, "default", "api"))
+	require.NoError(t, c.Reconcile(ctx, "default", "api"))
+	_, _, deleted := services.counts()
+	assert.Equal(t, 0, deleted)
+	_, err = services.Get(ctx, "svc-1")
+	assert.NoError(t, err)
+	_, err = reg.GetService(ctx, "default", "api")
+	assert.True(t, errors.IsNotFound(err), "expected NotFound, got %v", err)
+}
+
+func TestController_RecordsServiceCreatedByPreviousReconcile(t *testing.T) {
+	ctx := context.Background()
+	reg := newTestRegistry(t)
+	services := newFakeServices()
+	c := NewController(reg, &fakeClientset{services: services}, "default")
+
+	// 上一次调谐发出了创建请求，但没能记录服务 ID
+	want, err := reg.CreateService(ctx, newDynamicService("default", "api", 1))
+	require.NoError(t, err)
+	_, err = reg.SetServiceAnnotation(ctx, "default", "api", ecsmv1.ECSMServiceIDAnnotation, "")
+	require.NoError(t, err)
+	req, err := conversion.SpecToCreateRequest(want.Name, &want.Spec)
+	require.NoError(t, err)
+	_, err = services.Create(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Reconcile(ctx, "default", "api"))
+	svc, err := reg.GetService(ctx, "default", "api")
+	require.NoError(t, err)
+	assert.Equal(t, "svc-1", svc.Annotations[ecsmv1.ECSMServiceIDAnnotation])
+
+	require.NoError(t, reg.DeleteService(ctx, "default", "api"))
+	require.NoError(t, c.Reconcile(ctx, "default", "api"))
+	created, _, deleted := services.counts()
+	assert.Equal(t, 1, created)
+	assert.Equal(t, 1, deleted)
+}
+
+// unavailableServices 的 Get 总是返回服务端错误。
+type unavailableServices struct {
+	*fakeServices
+}
+
+func (s *unavailableServices) Get(ctx context.Context, id string) (*clientset.ServiceGet, error) {
+	return nil, &rest.Aerror{Status: http.StatusInternalServerError, Message: "internal error"}
+}
+
+func TestController_DoesNotFallBackToNameOnGetError(t *testing.T) {
+	ctx := context.Background()
+	reg := newTestRegistry(t)
+	services := newFakeServices()
+	c := NewController(reg, &fakeClientset{services: services}, "default")
+
+	_, err := reg.CreateService(ctx, newDynamicService("default", "api", 1))
+	require.NoError(t, err)
+	require.NoError(t, c.Reconcile(ctx, "default", "api"))
+
+	// ECSM 暂时不可用时不能当作服务不存在而按名称查找或重新创建
+	c.clientset = &fakeClientset{services: &unavailableServices{services}}
+	err = c.Reconcile(ctx, "default", "api")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "internal error")
+	created, _, _ := services.counts()
+	assert.Equal(t, 1, created)
+}
+
+func TestController_RunReactsToWatchEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	reg := newTestRegistry(t)
+	services := newFakeServices()
+	c := NewController(reg, &fakeClientset{services: services}, "default")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// resync 周期足够长，测试中的调谐只可能由 watch 事件触发
+		c.Run(ctx, time.Hour, 2)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// 无论对象在第一次全量调谐之前还是之后创建，都应该被及时处理：
+	// watch 从全量调谐所基于的 resourceVersion 开始，不会漏掉中间的写入
+	_, err := reg.CreateService(ctx, newDynamicService("default", "web", 1))
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		created, _, _ := services.counts()
+		return created == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, reg.DeleteService(ctx, "default", "web"))
+	assert.Eventually(t, func() bool {
+		_, _, deleted := services.counts()
+		return deleted == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// flakyServices 在前 failures 次 Create 时返回错误，模拟暂时不可用的 ECSM。
+type flakyServices struct {
+	*fakeServices
+	failures int
+	attempts atomic.Int32
+}
+
+func (f *flakyServices) Create(ctx context.Context, req *clientset.CreateServiceRequest) (*clientset.ServiceCreateResponse, error) {
+	if int(f.attempts.Add(1)) <= f.failures {
+		return nil, fmt.Errorf("connection reset by peer")
+	}
+	return f.fakeServices.Create(ctx, req)
+}
+
+func TestController_RunRetriesFailedReconcilesWithBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	reg := newTestRegistry(t)
+	services := &flakyServices{fakeServices: newFakeServices(), failures: 3}
+	c := NewControllerWithRateLimiter(reg, &fakeClientset{services: services}, "default",
+		NewRequeueRateLimiter(10*time.Millisecond, time.Second, 100, 10))
+
+	_, err := reg.CreateService(ctx, newDynamicService("default", "web", 1))
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Run(ctx, time.Hour, 4)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// 失败的 key 不等 resync 就会被重试，直到成功
+	assert.Eventually(t, func() bool {
+		created, _, _ := services.counts()
+		return created == 1
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(4), services.attempts.Load())
+
+	// 成功之后该 key 的退避被重置
+	assert.Eventually(t, func() bool {
+		return c.queue.NumRequeues("default/web") == 0
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestController_ReconcileAgainstSimulator(t *testing.T) {
+	ctx := context.Background()
+	reg := newTestRegistry(t)
+	cs := fake.NewSimpleClientset()
+	cs.Simulator.AddNode("worker1", "10.0.0.1")
+	cs.Simulator.AddNode("worker2", "10.0.0.2")
+	c := NewController(reg, cs, "default")
+	c.deletePollInterval = time.Millisecond
+
+	_, err := reg.CreateService(ctx, newDynamicService("default", "web", 2))
+	require.NoError(t, err)
+
+	t.Run("Create", func(t *testing.T) {
+		require.NoError(t, c.Reconcile(ctx, "default", "web"))
+
+		svc, err := reg.GetService(ctx, "default", "web")
+		require.NoError(t, err)
+		require.NotEmpty(t, svc.Status.UnderlyingServiceID)
+		assert.Equal(t, int32(2), svc.Status.Replicas)
+		assert.Equal(t, int32(2), svc.Status.ReadyReplicas)
+
+		containers, err := cs.Containers().ListAllByService(ctx, clientset.ListContainersByServiceOptions{ServiceIDs: []string{svc.Status.UnderlyingServiceID}})
+		require.NoError(t, err)
+		assert.Len(t, containers, 2)
+	})
+
+	t.Run("NoDrift", func(t *testing.T) {
+		cs.ClearActions()
+		require.NoError(t, c.Reconcile(ctx, "default", "web"))
+		for _, action := range cs.Actions() {
+			assert.Equal(t, "GET", action.Verb, "unexpected write %s %s", action.Verb, action.Path)
+		}
+	})
+
+	t.Run("Degraded", func(t *testing.T) {
+		svc, err := reg.GetService(ctx, "default", "web")
+		require.NoError(t, err)
+		containers, err := cs.Containers().ListAllByService(ctx, clientset.ListContainersByServiceOptions{ServiceIDs: []string{svc.Status.UnderlyingServiceID}})
+		require.NoError(t, err)
+		require.NoError(t, cs.Simulator.FailContainer(containers[0].ID, "out of memory"))
+
+		require.NoError(t, c.Reconcile(ctx, "default", "web"))
+		svc, err = reg.GetService(ctx, "default", "web")
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), svc.Status.ReadyReplicas)
+		assert.False(t, meta.IsStatusConditionTrue(svc.Status.Conditions, ecsmv1.ECSMServiceAvailable))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, reg.DeleteService(ctx, "default", "web"))
+		require.NoError(t, c.Reconcile(ctx, "default", "web"))
+
+		_, err := reg.GetService(ctx, "default", "web")
+		assert.True(t, errors.IsNotFound(err), "expected NotFound, got %v", err)
+		rows, err := cs.Services().ListAll(ctx, clientset.ListServicesOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, rows)
+	})
+}
+
+func TestController_FinalizeReportsFailedDeleteTransaction(t *testing.T) {
+	ctx := context.Background()
+	reg := newTestRegistry(t)
+	cs := fake.NewSimpleClientset()
+	cs.Simulator.AddNode("worker1", "10.0.0.1")
+	cs.Simulator.AddNode("worker2", "10.0.0.2")
+	c := NewController(reg, cs, "default")
+	c.deletePollInterval = time.Millisecond
+
+	_, err := reg.CreateService(ctx, newDynamicService("default", "web", 1))
+	require.NoError(t, err)
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+	require.NoError(t, reg.DeleteService(ctx, "default", "web"))
+
+	// 删除事务失败时不需要等到超时，finalizer 保留到下一次调谐
+	cs.Simulator.FailNextTransaction("service is locked")
+	err = c.Reconcile(ctx, "default", "web")
+	require.True(t, clientset.IsTransactionFailed(err), "expected transaction failure, got %v", err)
+	assert.Contains(t, err.Error(), "service is locked")
+	svc, err := reg.GetService(ctx, "default", "web")
+	require.NoError(t, err)
+	assert.Contains(t, svc.Finalizers, ecsmv1.ECSMServiceFinalizer)
+	assert.NotEmpty(t, svc.Annotations[ecsmv1.ECSMServiceDeleteTransactionAnnotation], "expected the delete transaction to be recorded")
+	degraded := meta.FindStatusCondition(svc.Status.Conditions, ecsmv1.ECSMServiceDegraded)
+	require.NotNil(t, degraded)
+	assert.Equal(t, metav1.ConditionTrue, degraded.Status)
+	assert.Equal(t, reasonDeleteFailed, degraded.Reason)
+	assert.Contains(t, degraded.Message, "service is locked")
+
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+	_, err = reg.GetService(ctx, "default", "web")
+	assert.True(t, errors.IsNotFound(err), "expected NotFound, got %v", err)
+}
@@ -0,0 +1,256 @@
+// file: pkg/controller/reconcile.go
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/conversion"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/rest"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// Reconcile 对单个 ECSMService 执行一次调谐：
+//  1. 从 Registry 读取期望状态，正在被删除的对象转交 finalize 处理；
+//  2. 确保对象上有 ECSMServiceFinalizer，之后才会在 ECSM 上创建服务；
+//  3. 在 ECSM 上查找对应的服务，不存在则创建，存在但与期望不一致则更新；
+//  4. 把观测到的实际状态写回 ECSMService 的 status。
+func (c *Controller) Reconcile(ctx context.Context, namespace, name string) error {
+	key := keyFor(namespace, name)
+
+	svc, err := c.registry.GetService(ctx, namespace, name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// 对象已被删除，ECSM 上的服务在移除 finalizer 之前就已经被删除了
+			klog.V(4).InfoS("ECSMService not found, skipping", "key", key)
+			return nil
+		}
+		return err
+	}
+
+	if svc.DeletionTimestamp != nil {
+		return c.finalize(ctx, svc)
+	}
+
+	// 先添加 finalizer 再创建 ECSM 服务，这样对象被删除时服务一定会被清理
+	if !slices.Contains(svc.Finalizers, ecsmv1.ECSMServiceFinalizer) {
+		svc, err = c.registry.AddServiceFinalizer(ctx, namespace, name, ecsmv1.ECSMServiceFinalizer)
+		if err != nil {
+			return fmt.Errorf("failed to add finalizer: %w", err)
+		}
+	}
+
+	desired, err := conversion.SpecToCreateRequest(svc.Name, &svc.Spec)
+	if err != nil {
+		return fmt.Errorf("failed to convert spec: %w", err)
+	}
+
+	actual, row, err := c.findECSMService(ctx, svc)
+	if err != nil {
+		return err
+	}
+
+	if actual == nil {
+		// 先把注解置为空值再创建：如果创建成功后没能记录服务 ID，下一次调谐按名称找到的服务仍然会被认作是自己创建的
+		if id, ok := svc.Annotations[ecsmv1.ECSMServiceIDAnnotation]; !ok || id != "" {
+			svc, err = c.registry.SetServiceAnnotation(ctx, namespace, name, ecsmv1.ECSMServiceIDAnnotation, "")
+			if err != nil {
+				return fmt.Errorf("failed to record ECSM service ownership: %w", err)
+			}
+		}
+		klog.InfoS("Creating ECSM service", "key", key, "image", desired.Image.Ref, "policy", desired.Policy)
+		resp, err := c.clientset.Services().Create(ctx, desired)
+		if err != nil {
+			return fmt.Errorf("failed to create ECSM service: %w", err)
+		}
+		svc, err = c.registry.SetServiceAnnotation(ctx, namespace, name, ecsmv1.ECSMServiceIDAnnotation, resp.ID)
+		if err != nil {
+			return fmt.Errorf("failed to record ECSM service ownership: %w", err)
+		}
+		actual, err = c.clientset.Services().Get(ctx, resp.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get ECSM service %s after creation: %w", resp.ID, err)
+		}
+	} else {
+		drifted, err := conversion.NeedsUpdate(&svc.Spec, actual)
+		if err != nil {
+			return fmt.Errorf("failed to compare ECSM service %s with spec: %w", actual.ID, err)
+		}
+		if drifted {
+			klog.InfoS("Updating ECSM service", "key", key, "serviceID", actual.ID)
+			update, err := conversion.SpecToUpdateRequest(actual.ID, svc.Name, &svc.Spec)
+			if err != nil {
+				return fmt.Errorf("failed to convert spec: %w", err)
+			}
+			if _, err := c.clientset.Services().Update(ctx, actual.ID, update); err != nil {
+				return fmt.Errorf("failed to update ECSM service %s: %w", actual.ID, err)
+			}
+			actual, err = c.clientset.Services().Get(ctx, actual.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get ECSM service %s after update: %w", update.ID, err)
+			}
+		}
+	}
+
+	return c.updateStatus(ctx, svc, actual, row)
+}
+
+// finalize 清理一个正在被删除的 ECSMService：删除 ECSM 上对应的服务，等待删除完成后移除 finalizer，
+// Registry 随后才会真正删除该对象。任何一步失败都会保留 finalizer，在下一次调谐中重试。
+func (c *Controller) finalize(ctx context.Context, svc *ecsmv1.ECSMService) error {
+	key := keyFor(svc.Namespace, svc.Name)
+	if !slices.Contains(svc.Finalizers, ecsmv1.ECSMServiceFinalizer) {
+		return nil
+	}
+
+	actual, _, err := c.findECSMService(ctx, svc)
+	if err != nil {
+		return err
+	}
+	if actual != nil && !ownsECSMService(svc, actual) {
+		// 服务是按名称接管的，不是控制器创建的，删除 ECSMService 时保留它
+		klog.InfoS("ECSM service was not created by the controller, leaving it in place", "key", key, "serviceID", actual.ID)
+		actual = nil
+	}
+	if actual != nil {
+		klog.InfoS("Deleting ECSM service", "key", key, "serviceID", actual.ID)
+		resp, err := c.clientset.Services().Delete(ctx, actual.ID)
+		if err != nil {
+			return fmt.Errorf("failed to delete ECSM service %s: %w", actual.ID, err)
+		}
+		if resp.ID != "" {
+			// 记录删除事务，ecsm-cli delete --wait 通过它得知删除是否失败
+			svc, err = c.registry.SetServiceAnnotation(ctx, svc.Namespace, svc.Name, ecsmv1.ECSMServiceDeleteTransactionAnnotation, resp.ID)
+			if err != nil {
+				return fmt.Errorf("failed to record delete transaction %s: %w", resp.ID, err)
+			}
+		}
+		if err := c.waitForServiceDeletion(ctx, actual, resp.ID); err != nil {
+			if statusErr := c.updateDeleteFailedStatus(ctx, svc, err); statusErr != nil {
+				klog.ErrorS(statusErr, "Failed to report the failed deletion", "key", key)
+			}
+			return fmt.Errorf("ECSM service %s was not deleted (transaction %s): %w", actual.ID, resp.ID, err)
+		}
+	}
+
+	if err := c.registry.RemoveServiceFinalizer(ctx, svc.Namespace, svc.Name, ecsmv1.ECSMServiceFinalizer); err != nil {
+		return fmt.Errorf("failed to remove finalizer: %w", err)
+	}
+	klog.InfoS("ECSMService finalized", "key", key)
+	return nil
+}
+
+// waitForServiceDeletion 等待删除服务的事务完成，事务失败时返回 *clientset.TransactionFailedError。
+// 没有事务 ID 或者 ECSM 查不到这个事务时，退回到按 ID 查询服务，直到 ECSM 明确返回服务不存在或超时；
+// 查询失败不等于服务已被删除，所以只认 NotFound。
+func (c *Controller) waitForServiceDeletion(ctx context.Context, actual *clientset.ServiceGet, transactionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.deleteTimeout)
+	defer cancel()
+
+	if transactionID != "" {
+		_, err := c.clientset.Transactions().WaitForTransaction(ctx, transactionID, c.deletePollInterval)
+		if !rest.IsNotFound(err) {
+			return err
+		}
+		klog.V(2).InfoS("Delete transaction not found, falling back to getting the service",
+			"serviceID", actual.ID, "transactionID", transactionID)
+	}
+
+	return wait.PollUntilContextCancel(ctx, c.deletePollInterval, true, func(ctx context.Context) (bool, error) {
+		_, err := c.clientset.Services().Get(ctx, actual.ID)
+		if rest.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			klog.V(2).InfoS("Failed to check whether ECSM service is deleted, will retry", "serviceID", actual.ID, "err", err)
+		}
+		return false, nil
+	})
+}
+
+// findECSMService 查找 ECSMService 在 ECSM 平台上对应的服务。
+// 优先使用控制器记录的服务 ID（ECSMServiceIDAnnotation 或 status 中的 UnderlyingServiceID），
+// 只有 ECSM 明确返回该服务不存在时才按名称查找，其他错误直接返回，以免在 ECSM 暂时不可用时接管或重复创建服务。
+// 按名称找到的服务如果是控制器之前发出创建请求、但没来得及记录 ID 的那一个，会先记录它的 ID 再返回。
+// 按名称查找时还会返回服务列表中的那一行，其中有服务详情接口不返回的 errorInstance；按 ID 找到时它为 nil。
+// 如果 ECSM 上不存在对应服务，返回 (nil, nil, nil)。
+func (c *Controller) findECSMService(ctx context.Context, svc *ecsmv1.ECSMService) (*clientset.ServiceGet, *clientset.ProvisionListRow, error) {
+	id := svc.Annotations[ecsmv1.ECSMServiceIDAnnotation]
+	if id == "" {
+		id = svc.Status.UnderlyingServiceID
+	}
+	if id != "" {
+		actual, err := c.clientset.Services().Get(ctx, id)
+		if err != nil && !rest.IsNotFound(err) {
+			return nil, nil, fmt.Errorf("failed to get ECSM service %s: %w", id, err)
+		}
+		if err == nil && actual.ID != "" {
+			return actual, nil, nil
+		}
+		klog.V(2).InfoS("Recorded ECSM service not found, falling back to lookup by name",
+			"key", keyFor(svc.Namespace, svc.Name), "serviceID", id)
+	}
+
+	// List 的 name 过滤是模糊匹配，需要在客户端做精确匹配
+	rows, err := c.clientset.Services().ListAll(ctx, clientset.ListServicesOptions{Name: svc.Name})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list ECSM services: %w", err)
+	}
+	for i := range rows {
+		row := &rows[i]
+		if row.Name != svc.Name {
+			continue
+		}
+		actual, err := c.clientset.Services().Get(ctx, row.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get ECSM service %s: %w", row.ID, err)
+		}
+		if id, ok := svc.Annotations[ecsmv1.ECSMServiceIDAnnotation]; ok && id == "" {
+			klog.InfoS("Recording ECSM service created by a previous reconcile", "key", keyFor(svc.Namespace, svc.Name), "serviceID", actual.ID)
+			updated, err := c.registry.SetServiceAnnotation(ctx, svc.Namespace, svc.Name, ecsmv1.ECSMServiceIDAnnotation, actual.ID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to record ECSM service ownership: %w", err)
+			}
+			*svc = *updated
+		} else {
+			klog.InfoS("Adopting existing ECSM service by name", "key", keyFor(svc.Namespace, svc.Name), "serviceID", actual.ID)
+		}
+		return actual, row, nil
+	}
+	return nil, nil, nil
+}
+
+// ownsECSMService 判断 actual 是否是控制器为 svc 创建的服务。
+func ownsECSMService(svc *ecsmv1.ECSMService, actual *clientset.ServiceGet) bool {
+	id := svc.Annotations[ecsmv1.ECSMServiceIDAnnotation]
+	return id != "" && id == actual.ID
+}
+
+// updateStatus 根据 ECSM 上观测到的服务状态计算新的 status（包括 conditions），仅在发生变化时写回 Registry。
+// row 是 findECSMService 返回的服务列表中的一行，可以为 nil。
+func (c *Controller) updateStatus(ctx context.Context, svc *ecsmv1.ECSMService, actual *clientset.ServiceGet, row *clientset.ProvisionListRow) error {
+	newStatus := svc.Status.DeepCopy()
+	newStatus.Replicas = int32(actual.Factor)
+	newStatus.ReadyReplicas = int32(actual.InstanceOnline)
+	newStatus.UnderlyingServiceID = actual.ID
+	newStatus.ObservedGeneration = svc.Generation
+	setConditions(&newStatus.Conditions, svc.Generation, c.observe(ctx, actual, row))
+
+	if equality.Semantic.DeepEqual(&svc.Status, newStatus) {
+		return nil
+	}
+
+	toUpdate := svc.DeepCopy()
+	toUpdate.Status = *newStatus
+	if _, err := c.registry.UpdateServiceStatus(ctx, toUpdate); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+	return nil
+}
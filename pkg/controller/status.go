@@ -0,0 +1,240 @@
+// file: pkg/controller/status.go
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// status.conditions 中使用的 reason
+const (
+	reasonAllInstancesOnline = "AllInstancesOnline"
+	reasonInstancesOffline   = "InstancesOffline"
+	reasonNoInstances        = "NoInstances"
+	reasonDeploymentComplete = "DeploymentComplete"
+	reasonInstancesDeploying = "InstancesDeploying"
+	reasonDeploymentStalled  = "DeploymentStalled"
+	reasonAsExpected         = "AsExpected"
+	reasonServiceUnhealthy   = "ServiceUnhealthy"
+	reasonInstanceErrors     = "InstanceErrors"
+	reasonContainerFailures  = "ContainerFailures"
+	reasonObservationFailed  = "ObservationFailed"
+	reasonDeleteFailed       = "DeleteFailed"
+)
+
+// maxFailureMessagesInCondition 是 Degraded condition 的 message 中最多列出的失败信息条数
+const maxFailureMessagesInCondition = 3
+
+// observedState 汇总了计算 status 所需的、在 ECSM 上观测到的状态。
+type observedState struct {
+	service *clientset.ServiceGet
+	// errorInstances 来自服务列表中的 errorInstance 字段
+	errorInstances []clientset.ErrorInstance
+	// failedMessages 是该服务的容器上报的失败信息
+	failedMessages []string
+	// observeErrors 是查询 errorInstances 或 failedMessages 失败时的错误信息，
+	// 此时无法确定服务是否有失败的实例，Degraded 不能报告为 False
+	observeErrors []string
+}
+
+// observe 收集服务列表中的错误实例；只有当服务没有完全就绪时，才会额外查询容器的失败信息，
+// 以免每次调谐都为健康的服务多发一次请求。查询失败记录在 observeErrors 中，体现在 Degraded condition 里。
+//
+// 错误实例依次取自 row（findECSMService 按名称查找时取到的服务列表中的一行）和服务 informer 的缓存，
+// 只有这两者都没有该服务时（没有配置 informer、informer 还没有同步，或服务在上一次 relist 之后才创建）才查询 ECSM。
+func (c *Controller) observe(ctx context.Context, actual *clientset.ServiceGet, row *clientset.ProvisionListRow) *observedState {
+	state := &observedState{service: actual}
+
+	if row == nil && c.serviceInformer != nil && c.serviceInformer.HasSynced() {
+		row, _ = c.serviceInformer.Lister().Get(actual.ID)
+	}
+	if row == nil {
+		rows, err := c.clientset.Services().ListAll(ctx, clientset.ListServicesOptions{Name: actual.Name})
+		if err != nil {
+			state.observeErrors = append(state.observeErrors, fmt.Sprintf("failed to list error instances: %v", err))
+		}
+		for i := range rows {
+			if rows[i].ID == actual.ID {
+				row = &rows[i]
+				break
+			}
+		}
+	}
+	if row != nil {
+		state.errorInstances = row.ErrorInstances
+	}
+
+	if actual.Healthy && actual.InstanceOnline >= actual.Factor && len(state.errorInstances) == 0 && len(state.observeErrors) == 0 {
+		return state
+	}
+	containers, err := c.clientset.Containers().ListAllByService(ctx, clientset.ListContainersByServiceOptions{
+		ServiceIDs: []string{actual.ID},
+	})
+	if err != nil {
+		state.observeErrors = append(state.observeErrors, fmt.Sprintf("failed to list containers: %v", err))
+	}
+	for _, container := range containers {
+		if container.FailedMessage != nil && *container.FailedMessage != "" {
+			state.failedMessages = append(state.failedMessages, fmt.Sprintf("%s: %s", container.Name, *container.FailedMessage))
+		}
+	}
+	return state
+}
+
+// setConditions 根据观测到的状态更新 conditions。
+// meta.SetStatusCondition 只在 condition 的 status 变化时更新 lastTransitionTime，
+// 而 reason 和 message 完全由观测到的状态决定，状态不变时 conditions 也保持不变。
+func setConditions(conditions *[]metav1.Condition, generation int64, state *observedState) {
+	for _, cond := range computeConditions(state) {
+		cond.ObservedGeneration = generation
+		meta.SetStatusCondition(conditions, cond)
+	}
+}
+
+// updateDeleteFailedStatus 在 err 表示删除 ECSM 服务的事务失败时，把失败原因记录在 Degraded condition 中，
+// 其余 condition 保持不变，让用户在对象一直没有被删除时能看到原因。其他错误（例如等待超时）不记录。
+func (c *Controller) updateDeleteFailedStatus(ctx context.Context, svc *ecsmv1.ECSMService, err error) error {
+	var failed *clientset.TransactionFailedError
+	if !errors.As(err, &failed) {
+		return nil
+	}
+	newStatus := svc.Status.DeepCopy()
+	meta.SetStatusCondition(&newStatus.Conditions, metav1.Condition{
+		Type:               ecsmv1.ECSMServiceDegraded,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: svc.Generation,
+		Reason:             reasonDeleteFailed,
+		Message:            fmt.Sprintf("failed to delete ECSM service (transaction %s): %s", failed.Transaction.ID, failed.Message()),
+	})
+	if equality.Semantic.DeepEqual(&svc.Status, newStatus) {
+		return nil
+	}
+
+	toUpdate := svc.DeepCopy()
+	toUpdate.Status = *newStatus
+	if _, err := c.registry.UpdateServiceStatus(ctx, toUpdate); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+	return nil
+}
+
+// computeConditions 计算 Available、Progressing 和 Degraded 三个 condition。
+func computeConditions(state *observedState) []metav1.Condition {
+	svc := state.service
+	online := fmt.Sprintf("%d/%d instances online", svc.InstanceOnline, svc.Factor)
+	if groups := summarizeContainerStatus(svc.ContainerStatusGroup); groups != "" {
+		online += " (containers: " + groups + ")"
+	}
+
+	failures := failureMessages(state)
+	ready := svc.Factor > 0 && svc.InstanceOnline >= svc.Factor
+
+	available := metav1.Condition{Type: ecsmv1.ECSMServiceAvailable, Message: online}
+	switch {
+	case ready:
+		available.Status, available.Reason = metav1.ConditionTrue, reasonAllInstancesOnline
+	case svc.Factor == 0:
+		available.Status, available.Reason = metav1.ConditionFalse, reasonNoInstances
+	default:
+		available.Status, available.Reason = metav1.ConditionFalse, reasonInstancesOffline
+	}
+
+	progressing := metav1.Condition{Type: ecsmv1.ECSMServiceProgressing, Message: online}
+	switch {
+	case ready:
+		progressing.Status, progressing.Reason = metav1.ConditionFalse, reasonDeploymentComplete
+	case len(failures) > 0:
+		// 有实例失败时 ECSM 不会自行恢复，不再认为部署在进行中
+		progressing.Status, progressing.Reason = metav1.ConditionFalse, reasonDeploymentStalled
+	default:
+		progressing.Status, progressing.Reason = metav1.ConditionTrue, reasonInstancesDeploying
+	}
+
+	degraded := metav1.Condition{
+		Type:    ecsmv1.ECSMServiceDegraded,
+		Status:  metav1.ConditionTrue,
+		Message: joinFailures(failures),
+	}
+	switch {
+	case len(state.errorInstances) > 0:
+		degraded.Reason = reasonInstanceErrors
+	case len(state.failedMessages) > 0:
+		degraded.Reason = reasonContainerFailures
+	case !svc.Healthy:
+		degraded.Reason = reasonServiceUnhealthy
+		degraded.Message = "ECSM reports the service as unhealthy"
+	case len(state.observeErrors) > 0:
+		degraded.Status, degraded.Reason = metav1.ConditionUnknown, reasonObservationFailed
+		degraded.Message = ""
+	default:
+		degraded.Status, degraded.Reason, degraded.Message = metav1.ConditionFalse, reasonAsExpected, ""
+	}
+	if len(state.observeErrors) > 0 {
+		// 已经观测到的失败仍然报告为 True，但说明信息可能不完整
+		degraded.Message = joinMessages(degraded.Message, strings.Join(state.observeErrors, "; "))
+	}
+
+	return []metav1.Condition{available, progressing, degraded}
+}
+
+// failureMessages 返回所有错误实例和失败容器的信息，排序后保证同样的状态得到同样的结果。
+func failureMessages(state *observedState) []string {
+	var msgs []string
+	for _, inst := range state.errorInstances {
+		msg := inst.Message
+		if msg == "" {
+			msg = "instance failed"
+		}
+		msgs = append(msgs, fmt.Sprintf("%s on %s: %s", inst.ContainerID, inst.NodeName, msg))
+	}
+	msgs = append(msgs, state.failedMessages...)
+	sort.Strings(msgs)
+	return msgs
+}
+
+func joinFailures(msgs []string) string {
+	if len(msgs) <= maxFailureMessagesInCondition {
+		return strings.Join(msgs, "; ")
+	}
+	return fmt.Sprintf("%s; and %d more", strings.Join(msgs[:maxFailureMessagesInCondition], "; "), len(msgs)-maxFailureMessagesInCondition)
+}
+
+// joinMessages 用 "; " 连接非空的信息。
+func joinMessages(msgs ...string) string {
+	var parts []string
+	for _, msg := range msgs {
+		if msg != "" {
+			parts = append(parts, msg)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// summarizeContainerStatus 把 containerStatusGroup 汇总为 "2 running, 1 stopped" 的形式。
+func summarizeContainerStatus(group []string) string {
+	counts := make(map[string]int)
+	for _, status := range group {
+		counts[status]++
+	}
+	statuses := make([]string, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	parts := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+	}
+	return strings.Join(parts, ", ")
+}
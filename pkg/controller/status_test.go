@@ -0,0 +1,330 @@
+// file: pkg/controller/status_test.go
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/conversion"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/informers"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestComputeConditions(t *testing.T) {
+	failed := "image pull failed"
+
+	tests := []struct {
+		name            string
+		state           *observedState
+		wantAvailable   metav1.ConditionStatus
+		wantProgressing metav1.ConditionStatus
+		wantDegraded    metav1.ConditionStatus
+		wantReason      string // Degraded 为 True 时的 reason，否则是 Available 的 reason
+		wantMessage     string
+	}{
+		{
+			name: "all instances online",
+			state: &observedState{service: &clientset.ServiceGet{
+				Factor: 2, InstanceOnline: 2, Healthy: true, ContainerStatusGroup: []string{"running", "running"},
+			}},
+			wantAvailable:   metav1.ConditionTrue,
+			wantProgressing: metav1.ConditionFalse,
+			wantDegraded:    metav1.ConditionFalse,
+			wantReason:      reasonAllInstancesOnline,
+			wantMessage:     "2/2 instances online (containers: 2 running)",
+		},
+		{
+			name: "still deploying",
+			state: &observedState{service: &clientset.ServiceGet{
+				Factor: 3, InstanceOnline: 1, Healthy: true, ContainerStatusGroup: []string{"running", "created", "created"},
+			}},
+			wantAvailable:   metav1.ConditionFalse,
+			wantProgressing: metav1.ConditionTrue,
+			wantDegraded:    metav1.ConditionFalse,
+			wantReason:      reasonInstancesOffline,
+			wantMessage:     "1/3 instances online (containers: 2 created, 1 running)",
+		},
+		{
+			name: "error instances",
+			state: &observedState{
+				service: &clientset.ServiceGet{Factor: 2, InstanceOnline: 1, Healthy: true},
+				errorInstances: []clientset.ErrorInstance{
+					{ContainerID: "c2", NodeName: "node-2", Message: "out of memory"},
+				},
+				failedMessages: []string{"web-1: " + failed},
+			},
+			wantAvailable:   metav1.ConditionFalse,
+			wantProgressing: metav1.ConditionFalse,
+			wantDegraded:    metav1.ConditionTrue,
+			wantReason:      reasonInstanceErrors,
+			wantMessage:     "c2 on node-2: out of memory; web-1: image pull failed",
+		},
+		{
+			name: "container failures only",
+			state: &observedState{
+				service:        &clientset.ServiceGet{Factor: 1, InstanceOnline: 0, Healthy: true},
+				failedMessages: []string{"web-1: " + failed},
+			},
+			wantAvailable:   metav1.ConditionFalse,
+			wantProgressing: metav1.ConditionFalse,
+			wantDegraded:    metav1.ConditionTrue,
+			wantReason:      reasonContainerFailures,
+			wantMessage:     "web-1: image pull failed",
+		},
+		{
+			name: "unhealthy",
+			state: &observedState{service: &clientset.ServiceGet{
+				Factor: 1, InstanceOnline: 1, Healthy: false,
+			}},
+			wantAvailable:   metav1.ConditionTrue,
+			wantProgressing: metav1.ConditionFalse,
+			wantDegraded:    metav1.ConditionTrue,
+			wantReason:      reasonServiceUnhealthy,
+			wantMessage:     "ECSM reports the service as unhealthy",
+		},
+		{
+			name: "error instances with failed container list",
+			state: &observedState{
+				service: &clientset.ServiceGet{Factor: 1, InstanceOnline: 0, Healthy: true},
+				errorInstances: []clientset.ErrorInstance{
+					{ContainerID: "c1", NodeName: "node-1", Message: "out of memory"},
+				},
+				observeErrors: []string{"failed to list containers: timeout"},
+			},
+			wantAvailable:   metav1.ConditionFalse,
+			wantProgressing: metav1.ConditionFalse,
+			wantDegraded:    metav1.ConditionTrue,
+			wantReason:      reasonInstanceErrors,
+			wantMessage:     "c1 on node-1: out of memory; failed to list containers: timeout",
+		},
+		{
+			name: "failed to list error instances",
+			state: &observedState{
+				service:       &clientset.ServiceGet{Factor: 1, InstanceOnline: 1, Healthy: true},
+				observeErrors: []string{"failed to list error instances: timeout"},
+			},
+			wantAvailable:   metav1.ConditionTrue,
+			wantProgressing: metav1.ConditionFalse,
+			wantDegraded:    metav1.ConditionUnknown,
+			wantReason:      reasonAllInstancesOnline,
+			wantMessage:     "1/1 instances online",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conditions []metav1.Condition
+			setConditions(&conditions, 3, tt.state)
+
+			available := meta.FindStatusCondition(conditions, ecsmv1.ECSMServiceAvailable)
+			progressing := meta.FindStatusCondition(conditions, ecsmv1.ECSMServiceProgressing)
+			degraded := meta.FindStatusCondition(conditions, ecsmv1.ECSMServiceDegraded)
+			require.NotNil(t, available)
+			require.NotNil(t, progressing)
+			require.NotNil(t, degraded)
+
+			assert.Equal(t, tt.wantAvailable, available.Status)
+			assert.Equal(t, tt.wantProgressing, progressing.Status)
+			assert.Equal(t, tt.wantDegraded, degraded.Status)
+			assert.Equal(t, int64(3), available.ObservedGeneration)
+
+			if degraded.Status == metav1.ConditionTrue {
+				assert.Equal(t, tt.wantReason, degraded.Reason)
+				assert.Equal(t, tt.wantMessage, degraded.Message)
+			} else {
+				assert.Equal(t, tt.wantReason, available.Reason)
+				assert.Equal(t, tt.wantMessage, available.Message)
+			}
+		})
+	}
+}
+
+func TestSetConditions_TransitionTimeOnlyChangesWithState(t *testing.T) {
+	healthy := &observedState{service: &clientset.ServiceGet{Factor: 1, InstanceOnline: 1, Healthy: true}}
+	broken := &observedState{
+		service:        &clientset.ServiceGet{Factor: 1, InstanceOnline: 0, Healthy: false},
+		failedMessages: []string{"web-1: crashed"},
+	}
+
+	var conditions []metav1.Condition
+	setConditions(&conditions, 1, healthy)
+	before := meta.FindStatusCondition(conditions, ecsmv1.ECSMServiceAvailable).LastTransitionTime
+
+	// 状态不变时，再次计算不应该修改 conditions
+	past := metav1.NewTime(before.Add(-time.Hour))
+	for i := range conditions {
+		conditions[i].LastTransitionTime = past
+	}
+	snapshot := append([]metav1.Condition(nil), conditions...)
+	setConditions(&conditions, 1, healthy)
+	assert.Equal(t, snapshot, conditions)
+
+	// 状态变化时，只有 status 变化的 condition 更新 lastTransitionTime
+	setConditions(&conditions, 1, broken)
+	assert.NotEqual(t, past, meta.FindStatusCondition(conditions, ecsmv1.ECSMServiceAvailable).LastTransitionTime)
+	assert.NotEqual(t, past, meta.FindStatusCondition(conditions, ecsmv1.ECSMServiceDegraded).LastTransitionTime)
+	assert.Equal(t, past, meta.FindStatusCondition(conditions, ecsmv1.ECSMServiceProgressing).LastTransitionTime)
+}
+
+func TestController_ReportsDegradedService(t *testing.T) {
+	ctx := context.Background()
+	reg := newTestRegistry(t)
+	services := newFakeServices()
+	failed := "exec format error"
+	cs := &fakeClientset{
+		services:   services,
+		containers: fakeContainers{items: []clientset.ContainerInfo{{Name: "web-1", FailedMessage: &failed}}},
+	}
+	c := NewController(reg, cs, "default")
+
+	_, err := reg.CreateService(ctx, newDynamicService("default", "web", 1))
+	require.NoError(t, err)
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+
+	// ECSM 上的实例掉线并上报了错误
+	services.mu.Lock()
+	services.items["svc-1"].InstanceOnline = 0
+	services.errorInstances["svc-1"] = []clientset.ErrorInstance{{ContainerID: "c1", NodeName: "worker1", Message: "crashed"}}
+	services.mu.Unlock()
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+
+	svc, err := reg.GetService(ctx, "default", "web")
+	require.NoError(t, err)
+	degraded := meta.FindStatusCondition(svc.Status.Conditions, ecsmv1.ECSMServiceDegraded)
+	require.NotNil(t, degraded)
+	assert.Equal(t, metav1.ConditionTrue, degraded.Status)
+	assert.Equal(t, reasonInstanceErrors, degraded.Reason)
+	assert.Equal(t, "c1 on worker1: crashed; web-1: exec format error", degraded.Message)
+	assert.True(t, meta.IsStatusConditionFalse(svc.Status.Conditions, ecsmv1.ECSMServiceAvailable))
+
+	// 状态不变时，再次调谐不会写入 Registry
+	rv := svc.ResourceVersion
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+	svc, err = reg.GetService(ctx, "default", "web")
+	require.NoError(t, err)
+	assert.Equal(t, rv, svc.ResourceVersion)
+}
+
+func TestController_ReadsErrorInstancesFromServiceInformer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reg := newTestRegistry(t)
+	services := newFakeServices()
+	cs := &fakeClientset{services: services}
+	c := NewController(reg, cs, "default")
+
+	_, err := reg.CreateService(ctx, newDynamicService("default", "web", 1))
+	require.NoError(t, err)
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+
+	services.mu.Lock()
+	services.errorInstances["svc-1"] = []clientset.ErrorInstance{{ContainerID: "c1", NodeName: "worker1", Message: "crashed"}}
+	services.mu.Unlock()
+
+	factory := informers.NewSharedInformerFactory(cs, time.Hour)
+	c.SetServiceInformer(factory.Services())
+	factory.Start(ctx)
+	require.True(t, factory.WaitForCacheSync(ctx))
+
+	// ECSM 上的错误已经消失，但在下一次 relist 之前控制器读取的是缓存中的服务列表
+	services.mu.Lock()
+	delete(services.errorInstances, "svc-1")
+	services.mu.Unlock()
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+
+	svc, err := reg.GetService(ctx, "default", "web")
+	require.NoError(t, err)
+	degraded := meta.FindStatusCondition(svc.Status.Conditions, ecsmv1.ECSMServiceDegraded)
+	require.NotNil(t, degraded)
+	assert.Equal(t, reasonInstanceErrors, degraded.Reason)
+	assert.Equal(t, "c1 on worker1: crashed", degraded.Message)
+}
+
+func TestComputeConditions_ObservationFailed(t *testing.T) {
+	state := &observedState{
+		service:       &clientset.ServiceGet{Factor: 1, InstanceOnline: 1, Healthy: true},
+		observeErrors: []string{"failed to list error instances: timeout", "failed to list containers: timeout"},
+	}
+	var conditions []metav1.Condition
+	setConditions(&conditions, 1, state)
+
+	degraded := meta.FindStatusCondition(conditions, ecsmv1.ECSMServiceDegraded)
+	require.NotNil(t, degraded)
+	assert.Equal(t, metav1.ConditionUnknown, degraded.Status)
+	assert.Equal(t, reasonObservationFailed, degraded.Reason)
+	assert.Equal(t, "failed to list error instances: timeout; failed to list containers: timeout", degraded.Message)
+}
+
+func TestController_ObserveUsesRowFromLookupByName(t *testing.T) {
+	ctx := context.Background()
+	reg := newTestRegistry(t)
+	services := newFakeServices()
+	c := NewController(reg, &fakeClientset{services: services}, "default")
+
+	// ECSM 上已经有同名的服务，控制器按名称接管它
+	want, err := reg.CreateService(ctx, newDynamicService("default", "web", 1))
+	require.NoError(t, err)
+	req, err := conversion.SpecToCreateRequest(want.Name, &want.Spec)
+	require.NoError(t, err)
+	_, err = services.Create(ctx, req)
+	require.NoError(t, err)
+	services.mu.Lock()
+	services.errorInstances["svc-1"] = []clientset.ErrorInstance{{ContainerID: "c1", NodeName: "worker1", Message: "crashed"}}
+	services.mu.Unlock()
+
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+
+	services.mu.Lock()
+	lists := services.lists
+	services.mu.Unlock()
+	assert.Equal(t, 1, lists, "error instances must be read from the row found by name, not listed again")
+
+	svc, err := reg.GetService(ctx, "default", "web")
+	require.NoError(t, err)
+	degraded := meta.FindStatusCondition(svc.Status.Conditions, ecsmv1.ECSMServiceDegraded)
+	require.NotNil(t, degraded)
+	assert.Equal(t, reasonInstanceErrors, degraded.Reason)
+	assert.Equal(t, "c1 on worker1: crashed", degraded.Message)
+}
+
+// unlistableServices 的 ListAll 总是返回服务端错误。
+type unlistableServices struct {
+	*fakeServices
+}
+
+func (s *unlistableServices) ListAll(ctx context.Context, opts clientset.ListServicesOptions) ([]clientset.ProvisionListRow, error) {
+	return nil, &rest.Aerror{Status: http.StatusServiceUnavailable, Message: "unavailable"}
+}
+
+func TestController_ReportsObservationFailures(t *testing.T) {
+	ctx := context.Background()
+	reg := newTestRegistry(t)
+	services := newFakeServices()
+	c := NewController(reg, &fakeClientset{services: services}, "default")
+
+	_, err := reg.CreateService(ctx, newDynamicService("default", "web", 1))
+	require.NoError(t, err)
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+
+	// 服务按记录的 ID 找到，但无法列出服务中的错误实例
+	c.clientset = &fakeClientset{services: &unlistableServices{fakeServices: services}}
+	require.NoError(t, c.Reconcile(ctx, "default", "web"))
+
+	svc, err := reg.GetService(ctx, "default", "web")
+	require.NoError(t, err)
+	degraded := meta.FindStatusCondition(svc.Status.Conditions, ecsmv1.ECSMServiceDegraded)
+	require.NotNil(t, degraded)
+	assert.Equal(t, metav1.ConditionUnknown, degraded.Status)
+	assert.Equal(t, reasonObservationFailed, degraded.Reason)
+	assert.Contains(t, degraded.Message, "failed to list error instances")
+	assert.True(t, meta.IsStatusConditionTrue(svc.Status.Conditions, ecsmv1.ECSMServiceAvailable))
+}
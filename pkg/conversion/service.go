@@ -0,0 +1,583 @@
+// file: pkg/conversion/service.go
+
+package conversion
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	policyStatic  = "static"
+	policyDynamic = "dynamic"
+
+	actionRun = "run"
+
+	// defaultCwd 是 ECSM 进程配置中的默认工作目录
+	defaultCwd = "/"
+
+	mountOptionReadOnly  = "ro"
+	mountOptionReadWrite = "rw"
+
+	// mebibyte 是 ECSM 资源限制 (memoryLimitMB, limitMB) 使用的单位
+	mebibyte = 1024 * 1024
+)
+
+// SpecToCreateRequest 把 ECSMServiceSpec 翻译成 ECSM 创建服务的 payload。
+// name 是 ECSM 上的服务名，通常与 ECSMService 的名称保持一致。
+func SpecToCreateRequest(name string, spec *ecsmv1.ECSMServiceSpec) (*clientset.CreateServiceRequest, error) {
+	policy, factor, nodes, err := convertDeploymentStrategy(&spec.DeploymentStrategy)
+	if err != nil {
+		return nil, err
+	}
+	image, err := convertTemplate(&spec.Template, &spec.UpgradeStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &clientset.CreateServiceRequest{
+		Name:   name,
+		Image:  *image,
+		Node:   clientset.NodeSpec{Names: nodes},
+		Factor: &factor,
+		Policy: policy,
+	}
+	if spec.Template.Prepull {
+		prepull := true
+		req.Prepull = &prepull
+	}
+	return req, nil
+}
+
+// SpecToUpdateRequest 把 ECSMServiceSpec 翻译成 ECSM 更新服务的 payload。
+// id 是 ECSM 上已存在服务的 ID。
+func SpecToUpdateRequest(id, name string, spec *ecsmv1.ECSMServiceSpec) (*clientset.UpdateServiceRequest, error) {
+	create, err := SpecToCreateRequest(name, spec)
+	if err != nil {
+		return nil, err
+	}
+	return &clientset.UpdateServiceRequest{
+		ID:     id,
+		Name:   create.Name,
+		Image:  create.Image,
+		Node:   create.Node,
+		Factor: create.Factor,
+		Policy: create.Policy,
+	}, nil
+}
+
+// ServiceGetToSpec 把 ECSM 上查询到的服务详情反向翻译成 ECSMServiceSpec。
+// 有些信息在 ECSM 上是无法观测的（例如 prepull 和挂载点的名称），
+// 反向翻译得到的 spec 中这些字段为零值，挂载点名称按顺序生成。
+func ServiceGetToSpec(actual *clientset.ServiceGet) (*ecsmv1.ECSMServiceSpec, error) {
+	spec := &ecsmv1.ECSMServiceSpec{}
+
+	names := ActualNodeNames(actual)
+	switch actual.Policy {
+	case policyStatic:
+		spec.DeploymentStrategy.Type = ecsmv1.DeploymentStrategyTypeStatic
+		spec.DeploymentStrategy.Nodes = names
+	case policyDynamic:
+		replicas := int32(actual.Factor)
+		spec.DeploymentStrategy.Type = ecsmv1.DeploymentStrategyTypeDynamic
+		spec.DeploymentStrategy.Replicas = &replicas
+		spec.DeploymentStrategy.NodePool = names
+	default:
+		return nil, fmt.Errorf("unknown ECSM service policy %q", actual.Policy)
+	}
+
+	if actual.Image == nil {
+		return spec, nil
+	}
+	image := actual.Image
+
+	if image.AutoUpgrade != "" {
+		t, err := upgradeStrategyFromECSM(image.AutoUpgrade)
+		if err != nil {
+			return nil, err
+		}
+		spec.UpgradeStrategy.Type = t
+	}
+
+	template := &spec.Template
+	template.Image = image.Ref
+	if image.PullPolicy != "" {
+		policy, err := pullPolicyFromECSM(image.PullPolicy)
+		if err != nil {
+			return nil, err
+		}
+		template.ImagePullPolicy = policy
+	}
+
+	var ps ecsmv1.PlatformSpecificConfig
+	if image.Action != "" && image.Action != actionRun {
+		ps.Action = ecsmv1.ActionType(strings.ToUpper(image.Action[:1]) + image.Action[1:])
+	}
+
+	if vsoa := image.VSOA; vsoa != nil {
+		template.VSOA = vsoaFromECSM(vsoa)
+	}
+
+	if config := image.Config; config != nil {
+		template.Hostname = config.Hostname
+		if p := config.Process; p != nil {
+			template.Command = p.Args
+			for _, kv := range p.Env {
+				name, value, _ := strings.Cut(kv, "=")
+				template.Env = append(template.Env, ecsmv1.EnvVar{Name: name, Value: value})
+			}
+		}
+		for i, m := range config.Mounts {
+			template.VolumeMounts = append(template.VolumeMounts, ecsmv1.VolumeMount{
+				Name:          fmt.Sprintf("mount-%d", i),
+				HostPath:      m.Source,
+				ContainerPath: m.Destination,
+				ReadOnly:      hasOption(m.Options, mountOptionReadOnly),
+			})
+		}
+		if r := config.Root; r != nil {
+			ps.Root = &ecsmv1.RootSpec{Path: r.Path, ReadOnly: r.Readonly}
+		}
+		if p := config.Platform; p != nil {
+			ps.Platform = &ecsmv1.PlatformSpec{OS: p.OS, Arch: p.Arch}
+		}
+		if s := config.SylixOS; s != nil {
+			template.Resources = resourcesFromECSM(s.Resources)
+			ps.SylixOS = sylixOSFromECSM(s)
+		}
+	}
+
+	if ps.Action != "" || ps.Root != nil || ps.Platform != nil || ps.SylixOS != nil {
+		template.PlatformSpecific = &ps
+	}
+	return spec, nil
+}
+
+// NeedsUpdate 判断 ECSM 上的服务是否偏离了期望状态。
+// 它把实际状态反向翻译成 spec，去掉期望状态中没有声明的字段（这些字段由 ECSM 填充默认值），
+// 再把两边都正向翻译成 payload 进行比较，这样 ECSM 返回的默认值和无法观测的字段不会导致反复更新。
+func NeedsUpdate(desired *ecsmv1.ECSMServiceSpec, actual *clientset.ServiceGet) (bool, error) {
+	observed, err := ServiceGetToSpec(actual)
+	if err != nil {
+		return false, err
+	}
+	pruneUnmanaged(observed, desired)
+
+	want, err := SpecToCreateRequest("", desired)
+	if err != nil {
+		return false, err
+	}
+	got, err := SpecToCreateRequest("", observed)
+	if err != nil {
+		return false, err
+	}
+	normalize(want)
+	normalize(got)
+	return !equality.Semantic.DeepEqual(want, got), nil
+}
+
+// ActualNodeNames 返回 ECSM 服务当前使用的节点名称列表。
+func ActualNodeNames(actual *clientset.ServiceGet) []string {
+	if actual.Node != nil {
+		return actual.Node.Names
+	}
+	if len(actual.NodeList) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(actual.NodeList))
+	for _, n := range actual.NodeList {
+		names = append(names, n.NodeName)
+	}
+	return names
+}
+
+// convertDeploymentStrategy 把部署策略翻译成 ECSM 的 policy、factor 和节点列表。
+func convertDeploymentStrategy(ds *ecsmv1.DeploymentStrategy) (string, int, []string, error) {
+	switch ds.Type {
+	case ecsmv1.DeploymentStrategyTypeStatic:
+		// 静态策略：在每个指定节点上部署一个实例
+		return policyStatic, len(ds.Nodes), append([]string(nil), ds.Nodes...), nil
+	case ecsmv1.DeploymentStrategyTypeDynamic:
+		factor := 1
+		if ds.Replicas != nil {
+			factor = int(*ds.Replicas)
+		}
+		return policyDynamic, factor, append([]string(nil), ds.NodePool...), nil
+	default:
+		return "", 0, nil, fmt.Errorf("unknown deployment strategy type %q", ds.Type)
+	}
+}
+
+// convertTemplate 把容器模版和升级策略翻译成 ECSM 的 ImageSpec。
+func convertTemplate(t *ecsmv1.ContainerTemplateSpec, us *ecsmv1.UpgradeStrategy) (*clientset.ImageSpec, error) {
+	image := &clientset.ImageSpec{
+		Ref:         t.Image,
+		Action:      actionRun,
+		PullPolicy:  pullPolicyToECSM(t.ImagePullPolicy),
+		AutoUpgrade: strings.ToLower(string(us.Type)),
+	}
+
+	config := &clientset.EcsImageConfig{Hostname: t.Hostname}
+	if len(t.Command) > 0 || len(t.Env) > 0 {
+		process := &clientset.Process{Args: t.Command, Cwd: defaultCwd}
+		for _, env := range t.Env {
+			process.Env = append(process.Env, env.Name+"="+env.Value)
+		}
+		config.Process = process
+	}
+	for _, vm := range t.VolumeMounts {
+		option := mountOptionReadWrite
+		if vm.ReadOnly {
+			option = mountOptionReadOnly
+		}
+		config.Mounts = append(config.Mounts, clientset.Mount{
+			Destination: vm.ContainerPath,
+			Source:      vm.HostPath,
+			Options:     []string{option},
+		})
+	}
+
+	resources, err := convertResources(t.Resources)
+	if err != nil {
+		return nil, err
+	}
+
+	var sylixOS *ecsmv1.SylixOSConfig
+	if ps := t.PlatformSpecific; ps != nil {
+		if ps.Action != "" {
+			image.Action = strings.ToLower(string(ps.Action))
+		}
+		if ps.Root != nil {
+			config.Root = &clientset.Root{Path: ps.Root.Path, Readonly: ps.Root.ReadOnly}
+		}
+		if ps.Platform != nil {
+			config.Platform = &clientset.Platform{OS: ps.Platform.OS, Arch: ps.Platform.Arch}
+		}
+		sylixOS = ps.SylixOS
+	}
+	config.SylixOS = convertSylixOS(sylixOS, resources)
+	image.Config = config
+
+	if t.VSOA != nil {
+		image.VSOA = convertVSOA(t.VSOA)
+	}
+	return image, nil
+}
+
+// convertResources 把资源限制翻译成 ECSM 的 Resources，内存和硬盘以 MB 为单位并向上取整。
+// 没有声明任何限制时返回 nil。
+func convertResources(r *ecsmv1.ResourceRequirements) (*clientset.Resources, error) {
+	if r == nil || len(r.Limits) == 0 {
+		return nil, nil
+	}
+	resources := &clientset.Resources{}
+	for name, value := range r.Limits {
+		mb, err := quantityToMB(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s limit %q: %w", name, value, err)
+		}
+		switch name {
+		case ecsmv1.ResourceTypeMemory:
+			resources.Memory = &clientset.Memory{MemoryLimitMB: mb}
+		case ecsmv1.ResourceTypeDisk:
+			resources.Disk = &clientset.Disk{LimitMB: mb}
+		default:
+			return nil, fmt.Errorf("unsupported resource type %q", name)
+		}
+	}
+	return resources, nil
+}
+
+// convertSylixOS 合并资源限制和 SylixOS 底层配置，两者都为空时返回 nil。
+func convertSylixOS(s *ecsmv1.SylixOSConfig, resources *clientset.Resources) *clientset.SylixOS {
+	if s == nil && resources == nil {
+		return nil
+	}
+	out := &clientset.SylixOS{Resources: resources}
+	if s == nil {
+		return out
+	}
+
+	for _, d := range s.Devices {
+		out.Devices = append(out.Devices, clientset.Device{Path: d.Path, Access: d.Access})
+	}
+	if s.Network != nil {
+		out.Network = &clientset.Network{FtpdEnable: s.Network.FTPD, TelnetdEnable: s.Network.TELNETD}
+	}
+	if s.CPU != nil {
+		if out.Resources == nil {
+			out.Resources = &clientset.Resources{}
+		}
+		out.Resources.CPU = &clientset.CPU{
+			HighestPrio: intPtrFrom(s.CPU.HighestPrio),
+			LowestPrio:  intPtrFrom(s.CPU.LowestPrio),
+		}
+	}
+	if s.Memory != nil && s.Memory.KheapLimit != nil {
+		if out.Resources == nil {
+			out.Resources = &clientset.Resources{}
+		}
+		if out.Resources.Memory == nil {
+			out.Resources.Memory = &clientset.Memory{}
+		}
+		out.Resources.Memory.KheapLimit = int(*s.Memory.KheapLimit)
+	}
+	return out
+}
+
+// convertVSOA 把 VSOA 配置和健康检查翻译成 ECSM 的 ImageVSOA，值为 0 的字段不会下发。
+func convertVSOA(v *ecsmv1.VSOASpec) *clientset.ImageVSOA {
+	out := &clientset.ImageVSOA{Password: v.Password}
+	if v.Port != nil {
+		port := int(*v.Port)
+		out.Port = &port
+	}
+	if hc := v.HealthCheck; hc != nil {
+		out.HealthStartPeriod = intPtrOrNil(hc.InitialDelaySeconds)
+		out.HealthTimeout = intPtrOrNil(hc.TimeoutSeconds)
+		out.HealthInterval = intPtrOrNil(hc.PeriodSeconds)
+		out.HealthRetries = intPtrOrNil(hc.FailureThreshold)
+	}
+	return out
+}
+
+// pullPolicyToECSM 把镜像拉取策略翻译成 ECSM 使用的首字母小写的形式，例如 "IfNotPresent" -> "ifNotPresent"。
+func pullPolicyToECSM(p ecsmv1.ImagePullPolicyType) string {
+	if p == "" {
+		return ""
+	}
+	return strings.ToLower(string(p[:1])) + string(p[1:])
+}
+
+func pullPolicyFromECSM(pullPolicy string) (ecsmv1.ImagePullPolicyType, error) {
+	for _, p := range []ecsmv1.ImagePullPolicyType{
+		ecsmv1.ImagePullPolicyAlways,
+		ecsmv1.ImagePullPolicyIfNotPresent,
+		ecsmv1.ImagePullPolicyNever,
+	} {
+		if strings.EqualFold(pullPolicy, string(p)) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown ECSM pullPolicy value %q", pullPolicy)
+}
+
+func upgradeStrategyFromECSM(autoUpgrade string) (ecsmv1.UpgradeStrategyType, error) {
+	for _, t := range []ecsmv1.UpgradeStrategyType{
+		ecsmv1.UpgradeStrategyTypeNever,
+		ecsmv1.UpgradeStrategyTypeLarger,
+		ecsmv1.UpgradeStrategyTypeAlways,
+	} {
+		if strings.EqualFold(autoUpgrade, string(t)) {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown ECSM autoUpgrade value %q", autoUpgrade)
+}
+
+func vsoaFromECSM(v *clientset.ImageVSOA) *ecsmv1.VSOASpec {
+	out := &ecsmv1.VSOASpec{Password: v.Password}
+	if v.Port != nil {
+		port := int32(*v.Port)
+		out.Port = &port
+	}
+	hc := ecsmv1.HealthCheckSpec{
+		InitialDelaySeconds: int32(ptrValue(v.HealthStartPeriod)),
+		TimeoutSeconds:      int32(ptrValue(v.HealthTimeout)),
+		PeriodSeconds:       int32(ptrValue(v.HealthInterval)),
+		FailureThreshold:    int32(ptrValue(v.HealthRetries)),
+	}
+	if hc != (ecsmv1.HealthCheckSpec{}) {
+		out.HealthCheck = &hc
+	}
+	return out
+}
+
+func resourcesFromECSM(r *clientset.Resources) *ecsmv1.ResourceRequirements {
+	if r == nil {
+		return nil
+	}
+	limits := make(map[ecsmv1.ResourceType]string)
+	if r.Memory != nil && r.Memory.MemoryLimitMB > 0 {
+		limits[ecsmv1.ResourceTypeMemory] = mbToQuantity(r.Memory.MemoryLimitMB)
+	}
+	if r.Disk != nil && r.Disk.LimitMB > 0 {
+		limits[ecsmv1.ResourceTypeDisk] = mbToQuantity(r.Disk.LimitMB)
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+	return &ecsmv1.ResourceRequirements{Limits: limits}
+}
+
+func sylixOSFromECSM(s *clientset.SylixOS) *ecsmv1.SylixOSConfig {
+	out := &ecsmv1.SylixOSConfig{}
+	for _, d := range s.Devices {
+		out.Devices = append(out.Devices, ecsmv1.Device{Path: d.Path, Access: d.Access})
+	}
+	if s.Network != nil {
+		out.Network = &ecsmv1.NetworkSpec{FTPD: s.Network.FtpdEnable, TELNETD: s.Network.TelnetdEnable}
+	}
+	if r := s.Resources; r != nil {
+		if r.CPU != nil {
+			out.CPU = &ecsmv1.SylixOSCPUConfig{HighestPrio: int64PtrFrom(r.CPU.HighestPrio), LowestPrio: int64PtrFrom(r.CPU.LowestPrio)}
+		}
+		if r.Memory != nil && r.Memory.KheapLimit != 0 {
+			kheap := int64(r.Memory.KheapLimit)
+			out.Memory = &ecsmv1.SylixOSMemoryConfig{KheapLimit: &kheap}
+		}
+	}
+	if out.Devices == nil && out.Network == nil && out.CPU == nil && out.Memory == nil {
+		return nil
+	}
+	return out
+}
+
+// pruneUnmanaged 清除 observed 中那些 desired 没有声明的可选字段。
+// 这些字段的值来自 ECSM 的默认填充，不属于控制器管理的范围。
+func pruneUnmanaged(observed, desired *ecsmv1.ECSMServiceSpec) {
+	if desired.UpgradeStrategy.Type == "" {
+		observed.UpgradeStrategy.Type = ""
+	}
+
+	ot, dt := &observed.Template, &desired.Template
+	// prepull 在 ECSM 服务详情中不可观测
+	ot.Prepull = dt.Prepull
+	if dt.ImagePullPolicy == "" {
+		ot.ImagePullPolicy = ""
+	}
+	if dt.Hostname == "" {
+		ot.Hostname = ""
+	}
+	if len(dt.Command) == 0 && len(dt.Env) == 0 {
+		ot.Command, ot.Env = nil, nil
+	}
+	if len(dt.VolumeMounts) == 0 {
+		ot.VolumeMounts = nil
+	}
+
+	if dt.Resources == nil {
+		ot.Resources = nil
+	} else if ot.Resources != nil {
+		for name := range ot.Resources.Limits {
+			if _, ok := dt.Resources.Limits[name]; !ok {
+				delete(ot.Resources.Limits, name)
+			}
+		}
+	}
+
+	if dt.VSOA == nil {
+		ot.VSOA = nil
+	} else if ot.VSOA != nil {
+		if dt.VSOA.Port == nil {
+			ot.VSOA.Port = nil
+		}
+		if dt.VSOA.HealthCheck == nil {
+			ot.VSOA.HealthCheck = nil
+		}
+	}
+
+	dps := dt.PlatformSpecific
+	if dps == nil {
+		ot.PlatformSpecific = nil
+		return
+	}
+	ops := ot.PlatformSpecific
+	if ops == nil {
+		return
+	}
+	if dps.Root == nil {
+		ops.Root = nil
+	}
+	if dps.Platform == nil {
+		ops.Platform = nil
+	}
+	if dps.SylixOS == nil {
+		ops.SylixOS = nil
+	} else if ops.SylixOS != nil {
+		if len(dps.SylixOS.Devices) == 0 {
+			ops.SylixOS.Devices = nil
+		}
+		if dps.SylixOS.Network == nil {
+			ops.SylixOS.Network = nil
+		}
+		if dps.SylixOS.CPU == nil {
+			ops.SylixOS.CPU = nil
+		}
+		if dps.SylixOS.Memory == nil {
+			ops.SylixOS.Memory = nil
+		}
+	}
+}
+
+// normalize 消除 payload 中与语义无关的差异，例如节点列表的顺序。
+func normalize(req *clientset.CreateServiceRequest) {
+	sort.Strings(req.Node.Names)
+	if len(req.Node.Names) == 0 {
+		req.Node.Names = nil
+	}
+}
+
+// quantityToMB 把 resource.Quantity 格式的字符串 (例如 "512Mi", "1Gi") 换算成 MB，向上取整。
+func quantityToMB(value string) (int, error) {
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, err
+	}
+	if q.Sign() < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	bytes := q.Value()
+	return int((bytes + mebibyte - 1) / mebibyte), nil
+}
+
+func mbToQuantity(mb int) string {
+	return fmt.Sprintf("%dMi", mb)
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+func intPtrOrNil(v int32) *int {
+	if v == 0 {
+		return nil
+	}
+	i := int(v)
+	return &i
+}
+
+// intPtrFrom 和 int64PtrFrom 在两种整数指针之间转换，nil 保持为 nil。
+func intPtrFrom(p *int64) *int {
+	if p == nil {
+		return nil
+	}
+	i := int(*p)
+	return &i
+}
+
+func int64PtrFrom(p *int) *int64 {
+	if p == nil {
+		return nil
+	}
+	i := int64(*p)
+	return &i
+}
+
+func ptrValue[T ~int | ~int64](p *T) T {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
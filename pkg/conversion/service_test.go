@@ -0,0 +1,308 @@
+// file: pkg/conversion/service_test.go
+
+package conversion
+
+import (
+	"encoding/json"
+	"testing"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func int32Ptr(v int32) *int32 { return &v }
+func int64Ptr(v int64) *int64 { return &v }
+func intPtr(v int) *int       { return &v }
+
+// fullSpec 返回一个填满了所有可翻译字段的 spec。
+func fullSpec() *ecsmv1.ECSMServiceSpec {
+	return &ecsmv1.ECSMServiceSpec{
+		DeploymentStrategy: ecsmv1.DeploymentStrategy{
+			Type:     ecsmv1.DeploymentStrategyTypeDynamic,
+			Replicas: int32Ptr(3),
+			NodePool: []string{"worker1", "worker2"},
+		},
+		UpgradeStrategy: ecsmv1.UpgradeStrategy{Type: ecsmv1.UpgradeStrategyTypeLarger},
+		Template: ecsmv1.ContainerTemplateSpec{
+			Image:           "nginx@1.0#sylixos",
+			ImagePullPolicy: ecsmv1.ImagePullPolicyAlways,
+			Hostname:        "web",
+			Command:         []string{"/apps/nginx", "-g", "daemon off;"},
+			Env:             []ecsmv1.EnvVar{{Name: "MODE", Value: "prod"}, {Name: "EMPTY", Value: ""}},
+			Resources: &ecsmv1.ResourceRequirements{
+				Limits: map[ecsmv1.ResourceType]string{
+					ecsmv1.ResourceTypeMemory: "512Mi",
+					ecsmv1.ResourceTypeDisk:   "1024Mi",
+				},
+			},
+			VolumeMounts: []ecsmv1.VolumeMount{
+				{Name: "mount-0", HostPath: "/lib", ContainerPath: "/lib", ReadOnly: true},
+				{Name: "mount-1", HostPath: "/data", ContainerPath: "/var/data"},
+			},
+			VSOA: &ecsmv1.VSOASpec{
+				Password: "secret",
+				Port:     int32Ptr(3002),
+				HealthCheck: &ecsmv1.HealthCheckSpec{
+					InitialDelaySeconds: 5,
+					TimeoutSeconds:      2,
+					PeriodSeconds:       10,
+					FailureThreshold:    3,
+				},
+			},
+			PlatformSpecific: &ecsmv1.PlatformSpecificConfig{
+				Action:   ecsmv1.ActionTypeLoad,
+				Root:     &ecsmv1.RootSpec{Path: "/", ReadOnly: true},
+				Platform: &ecsmv1.PlatformSpec{OS: "sylixos", Arch: "arm64"},
+				SylixOS: &ecsmv1.SylixOSConfig{
+					Devices: []ecsmv1.Device{{Path: "/dev/ttyS0", Access: "rw"}},
+					Network: &ecsmv1.NetworkSpec{FTPD: true},
+					CPU:     &ecsmv1.SylixOSCPUConfig{HighestPrio: int64Ptr(150), LowestPrio: int64Ptr(250)},
+					Memory:  &ecsmv1.SylixOSMemoryConfig{KheapLimit: int64Ptr(2097152)},
+				},
+			},
+		},
+	}
+}
+
+// toServiceGet 模拟 ECSM 保存 payload 后在查询接口中返回的服务详情。
+func toServiceGet(req *clientset.CreateServiceRequest) *clientset.ServiceGet {
+	image := req.Image
+	node := req.Node
+	return &clientset.ServiceGet{
+		ID:     "svc-1",
+		Name:   req.Name,
+		Policy: req.Policy,
+		Factor: *req.Factor,
+		Image:  &image,
+		Node:   &node,
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestSpecToCreateRequest(t *testing.T) {
+	req, err := SpecToCreateRequest("web", fullSpec())
+	require.NoError(t, err)
+
+	assert.Equal(t, "web", req.Name)
+	assert.Equal(t, "dynamic", req.Policy)
+	assert.Equal(t, 3, *req.Factor)
+	assert.Equal(t, []string{"worker1", "worker2"}, req.Node.Names)
+	assert.Equal(t, "load", req.Image.Action)
+	assert.Equal(t, "always", req.Image.PullPolicy)
+	assert.Equal(t, "larger", req.Image.AutoUpgrade)
+
+	config := req.Image.Config
+	require.NotNil(t, config)
+	assert.Equal(t, []string{"MODE=prod", "EMPTY="}, config.Process.Env)
+	assert.Equal(t, []clientset.Mount{
+		{Destination: "/lib", Source: "/lib", Options: []string{"ro"}},
+		{Destination: "/var/data", Source: "/data", Options: []string{"rw"}},
+	}, config.Mounts)
+	assert.Equal(t, &clientset.Root{Path: "/", Readonly: true}, config.Root)
+	assert.Equal(t, &clientset.Platform{OS: "sylixos", Arch: "arm64"}, config.Platform)
+
+	resources := config.SylixOS.Resources
+	assert.Equal(t, &clientset.Memory{MemoryLimitMB: 512, KheapLimit: 2097152}, resources.Memory)
+	assert.Equal(t, &clientset.Disk{LimitMB: 1024}, resources.Disk)
+	assert.Equal(t, &clientset.CPU{HighestPrio: intPtr(150), LowestPrio: intPtr(250)}, resources.CPU)
+
+	vsoa := req.Image.VSOA
+	require.NotNil(t, vsoa)
+	assert.Equal(t, 3002, *vsoa.Port)
+	assert.Equal(t, 5, *vsoa.HealthStartPeriod)
+	assert.Equal(t, 2, *vsoa.HealthTimeout)
+	assert.Equal(t, 10, *vsoa.HealthInterval)
+	assert.Equal(t, 3, *vsoa.HealthRetries)
+}
+
+func TestSpecToCreateRequest_Static(t *testing.T) {
+	spec := &ecsmv1.ECSMServiceSpec{
+		DeploymentStrategy: ecsmv1.DeploymentStrategy{
+			Type:  ecsmv1.DeploymentStrategyTypeStatic,
+			Nodes: []string{"a", "b", "c"},
+		},
+		Template: ecsmv1.ContainerTemplateSpec{Image: "app@1.0"},
+	}
+	req, err := SpecToCreateRequest("app", spec)
+	require.NoError(t, err)
+	assert.Equal(t, "static", req.Policy)
+	assert.Equal(t, 3, *req.Factor)
+	assert.Equal(t, "run", req.Image.Action)
+	assert.Nil(t, req.Image.Config.SylixOS)
+	assert.Nil(t, req.Image.VSOA)
+}
+
+func TestSpecToCreateRequest_UnsetCPUPriority(t *testing.T) {
+	spec := fullSpec()
+	spec.Template.PlatformSpecific.SylixOS.CPU = &ecsmv1.SylixOSCPUConfig{HighestPrio: int64Ptr(0)}
+	req, err := SpecToCreateRequest("web", spec)
+	require.NoError(t, err)
+
+	// 没有设置的优先级不能以 0 下发，0 本身是合法的最高优先级
+	cpu := req.Image.Config.SylixOS.Resources.CPU
+	assert.Equal(t, &clientset.CPU{HighestPrio: intPtr(0)}, cpu)
+	assert.JSONEq(t, `{"highestPrio": 0}`, mustMarshal(t, cpu))
+
+	back, err := ServiceGetToSpec(toServiceGet(req))
+	require.NoError(t, err)
+	assert.Equal(t, spec.Template.PlatformSpecific.SylixOS.CPU, back.Template.PlatformSpecific.SylixOS.CPU)
+}
+
+func TestSpecToCreateRequest_Errors(t *testing.T) {
+	tests := map[string]func(*ecsmv1.ECSMServiceSpec){
+		"unknown strategy": func(s *ecsmv1.ECSMServiceSpec) { s.DeploymentStrategy.Type = "Random" },
+		"bad quantity": func(s *ecsmv1.ECSMServiceSpec) {
+			s.Template.Resources.Limits[ecsmv1.ResourceTypeMemory] = "lots"
+		},
+		"unknown resource": func(s *ecsmv1.ECSMServiceSpec) {
+			s.Template.Resources.Limits["cpu"] = "1"
+		},
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			spec := fullSpec()
+			mutate(spec)
+			_, err := SpecToCreateRequest("web", spec)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestQuantityToMB(t *testing.T) {
+	tests := map[string]int{
+		"512Mi": 512,
+		"1Gi":   1024,
+		"1M":    1, // 1000000 字节向上取整
+		"0":     0,
+	}
+	for in, want := range tests {
+		got, err := quantityToMB(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := quantityToMB("-1Mi")
+	assert.Error(t, err)
+}
+
+func TestRoundTrip(t *testing.T) {
+	for name, spec := range map[string]*ecsmv1.ECSMServiceSpec{
+		"full": fullSpec(),
+		"minimal": {
+			DeploymentStrategy: ecsmv1.DeploymentStrategy{
+				Type:  ecsmv1.DeploymentStrategyTypeStatic,
+				Nodes: []string{"worker1"},
+			},
+			Template: ecsmv1.ContainerTemplateSpec{Image: "app@1.0"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req, err := SpecToCreateRequest("web", spec)
+			require.NoError(t, err)
+
+			back, err := ServiceGetToSpec(toServiceGet(req))
+			require.NoError(t, err)
+			assert.Equal(t, spec, back)
+
+			drifted, err := NeedsUpdate(spec, toServiceGet(req))
+			require.NoError(t, err)
+			assert.False(t, drifted)
+		})
+	}
+}
+
+func TestNeedsUpdate(t *testing.T) {
+	base := fullSpec()
+	req, err := SpecToCreateRequest("web", base)
+	require.NoError(t, err)
+
+	t.Run("IgnoresNodeOrder", func(t *testing.T) {
+		actual := toServiceGet(req)
+		actual.Node = &clientset.NodeSpec{Names: []string{"worker2", "worker1"}}
+		drifted, err := NeedsUpdate(base, actual)
+		require.NoError(t, err)
+		assert.False(t, drifted)
+	})
+
+	t.Run("IgnoresECSMDefaults", func(t *testing.T) {
+		// 期望状态只声明了最基本的字段，ECSM 返回的详情里带有大量默认值
+		minimal := &ecsmv1.ECSMServiceSpec{
+			DeploymentStrategy: base.DeploymentStrategy,
+			Template:           ecsmv1.ContainerTemplateSpec{Image: base.Template.Image},
+		}
+		actual := toServiceGet(req)
+		actual.Image.Action = "run"
+		actual.Image.PullPolicy = "ifNotPresent"
+		actual.Image.Config.SylixOS.Resources.KernelObject = &clientset.KernelObject{ThreadLimit: 100}
+		drifted, err := NeedsUpdate(minimal, actual)
+		require.NoError(t, err)
+		assert.False(t, drifted)
+	})
+
+	t.Run("IgnoresPrepull", func(t *testing.T) {
+		spec := fullSpec()
+		spec.Template.Prepull = true
+		drifted, err := NeedsUpdate(spec, toServiceGet(req))
+		require.NoError(t, err)
+		assert.False(t, drifted)
+	})
+
+	t.Run("EquivalentQuantity", func(t *testing.T) {
+		spec := fullSpec()
+		spec.Template.Resources.Limits[ecsmv1.ResourceTypeDisk] = "1Gi"
+		drifted, err := NeedsUpdate(spec, toServiceGet(req))
+		require.NoError(t, err)
+		assert.False(t, drifted)
+	})
+
+	changes := map[string]func(*ecsmv1.ECSMServiceSpec){
+		"Replicas":    func(s *ecsmv1.ECSMServiceSpec) { s.DeploymentStrategy.Replicas = int32Ptr(4) },
+		"Policy":      func(s *ecsmv1.ECSMServiceSpec) { s.DeploymentStrategy.Type = ecsmv1.DeploymentStrategyTypeStatic },
+		"Image":       func(s *ecsmv1.ECSMServiceSpec) { s.Template.Image = "nginx@2.0#sylixos" },
+		"Upgrade":     func(s *ecsmv1.ECSMServiceSpec) { s.UpgradeStrategy.Type = ecsmv1.UpgradeStrategyTypeAlways },
+		"Memory":      func(s *ecsmv1.ECSMServiceSpec) { s.Template.Resources.Limits[ecsmv1.ResourceTypeMemory] = "1Gi" },
+		"Env":         func(s *ecsmv1.ECSMServiceSpec) { s.Template.Env[0].Value = "dev" },
+		"Mount":       func(s *ecsmv1.ECSMServiceSpec) { s.Template.VolumeMounts[1].ReadOnly = true },
+		"HealthCheck": func(s *ecsmv1.ECSMServiceSpec) { s.Template.VSOA.HealthCheck.PeriodSeconds = 30 },
+		"Action":      func(s *ecsmv1.ECSMServiceSpec) { s.Template.PlatformSpecific.Action = ecsmv1.ActionTypeRun },
+		"Network":     func(s *ecsmv1.ECSMServiceSpec) { s.Template.PlatformSpecific.SylixOS.Network.TELNETD = true },
+		"PullPolicy":  func(s *ecsmv1.ECSMServiceSpec) { s.Template.ImagePullPolicy = ecsmv1.ImagePullPolicyNever },
+	}
+	for name, mutate := range changes {
+		t.Run("Detects"+name, func(t *testing.T) {
+			spec := fullSpec()
+			mutate(spec)
+			drifted, err := NeedsUpdate(spec, toServiceGet(req))
+			require.NoError(t, err)
+			assert.True(t, drifted)
+		})
+	}
+}
+
+func TestServiceGetToSpec_NodeList(t *testing.T) {
+	actual := &clientset.ServiceGet{
+		Policy:   "static",
+		Factor:   2,
+		Image:    &clientset.ImageSpec{Ref: "app@1.0", Action: "run"},
+		NodeList: []clientset.ServiceNodeInfo{{NodeName: "a"}, {NodeName: "b"}},
+	}
+	spec, err := ServiceGetToSpec(actual)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, spec.DeploymentStrategy.Nodes)
+	assert.Nil(t, spec.Template.PlatformSpecific)
+
+	actual.Image.PullPolicy = "sometimes"
+	_, err = ServiceGetToSpec(actual)
+	assert.Error(t, err)
+
+	actual.Image.PullPolicy = ""
+	actual.Policy = "round-robin"
+	_, err = ServiceGetToSpec(actual)
+	assert.Error(t, err)
+}
@@ -1,9 +1,13 @@
 package clientset
 
-import "github.com/fx147/ecsm-operator/pkg/ecsm-client/rest"
+import (
+	"net/http"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/rest"
+)
 
 type Interface interface {
-	RESTClient() rest.RESTClient
+	RESTClient() *rest.RESTClient
 	ServiceGetter
 	MicroServiceGetter
 	RecordGetter
@@ -11,62 +15,85 @@ type Interface interface {
 	NodeGetter
 	ConfigGetter
 	TemplateGetter
+	TransactionGetter
 }
 
+// Clientset 中所有的 typed client 共享同一个 RESTClient，因此对它的设置（例如重试策略）对所有资源生效。
 type Clientset struct {
-	restClient rest.RESTClient
+	restClient *rest.RESTClient
+}
+
+// NewClientsetForConfig 根据 rest.Config 创建 Clientset，
+// 可以设置认证、TLS、超时、自定义 Transport 以及反向代理下的 API 路径前缀。
+func NewClientsetForConfig(config *rest.Config) (*Clientset, error) {
+	restClient, err := rest.RESTClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Clientset{restClient: restClient}, nil
 }
 
 // NewClientset 创建一个新的 Clientset 实例，用于与 ECSM API 交互
 func NewClientset(protocol, host, port string) (*Clientset, error) {
+	return NewClientsetWithHTTPClient(protocol, host, port, nil)
+}
+
+// NewClientsetWithHTTPClient 使用指定的 http.Client 创建 Clientset，
+// 例如 Transport 中带有认证信息的客户端。httpClient 为 nil 时使用 http.DefaultClient。
+func NewClientsetWithHTTPClient(protocol, host, port string, httpClient *http.Client) (*Clientset, error) {
 	// 创建 REST 客户端
-	restClient, err := rest.NewRESTClient(protocol, host, port, nil)
+	restClient, err := rest.NewRESTClient(protocol, host, port, httpClient)
 	if err != nil {
 		return nil, err
 	}
 
 	// 创建并返回 Clientset
 	return &Clientset{
-		restClient: *restClient,
+		restClient: restClient,
 	}, nil
 }
 
 // RESTClient 返回底层的 REST 客户端
-func (c *Clientset) RESTClient() rest.RESTClient {
+func (c *Clientset) RESTClient() *rest.RESTClient {
 	return c.restClient
 }
 
 // Services 返回 ServiceInterface，用于操作 Service 资源
 func (c *Clientset) Services() ServiceInterface {
-	return newServices(&c.restClient)
+	return newServices(c.restClient)
 }
 
 func (c *Clientset) MicroServices() MicroServiceInterface {
-	return newMicroServices(&c.restClient)
+	return newMicroServices(c.restClient)
 }
 
 // Records 返回 RecordInterface，用于操作 Record 资源
 func (c *Clientset) Records() RecordInterface {
-	return newRecords(&c.restClient)
+	return newRecords(c.restClient)
 }
 
 // Containers 返回 ContainerInterface，用于操作 Container 资源
 func (c *Clientset) Containers() ContainerInterface {
-	return newContainers(&c.restClient)
+	return newContainers(c.restClient)
 }
 
 func (c *Clientset) Nodes() NodeInterface {
-	return newNodes(&c.restClient)
+	return newNodes(c.restClient)
 }
 
 func (c *Clientset) Images() ImageInterface {
-	return newImages(&c.restClient)
+	return newImages(c.restClient)
 }
 
 func (c *Clientset) Configs() ConfigInterface {
-	return newConfigs(&c.restClient)
+	return newConfigs(c.restClient)
 }
 
 func (c *Clientset) Templates() TemplateInterface {
-	return newTemplates(&c.restClient)
+	return newTemplates(c.restClient)
+}
+
+// Transactions 返回 TransactionInterface，用于查询和等待异步事务
+func (c *Clientset) Transactions() TransactionInterface {
+	return newTransactions(c.restClient)
 }
@@ -144,29 +144,5 @@ func (c *configClient) ListConfig(ctx context.Context, opts ListConfigsOptions)
 }
 
 func (c *configClient) ListAllConfig(ctx context.Context, opts ListConfigsOptions) ([]ConfigItem, error) {
-	var allItems []ConfigItem
-	opts.PageNum = 1
-	if opts.PageSize == 0 {
-		opts.PageSize = 100
-	}
-
-	for {
-		pageItems, err := c.ListConfig(ctx, opts)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(pageItems) == 0 {
-			break
-		}
-
-		allItems = append(allItems, pageItems...)
-
-		if len(pageItems) < opts.PageSize {
-			break
-		}
-
-		opts.PageNum++
-	}
-	return allItems, nil
+	return NewConfigPager(c, opts).List(ctx)
 }
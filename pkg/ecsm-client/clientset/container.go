@@ -149,59 +149,11 @@ func (c *containerClient) GetHistory(ctx context.Context, opts ContainerHistoryO
 }
 
 func (c *containerClient) ListAllByService(ctx context.Context, opts ListContainersByServiceOptions) ([]ContainerInfo, error) {
-	var allItems []ContainerInfo
-	opts.PageNum = 1
-	if opts.PageSize == 0 {
-		opts.PageSize = 100
-	}
-
-	for {
-		list, err := c.ListByService(ctx, opts)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(list.Items) == 0 {
-			break
-		}
-
-		allItems = append(allItems, list.Items...)
-
-		if len(allItems) >= list.Total {
-			break
-		}
-
-		opts.PageNum++
-	}
-	return allItems, nil
+	return NewContainerByServicePager(c, opts).List(ctx)
 }
 
 func (c *containerClient) ListAllByNode(ctx context.Context, opts ListContainersByNodeOptions) ([]ContainerInfo, error) {
-	var allItems []ContainerInfo
-	opts.PageNum = 1
-	if opts.PageSize == 0 {
-		opts.PageSize = 100
-	}
-
-	for {
-		list, err := c.ListByNode(ctx, opts)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(list.Items) == 0 {
-			break
-		}
-
-		allItems = append(allItems, list.Items...)
-
-		if len(allItems) >= list.Total {
-			break
-		}
-
-		opts.PageNum++
-	}
-	return allItems, nil
+	return NewContainerByNodePager(c, opts).List(ctx)
 }
 
 func (c *containerClient) GetByName(ctx context.Context, serviceClient ServiceInterface, name string) (*ContainerInfo, error) {
@@ -92,10 +92,10 @@ type ServiceControlContainerRequest struct {
 	Action ContainerAction `json:"action"`
 }
 
-// Transaction 描述了一个异步操作任务。
+// Transaction 描述了一个异步操作任务，可以通过 TransactionInterface 查询或等待它完成。
 type Transaction struct {
 	ID        string      `json:"id"`
-	Status    string      `json:"status"` // "running", "failure", "success"
+	Status    string      `json:"status"` // TransactionStatusRunning, TransactionStatusSuccess 或 TransactionStatusFailure
 	Data      interface{} `json:"data"`   // 使用 interface{} 来匹配任意对象
 	Timestamp int64       `json:"timestamp"`
 }
@@ -0,0 +1,120 @@
+// file: pkg/ecsm-client/clientset/fake/clientset.go
+
+// Package fake 提供了一个由内存中的 ECSM 模拟器支撑的 clientset.Interface 实现，用于控制器等代码的单元测试。
+//
+// 与手写的 fake 不同，它走的是真实的 clientset 和 RESTClient 代码路径，只是把 HTTP 请求交给
+// simulator.Simulator 处理，因此服务创建后可以查询到容器、删除是异步完成的等行为都与 ECSM 一致。
+// 测试可以通过 Simulator 预置节点、注入故障，通过 Actions 检查代码发出了哪些请求。
+package fake
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/rest"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/simulator"
+)
+
+// Action 是 Clientset 发出的一个请求。
+type Action struct {
+	// Verb 是 HTTP 方法，例如 "POST"。
+	Verb string
+	// Path 是相对 API 前缀的路径，例如 "service" 或 "service/<id>"。
+	Path string
+	// Query 是请求的查询参数。
+	Query url.Values
+	// Body 是请求体，没有请求体时为 nil。
+	Body []byte
+}
+
+// Matches 判断 Action 的方法和路径是否分别等于 verb 和 path。
+func (a Action) Matches(verb, path string) bool {
+	return a.Verb == verb && a.Path == path
+}
+
+// Clientset 实现了 clientset.Interface，所有请求都由 Simulator 处理。
+// 它关闭了客户端限流和自动重试，注入的故障会直接返回给调用方。
+type Clientset struct {
+	*clientset.Clientset
+
+	// Simulator 是处理请求的模拟器，可以用来预置节点、镜像、模板，或者注入故障。
+	Simulator *simulator.Simulator
+
+	mu      sync.Mutex
+	actions []Action
+}
+
+var _ clientset.Interface = &Clientset{}
+
+// NewSimpleClientset 返回一个由空的 Simulator 支撑的 Clientset。
+func NewSimpleClientset() *Clientset {
+	return NewClientsetWithSimulator(simulator.New())
+}
+
+// NewClientsetWithSimulator 返回一个由 sim 支撑的 Clientset，多个 Clientset 可以共享同一个 Simulator。
+func NewClientsetWithSimulator(sim *simulator.Simulator) *Clientset {
+	c := &Clientset{Simulator: sim}
+	cs, err := clientset.NewClientsetForConfig(&rest.Config{
+		Host:        "http://ecsm.fake",
+		Transport:   &recordingTransport{clientset: c, rt: sim.Transport()},
+		QPS:         -1,
+		RetryPolicy: &rest.RetryPolicy{},
+	})
+	if err != nil {
+		// 配置是固定的，只有代码有误时才会出错
+		panic(err)
+	}
+	c.Clientset = cs
+	return c
+}
+
+// Actions 按发出的顺序返回所有请求。
+func (c *Clientset) Actions() []Action {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Action(nil), c.actions...)
+}
+
+// ClearActions 清空已经记录的请求。
+func (c *Clientset) ClearActions() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.actions = nil
+}
+
+func (c *Clientset) record(action Action) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.actions = append(c.actions, action)
+}
+
+// recordingTransport 记录每个请求，然后把它交给 rt。
+type recordingTransport struct {
+	clientset *Clientset
+	rt        http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	action := Action{
+		Verb:  req.Method,
+		Path:  strings.TrimPrefix(req.URL.Path, simulator.APIPrefix),
+		Query: req.URL.Query(),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		action.Body = body
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	t.clientset.record(action)
+	return t.rt.RoundTrip(req)
+}
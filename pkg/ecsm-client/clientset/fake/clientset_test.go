@@ -0,0 +1,73 @@
+// file: pkg/ecsm-client/clientset/fake/clientset_test.go
+
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/rest"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientset_RecordsActions(t *testing.T) {
+	ctx := context.Background()
+	cs := NewSimpleClientset()
+	cs.Simulator.AddNode("worker1", "10.0.0.1")
+
+	created, err := cs.Services().Create(ctx, &clientset.CreateServiceRequest{
+		Name:  "web",
+		Image: clientset.ImageSpec{Ref: "nginx@1.0#sylixos"},
+	})
+	require.NoError(t, err)
+	_, err = cs.Services().Get(ctx, created.ID)
+	require.NoError(t, err)
+
+	actions := cs.Actions()
+	require.Len(t, actions, 2)
+	assert.True(t, actions[0].Matches(http.MethodPost, "service"))
+	var body clientset.CreateServiceRequest
+	require.NoError(t, json.Unmarshal(actions[0].Body, &body))
+	assert.Equal(t, "web", body.Name)
+	assert.True(t, actions[1].Matches(http.MethodGet, "service/"+created.ID))
+	assert.Nil(t, actions[1].Body)
+
+	cs.ClearActions()
+	assert.Empty(t, cs.Actions())
+}
+
+func TestClientset_FaultsAreNotRetried(t *testing.T) {
+	ctx := context.Background()
+	cs := NewSimpleClientset()
+	cs.Simulator.InjectFault(simulator.Fault{Path: "service", StatusCode: http.StatusServiceUnavailable, Times: 1})
+
+	_, err := cs.Services().GetStatistics(ctx)
+	assert.Error(t, err)
+	assert.Len(t, cs.Actions(), 1)
+
+	_, err = cs.Services().GetStatistics(ctx)
+	assert.NoError(t, err)
+}
+
+func TestClientset_SharedSimulator(t *testing.T) {
+	ctx := context.Background()
+	sim := simulator.New()
+	a := NewClientsetWithSimulator(sim)
+	b := NewClientsetWithSimulator(sim)
+
+	require.NoError(t, a.Configs().CreateConfig(ctx, &clientset.CreateConfigRequest{Key: "mode", Type: clientset.ConfigItemTypeString, Value: "edge"}))
+	value, err := b.Configs().GetConfig(ctx, "mode")
+	require.NoError(t, err)
+	assert.Equal(t, "edge", value)
+	// 请求只记录在发出它的 Clientset 上
+	assert.Len(t, a.Actions(), 1)
+	assert.Len(t, b.Actions(), 1)
+
+	_, err = b.Configs().GetConfig(ctx, "missing")
+	assert.True(t, rest.IsNotFound(err), "expected not found, got %v", err)
+}
@@ -73,31 +73,7 @@ func (c *imageClient) List(ctx context.Context, opts ImageListOptions) (*ImageLi
 }
 
 func (c *imageClient) ListAll(ctx context.Context, opts ImageListOptions) ([]ImageListItem, error) {
-	var allItems []ImageListItem
-	opts.PageNum = 1
-	if opts.PageSize == 0 {
-		opts.PageSize = 100
-	}
-
-	for {
-		list, err := c.List(ctx, opts)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(list.Items) == 0 {
-			break
-		}
-
-		allItems = append(allItems, list.Items...)
-
-		if len(allItems) >= list.Total {
-			break
-		}
-
-		opts.PageNum++
-	}
-	return allItems, nil
+	return NewImagePager(c, opts).List(ctx)
 }
 
 func (c *imageClient) GetStatistics(ctx context.Context) (*ImageStatistics, error) {
@@ -50,9 +50,10 @@ type Resources struct {
 	KernelObject *KernelObject `json:"kernelObject"`
 }
 
+// CPU 是 SylixOS 容器的线程优先级范围。0 是合法的优先级，所以未设置的字段用 nil 表示，不会下发。
 type CPU struct {
-	HighestPrio int `json:"highestPrio"`
-	LowestPrio  int `json:"lowestPrio"`
+	HighestPrio *int `json:"highestPrio,omitempty"`
+	LowestPrio  *int `json:"lowestPrio,omitempty"`
 }
 
 type Memory struct {
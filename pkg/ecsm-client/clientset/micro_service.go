@@ -71,31 +71,7 @@ func (c *MicroserviceClient) ListMicroService(ctx context.Context, opts ListMicr
 }
 
 func (c *MicroserviceClient) ListAllMicroService(ctx context.Context, opts ListMicroServicesOptions) ([]MicroServiceListRow, error) {
-	var allItems []MicroServiceListRow
-	opts.PageNum = 1
-	if opts.PageSize == 0 {
-		opts.PageSize = 100
-	}
-
-	for {
-		list, err := c.ListMicroService(ctx, opts)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(list.Items) == 0 {
-			break
-		}
-
-		allItems = append(allItems, list.Items...)
-
-		if len(allItems) >= list.Total {
-			break
-		}
-
-		opts.PageNum++
-	}
-	return allItems, nil
+	return NewMicroServicePager(c, opts).List(ctx)
 }
 
 func (c *MicroserviceClient) GetMicroService(ctx context.Context, MicroServiceID string) (*MicroServiceGet, error) {
@@ -0,0 +1,125 @@
+package clientset
+
+import (
+	"context"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/pager"
+)
+
+// 本文件中的 NewXxxPager 把各个分页列表接口包装成 pager.Pager。
+// opts 中的 PageNum 被忽略；PageSize 不为 0 时作为每页的对象数，否则使用 pager.DefaultPageSize。
+// 需要逐个处理大量对象、限制返回数量或者并发预取时，可以直接使用返回的 Pager，
+// 各个 ListAll 方法就是 Pager.List 的简单包装。
+
+// NewServicePager 返回按 opts 列出服务的 Pager。
+func NewServicePager(c ServiceInterface, opts ListServicesOptions) *pager.Pager[ProvisionListRow] {
+	return newListPager(opts.PageSize, opts, c.List, func(list *ServiceList) ([]ProvisionListRow, int) {
+		return list.Items, list.Total
+	})
+}
+
+// NewNodePager 返回按 opts 列出节点的 Pager。
+func NewNodePager(c NodeInterface, opts NodeListOptions) *pager.Pager[NodeInfo] {
+	return newListPager(opts.PageSize, opts, c.List, func(list *NodeList) ([]NodeInfo, int) {
+		return list.Items, list.Total
+	})
+}
+
+// NewImagePager 返回按 opts 列出镜像的 Pager。
+func NewImagePager(c ImageInterface, opts ImageListOptions) *pager.Pager[ImageListItem] {
+	return newListPager(opts.PageSize, opts, c.List, func(list *ImageList) ([]ImageListItem, int) {
+		return list.Items, list.Total
+	})
+}
+
+// NewContainerByServicePager 返回按 opts 列出服务的容器的 Pager。
+func NewContainerByServicePager(c ContainerInterface, opts ListContainersByServiceOptions) *pager.Pager[ContainerInfo] {
+	return newListPager(opts.PageSize, opts, c.ListByService, containerListPage)
+}
+
+// NewContainerByNodePager 返回按 opts 列出节点上的容器的 Pager。
+func NewContainerByNodePager(c ContainerInterface, opts ListContainersByNodeOptions) *pager.Pager[ContainerInfo] {
+	return newListPager(opts.PageSize, opts, c.ListByNode, containerListPage)
+}
+
+// NewRecordPager 返回按 opts 列出部署记录的 Pager。
+func NewRecordPager(c RecordInterface, opts ListRecordOptions) *pager.Pager[DeployRecord] {
+	return newListPager(opts.PageSize, opts, c.ListRecord, func(list *RecordList) ([]DeployRecord, int) {
+		return list.Items, list.Total
+	})
+}
+
+// NewConfigPager 返回按 opts 列出配置项的 Pager。配置接口不返回总数，读到不满一页时结束。
+func NewConfigPager(c ConfigInterface, opts ListConfigsOptions) *pager.Pager[ConfigItem] {
+	return newListPager(opts.PageSize, opts, c.ListConfig, func(items []ConfigItem) ([]ConfigItem, int) {
+		return items, -1
+	})
+}
+
+// NewMicroServicePager 返回按 opts 列出微服务的 Pager。
+func NewMicroServicePager(c MicroServiceInterface, opts ListMicroServicesOptions) *pager.Pager[MicroServiceListRow] {
+	return newListPager(opts.PageSize, opts, c.ListMicroService, func(list *MicroServiceList) ([]MicroServiceListRow, int) {
+		return list.Items, list.Total
+	})
+}
+
+func containerListPage(list *ContainerList) ([]ContainerInfo, int) {
+	return list.Items, list.Total
+}
+
+// pageOptions 是可以设置页码和每页对象数的列表参数 O 的指针类型。
+type pageOptions[O any] interface {
+	*O
+	setPage(pageNum, pageSize int)
+}
+
+// newListPager 把列表接口 list 包装成 pager.Pager：每一页用设置了页码的 opts 副本调用 list，
+// 再用 page 从返回的列表中取出这一页的对象和总数，接口不返回总数时 page 返回 -1。
+func newListPager[O any, PO pageOptions[O], L, T any](pageSize int, opts O, list func(context.Context, O) (L, error), page func(L) ([]T, int)) *pager.Pager[T] {
+	p := pager.New(func(ctx context.Context, pageNum, pageSize int) (pager.Page[T], error) {
+		opts := opts
+		PO(&opts).setPage(pageNum, pageSize)
+		l, err := list(ctx, opts)
+		if err != nil {
+			return pager.Page[T]{}, err
+		}
+		items, total := page(l)
+		return pager.Page[T]{Items: items, Total: total}, nil
+	})
+	if pageSize > 0 {
+		p.PageSize = pageSize
+	}
+	return p
+}
+
+func (o *ListServicesOptions) setPage(pageNum, pageSize int) {
+	o.PageNum, o.PageSize = pageNum, pageSize
+}
+
+func (o *NodeListOptions) setPage(pageNum, pageSize int) {
+	o.PageNum, o.PageSize = pageNum, pageSize
+}
+
+func (o *ImageListOptions) setPage(pageNum, pageSize int) {
+	o.PageNum, o.PageSize = pageNum, pageSize
+}
+
+func (o *ListContainersByServiceOptions) setPage(pageNum, pageSize int) {
+	o.PageNum, o.PageSize = pageNum, pageSize
+}
+
+func (o *ListContainersByNodeOptions) setPage(pageNum, pageSize int) {
+	o.PageNum, o.PageSize = pageNum, pageSize
+}
+
+func (o *ListRecordOptions) setPage(pageNum, pageSize int) {
+	o.PageNum, o.PageSize = pageNum, pageSize
+}
+
+func (o *ListConfigsOptions) setPage(pageNum, pageSize int) {
+	o.PageNum, o.PageSize = pageNum, pageSize
+}
+
+func (o *ListMicroServicesOptions) setPage(pageNum, pageSize int) {
+	o.PageNum, o.PageSize = pageNum, pageSize
+}
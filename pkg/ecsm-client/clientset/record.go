@@ -82,29 +82,5 @@ func (c *recordClient) ListRecord(ctx context.Context, opts ListRecordOptions) (
 }
 
 func (c *recordClient) ListAllRecord(ctx context.Context, opts ListRecordOptions) ([]DeployRecord, error) {
-	var allItems []DeployRecord
-	opts.PageNum = 1
-	if opts.PageSize == 0 {
-		opts.PageSize = 100
-	}
-
-	for {
-		list, err := c.ListRecord(ctx, opts)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(list.Items) == 0 {
-			break
-		}
-
-		allItems = append(allItems, list.Items...)
-
-		if len(allItems) >= list.Total {
-			break
-		}
-
-		opts.PageNum++
-	}
-	return allItems, nil
+	return NewRecordPager(c, opts).List(ctx)
 }
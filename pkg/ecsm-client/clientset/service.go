@@ -273,31 +273,7 @@ func (c *serviceClient) List(ctx context.Context, opts ListServicesOptions) (*Se
 }
 
 func (c *serviceClient) ListAll(ctx context.Context, opts ListServicesOptions) ([]ProvisionListRow, error) {
-	var allItems []ProvisionListRow
-	opts.PageNum = 1
-	if opts.PageSize == 0 {
-		opts.PageSize = 100
-	}
-
-	for {
-		list, err := c.List(ctx, opts)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(list.Items) == 0 {
-			break
-		}
-
-		allItems = append(allItems, list.Items...)
-
-		if len(allItems) >= list.Total {
-			break
-		}
-
-		opts.PageNum++
-	}
-	return allItems, nil
+	return NewServicePager(c, opts).List(ctx)
 }
 
 func (c *serviceClient) Redeploy(ctx context.Context, serviceID string) error {
@@ -5,21 +5,31 @@ import (
 	"testing"
 	"time"
 	"fmt"
+	"net/url"
+	"os"
 
 	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-const (
-	protocol = "http"
-	host     = "192.168.31.129"
-	port     = "3001"
-)
+// liveServerEnv 是这些集成测试所连接的 ECSM 服务器地址的环境变量，例如 http://192.168.31.129:3001。
+// 测试依赖真实环境中的节点、镜像和模板，并且会修改它们，没有设置时全部跳过：
+//
+//	ECSM_TEST_SERVER=http://192.168.31.129:3001 go test ./pkg/ecsm-client/clientset/test/
+const liveServerEnv = "ECSM_TEST_SERVER"
+
+func intPtr(i int) *int { return &i }
 
-// 创建测试用的 Clientset 实例
+// 创建测试用的 Clientset 实例，没有设置 ECSM_TEST_SERVER 时跳过测试
 func newTestClientset(t *testing.T) *clientset.Clientset {
-	clientsetInstance, err := clientset.NewClientset(protocol, host, port)
+	server := os.Getenv(liveServerEnv)
+	if server == "" {
+		t.Skipf("%s is not set, skipping test against a live ECSM server", liveServerEnv)
+	}
+	u, err := url.Parse(server)
+	require.NoError(t, err, "无法解析 %s", liveServerEnv)
+	clientsetInstance, err := clientset.NewClientset(u.Scheme, u.Hostname(), u.Port())
 	require.NoError(t, err, "创建 Clientset 失败")
 	require.NotNil(t, clientsetInstance, "Clientset 不应为 nil")
 	return clientsetInstance
@@ -44,8 +54,8 @@ func _createTestServiceRequest(name string) *clientset.CreateServiceRequest {
 				SylixOS: &clientset.SylixOS{
 					Resources: &clientset.Resources{
 						CPU: &clientset.CPU{
-							HighestPrio: 200,
-							LowestPrio:  255,
+							HighestPrio: intPtr(200),
+							LowestPrio:  intPtr(255),
 						},
 						Memory: &clientset.Memory{
 							KheapLimit:    1024,
@@ -185,8 +195,8 @@ func TestServiceClient_Create(t *testing.T) {
 				SylixOS: &clientset.SylixOS{
 					Resources: &clientset.Resources{
 						CPU: &clientset.CPU{
-							HighestPrio: 200,
-							LowestPrio:  255,
+							HighestPrio: intPtr(200),
+							LowestPrio:  intPtr(255),
 						},
 						Memory: &clientset.Memory{
 							KheapLimit:    1024,
@@ -276,8 +286,8 @@ func TestServiceClient_Update(t *testing.T) {
 				SylixOS: &clientset.SylixOS{
 					Resources: &clientset.Resources{
 						CPU: &clientset.CPU{
-							HighestPrio: 200,
-							LowestPrio:  255,
+							HighestPrio: intPtr(200),
+							LowestPrio:  intPtr(255),
 						},
 						Memory: &clientset.Memory{
 							KheapLimit:    1024,
@@ -338,8 +348,8 @@ func TestServiceClient_Update(t *testing.T) {
 				SylixOS: &clientset.SylixOS{
 					Resources: &clientset.Resources{
 						CPU: &clientset.CPU{
-							HighestPrio: 200,
-							LowestPrio:  255,
+							HighestPrio: intPtr(200),
+							LowestPrio:  intPtr(255),
 						},
 						Memory: &clientset.Memory{
 							KheapLimit:    2048,
@@ -0,0 +1,70 @@
+package clientset
+
+import (
+	"context"
+	"time"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/rest"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+type TransactionGetter interface {
+	Transactions() TransactionInterface
+}
+
+// TransactionInterface 用于查询 Delete、RollBack、容器操作等接口返回的异步事务。
+type TransactionInterface interface {
+	// Get 根据事务 ID 获取事务的当前状态。
+	Get(ctx context.Context, transactionID string) (*Transaction, error)
+
+	// WaitForTransaction 每隔 pollInterval 查询一次事务，直到事务结束或 ctx 被取消。
+	// 事务成功时返回最终状态；事务失败时同时返回最终状态和 *TransactionFailedError。
+	WaitForTransaction(ctx context.Context, transactionID string, pollInterval time.Duration) (*Transaction, error)
+}
+
+type transactionClient struct {
+	restClient rest.Interface
+}
+
+func newTransactions(restClient rest.Interface) *transactionClient {
+	return &transactionClient{restClient: restClient}
+}
+
+// Get 实现了 TransactionInterface 的 Get 方法
+func (c *transactionClient) Get(ctx context.Context, transactionID string) (*Transaction, error) {
+	result := &Transaction{}
+
+	err := c.restClient.Get().
+		Resource("transaction").
+		Name(transactionID).
+		Do(ctx).
+		Into(result)
+
+	return result, err
+}
+
+// WaitForTransaction 实现了 TransactionInterface 的 WaitForTransaction 方法。
+// 查询失败会直接返回错误，可重试的错误已经由 RESTClient 的重试策略处理过了。
+func (c *transactionClient) WaitForTransaction(ctx context.Context, transactionID string, pollInterval time.Duration) (*Transaction, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultTransactionPollInterval
+	}
+
+	var last *Transaction
+	err := wait.PollUntilContextCancel(ctx, pollInterval, true, func(ctx context.Context) (bool, error) {
+		tx, err := c.Get(ctx, transactionID)
+		if err != nil {
+			return false, err
+		}
+		last = tx
+		switch tx.Status {
+		case TransactionStatusSuccess:
+			return true, nil
+		case TransactionStatusFailure:
+			return true, &TransactionFailedError{Transaction: tx}
+		default:
+			return false, nil
+		}
+	})
+	return last, err
+}
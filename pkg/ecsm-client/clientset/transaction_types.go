@@ -0,0 +1,55 @@
+package clientset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Transaction.Status 的取值
+const (
+	TransactionStatusRunning = "running"
+	TransactionStatusSuccess = "success"
+	TransactionStatusFailure = "failure"
+)
+
+// DefaultTransactionPollInterval 是 WaitForTransaction 的 pollInterval 不大于 0 时使用的轮询间隔。
+const DefaultTransactionPollInterval = time.Second
+
+// TransactionFailedError 表示异步事务以 failure 状态结束。
+type TransactionFailedError struct {
+	Transaction *Transaction
+}
+
+func (e *TransactionFailedError) Error() string {
+	return fmt.Sprintf("transaction %s failed: %s", e.Transaction.ID, e.Message())
+}
+
+// Message 返回事务的失败原因。ECSM 把它放在 data 中，可能是字符串，也可能是带 message 字段的对象。
+func (e *TransactionFailedError) Message() string {
+	switch data := e.Transaction.Data.(type) {
+	case nil:
+		return "unknown error"
+	case string:
+		return data
+	case map[string]interface{}:
+		for _, key := range []string{"message", "msg", "error"} {
+			if msg, ok := data[key].(string); ok && msg != "" {
+				return msg
+			}
+		}
+	}
+	raw, err := json.Marshal(e.Transaction.Data)
+	if err != nil {
+		return fmt.Sprintf("%v", e.Transaction.Data)
+	}
+	return string(raw)
+}
+
+// IsTransactionFailed 判断 err 是否表示异步事务执行失败。
+// 与查询事务时的网络或 API 错误不同，这类错误重试同一个事务没有意义。
+func IsTransactionFailed(err error) bool {
+	var failed *TransactionFailedError
+	return errors.As(err, &failed)
+}
@@ -0,0 +1,191 @@
+// file: pkg/ecsm-client/informers/factory.go
+
+package informers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// 各 Informer 支持的索引名
+const (
+	// ByName 按名称索引服务、容器和节点。ECSM 不保证容器名称唯一。
+	ByName = "name"
+	// ByNode 按节点 ID 索引服务（部署到了哪些节点）和容器（运行在哪个节点）。
+	ByNode = "node"
+	// ByService 按服务 ID 索引容器。
+	ByService = "service"
+)
+
+// cacheSyncPollInterval 是等待 Informer 完成第一次 list 时检查的间隔
+const cacheSyncPollInterval = 100 * time.Millisecond
+
+// SharedInformerFactory 为服务、容器和节点各提供一个共享的 Informer。
+// 同一个工厂返回的同类 Informer 是同一个实例，所有使用者共享一份缓存和一轮 list 请求。
+// Informer 在第一次被请求时创建，调用 Start 之后才开始 list。
+type SharedInformerFactory struct {
+	client       clientset.Interface
+	resyncPeriod time.Duration
+
+	mu         sync.Mutex
+	services   *Informer[clientset.ProvisionListRow]
+	containers *Informer[clientset.ContainerInfo]
+	nodes      *Informer[clientset.NodeInfo]
+	started    map[runnable]bool
+}
+
+// NewSharedInformerFactory 创建一个每隔 resyncPeriod 重新 list 的 SharedInformerFactory。
+func NewSharedInformerFactory(client clientset.Interface, resyncPeriod time.Duration) *SharedInformerFactory {
+	return &SharedInformerFactory{
+		client:       client,
+		resyncPeriod: resyncPeriod,
+		started:      make(map[runnable]bool),
+	}
+}
+
+// Services 返回 ECSM 服务的 Informer，key 为服务 ID，支持 ByName 和 ByNode 索引。
+func (f *SharedInformerFactory) Services() *Informer[clientset.ProvisionListRow] {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.services == nil {
+		f.services = NewInformer("services",
+			func(ctx context.Context) ([]clientset.ProvisionListRow, error) {
+				return f.client.Services().ListAll(ctx, clientset.ListServicesOptions{})
+			},
+			func(svc *clientset.ProvisionListRow) string { return svc.ID },
+			map[string]IndexFunc[clientset.ProvisionListRow]{
+				ByName: func(svc *clientset.ProvisionListRow) []string { return []string{svc.Name} },
+				ByNode: func(svc *clientset.ProvisionListRow) []string {
+					ids := make([]string, 0, len(svc.NodeList))
+					for _, node := range svc.NodeList {
+						ids = append(ids, node.NodeID)
+					}
+					return ids
+				},
+			},
+			f.resyncPeriod)
+	}
+	return f.services
+}
+
+// Containers 返回容器的 Informer，key 为容器 ID，支持 ByName、ByNode 和 ByService 索引。
+// ECSM 只能按服务列出容器，因此 list 时使用服务 Informer 缓存中的服务 ID：
+// 服务的 Informer 会随容器的 Informer 一起启动，在它第一次同步完成之前容器的 list 会一直等待，
+// 而不是每次都自己再全量 list 一遍服务。
+func (f *SharedInformerFactory) Containers() *Informer[clientset.ContainerInfo] {
+	services := f.Services()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.containers == nil {
+		f.containers = NewInformer("containers",
+			func(ctx context.Context) ([]clientset.ContainerInfo, error) {
+				err := wait.PollUntilContextCancel(ctx, cacheSyncPollInterval, true, func(context.Context) (bool, error) {
+					return services.HasSynced(), nil
+				})
+				if err != nil {
+					return nil, fmt.Errorf("services informer has not synced: %w", err)
+				}
+
+				var serviceIDs []string
+				for _, svc := range services.Lister().List() {
+					serviceIDs = append(serviceIDs, svc.ID)
+				}
+				if len(serviceIDs) == 0 {
+					return nil, nil
+				}
+				return f.client.Containers().ListAllByService(ctx, clientset.ListContainersByServiceOptions{ServiceIDs: serviceIDs})
+			},
+			func(c *clientset.ContainerInfo) string { return c.ID },
+			map[string]IndexFunc[clientset.ContainerInfo]{
+				ByName:    func(c *clientset.ContainerInfo) []string { return []string{c.Name} },
+				ByNode:    func(c *clientset.ContainerInfo) []string { return []string{c.NodeID} },
+				ByService: func(c *clientset.ContainerInfo) []string { return []string{c.ServiceID} },
+			},
+			f.resyncPeriod)
+	}
+	return f.containers
+}
+
+// Nodes 返回节点的 Informer，key 为节点 ID，支持 ByName 索引。
+func (f *SharedInformerFactory) Nodes() *Informer[clientset.NodeInfo] {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.nodes == nil {
+		f.nodes = NewInformer("nodes",
+			func(ctx context.Context) ([]clientset.NodeInfo, error) {
+				return f.client.Nodes().ListAll(ctx, clientset.NodeListOptions{})
+			},
+			func(node *clientset.NodeInfo) string { return node.ID },
+			map[string]IndexFunc[clientset.NodeInfo]{
+				ByName: func(node *clientset.NodeInfo) []string { return []string{node.Name} },
+			},
+			f.resyncPeriod)
+	}
+	return f.nodes
+}
+
+// Start 在后台运行所有已经被请求过、且尚未启动的 Informer，直到 ctx 被取消。
+// 可以多次调用，之后才被请求的 Informer 会在下一次调用 Start 时启动。
+func (f *SharedInformerFactory) Start(ctx context.Context) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, informer := range f.informersLocked() {
+		if f.started[informer] {
+			continue
+		}
+		f.started[informer] = true
+		go informer.Run(ctx)
+	}
+}
+
+// WaitForCacheSync 等待所有已启动的 Informer 完成第一次 list，ctx 被取消时返回 false。
+func (f *SharedInformerFactory) WaitForCacheSync(ctx context.Context) bool {
+	f.mu.Lock()
+	var informers []runnable
+	for _, informer := range f.informersLocked() {
+		if f.started[informer] {
+			informers = append(informers, informer)
+		}
+	}
+	f.mu.Unlock()
+
+	err := wait.PollUntilContextCancel(ctx, cacheSyncPollInterval, true, func(context.Context) (bool, error) {
+		for _, informer := range informers {
+			if !informer.HasSynced() {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	return err == nil
+}
+
+// runnable 是不同类型的 Informer 共有的方法。
+type runnable interface {
+	Run(ctx context.Context)
+	HasSynced() bool
+}
+
+func (f *SharedInformerFactory) informersLocked() []runnable {
+	var informers []runnable
+	if f.services != nil {
+		informers = append(informers, f.services)
+	}
+	if f.containers != nil {
+		informers = append(informers, f.containers)
+	}
+	if f.nodes != nil {
+		informers = append(informers, f.nodes)
+	}
+	return informers
+}
@@ -0,0 +1,226 @@
+// file: pkg/ecsm-client/informers/informer.go
+
+package informers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// ListFunc 返回某种 ECSM 资源的完整列表。
+type ListFunc[T any] func(ctx context.Context) ([]T, error)
+
+// KeyFunc 返回对象在缓存中的唯一 key，通常是 ECSM 分配的 ID。
+type KeyFunc[T any] func(obj *T) string
+
+// IndexFunc 返回对象在某个索引中的所有索引值。
+type IndexFunc[T any] func(obj *T) []string
+
+// ResourceEventHandler 接收缓存中对象的变更通知。
+// 通知在 Informer 的 goroutine 中依次同步调用，处理函数不应长时间阻塞，也不能修改收到的对象。
+type ResourceEventHandler[T any] interface {
+	OnAdd(obj *T)
+	OnUpdate(oldObj, newObj *T)
+	OnDelete(obj *T)
+}
+
+// ResourceEventHandlerFuncs 是 ResourceEventHandler 的函数适配器，为 nil 的函数会被忽略。
+type ResourceEventHandlerFuncs[T any] struct {
+	AddFunc    func(obj *T)
+	UpdateFunc func(oldObj, newObj *T)
+	DeleteFunc func(obj *T)
+}
+
+func (f ResourceEventHandlerFuncs[T]) OnAdd(obj *T) {
+	if f.AddFunc != nil {
+		f.AddFunc(obj)
+	}
+}
+
+func (f ResourceEventHandlerFuncs[T]) OnUpdate(oldObj, newObj *T) {
+	if f.UpdateFunc != nil {
+		f.UpdateFunc(oldObj, newObj)
+	}
+}
+
+func (f ResourceEventHandlerFuncs[T]) OnDelete(obj *T) {
+	if f.DeleteFunc != nil {
+		f.DeleteFunc(obj)
+	}
+}
+
+// Informer 周期性地全量 list 一种 ECSM 资源，维护一个带索引的内存缓存，
+// 并把两次 list 之间的差异以 add/update/delete 通知的形式分发给注册的处理函数。
+// ECSM 没有 watch 接口，所以每个周期固定发起一轮 list 请求，
+// 对 ECSM 的负载只取决于资源的数量和 resyncPeriod，而与读取缓存的次数无关。
+type Informer[T any] struct {
+	name         string
+	listFunc     ListFunc[T]
+	keyFunc      KeyFunc[T]
+	indexer      cache.Indexer
+	resyncPeriod time.Duration
+
+	// mu 保证一次 relist 的差异计算和通知分发与 AddEventHandler 互斥，
+	// 新注册的处理函数不会漏掉或重复收到通知。
+	mu       sync.Mutex
+	handlers []ResourceEventHandler[T]
+
+	synced atomic.Bool
+}
+
+// NewInformer 创建一个 Informer。name 仅用于日志；indexers 的 key 是索引名。
+func NewInformer[T any](name string, listFunc ListFunc[T], keyFunc KeyFunc[T], indexers map[string]IndexFunc[T], resyncPeriod time.Duration) *Informer[T] {
+	cacheIndexers := cache.Indexers{}
+	for indexName, indexFunc := range indexers {
+		cacheIndexers[indexName] = func(obj interface{}) ([]string, error) {
+			return indexFunc(obj.(*T)), nil
+		}
+	}
+	return &Informer[T]{
+		name:     name,
+		listFunc: listFunc,
+		keyFunc:  keyFunc,
+		indexer: cache.NewIndexer(func(obj interface{}) (string, error) {
+			return keyFunc(obj.(*T)), nil
+		}, cacheIndexers),
+		resyncPeriod: resyncPeriod,
+	}
+}
+
+// AddEventHandler 注册一个处理函数。缓存中已有的对象会立即以 OnAdd 的形式通知给它。
+func (i *Informer[T]) AddEventHandler(handler ResourceEventHandler[T]) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, obj := range i.indexer.List() {
+		handler.OnAdd(obj.(*T))
+	}
+	i.handlers = append(i.handlers, handler)
+}
+
+// Lister 返回读取缓存的 Lister。
+func (i *Informer[T]) Lister() Lister[T] {
+	return Lister[T]{indexer: i.indexer}
+}
+
+// HasSynced 在第一次 list 成功完成后返回 true。
+func (i *Informer[T]) HasSynced() bool {
+	return i.synced.Load()
+}
+
+// Run 立即执行一次 list，之后每隔 resyncPeriod 重新 list，直到 ctx 被取消。
+// list 失败时缓存保持上一次的内容，在下一个周期重试。
+func (i *Informer[T]) Run(ctx context.Context) {
+	klog.V(2).InfoS("Starting informer", "resource", i.name, "resyncPeriod", i.resyncPeriod)
+	defer klog.V(2).InfoS("Stopping informer", "resource", i.name)
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := i.relist(ctx); err != nil {
+			klog.ErrorS(err, "Failed to relist, keeping the previous cache", "resource", i.name)
+		}
+	}, i.resyncPeriod)
+}
+
+// relist 执行一次全量 list，用结果替换缓存中的内容，并通知所有处理函数。
+func (i *Informer[T]) relist(ctx context.Context) error {
+	items, err := i.listFunc(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", i.name, err)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	seen := sets.New[string]()
+	for idx := range items {
+		obj := &items[idx]
+		key := i.keyFunc(obj)
+		// 分页 list 期间列表可能发生变化，同一个对象可能出现两次，以后出现的为准
+		seen.Insert(key)
+
+		old, exists, err := i.indexer.GetByKey(key)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !exists:
+			if err := i.indexer.Add(obj); err != nil {
+				return err
+			}
+			for _, h := range i.handlers {
+				h.OnAdd(obj)
+			}
+		case !reflect.DeepEqual(old, obj):
+			if err := i.indexer.Update(obj); err != nil {
+				return err
+			}
+			for _, h := range i.handlers {
+				h.OnUpdate(old.(*T), obj)
+			}
+		}
+	}
+
+	for _, key := range i.indexer.ListKeys() {
+		if seen.Has(key) {
+			continue
+		}
+		old, exists, err := i.indexer.GetByKey(key)
+		if err != nil || !exists {
+			continue
+		}
+		if err := i.indexer.Delete(old); err != nil {
+			return err
+		}
+		for _, h := range i.handlers {
+			h.OnDelete(old.(*T))
+		}
+	}
+
+	i.synced.Store(true)
+	return nil
+}
+
+// Lister 从 Informer 的缓存中读取对象。返回的对象与缓存共享，调用方不能修改它们。
+type Lister[T any] struct {
+	indexer cache.Indexer
+}
+
+// List 返回缓存中的所有对象，顺序不确定。
+func (l Lister[T]) List() []*T {
+	return toTyped[T](l.indexer.List())
+}
+
+// Get 按 key（通常是 ID）返回对象。
+func (l Lister[T]) Get(key string) (*T, bool) {
+	obj, exists, err := l.indexer.GetByKey(key)
+	if err != nil || !exists {
+		return nil, false
+	}
+	return obj.(*T), true
+}
+
+// ByIndex 返回索引 indexName 中值为 value 的所有对象。
+func (l Lister[T]) ByIndex(indexName, value string) ([]*T, error) {
+	objs, err := l.indexer.ByIndex(indexName, value)
+	if err != nil {
+		return nil, err
+	}
+	return toTyped[T](objs), nil
+}
+
+func toTyped[T any](objs []interface{}) []*T {
+	typed := make([]*T, 0, len(objs))
+	for _, obj := range objs {
+		typed = append(typed, obj.(*T))
+	}
+	return typed
+}
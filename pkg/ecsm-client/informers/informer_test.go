@@ -0,0 +1,255 @@
+// file: pkg/ecsm-client/informers/informer_test.go
+
+package informers
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	ID    string
+	Name  string
+	Value int
+}
+
+// recorder 记录收到的通知，格式为 "add:ID"、"update:ID" 和 "delete:ID"。
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) handler() ResourceEventHandler[item] {
+	return ResourceEventHandlerFuncs[item]{
+		AddFunc:    func(obj *item) { r.record("add:" + obj.ID) },
+		UpdateFunc: func(oldObj, newObj *item) { r.record("update:" + newObj.ID) },
+		DeleteFunc: func(obj *item) { r.record("delete:" + obj.ID) },
+	}
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// take 返回排序后的通知并清空记录。
+func (r *recorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.events
+	r.events = nil
+	sort.Strings(events)
+	return events
+}
+
+func newItemInformer(list *[]item, listErr *error) *Informer[item] {
+	return NewInformer("items",
+		func(ctx context.Context) ([]item, error) {
+			if *listErr != nil {
+				return nil, *listErr
+			}
+			return append([]item(nil), *list...), nil
+		},
+		func(obj *item) string { return obj.ID },
+		map[string]IndexFunc[item]{
+			ByName: func(obj *item) []string { return []string{obj.Name} },
+		},
+		time.Hour)
+}
+
+func TestInformer_RelistEmitsDiffs(t *testing.T) {
+	ctx := context.Background()
+	list := []item{{ID: "1", Name: "a"}, {ID: "2", Name: "b"}}
+	var listErr error
+	informer := newItemInformer(&list, &listErr)
+	r := &recorder{}
+	informer.AddEventHandler(r.handler())
+
+	assert.False(t, informer.HasSynced())
+	require.NoError(t, informer.relist(ctx))
+	assert.True(t, informer.HasSynced())
+	assert.Equal(t, []string{"add:1", "add:2"}, r.take())
+
+	// 没有变化时不发出任何通知
+	require.NoError(t, informer.relist(ctx))
+	assert.Empty(t, r.take())
+
+	list = []item{{ID: "1", Name: "a", Value: 1}, {ID: "3", Name: "a"}}
+	require.NoError(t, informer.relist(ctx))
+	assert.Equal(t, []string{"add:3", "delete:2", "update:1"}, r.take())
+
+	lister := informer.Lister()
+	assert.Len(t, lister.List(), 2)
+	got, ok := lister.Get("1")
+	require.True(t, ok)
+	assert.Equal(t, 1, got.Value)
+	_, ok = lister.Get("2")
+	assert.False(t, ok)
+
+	byName, err := lister.ByIndex(ByName, "a")
+	require.NoError(t, err)
+	assert.Len(t, byName, 2)
+	byName, err = lister.ByIndex(ByName, "b")
+	require.NoError(t, err)
+	assert.Empty(t, byName)
+}
+
+func TestInformer_ListErrorKeepsCache(t *testing.T) {
+	ctx := context.Background()
+	list := []item{{ID: "1", Name: "a"}}
+	var listErr error
+	informer := newItemInformer(&list, &listErr)
+	require.NoError(t, informer.relist(ctx))
+
+	r := &recorder{}
+	informer.AddEventHandler(r.handler())
+	// 新注册的处理函数会收到缓存中已有的对象
+	assert.Equal(t, []string{"add:1"}, r.take())
+
+	listErr = errors.New("connection reset by peer")
+	list = nil
+	assert.Error(t, informer.relist(ctx))
+	assert.Empty(t, r.take())
+	assert.Len(t, informer.Lister().List(), 1)
+}
+
+// fakeClientset 只实现 Informer 用到的 Services()、Containers() 和 Nodes()。
+type fakeClientset struct {
+	clientset.Interface
+	services   fakeServices
+	containers fakeContainers
+	nodes      fakeNodes
+}
+
+func (f *fakeClientset) Services() clientset.ServiceInterface     { return f.services }
+func (f *fakeClientset) Containers() clientset.ContainerInterface { return f.containers }
+func (f *fakeClientset) Nodes() clientset.NodeInterface           { return f.nodes }
+
+type fakeServices struct {
+	clientset.ServiceInterface
+	items []clientset.ProvisionListRow
+	// lists 不为 nil 时记录 ListAll 被调用的次数
+	lists *atomic.Int32
+}
+
+func (f fakeServices) ListAll(ctx context.Context, opts clientset.ListServicesOptions) ([]clientset.ProvisionListRow, error) {
+	if f.lists != nil {
+		f.lists.Add(1)
+	}
+	return f.items, nil
+}
+
+type fakeContainers struct {
+	clientset.ContainerInterface
+	items []clientset.ContainerInfo
+}
+
+// ListAllByService 只返回属于请求中的服务的容器。
+func (f fakeContainers) ListAllByService(ctx context.Context, opts clientset.ListContainersByServiceOptions) ([]clientset.ContainerInfo, error) {
+	var items []clientset.ContainerInfo
+	for _, c := range f.items {
+		for _, id := range opts.ServiceIDs {
+			if c.ServiceID == id {
+				items = append(items, c)
+			}
+		}
+	}
+	return items, nil
+}
+
+type fakeNodes struct {
+	clientset.NodeInterface
+	items []clientset.NodeInfo
+}
+
+func (f fakeNodes) ListAll(ctx context.Context, opts clientset.NodeListOptions) ([]clientset.NodeInfo, error) {
+	return f.items, nil
+}
+
+func TestSharedInformerFactory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cs := &fakeClientset{
+		services: fakeServices{items: []clientset.ProvisionListRow{
+			{ID: "svc-1", Name: "web", NodeList: []clientset.ServiceNodeInfo{{NodeID: "node-1"}, {NodeID: "node-2"}}},
+			{ID: "svc-2", Name: "db", NodeList: []clientset.ServiceNodeInfo{{NodeID: "node-2"}}},
+		}},
+		containers: fakeContainers{items: []clientset.ContainerInfo{
+			{ID: "c-1", Name: "web-1", ServiceID: "svc-1", NodeID: "node-1"},
+			{ID: "c-2", Name: "web-2", ServiceID: "svc-1", NodeID: "node-2"},
+			{ID: "c-3", Name: "db-1", ServiceID: "svc-2", NodeID: "node-2"},
+		}},
+		nodes: fakeNodes{items: []clientset.NodeInfo{{ID: "node-1", Name: "worker1"}, {ID: "node-2", Name: "worker2"}}},
+	}
+
+	factory := NewSharedInformerFactory(cs, time.Hour)
+	services := factory.Services()
+	containers := factory.Containers()
+	nodes := factory.Nodes()
+	assert.Same(t, services, factory.Services(), "informers must be shared")
+
+	factory.Start(ctx)
+	require.True(t, factory.WaitForCacheSync(ctx))
+
+	svcs, err := services.Lister().ByIndex(ByNode, "node-2")
+	require.NoError(t, err)
+	assert.Len(t, svcs, 2)
+	svcs, err = services.Lister().ByIndex(ByName, "web")
+	require.NoError(t, err)
+	require.Len(t, svcs, 1)
+	assert.Equal(t, "svc-1", svcs[0].ID)
+
+	byService, err := containers.Lister().ByIndex(ByService, "svc-1")
+	require.NoError(t, err)
+	assert.Len(t, byService, 2)
+	byNode, err := containers.Lister().ByIndex(ByNode, "node-2")
+	require.NoError(t, err)
+	assert.Len(t, byNode, 2)
+	byName, err := containers.Lister().ByIndex(ByName, "db-1")
+	require.NoError(t, err)
+	require.Len(t, byName, 1)
+	assert.Equal(t, "c-3", byName[0].ID)
+
+	node, ok := nodes.Lister().Get("node-1")
+	require.True(t, ok)
+	assert.Equal(t, "worker1", node.Name)
+}
+
+func TestSharedInformerFactory_ContainersWaitForServices(t *testing.T) {
+	lists := &atomic.Int32{}
+	cs := &fakeClientset{
+		services: fakeServices{items: []clientset.ProvisionListRow{{ID: "svc-1", Name: "web"}}, lists: lists},
+		containers: fakeContainers{items: []clientset.ContainerInfo{
+			{ID: "c-1", Name: "web-1", ServiceID: "svc-1"},
+		}},
+	}
+	factory := NewSharedInformerFactory(cs, time.Hour)
+	containers := factory.Containers()
+
+	// 服务的 Informer 没有同步时，容器的 list 等待它，而不是自己去 list 服务
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	assert.Error(t, containers.relist(ctx))
+	assert.False(t, containers.HasSynced())
+	assert.Zero(t, lists.Load())
+
+	runCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	factory.Start(runCtx)
+	require.True(t, factory.WaitForCacheSync(runCtx))
+
+	_, ok := containers.Lister().Get("c-1")
+	assert.True(t, ok)
+	assert.Equal(t, int32(1), lists.Load(), "services must be listed once and shared with the containers informer")
+}
@@ -0,0 +1,171 @@
+// file: pkg/ecsm-client/pager/pager.go
+
+// Package pager 提供了逐页读取 ECSM 列表接口的通用 Pager。
+//
+// ECSM 的列表接口都以 pageNum/pageSize 分页，Pager 负责翻页、判断何时结束，
+// 并可以在调用方处理当前页时并发预取后续的页。All 以 iter.Seq2 的形式逐个返回对象，
+// 调用方不必把所有对象都保存在内存中；List 则返回完整的列表。
+package pager
+
+import (
+	"context"
+	"iter"
+	"math"
+)
+
+// DefaultPageSize 是 PageSize 不大于 0 时每页请求的对象数。
+const DefaultPageSize = 100
+
+// Page 是列表接口返回的一页对象。
+type Page[T any] struct {
+	Items []T
+	// Total 是服务端报告的对象总数，接口不返回总数时为 -1。
+	Total int
+}
+
+// PageFunc 获取第 pageNum 页（从 1 开始）的对象，每页最多 pageSize 个。
+// 开启预取时 PageFunc 会被并发调用。
+type PageFunc[T any] func(ctx context.Context, pageNum, pageSize int) (Page[T], error)
+
+// Pager 逐页调用 PageFn，直到满足以下任一条件：
+//   - 某一页没有对象；
+//   - 已知 Total 时，已经读到的对象数达到了 Total；
+//   - 某一页的对象数少于实际的每页对象数；
+//   - 已经返回了 Limit 个对象。
+//
+// 服务端可能把 pageSize 限制在一个上限内。第一页的对象数少于 PageSize 而 Total 表明还有更多对象时，
+// Pager 把第一页的对象数作为实际的每页对象数，用它请求后续的页并判断何时结束。
+//
+// 接口不返回 Total 时，只有读到一个不满的页才知道已经结束：对象数恰好是每页对象数的整数倍时，
+// Pager 会多请求一个空页，开启预取时还可能预取到最后一页之后的页。设置 Limit 可以避免这些多余的请求。
+type Pager[T any] struct {
+	PageFn PageFunc[T]
+
+	// PageSize 是每页请求的对象数，不大于 0 时使用 DefaultPageSize。
+	PageSize int
+
+	// PrefetchPages 是在处理当前页时并发预取的后续页数，0 表示逐页顺序请求。
+	// 返回的对象顺序不受预取影响。
+	PrefetchPages int
+
+	// Limit 是最多返回的对象数，不大于 0 表示不限制。
+	Limit int
+}
+
+// New 创建一个使用 DefaultPageSize、不预取、不限制对象数的 Pager。
+func New[T any](fn PageFunc[T]) *Pager[T] {
+	return &Pager[T]{PageFn: fn, PageSize: DefaultPageSize}
+}
+
+// List 读取所有页，返回最多 Limit 个对象。任何一页出错时返回该错误。
+func (p *Pager[T]) List(ctx context.Context) ([]T, error) {
+	var items []T
+	for item, err := range p.All(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// All 返回逐个产生对象的迭代器，只在需要时才请求下一页。
+// 某一页出错时迭代器产生一次该错误后结束；调用方提前结束迭代时，还在进行的预取请求会被取消。
+func (p *Pager[T]) All(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		pageSize := p.PageSize
+		if pageSize <= 0 {
+			pageSize = DefaultPageSize
+		}
+		// maxPage 是根据 Limit 算出的、最多需要请求的页数
+		maxPage := p.maxPage(pageSize)
+
+		pending := []*pageResult[T]{p.fetch(ctx, 1, pageSize)}
+		nextPage := 2
+		count := 0
+		for pageNum := 1; len(pending) > 0; pageNum++ {
+			result := pending[0]
+			pending = pending[1:]
+			<-result.done
+			if result.err != nil {
+				var zero T
+				yield(zero, result.err)
+				return
+			}
+
+			page := result.page
+			if pageNum == 1 && page.Total >= 0 && len(page.Items) > 0 &&
+				len(page.Items) < pageSize && len(page.Items) < page.Total {
+				// 服务端限制了每页的对象数，后续的页按服务端实际使用的大小请求
+				pageSize = len(page.Items)
+				maxPage = p.maxPage(pageSize)
+			}
+			// 这一页已经足够返回 Limit 个对象时同样是最后一页，不再请求下一页
+			last := isLastPage(pageSize, count+len(page.Items), page) || (p.Limit > 0 && count+len(page.Items) >= p.Limit)
+			if !last {
+				// 先发出后续页的请求，它们与下面处理当前页的过程并发进行
+				lastPage := maxPage
+				if page.Total >= 0 {
+					lastPage = min(lastPage, (page.Total+pageSize-1)/pageSize)
+				}
+				for ; nextPage <= pageNum+1 || (nextPage <= pageNum+1+p.PrefetchPages && nextPage <= lastPage); nextPage++ {
+					pending = append(pending, p.fetch(ctx, nextPage, pageSize))
+				}
+			}
+
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+				count++
+				if p.Limit > 0 && count >= p.Limit {
+					return
+				}
+			}
+			if last {
+				return
+			}
+		}
+	}
+}
+
+// maxPage 返回每页 pageSize 个对象时，根据 Limit 算出的最多需要请求的页数。
+func (p *Pager[T]) maxPage(pageSize int) int {
+	if p.Limit <= 0 {
+		return math.MaxInt
+	}
+	return (p.Limit + pageSize - 1) / pageSize
+}
+
+// isLastPage 判断 page 是否是最后一页，pageSize 是实际的每页对象数，fetched 是包括这一页在内已经读到的对象数。
+// 服务端可能忽略 pageSize 一次返回所有对象，所以已知 Total 时检查的是已读到的对象数，而不是已经请求过的页。
+func isLastPage[T any](pageSize, fetched int, page Page[T]) bool {
+	switch {
+	case len(page.Items) == 0:
+		return true
+	case page.Total >= 0 && fetched >= page.Total:
+		return true
+	default:
+		return len(page.Items) < pageSize
+	}
+}
+
+// pageResult 是一次页请求的结果，done 关闭后 page 和 err 才可以读取。
+type pageResult[T any] struct {
+	done chan struct{}
+	page Page[T]
+	err  error
+}
+
+// fetch 在新的 goroutine 中请求第 pageNum 页。
+func (p *Pager[T]) fetch(ctx context.Context, pageNum, pageSize int) *pageResult[T] {
+	result := &pageResult[T]{done: make(chan struct{})}
+	go func() {
+		defer close(result.done)
+		result.page, result.err = p.PageFn(ctx, pageNum, pageSize)
+	}()
+	return result
+}
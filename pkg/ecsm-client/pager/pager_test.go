@@ -0,0 +1,253 @@
+// file: pkg/ecsm-client/pager/pager_test.go
+
+package pager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer 按 pageNum/pageSize 分页返回 0..n-1，并记录收到的请求。
+type fakeServer struct {
+	n int
+	// noTotal 为 true 时像配置接口一样不返回总数
+	noTotal bool
+	// ignorePageSize 为 true 时每页都返回所有对象
+	ignorePageSize bool
+	// maxPageSize 不为 0 时，请求的 pageSize 超过它会被限制为 maxPageSize
+	maxPageSize int
+	// delay 是每个请求的处理时间
+	delay time.Duration
+	// failPage 不为 0 时，该页返回错误
+	failPage int
+
+	mu       sync.Mutex
+	requests []int
+	inFlight atomic.Int32
+	maxConc  atomic.Int32
+}
+
+func (s *fakeServer) list(ctx context.Context, pageNum, pageSize int) (Page[int], error) {
+	s.mu.Lock()
+	s.requests = append(s.requests, pageNum)
+	s.mu.Unlock()
+
+	cur := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		prev := s.maxConc.Load()
+		if cur <= prev || s.maxConc.CompareAndSwap(prev, cur) {
+			break
+		}
+	}
+
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return Page[int]{}, ctx.Err()
+		}
+	}
+	if pageNum == s.failPage {
+		return Page[int]{}, errors.New("boom")
+	}
+
+	if s.maxPageSize > 0 && pageSize > s.maxPageSize {
+		pageSize = s.maxPageSize
+	}
+	start, end := (pageNum-1)*pageSize, pageNum*pageSize
+	if s.ignorePageSize {
+		start, end = 0, s.n
+	}
+	page := Page[int]{Total: s.n}
+	if s.noTotal {
+		page.Total = -1
+	}
+	for i := start; i < end && i < s.n; i++ {
+		page.Items = append(page.Items, i)
+	}
+	return page, nil
+}
+
+func (s *fakeServer) requestedPages() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.requests...)
+}
+
+func sequence(n int) []int {
+	var items []int
+	for i := 0; i < n; i++ {
+		items = append(items, i)
+	}
+	return items
+}
+
+func TestPager_List(t *testing.T) {
+	tests := []struct {
+		name      string
+		server    *fakeServer
+		pageSize  int
+		want      []int
+		wantPages []int
+	}{
+		{name: "Empty", server: &fakeServer{n: 0}, pageSize: 10, wantPages: []int{1}},
+		{name: "SinglePage", server: &fakeServer{n: 3}, pageSize: 10, want: sequence(3), wantPages: []int{1}},
+		{name: "ExactPages", server: &fakeServer{n: 20}, pageSize: 10, want: sequence(20), wantPages: []int{1, 2}},
+		{name: "PartialLastPage", server: &fakeServer{n: 25}, pageSize: 10, want: sequence(25), wantPages: []int{1, 2, 3}},
+		{name: "DefaultPageSize", server: &fakeServer{n: 150}, want: sequence(150), wantPages: []int{1, 2}},
+		// 不知道总数时，读到不满一页才结束；正好整页时需要多请求一个空页
+		{name: "NoTotalShortPage", server: &fakeServer{n: 25, noTotal: true}, pageSize: 10, want: sequence(25), wantPages: []int{1, 2, 3}},
+		{name: "NoTotalExactPages", server: &fakeServer{n: 20, noTotal: true}, pageSize: 10, want: sequence(20), wantPages: []int{1, 2, 3}},
+		// 服务端忽略 pageSize 时不能重复请求
+		{name: "ServerIgnoresPageSize", server: &fakeServer{n: 25, ignorePageSize: true}, pageSize: 10, want: sequence(25), wantPages: []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.server.list)
+			p.PageSize = tt.pageSize
+			got, err := p.List(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantPages, tt.server.requestedPages())
+		})
+	}
+}
+
+func TestPager_ServerCapsPageSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		prefetch  int
+		limit     int
+		want      []int
+		wantPages []int
+	}{
+		{name: "Sequential", want: sequence(23), wantPages: []int{1, 2, 3, 4, 5}},
+		// 预取的页数按服务端实际的每页对象数计算，不会请求超出总数的页
+		{name: "Prefetch", prefetch: 10, want: sequence(23), wantPages: []int{1, 2, 3, 4, 5}},
+		{name: "Limit", prefetch: 10, limit: 12, want: sequence(12), wantPages: []int{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &fakeServer{n: 23, maxPageSize: 5}
+			p := New(server.list)
+			p.PageSize = 10
+			p.PrefetchPages = tt.prefetch
+			p.Limit = tt.limit
+
+			got, err := p.List(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.ElementsMatch(t, tt.wantPages, server.requestedPages())
+		})
+	}
+}
+
+func TestPager_Limit(t *testing.T) {
+	server := &fakeServer{n: 100}
+	p := New(server.list)
+	p.PageSize = 10
+	p.PrefetchPages = 5
+	p.Limit = 25
+
+	got, err := p.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, sequence(25), got)
+	// 预取不会超过 Limit 需要的页数
+	assert.ElementsMatch(t, []int{1, 2, 3}, server.requestedPages())
+}
+
+// TestPager_LimitOnPageBoundary 测试 Limit 恰好是每页对象数的整数倍时，读完最后需要的一页就停止请求
+func TestPager_LimitOnPageBoundary(t *testing.T) {
+	for _, noTotal := range []bool{false, true} {
+		t.Run(fmt.Sprintf("NoTotal=%v", noTotal), func(t *testing.T) {
+			server := &fakeServer{n: 100, noTotal: noTotal}
+			p := New(server.list)
+			p.PageSize = 10
+			p.PrefetchPages = 5
+			p.Limit = 30
+
+			got, err := p.List(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, sequence(30), got)
+			// 请求在后台进行，等待一段时间确认没有多余的请求
+			assert.Never(t, func() bool { return len(server.requestedPages()) > 3 }, 100*time.Millisecond, 10*time.Millisecond)
+			assert.ElementsMatch(t, []int{1, 2, 3}, server.requestedPages())
+		})
+	}
+}
+
+func TestPager_Prefetch(t *testing.T) {
+	server := &fakeServer{n: 95, delay: 20 * time.Millisecond}
+	p := New(server.list)
+	p.PageSize = 10
+	p.PrefetchPages = 3
+
+	got, err := p.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, sequence(95), got, "prefetching must not change the order of items")
+	assert.ElementsMatch(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, server.requestedPages())
+	assert.Greater(t, server.maxConc.Load(), int32(1), "expected pages to be fetched concurrently")
+	// 第一页返回总数之前不会预取
+	assert.Equal(t, 1, server.requestedPages()[0])
+}
+
+func TestPager_AllStopsEarly(t *testing.T) {
+	server := &fakeServer{n: 1000}
+	p := New(server.list)
+	p.PageSize = 10
+
+	var got []int
+	for item, err := range p.All(context.Background()) {
+		require.NoError(t, err)
+		if item == 15 {
+			break
+		}
+		got = append(got, item)
+	}
+	assert.Equal(t, sequence(15), got)
+	// 最多请求了正在处理的页和紧接着的下一页，下一页的请求可能还没有发出就被取消了
+	assert.LessOrEqual(t, len(server.requestedPages()), 3)
+}
+
+func TestPager_Error(t *testing.T) {
+	server := &fakeServer{n: 100, failPage: 3}
+	p := New(server.list)
+	p.PageSize = 10
+	p.PrefetchPages = 2
+
+	got, err := p.List(context.Background())
+	assert.EqualError(t, err, "boom")
+	assert.Nil(t, got)
+
+	// 迭代器在出错之前正常返回已经读到的对象
+	var items []int
+	var errs []error
+	for item, err := range p.All(context.Background()) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		items = append(items, item)
+	}
+	assert.Equal(t, sequence(20), items)
+	assert.Len(t, errs, 1)
+}
+
+func TestPager_ContextCanceled(t *testing.T) {
+	server := &fakeServer{n: 100, delay: time.Minute}
+	p := New(server.list)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := p.List(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
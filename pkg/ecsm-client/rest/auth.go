@@ -0,0 +1,263 @@
+// file: pkg/ecsm-client/rest/auth.go
+
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// AuthConfig 描述了访问 ECSM API 所需的凭据。
+// BearerToken（或 BearerTokenFile）与 Username/Password 只能二选一；
+// 设置了 LoginPath 时，Username/Password 用于登录换取会话 token，否则作为 HTTP basic auth 发送。
+type AuthConfig struct {
+	BearerToken string
+	// BearerTokenFile 是保存 token 的文件，在创建客户端时读取一次。
+	BearerTokenFile string
+
+	Username string
+	Password string
+	// LoginPath 是 ECSM 登录接口的路径，例如 "/api/v1/login"。
+	LoginPath string
+}
+
+// IsEmpty 判断是否没有配置任何凭据。
+func (a AuthConfig) IsEmpty() bool {
+	return a == AuthConfig{}
+}
+
+// Validate 检查凭据的组合是否合法。
+func (a AuthConfig) Validate() error {
+	hasToken := a.BearerToken != "" || a.BearerTokenFile != ""
+	hasBasic := a.Username != "" || a.Password != ""
+	switch {
+	case a.BearerToken != "" && a.BearerTokenFile != "":
+		return errors.New("bearer token and bearer token file are mutually exclusive")
+	case hasToken && hasBasic:
+		return errors.New("bearer token and username/password are mutually exclusive")
+	case hasBasic && a.Username == "":
+		return errors.New("password is set but username is empty")
+	case a.LoginPath != "" && a.Username == "":
+		return errors.New("login path requires a username and password")
+	}
+	return nil
+}
+
+// WrapTransport 根据凭据在 rt 外面包装相应的 RoundTripper，没有配置凭据时原样返回 rt。
+// serverURL 是 ECSM API 的地址（例如 "https://ecsm:3001"），用于拼接登录接口的 URL。
+func (a AuthConfig) WrapTransport(serverURL string, rt http.RoundTripper) (http.RoundTripper, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	switch {
+	case a.BearerTokenFile != "":
+		data, err := os.ReadFile(a.BearerTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bearer token file: %w", err)
+		}
+		return NewBearerAuthRoundTripper(strings.TrimSpace(string(data)), rt), nil
+	case a.BearerToken != "":
+		return NewBearerAuthRoundTripper(a.BearerToken, rt), nil
+	case a.LoginPath != "":
+		base, err := url.Parse(serverURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse server url: %w", err)
+		}
+		// serverURL 带有路径前缀（例如反向代理的子路径）时，登录接口也在该前缀之下
+		loginURL := *base
+		loginURL.Path = path.Join("/", base.Path, a.LoginPath)
+		loginURL.RawPath, loginURL.RawQuery = "", ""
+		return NewLoginRoundTripper(loginURL.String(), a.Username, a.Password, rt), nil
+	case a.Username != "":
+		return NewBasicAuthRoundTripper(a.Username, a.Password, rt), nil
+	}
+	return rt, nil
+}
+
+// bearerAuthRoundTripper 为每个请求添加 "Authorization: Bearer <token>" 头。
+type bearerAuthRoundTripper struct {
+	token string
+	rt    http.RoundTripper
+}
+
+// NewBearerAuthRoundTripper 返回一个使用静态 token 的 RoundTripper。已经带有 Authorization 头的请求不会被修改。
+func NewBearerAuthRoundTripper(token string, rt http.RoundTripper) http.RoundTripper {
+	return &bearerAuthRoundTripper{token: token, rt: rt}
+}
+
+func (b *bearerAuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return b.rt.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	return b.rt.RoundTrip(req)
+}
+
+// basicAuthRoundTripper 为每个请求添加 HTTP basic auth 头。
+type basicAuthRoundTripper struct {
+	username string
+	password string
+	rt       http.RoundTripper
+}
+
+// NewBasicAuthRoundTripper 返回一个使用 HTTP basic auth 的 RoundTripper。已经带有 Authorization 头的请求不会被修改。
+func NewBasicAuthRoundTripper(username, password string, rt http.RoundTripper) http.RoundTripper {
+	return &basicAuthRoundTripper{username: username, password: password, rt: rt}
+}
+
+func (b *basicAuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return b.rt.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.SetBasicAuth(b.username, b.password)
+	return b.rt.RoundTrip(req)
+}
+
+// loginRoundTripper 在第一次请求前调用登录接口换取会话 token，并以 bearer token 的形式发送。
+// 会话过期、服务端返回 401 时，它会重新登录并重发一次请求。
+type loginRoundTripper struct {
+	loginURL string
+	username string
+	password string
+	rt       http.RoundTripper
+
+	mu    sync.Mutex
+	token string
+}
+
+// NewLoginRoundTripper 返回一个使用登录会话的 RoundTripper。
+// 登录请求以 JSON 形式 POST {"username": ..., "password": ...} 到 loginURL，
+// 响应信封的 data 可以是 token 字符串，也可以是带有 token 或 accessToken 字段的对象。
+func NewLoginRoundTripper(loginURL, username, password string, rt http.RoundTripper) http.RoundTripper {
+	return &loginRoundTripper{loginURL: loginURL, username: username, password: password, rt: rt}
+}
+
+func (l *loginRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := l.currentToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.rt.RoundTrip(withBearerToken(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// 请求体无法重放时只能把 401 交给调用方
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	klog.V(2).InfoS("ECSM session expired, logging in again", "url", l.loginURL)
+	l.invalidate(token)
+	token, err = l.currentToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	retry := withBearerToken(req, token)
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, fmt.Errorf("failed to replay request body: %w", err)
+		}
+	}
+	return l.rt.RoundTrip(retry)
+}
+
+// currentToken 返回当前的会话 token，没有时先登录。并发的请求只会触发一次登录。
+func (l *loginRoundTripper) currentToken(ctx context.Context) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.token != "" {
+		return l.token, nil
+	}
+	token, err := l.login(ctx)
+	if err != nil {
+		return "", err
+	}
+	l.token = token
+	return token, nil
+}
+
+// invalidate 丢弃已经失效的 token。其他请求已经换了新 token 时什么也不做。
+func (l *loginRoundTripper) invalidate(token string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.token == token {
+		l.token = ""
+	}
+}
+
+// login 调用登录接口并返回会话 token。
+func (l *loginRoundTripper) login(ctx context.Context) (string, error) {
+	body, err := json.Marshal(map[string]string{"username": l.username, "password": l.password})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.loginURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create login request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := l.rt.RoundTrip(req)
+	if err != nil {
+		return "", fmt.Errorf("login failed: %w", err)
+	}
+	result := &Result{body: resp.Body, statusCode: resp.StatusCode}
+	data, err := result.transformAndGetRawData()
+	if err != nil {
+		return "", fmt.Errorf("login failed: %w", err)
+	}
+
+	token := tokenFromLoginData(data)
+	if token == "" {
+		return "", errors.New("login failed: no token in response")
+	}
+	return token, nil
+}
+
+// tokenFromLoginData 从登录响应的 data 中取出 token。
+func tokenFromLoginData(data json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return s
+	}
+	var obj struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"accessToken"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return ""
+	}
+	if obj.Token != "" {
+		return obj.Token
+	}
+	return obj.AccessToken
+}
+
+func withBearerToken(req *http.Request, token string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
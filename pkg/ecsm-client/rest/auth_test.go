@@ -0,0 +1,148 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func writeOK(w http.ResponseWriter) {
+	fmt.Fprint(w, `{"status": 200, "message": "success", "data": null}`)
+}
+
+// TestAuth_StaticCredentials 测试静态 token 和 basic auth
+func TestAuth_StaticCredentials(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		auth AuthConfig
+		want string
+	}{
+		{name: "none", auth: AuthConfig{}, want: ""},
+		{name: "bearer token", auth: AuthConfig{BearerToken: "secret"}, want: "Bearer secret"},
+		{name: "bearer token file", auth: AuthConfig{BearerTokenFile: tokenFile}, want: "Bearer file-token"},
+		{name: "basic auth", auth: AuthConfig{Username: "admin", Password: "pass"}, want: "Basic YWRtaW46cGFzcw=="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get("Authorization")
+				writeOK(w)
+			}, withConfig(Config{AuthConfig: tt.auth}))
+			if err := client.Get().Resource("service").Do(context.Background()).Into(nil); err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected Authorization %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestAuth_Validate 测试互斥的凭据组合会被拒绝
+func TestAuth_Validate(t *testing.T) {
+	invalid := []AuthConfig{
+		{BearerToken: "a", BearerTokenFile: "b"},
+		{BearerToken: "a", Username: "admin"},
+		{Password: "pass"},
+		{LoginPath: "/api/v1/login"},
+	}
+	for _, auth := range invalid {
+		if _, err := auth.WrapTransport("http://localhost:3001", nil); err == nil {
+			t.Errorf("Expected %+v to be rejected", auth)
+		}
+	}
+}
+
+// sessionServer 模拟一个需要登录的 ECSM：每次登录签发一个新 token，expire 之后旧 token 失效。
+type sessionServer struct {
+	mu     sync.Mutex
+	logins int
+	valid  string
+	bodies []string
+}
+
+func (s *sessionServer) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.valid = ""
+}
+
+func (s *sessionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/api/v1/login" {
+		var creds map[string]string
+		json.NewDecoder(r.Body).Decode(&creds)
+		if creds["username"] != "admin" || creds["password"] != "pass" {
+			fmt.Fprint(w, `{"status": 401, "message": "invalid username or password"}`)
+			return
+		}
+		s.logins++
+		s.valid = fmt.Sprintf("session-%d", s.logins)
+		fmt.Fprintf(w, `{"status": 200, "message": "success", "data": {"token": %q}}`, s.valid)
+		return
+	}
+
+	if s.valid == "" || r.Header.Get("Authorization") != "Bearer "+s.valid {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	s.bodies = append(s.bodies, string(body))
+	writeOK(w)
+}
+
+// TestAuth_LoginSession 测试登录换取 token，以及会话过期后重新登录并重发请求
+func TestAuth_LoginSession(t *testing.T) {
+	server := &sessionServer{}
+	client := newTestClient(t, server.ServeHTTP,
+		withConfig(Config{AuthConfig: AuthConfig{Username: "admin", Password: "pass", LoginPath: "/api/v1/login"}}))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := client.Get().Resource("service").Do(ctx).Into(nil); err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+	}
+	if server.logins != 1 {
+		t.Errorf("Expected the session to be reused, got %d logins", server.logins)
+	}
+
+	// 会话过期后，带请求体的请求在重新登录后被完整重发
+	server.expire()
+	if err := client.Post().Resource("service").Body(map[string]string{"name": "web"}).Do(ctx).Into(nil); err != nil {
+		t.Fatalf("Request after session expiry failed: %v", err)
+	}
+	if server.logins != 2 {
+		t.Errorf("Expected a second login, got %d logins", server.logins)
+	}
+	if last := server.bodies[len(server.bodies)-1]; last != `{"name":"web"}` {
+		t.Errorf("Expected the body to be replayed, got %q", last)
+	}
+}
+
+// TestAuth_LoginFailure 测试登录失败时返回可以分类的错误
+func TestAuth_LoginFailure(t *testing.T) {
+	server := &sessionServer{}
+	client := newTestClient(t, server.ServeHTTP,
+		withConfig(Config{AuthConfig: AuthConfig{Username: "admin", Password: "wrong", LoginPath: "/api/v1/login"}}))
+
+	err := client.Get().Resource("service").Do(context.Background()).Into(nil)
+	if !IsUnauthorized(err) {
+		t.Fatalf("Expected unauthorized error, got %v", err)
+	}
+}
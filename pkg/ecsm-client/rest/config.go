@@ -0,0 +1,278 @@
+// file: pkg/ecsm-client/rest/config.go
+
+package rest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// Config 描述了如何连接一个 ECSM API Server，字段的含义与 kubeconfig 中的 cluster/user 类似。
+type Config struct {
+	// Host 是 ECSM API Server 的地址，例如 "https://192.168.31.129:3001"。
+	// ECSM 部署在反向代理的子路径下时，Host 可以带有路径前缀，例如 "https://gateway.example.com/ecsm"。
+	Host string
+	// APIPath 是 Host 之后、APIVersion 之前的路径，为空时使用 "/api"，为 "/" 时没有这一段。
+	APIPath string
+	// APIVersion 为空时使用 "v1"。
+	APIVersion string
+
+	// AuthConfig 是访问 ECSM API 使用的凭据。
+	AuthConfig
+
+	// TLSClientConfig 在 Host 为 https 时生效。
+	TLSClientConfig TLSClientConfig
+
+	// UserAgent 为空时使用 DefaultUserAgent()。
+	UserAgent string
+
+	// Timeout 是单次 HTTP 请求（包括读取响应体）的超时时间，0 表示不超时。
+	Timeout time.Duration
+
+	// Transport 替换默认的底层 http.RoundTripper，例如测试中使用的 fake 或录制用的 Transport。
+	// 设置了 Transport 时不能再设置 TLSClientConfig，认证信息仍然会包装在它外面。
+	Transport http.RoundTripper
+
+	// WrapTransport 在底层 Transport 外面、认证之前包装一层 RoundTripper，
+	// 因此它看到的请求已经带有认证头，可以用于埋点或者记录请求。
+	WrapTransport func(rt http.RoundTripper) http.RoundTripper
+
+	// RetryPolicy 为 nil 时使用 DefaultRetryPolicy()。
+	RetryPolicy *RetryPolicy
+
+	// QPS 和 Burst 设置客户端令牌桶限流，为 0 时使用 DefaultQPS 和 DefaultBurst，QPS 小于 0 时不限流。
+	QPS   float32
+	Burst int
+	// RateLimiter 不为 nil 时忽略 QPS 和 Burst，可以用于让多个客户端共享同一个限流器。
+	RateLimiter flowcontrol.RateLimiter
+}
+
+// DefaultUserAgent 返回默认的 User-Agent，例如 "ecsm-cli (linux/amd64) ecsm-client"。
+func DefaultUserAgent() string {
+	return fmt.Sprintf("%s (%s/%s) ecsm-client", filepath.Base(os.Args[0]), runtime.GOOS, runtime.GOARCH)
+}
+
+// TLSClientConfig 包含了连接 ECSM API Server 时的 TLS 设置。
+// *File 和 *Data 成对出现，同时设置时以 *Data 为准。
+type TLSClientConfig struct {
+	// Insecure 为 true 时不校验服务端证书，只应在测试环境中使用。
+	Insecure bool
+	// ServerName 用于校验服务端证书，为空时使用 Host 中的主机名。
+	ServerName string
+
+	// CAFile/CAData 是用于校验服务端证书的 CA 证书（PEM），为空时使用系统的根证书。
+	CAFile string
+	CAData []byte
+
+	// CertFile/CertData 和 KeyFile/KeyData 是向服务端出示的客户端证书和私钥（PEM）。
+	CertFile string
+	CertData []byte
+	KeyFile  string
+	KeyData  []byte
+}
+
+// HasCA 判断是否配置了自定义 CA。
+func (c TLSClientConfig) HasCA() bool {
+	return len(c.CAData) > 0 || c.CAFile != ""
+}
+
+// HasCertAuth 判断是否配置了客户端证书。
+func (c TLSClientConfig) HasCertAuth() bool {
+	return (len(c.CertData) > 0 || c.CertFile != "") && (len(c.KeyData) > 0 || c.KeyFile != "")
+}
+
+// validate 检查 TLS 设置的组合是否合法。
+func (c TLSClientConfig) validate() error {
+	hasCert := len(c.CertData) > 0 || c.CertFile != ""
+	hasKey := len(c.KeyData) > 0 || c.KeyFile != ""
+	switch {
+	case c.Insecure && c.HasCA():
+		return errors.New("specifying a root certificates file with the insecure flag is not allowed")
+	case hasCert != hasKey:
+		return errors.New("client certificate and client key must be specified together")
+	}
+	return nil
+}
+
+// TLSConfigFor 根据 config 构造 *tls.Config，没有任何 TLS 设置时返回 nil（使用默认设置）。
+func TLSConfigFor(config *Config) (*tls.Config, error) {
+	c := config.TLSClientConfig
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	if !c.Insecure && c.ServerName == "" && !c.HasCA() && !c.HasCertAuth() {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.Insecure,
+		ServerName:         c.ServerName,
+	}
+
+	if c.HasCA() {
+		caData, err := dataFromSliceOrFile(c.CAData, c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, errors.New("no valid PEM certificates found in CA data")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.HasCertAuth() {
+		certData, err := dataFromSliceOrFile(c.CertData, c.CertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client certificate: %w", err)
+		}
+		keyData, err := dataFromSliceOrFile(c.KeyData, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client key: %w", err)
+		}
+		cert, err := tls.X509KeyPair(certData, keyData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// TransportFor 根据 config 构造带有 TLS 设置、认证信息和 User-Agent 的 http.RoundTripper。
+// 从内到外依次是：底层 Transport、WrapTransport、认证、User-Agent。
+func TransportFor(config *Config) (http.RoundTripper, error) {
+	if _, err := parseHost(config.Host); err != nil {
+		return nil, err
+	}
+
+	var rt http.RoundTripper
+	if config.Transport != nil {
+		c := config.TLSClientConfig
+		if c.Insecure || c.ServerName != "" || c.HasCA() || c.HasCertAuth() {
+			return nil, errors.New("using a custom transport with TLS certificate options or the insecure flag is not allowed")
+		}
+		rt = config.Transport
+	} else {
+		tlsConfig, err := TLSConfigFor(config)
+		if err != nil {
+			return nil, err
+		}
+		rt = http.DefaultTransport
+		if tlsConfig != nil {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = tlsConfig
+			rt = transport
+		}
+	}
+
+	if config.WrapTransport != nil {
+		rt = config.WrapTransport(rt)
+	}
+	rt, err := config.AuthConfig.WrapTransport(config.Host, rt)
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent := config.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent()
+	}
+	return &userAgentRoundTripper{agent: userAgent, rt: rt}, nil
+}
+
+// HTTPClientFor 根据 config 构造访问 ECSM API 使用的 http.Client。
+func HTTPClientFor(config *Config) (*http.Client, error) {
+	rt, err := TransportFor(config)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: rt, Timeout: config.Timeout}, nil
+}
+
+// RESTClientFor 根据 config 构造 RESTClient。
+func RESTClientFor(config *Config) (*RESTClient, error) {
+	baseURL, err := parseHost(config.Host)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := HTTPClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	client := newRESTClient(baseURL, config.APIPath, config.APIVersion, httpClient)
+	if config.RetryPolicy != nil {
+		client.SetRetryPolicy(*config.RetryPolicy)
+	}
+	client.SetRateLimiter(rateLimiterFor(config))
+	return client, nil
+}
+
+// rateLimiterFor 根据 config 返回客户端限流器，不限流时返回 nil。
+func rateLimiterFor(config *Config) flowcontrol.RateLimiter {
+	if config.RateLimiter != nil {
+		return config.RateLimiter
+	}
+	qps, burst := config.QPS, config.Burst
+	if qps < 0 {
+		return nil
+	}
+	if qps == 0 {
+		qps = DefaultQPS
+	}
+	if burst <= 0 {
+		burst = DefaultBurst
+	}
+	return flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+}
+
+// parseHost 解析 Config.Host，它必须是带有 http 或 https scheme 的 URL。
+func parseHost(host string) (*url.URL, error) {
+	if host == "" {
+		return nil, errors.New("host must be specified")
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("host %q must be a URL of the form http(s)://host[:port][/path]", host)
+	}
+	return u, nil
+}
+
+// userAgentRoundTripper 为没有 User-Agent 的请求设置 User-Agent 头。
+type userAgentRoundTripper struct {
+	agent string
+	rt    http.RoundTripper
+}
+
+func (u *userAgentRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") != "" {
+		return u.rt.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", u.agent)
+	return u.rt.RoundTrip(req)
+}
+
+// dataFromSliceOrFile 优先返回 data，data 为空时读取 file。
+func dataFromSliceOrFile(data []byte, file string) ([]byte, error) {
+	if len(data) > 0 {
+		return data, nil
+	}
+	return os.ReadFile(file)
+}
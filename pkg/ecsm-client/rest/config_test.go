@@ -0,0 +1,271 @@
+package rest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// certPEM 把证书编码成 PEM。
+func certPEM(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// newClientCertificate 生成一个自签名的客户端证书，返回证书和私钥的 PEM。
+func newClientCertificate(t *testing.T) (certData, keyData []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ecsm-operator"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certPEM(der), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// TestHTTPClientFor_ServerVerification 测试使用自定义 CA、ServerName 和 Insecure 校验自签名的服务端证书
+func TestHTTPClientFor_ServerVerification(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeOK(w)
+	}))
+	defer server.Close()
+
+	caData := certPEM(server.Certificate().Raw)
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caFile, caData, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tls     TLSClientConfig
+		wantErr bool
+	}{
+		{name: "system roots", tls: TLSClientConfig{}, wantErr: true},
+		{name: "ca data", tls: TLSClientConfig{CAData: caData}},
+		{name: "ca file", tls: TLSClientConfig{CAFile: caFile}},
+		{name: "matching server name", tls: TLSClientConfig{CAData: caData, ServerName: "example.com"}},
+		{name: "wrong server name", tls: TLSClientConfig{CAData: caData, ServerName: "ecsm.example.org"}, wantErr: true},
+		{name: "insecure", tls: TLSClientConfig{Insecure: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, nil, withServer(server), withConfig(Config{TLSClientConfig: tt.tls}))
+			err := client.Get().Resource("service").Do(context.Background()).Into(nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error: %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestHTTPClientFor_ClientCertificate 测试向要求双向认证的服务端出示客户端证书，并同时携带 token
+func TestHTTPClientFor_ClientCertificate(t *testing.T) {
+	certData, keyData := newClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(certData)
+
+	var gotAuth, gotCN string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotCN = r.TLS.PeerCertificates[0].Subject.CommonName
+		writeOK(w)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+	caData := certPEM(server.Certificate().Raw)
+
+	client := newTestClient(t, nil, withServer(server), withConfig(Config{TLSClientConfig: TLSClientConfig{CAData: caData}}))
+	if err := client.Get().Resource("service").Do(context.Background()).Into(nil); err == nil {
+		t.Error("Expected the handshake to fail without a client certificate")
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certFile, certData, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyData, 0600); err != nil {
+		t.Fatal(err)
+	}
+	client = newTestClient(t, nil, withServer(server), withConfig(Config{
+		AuthConfig:      AuthConfig{BearerToken: "secret"},
+		TLSClientConfig: TLSClientConfig{CAData: caData, CertFile: certFile, KeyFile: keyFile},
+	}))
+	if err := client.Get().Resource("service").Do(context.Background()).Into(nil); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if gotCN != "ecsm-operator" {
+		t.Errorf("Expected client certificate CN %q, got %q", "ecsm-operator", gotCN)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Expected Authorization %q, got %q", "Bearer secret", gotAuth)
+	}
+}
+
+// TestTLSConfigFor_Validate 测试非法的 TLS 设置会被拒绝
+func TestTLSConfigFor_Validate(t *testing.T) {
+	invalid := []TLSClientConfig{
+		{Insecure: true, CAFile: "ca.crt"},
+		{CertFile: "client.crt"},
+		{KeyData: []byte("key")},
+		{CAData: []byte("not a certificate")},
+		{CAFile: filepath.Join(t.TempDir(), "missing.crt")},
+	}
+	for _, c := range invalid {
+		if _, err := TLSConfigFor(&Config{Host: "https://localhost:3001", TLSClientConfig: c}); err == nil {
+			t.Errorf("Expected %+v to be rejected", c)
+		}
+	}
+
+	if tlsConfig, err := TLSConfigFor(&Config{Host: "https://localhost:3001"}); err != nil || tlsConfig != nil {
+		t.Errorf("Expected no TLS config without TLS settings, got %v, %v", tlsConfig, err)
+	}
+}
+
+// countingRoundTripper 记录经过它的请求
+type countingRoundTripper struct {
+	rt       http.RoundTripper
+	requests []*http.Request
+}
+
+func (c *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.requests = append(c.requests, req)
+	return c.rt.RoundTrip(req)
+}
+
+// TestRESTClientFor 测试路径前缀、User-Agent、WrapTransport 和自定义 Transport
+func TestRESTClientFor(t *testing.T) {
+	var gotPath, gotAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAgent = r.URL.Path, r.Header.Get("User-Agent")
+		writeOK(w)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		config   Config
+		wantPath string
+	}{
+		{name: "defaults", config: Config{Host: server.URL}, wantPath: "/api/v1/service/abc"},
+		{name: "reverse proxy sub-path", config: Config{Host: server.URL + "/ecsm/"}, wantPath: "/ecsm/api/v1/service/abc"},
+		{name: "custom api path and version", config: Config{Host: server.URL, APIPath: "/gateway/ecsm-api", APIVersion: "v2"}, wantPath: "/gateway/ecsm-api/v2/service/abc"},
+		{name: "no api path", config: Config{Host: server.URL, APIPath: "/"}, wantPath: "/v1/service/abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := RESTClientFor(&tt.config)
+			if err != nil {
+				t.Fatalf("Failed to create REST client: %v", err)
+			}
+			if err := client.Get().Resource("service").Name("abc").Do(context.Background()).Into(nil); err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if gotPath != tt.wantPath {
+				t.Errorf("Expected path %q, got %q", tt.wantPath, gotPath)
+			}
+			if gotAgent != DefaultUserAgent() {
+				t.Errorf("Expected User-Agent %q, got %q", DefaultUserAgent(), gotAgent)
+			}
+		})
+	}
+
+	t.Run("transport wrappers", func(t *testing.T) {
+		base := &countingRoundTripper{rt: http.DefaultTransport}
+		var wrapped *countingRoundTripper
+		client, err := RESTClientFor(&Config{
+			Host:       server.URL,
+			UserAgent:  "ecsm-operator/test",
+			AuthConfig: AuthConfig{BearerToken: "secret"},
+			Transport:  base,
+			WrapTransport: func(rt http.RoundTripper) http.RoundTripper {
+				wrapped = &countingRoundTripper{rt: rt}
+				return wrapped
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create REST client: %v", err)
+		}
+		if err := client.Get().Resource("node").Do(context.Background()).Into(nil); err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if len(base.requests) != 1 || len(wrapped.requests) != 1 {
+			t.Fatalf("Expected the request to go through both transports, got %d and %d", len(base.requests), len(wrapped.requests))
+		}
+		if got := wrapped.requests[0].Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Expected the wrapper to see the Authorization header, got %q", got)
+		}
+		if gotAgent != "ecsm-operator/test" {
+			t.Errorf("Expected User-Agent %q, got %q", "ecsm-operator/test", gotAgent)
+		}
+	})
+}
+
+// TestRESTClientFor_Invalid 测试非法的 Config 会被拒绝
+func TestRESTClientFor_Invalid(t *testing.T) {
+	invalid := []Config{
+		{},
+		{Host: "192.168.31.129:3001"},
+		{Host: "ftp://192.168.31.129"},
+		{Host: "https://192.168.31.129:3001", Transport: http.DefaultTransport, TLSClientConfig: TLSClientConfig{Insecure: true}},
+		{Host: "https://192.168.31.129:3001", AuthConfig: AuthConfig{Password: "pass"}},
+	}
+	for _, config := range invalid {
+		if _, err := RESTClientFor(&config); err == nil {
+			t.Errorf("Expected %+v to be rejected", config)
+		}
+	}
+}
+
+// TestRESTClientFor_LoginUnderSubPath 测试 Host 带有路径前缀时登录接口也在该前缀之下
+func TestRESTClientFor_LoginUnderSubPath(t *testing.T) {
+	session := &sessionServer{}
+	server := httptest.NewServer(http.StripPrefix("/ecsm", session))
+	defer server.Close()
+
+	client, err := RESTClientFor(&Config{
+		Host:       server.URL + "/ecsm",
+		AuthConfig: AuthConfig{Username: "admin", Password: "pass", LoginPath: "/api/v1/login"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create REST client: %v", err)
+	}
+	if err := client.Get().Resource("service").Do(context.Background()).Into(nil); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if session.logins != 1 {
+		t.Errorf("Expected one login, got %d", session.logins)
+	}
+}
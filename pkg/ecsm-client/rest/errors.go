@@ -0,0 +1,213 @@
+// file: pkg/ecsm-client/rest/errors.go
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// FieldError 描述了请求中某个字段的错误。
+type FieldError struct {
+	// Field 是出错的字段，无法从错误信息中识别时为空。
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) String() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// Parse 把 fieldErrors 解析为逐个字段的错误。支持以下几种格式：
+//   - JSON 数组，元素为 {"field": ..., "message": ...} 对象或字符串；
+//   - JSON 对象，key 为字段名，值为一条或多条错误信息；
+//   - 以分号或换行分隔的文本，每一条的形式为 "field: message" 或单独的 message。
+func (f FieldErrors) Parse() []FieldError {
+	text := strings.TrimSpace(string(f))
+	if text == "" {
+		return nil
+	}
+
+	switch text[0] {
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal([]byte(text), &items); err == nil {
+			var causes []FieldError
+			for _, item := range items {
+				causes = append(causes, parseFieldErrorItem(item)...)
+			}
+			return causes
+		}
+	case '{':
+		var byField map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(text), &byField); err == nil {
+			fields := make([]string, 0, len(byField))
+			for field := range byField {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+
+			var causes []FieldError
+			for _, field := range fields {
+				for _, msg := range jsonMessages(byField[field]) {
+					causes = append(causes, FieldError{Field: field, Message: msg})
+				}
+			}
+			return causes
+		}
+	}
+
+	var causes []FieldError
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == ';' || r == '\n' }) {
+		if line = strings.TrimSpace(line); line != "" {
+			causes = append(causes, parseFieldErrorText(line))
+		}
+	}
+	return causes
+}
+
+// parseFieldErrorItem 解析 fieldErrors 数组中的一个元素。
+func parseFieldErrorItem(item json.RawMessage) []FieldError {
+	var s string
+	if err := json.Unmarshal(item, &s); err == nil {
+		return []FieldError{parseFieldErrorText(s)}
+	}
+	var obj struct {
+		Field   string `json:"field"`
+		Name    string `json:"name"`
+		Message string `json:"message"`
+		Msg     string `json:"msg"`
+	}
+	if err := json.Unmarshal(item, &obj); err != nil {
+		return []FieldError{{Message: string(item)}}
+	}
+	field, msg := obj.Field, obj.Message
+	if field == "" {
+		field = obj.Name
+	}
+	if msg == "" {
+		msg = obj.Msg
+	}
+	return []FieldError{{Field: field, Message: msg}}
+}
+
+// parseFieldErrorText 解析 "field: message" 形式的文本，字段名中不能有空格。
+func parseFieldErrorText(text string) FieldError {
+	field, msg, ok := strings.Cut(text, ":")
+	field = strings.TrimSpace(field)
+	if !ok || field == "" || strings.ContainsAny(field, " \t") {
+		return FieldError{Message: strings.TrimSpace(text)}
+	}
+	return FieldError{Field: field, Message: strings.TrimSpace(msg)}
+}
+
+// jsonMessages 把一条或多条 JSON 错误信息转换为字符串。
+func jsonMessages(raw json.RawMessage) []string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return []string{s}
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+	return []string{string(raw)}
+}
+
+// newHTTPError 为 HTTP 状态码不是 2xx、且响应体不是 ECSM 错误信封的响应构造错误，
+// 例如反向代理返回的 HTML 错误页面。
+func newHTTPError(statusCode int, body []byte) *Aerror {
+	msg := http.StatusText(statusCode)
+	if msg == "" {
+		msg = fmt.Sprintf("unexpected HTTP status %d", statusCode)
+	}
+	if snippet := bodySnippet(body); snippet != "" {
+		msg = fmt.Sprintf("%s: %s", msg, snippet)
+	}
+	return &Aerror{Status: statusCode, Message: msg, HTTPStatus: statusCode}
+}
+
+// maxBodySnippet 是错误信息中最多包含的响应体长度
+const maxBodySnippet = 256
+
+// bodySnippet 返回适合放进错误信息的响应体片段，HTML 页面不包含在内。
+func bodySnippet(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if s == "" || strings.HasPrefix(s, "<") {
+		return ""
+	}
+	if len(s) > maxBodySnippet {
+		s = s[:maxBodySnippet] + "..."
+	}
+	return s
+}
+
+// ErrorCode 返回 err 链中 *Aerror 的状态码，不是 *Aerror 时返回 0。
+func ErrorCode(err error) int {
+	var aerr *Aerror
+	if errors.As(err, &aerr) {
+		return aerr.Code()
+	}
+	return 0
+}
+
+// IsNotFound 判断 err 是否表示请求的资源不存在。
+func IsNotFound(err error) bool {
+	return ErrorCode(err) == http.StatusNotFound
+}
+
+// IsConflict 判断 err 是否表示请求与资源的当前状态冲突，例如名称已被占用。
+func IsConflict(err error) bool {
+	return ErrorCode(err) == http.StatusConflict
+}
+
+// IsInvalid 判断 err 是否表示请求参数校验失败，具体的字段错误可以通过 (*Aerror).Causes 获取。
+func IsInvalid(err error) bool {
+	code := ErrorCode(err)
+	return code == http.StatusBadRequest || code == http.StatusUnprocessableEntity
+}
+
+// IsUnauthorized 判断 err 是否表示请求没有携带有效的凭据。
+func IsUnauthorized(err error) bool {
+	return ErrorCode(err) == http.StatusUnauthorized
+}
+
+// IsForbidden 判断 err 是否表示凭据有效但没有执行该操作的权限。
+func IsForbidden(err error) bool {
+	return ErrorCode(err) == http.StatusForbidden
+}
+
+// IsTooManyRequests 判断 err 是否表示服务端在限流。
+func IsTooManyRequests(err error) bool {
+	return ErrorCode(err) == http.StatusTooManyRequests
+}
+
+// IsServerTimeout 判断 err 是否表示请求在服务端或网关超时。
+func IsServerTimeout(err error) bool {
+	code := ErrorCode(err)
+	return code == http.StatusGatewayTimeout || code == http.StatusRequestTimeout
+}
+
+// IsServiceUnavailable 判断 err 是否表示服务端或网关暂时不可用。
+func IsServiceUnavailable(err error) bool {
+	code := ErrorCode(err)
+	return code == http.StatusServiceUnavailable || code == http.StatusBadGateway
+}
+
+// IsInternalError 判断 err 是否表示服务端内部错误。
+func IsInternalError(err error) bool {
+	return ErrorCode(err) == http.StatusInternalServerError
+}
+
+// IsServerError 判断 err 是否是任意的 5xx 错误。
+func IsServerError(err error) bool {
+	code := ErrorCode(err)
+	return code >= 500 && code <= 599
+}
@@ -0,0 +1,232 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// testClientOptions 是 newTestClient 的可选设置。
+type testClientOptions struct {
+	server *httptest.Server
+	config *Config
+}
+
+type testClientOption func(*testClientOptions)
+
+// withConfig 让 newTestClient 按 config 中的认证等设置构造 http.Client，config.Host 会被替换为测试服务器的地址。
+func withConfig(config Config) testClientOption {
+	return func(o *testClientOptions) { o.config = &config }
+}
+
+// withServer 让 newTestClient 使用调用者创建并启动的服务器（例如 TLS 服务器），此时 handler 应该为 nil。
+func withServer(server *httptest.Server) testClientOption {
+	return func(o *testClientOptions) { o.server = server }
+}
+
+// newTestClient 创建一个指向 httptest 服务器、不自动重试的 RESTClient。
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...testClientOption) *RESTClient {
+	t.Helper()
+	var o testClientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	server := o.server
+	if server == nil {
+		server = httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+	}
+
+	httpClient := server.Client()
+	if o.config != nil {
+		o.config.Host = server.URL
+		var err error
+		if httpClient, err = HTTPClientFor(o.config); err != nil {
+			t.Fatalf("Failed to create HTTP client: %v", err)
+		}
+	}
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+	client, err := NewRESTClient(u.Scheme, u.Hostname(), u.Port(), httpClient)
+	if err != nil {
+		t.Fatalf("Failed to create REST client: %v", err)
+	}
+	client.SetRetryPolicy(RetryPolicy{})
+	return client
+}
+
+// TestResult_ErrorClassification 测试不同的错误响应被归类为正确的错误类型
+func TestResult_ErrorClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		check      func(error) bool
+		wantCode   int
+	}{
+		{
+			name:       "envelope not found with HTTP 200",
+			statusCode: http.StatusOK,
+			body:       `{"status": 404, "message": "service not found", "data": null}`,
+			check:      IsNotFound,
+			wantCode:   404,
+		},
+		{
+			name:       "envelope conflict with HTTP 409",
+			statusCode: http.StatusConflict,
+			body:       `{"status": 409, "message": "name already exists"}`,
+			check:      IsConflict,
+			wantCode:   409,
+		},
+		{
+			name:       "validation error",
+			statusCode: http.StatusOK,
+			body:       `{"status": 400, "message": "Bad Request", "fieldErrors": "name: must not be empty"}`,
+			check:      IsInvalid,
+			wantCode:   400,
+		},
+		{
+			name:       "unauthorized without envelope",
+			statusCode: http.StatusUnauthorized,
+			body:       `Unauthorized`,
+			check:      IsUnauthorized,
+			wantCode:   401,
+		},
+		{
+			name:       "proxy bad gateway page",
+			statusCode: http.StatusBadGateway,
+			body:       `<html><body><h1>502 Bad Gateway</h1></body></html>`,
+			check:      IsServiceUnavailable,
+			wantCode:   502,
+		},
+		{
+			name:       "proxy gateway timeout page",
+			statusCode: http.StatusGatewayTimeout,
+			body:       `<html><body><h1>504 Gateway Time-out</h1></body></html>`,
+			check:      IsServerTimeout,
+			wantCode:   504,
+		},
+		{
+			name:       "envelope internal error",
+			statusCode: http.StatusInternalServerError,
+			body:       `{"status": 500, "message": "database is locked"}`,
+			check:      IsInternalError,
+			wantCode:   500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				fmt.Fprint(w, tt.body)
+			})
+
+			err := client.Get().Resource("service").Do(context.Background()).Into(&struct{}{})
+			if err == nil {
+				t.Fatal("Expected error, but got nil")
+			}
+			if !tt.check(err) {
+				t.Errorf("Error %q was not classified correctly", err)
+			}
+			if code := ErrorCode(err); code != tt.wantCode {
+				t.Errorf("Expected code %d, got %d", tt.wantCode, code)
+			}
+			if tt.wantCode != 404 && IsNotFound(err) {
+				t.Errorf("Error %q must not be classified as not found", err)
+			}
+			// 包装之后仍然可以分类
+			if wrapped := fmt.Errorf("failed to get service: %w", err); !tt.check(wrapped) {
+				t.Errorf("Wrapped error %q was not classified correctly", wrapped)
+			}
+		})
+	}
+}
+
+// TestResult_RawChecksHTTPStatus 测试 Raw 在 HTTP 状态码不是 2xx 时返回错误和响应体
+func TestResult_RawChecksHTTPStatus(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, "upstream connect error")
+	})
+
+	body, err := client.Get().Resource("node").Do(context.Background()).Raw()
+	if !IsServiceUnavailable(err) {
+		t.Fatalf("Expected service unavailable error, got %v", err)
+	}
+	if string(body) != "upstream connect error" {
+		t.Errorf("Expected the response body to be returned, got %q", body)
+	}
+	want := "ecsm api error (status 503): Service Unavailable: upstream connect error"
+	if err.Error() != want {
+		t.Errorf("Expected error %q, got %q", want, err.Error())
+	}
+}
+
+// TestFieldErrors_Parse 测试 fieldErrors 的各种格式
+func TestFieldErrors_Parse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []FieldError
+	}{
+		{
+			name: "null",
+			body: `{"status": 400, "message": "Bad Request", "fieldErrors": null}`,
+			want: nil,
+		},
+		{
+			name: "plain message",
+			body: `{"status": 400, "message": "Bad Request", "fieldErrors": "Invalid parameters"}`,
+			want: []FieldError{{Message: "Invalid parameters"}},
+		},
+		{
+			name: "field messages separated by semicolons",
+			body: `{"status": 400, "message": "Bad Request", "fieldErrors": "name: must not be empty; factor: must be positive"}`,
+			want: []FieldError{
+				{Field: "name", Message: "must not be empty"},
+				{Field: "factor", Message: "must be positive"},
+			},
+		},
+		{
+			name: "array of objects",
+			body: `{"status": 400, "message": "Bad Request", "fieldErrors": [{"field": "image.ref", "message": "not found"}, "node: offline"]}`,
+			want: []FieldError{
+				{Field: "image.ref", Message: "not found"},
+				{Field: "node", Message: "offline"},
+			},
+		},
+		{
+			name: "object keyed by field",
+			body: `{"status": 400, "message": "Bad Request", "fieldErrors": {"policy": "unknown policy", "name": ["too long", "invalid character"]}}`,
+			want: []FieldError{
+				{Field: "name", Message: "too long"},
+				{Field: "name", Message: "invalid character"},
+				{Field: "policy", Message: "unknown policy"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.body)
+			})
+
+			err := client.Post().Resource("service").Do(context.Background()).Into(nil)
+			if !IsInvalid(err) {
+				t.Fatalf("Expected invalid error, got %v", err)
+			}
+			aerr := err.(*Aerror)
+			if got := aerr.Causes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected causes %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
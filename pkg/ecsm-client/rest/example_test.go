@@ -56,18 +56,10 @@ func ExampleRESTClient() {
 }
 
 // TestManualRESTClient 手动测试函数，可以用来快速验证与真实 API 的连接
-// 运行方式: go test -v -run TestManualRESTClient
+// 运行方式: ECSM_TEST_SERVER=http://192.168.31.129:3001 go test -v -run TestManualRESTClient
 func TestManualRESTClient(t *testing.T) {
-	// 如果不想运行真实 API 测试，可以跳过
-	// t.Skip("Manual test - uncomment to run against real ECSM API")
-
-	// 创建客户端
-	client, err := NewRESTClient("http", "192.168.31.129", "3001", &http.Client{
-		Timeout: 10 * time.Second,
-	})
-	if err != nil {
-		t.Fatalf("Failed to create REST client: %v", err)
-	}
+	// 没有设置 ECSM_TEST_SERVER 时跳过
+	client := newLiveRESTClient(t)
 
 	// 测试获取服务列表
 	t.Log("Testing GET /api/v1/service")
@@ -79,7 +71,7 @@ func TestManualRESTClient(t *testing.T) {
 		Do(ctx)
 
 	var serviceList ServiceListResponse
-	err = result.Into(&serviceList)
+	err := result.Into(&serviceList)
 	if err != nil {
 		t.Fatalf("Failed to get services: %v", err)
 	}
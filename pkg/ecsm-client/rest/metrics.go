@@ -0,0 +1,34 @@
+// file: pkg/ecsm-client/rest/metrics.go
+
+package rest
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// LatencyMetric 观察一段耗时，例如请求因为客户端限流而等待的时间。
+// 它与 client-go 的 tools/metrics 类似，调用方可以把它适配到 Prometheus 等监控系统。
+type LatencyMetric interface {
+	Observe(ctx context.Context, verb string, u url.URL, latency time.Duration)
+}
+
+// RateLimiterLatency 记录每个请求（包括重试）在客户端限流器上等待的时间，默认什么也不做。
+var RateLimiterLatency LatencyMetric = noopLatency{}
+
+var registerMetrics sync.Once
+
+// RegisterMetrics 设置 ecsm-client 上报的指标，只有第一次调用生效。
+func RegisterMetrics(rateLimiterLatency LatencyMetric) {
+	registerMetrics.Do(func() {
+		if rateLimiterLatency != nil {
+			RateLimiterLatency = rateLimiterLatency
+		}
+	})
+}
+
+type noopLatency struct{}
+
+func (noopLatency) Observe(context.Context, string, url.URL, time.Duration) {}
@@ -25,6 +25,8 @@ type Request struct {
 	body      interface{}
 	err       error
 	params    url.Values
+	// noRetry 为 true 时即使 RetryPolicy 允许也不重试
+	noRetry bool
 }
 
 func NewRequest(c *RESTClient) *Request {
@@ -88,7 +90,15 @@ func (r *Request) Param(key, value string) *Request {
 	return r
 }
 
+// NoRetry 禁止该请求的自动重试，例如调用方自己实现了重试逻辑，或者请求不能重复执行。
+func (r *Request) NoRetry() *Request {
+	r.noRetry = true
+	return r
+}
+
 // Do 执行请求并返回一个 Result 对象。
+// 对于 RESTClient 的 RetryPolicy 允许重试的请求，网络错误和表示服务端暂时不可用的响应会按退避策略重试，
+// 重试时会重新发送相同的请求体。
 func (r *Request) Do(ctx context.Context) *Result {
 	if r.err != nil {
 		return &Result{err: r.err}
@@ -99,47 +109,84 @@ func (r *Request) Do(ctx context.Context) *Result {
 	resourcePath := strings.Join(r.pathParts, "/")
 
 	// --- 关键修正 ---
-	// 我们必须在这里包含 API 的基础路径 "api"。
-	p := path.Join(defaultAPIPath, r.c.apiVersion, resourcePath)
-
-	fullURL := r.c.baseURL.ResolveReference(&url.URL{Path: p})
+	// 我们必须在这里包含 API 的基础路径（默认是 "api"），以及 baseURL 自带的路径前缀（例如反向代理的子路径）。
+	fullURL := *r.c.baseURL
+	fullURL.Path = path.Join("/", r.c.baseURL.Path, r.c.apiPath, r.c.apiVersion, resourcePath)
+	fullURL.RawPath = ""
 
 	if len(r.params) > 0 {
 		fullURL.RawQuery = r.params.Encode()
 	}
 
-	// 2. 序列化 Body
-	var bodyReader io.Reader
+	// 2. 序列化 Body。序列化的结果在重试时会被重新发送
+	var data []byte
 	if r.body != nil {
-		data, err := json.Marshal(r.body)
+		var err error
+		data, err = json.Marshal(r.body)
 		if err != nil {
 			r.err = fmt.Errorf("failed to marshal body: %w", err)
 			return &Result{err: r.err}
 		}
-		bodyReader = bytes.NewBuffer(data)
 	}
 
-	// 3. 创建 HTTP Request
-	req, err := http.NewRequestWithContext(ctx, r.verb, fullURL.String(), bodyReader)
-	if err != nil {
-		r.err = fmt.Errorf("failed to create request: %w", err)
-		return &Result{err: r.err}
+	policy := r.c.retryPolicy
+	maxAttempts := 1
+	if !r.noRetry && policy.allowsVerb(r.verb) {
+		maxAttempts = policy.MaxAttempts
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
 
-	// 4. 执行请求
-	klog.V(4).InfoS("Executing request", "method", req.Method, "url", req.URL)
-	resp, err := r.c.httpClient.Do(req)
-	if err != nil {
-		r.err = fmt.Errorf("request failed: %w", err)
-		return &Result{err: r.err}
-	}
+	for attempt := 1; ; attempt++ {
+		// 3. 等待客户端限流器放行，重试同样需要等待
+		if err := r.c.throttle(ctx, r.verb, &fullURL); err != nil {
+			r.err = fmt.Errorf("client rate limiter wait failed: %w", err)
+			return &Result{err: r.err}
+		}
+
+		// 4. 创建 HTTP Request
+		var bodyReader io.Reader
+		if data != nil {
+			bodyReader = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, r.verb, fullURL.String(), bodyReader)
+		if err != nil {
+			r.err = fmt.Errorf("failed to create request: %w", err)
+			return &Result{err: r.err}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		// 5. 执行请求
+		klog.V(4).InfoS("Executing request", "method", req.Method, "url", req.URL, "attempt", attempt)
+		resp, err := r.c.httpClient.Do(req)
+
+		if attempt < maxAttempts && shouldRetry(ctx, resp, err) {
+			delay := policy.delay(attempt, resp)
+			if err != nil {
+				klog.V(2).InfoS("Request failed, retrying", "method", req.Method, "url", req.URL, "attempt", attempt, "delay", delay, "err", err)
+			} else {
+				klog.V(2).InfoS("Server temporarily unavailable, retrying", "method", req.Method, "url", req.URL, "attempt", attempt, "delay", delay, "statusCode", resp.StatusCode)
+				// 读完并关闭响应体，连接才能被复用
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+			if sleepErr := r.c.sleep(ctx, delay); sleepErr == nil {
+				continue
+			}
+			// 等待期间 ctx 结束：返回最后一次尝试的结果
+			if err == nil {
+				err = ctx.Err()
+			}
+		}
 
-	return &Result{
-		body:       resp.Body,
-		statusCode: resp.StatusCode,
-		err:        nil,
+		if err != nil {
+			r.err = fmt.Errorf("request failed: %w", err)
+			return &Result{err: r.err}
+		}
+		return &Result{
+			body:       resp.Body,
+			statusCode: resp.StatusCode,
+			err:        nil,
+		}
 	}
 }
 
@@ -153,7 +200,7 @@ type Result struct {
 // transformAndGetRawData 是一个新的辅助方法。
 // 它解码通用的响应信封，检查 API 错误，如果成功，则返回原始的 data 字段。
 func (r *Result) transformAndGetRawData() (json.RawMessage, error) {
-	// 先调用 Raw() 获取原始 body
+	// 先调用 Raw() 获取原始 body，HTTP 状态码不是 2xx 时 Raw 已经返回了错误
 	bodyBytes, err := r.Raw()
 	if err != nil {
 		return nil, err
@@ -176,6 +223,7 @@ func (r *Result) transformAndGetRawData() (json.RawMessage, error) {
 			Status:      apiResp.Status,
 			Message:     apiResp.Message,
 			FieldErrors: apiResp.FieldErrors,
+			HTTPStatus:  r.statusCode,
 		}
 	}
 
@@ -205,11 +253,36 @@ func (r *Result) Into(obj interface{}) error {
 }
 
 // Raw 读取并返回原始的响应体 []byte。
+// HTTP 状态码不是 2xx 时，除了响应体之外还会返回一个 *Aerror：
+// 响应体是 ECSM 的错误信封时使用其中的错误信息，否则（例如代理返回的错误页面）按 HTTP 状态码构造。
 // 注意：这个操作会消耗掉响应体，不能与 Into() 同时使用。
 func (r *Result) Raw() ([]byte, error) {
 	if r.err != nil {
 		return nil, r.err
 	}
 	defer r.body.Close()
-	return io.ReadAll(r.body)
+	bodyBytes, err := io.ReadAll(r.body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if r.statusCode < http.StatusOK || r.statusCode >= http.StatusMultipleChoices {
+		klog.V(4).InfoS("Received non-2xx response", "statusCode", r.statusCode, "body", bodySnippet(bodyBytes))
+		var apiResp Response
+		if err := json.Unmarshal(bodyBytes, &apiResp); err == nil && apiResp.Status != 0 && apiResp.Status != 200 {
+			return bodyBytes, &Aerror{
+				Status:      apiResp.Status,
+				Message:     apiResp.Message,
+				FieldErrors: apiResp.FieldErrors,
+				HTTPStatus:  r.statusCode,
+			}
+		}
+		return bodyBytes, newHTTPError(r.statusCode, bodyBytes)
+	}
+	return bodyBytes, nil
+}
+
+// StatusCode 返回响应的 HTTP 状态码，请求没有发出时为 0。
+func (r *Result) StatusCode() int {
+	return r.statusCode
 }
@@ -1,24 +1,41 @@
 package rest
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 )
 
 // aerror 是我们自定义的错误类型，它包含了 ECSM API 返回的详细错误信息。
-// 使用小写开头，因为它只在包内使用。
+// 可以使用 IsNotFound、IsConflict 等函数判断错误的类别，而不需要匹配错误信息。
 type Aerror struct {
-	Status      int    `json:"status"`
-	Message     string `json:"message"`
-	FieldErrors string `json:"fieldErrors"`
+	Status      int         `json:"status"`
+	Message     string      `json:"message"`
+	FieldErrors FieldErrors `json:"fieldErrors"`
+	// HTTPStatus 是响应的 HTTP 状态码。信封中的 status 缺失时（例如代理返回的 502 页面），
+	// 错误按 HTTPStatus 分类。
+	HTTPStatus int `json:"-"`
 }
 
 // Error 方法让 aerror 实现了 Go 的 error 接口。
 func (e *Aerror) Error() string {
 	if e.FieldErrors != "" {
-		return fmt.Sprintf("ecsm api error (status %d): %s (field: %s)", e.Status, e.Message, e.FieldErrors)
+		return fmt.Sprintf("ecsm api error (status %d): %s (field: %s)", e.Code(), e.Message, e.FieldErrors)
 	}
-	return fmt.Sprintf("ecsm api error (status %d): %s", e.Status, e.Message)
+	return fmt.Sprintf("ecsm api error (status %d): %s", e.Code(), e.Message)
+}
+
+// Code 返回用于分类的状态码：优先使用信封中的 status，没有时使用 HTTP 状态码。
+func (e *Aerror) Code() int {
+	if e.Status != 0 && e.Status != 200 {
+		return e.Status
+	}
+	return e.HTTPStatus
+}
+
+// Causes 把 FieldErrors 解析为逐个字段的错误，FieldErrors 为空时返回 nil。
+func (e *Aerror) Causes() []FieldError {
+	return e.FieldErrors.Parse()
 }
 
 // response 是用于解码所有 ECSM API 调用的通用响应体结构。
@@ -26,5 +43,25 @@ type Response struct {
 	Status      int             `json:"status"`
 	Message     string          `json:"message"`
 	Data        json.RawMessage `json:"data"` // 使用 json.RawMessage 来延迟解码 data 部分
-	FieldErrors string          `json:"fieldErrors"`
+	FieldErrors FieldErrors     `json:"fieldErrors"`
+}
+
+// FieldErrors 是 ECSM 响应中的 fieldErrors 字段。
+// 文档中它是一个字符串，但不同的接口也会返回数组或对象，非字符串的值以原始 JSON 文本保存。
+type FieldErrors string
+
+// UnmarshalJSON 实现了 json.Unmarshaler，接受任意 JSON 值。
+func (f *FieldErrors) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		*f = ""
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*f = FieldErrors(s)
+		return nil
+	}
+	*f = FieldErrors(data)
+	return nil
 }
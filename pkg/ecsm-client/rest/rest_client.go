@@ -1,9 +1,15 @@
 package rest
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog/v2"
 )
 
 const (
@@ -11,6 +17,16 @@ const (
 	defaultAPIPath    = "api"
 )
 
+const (
+	// DefaultQPS 和 DefaultBurst 是客户端限流的默认值。ECSM 常常运行在性能有限的边缘设备上，
+	// ListAll 之类的辅助方法或者控制器可能在短时间内发出大量请求。
+	DefaultQPS   float32 = 5.0
+	DefaultBurst int     = 10
+
+	// longThrottleLatency 是需要记录日志的限流等待时间
+	longThrottleLatency = time.Second
+)
+
 type Interface interface {
 	Verb(verb string) *Request
 	Get() *Request
@@ -26,6 +42,13 @@ type RESTClient struct {
 	httpClient *http.Client
 	apiVersion string
 	apiPath    string
+
+	retryPolicy RetryPolicy
+	// sleep 用于重试前的等待，测试中可以替换
+	sleep func(ctx context.Context, d time.Duration) error
+
+	// rateLimiter 由同一个 RESTClient 发出的所有请求共享，为 nil 时不限流
+	rateLimiter flowcontrol.RateLimiter
 }
 
 // NewClient 创建一个新的 ECSM 客户端实例。
@@ -40,12 +63,58 @@ func NewRESTClient(protocol, host, port string, httpClient *http.Client) (*RESTC
 		return nil, fmt.Errorf("failed to parse base url: %w", err)
 	}
 
+	return newRESTClient(baseURL, defaultAPIPath, defaultAPIVersion, httpClient), nil
+}
+
+// newRESTClient 创建 RESTClient，apiPath 和 apiVersion 为空时使用默认值。
+func newRESTClient(baseURL *url.URL, apiPath, apiVersion string, httpClient *http.Client) *RESTClient {
+	if apiPath == "" {
+		apiPath = defaultAPIPath
+	}
+	if apiVersion == "" {
+		apiVersion = defaultAPIVersion
+	}
 	return &RESTClient{
 		baseURL:    baseURL,
 		httpClient: httpClient,
-		apiVersion: defaultAPIVersion,
-		apiPath:    defaultAPIPath,
-	}, nil
+		apiVersion: apiVersion,
+		apiPath:    strings.Trim(apiPath, "/"),
+
+		retryPolicy: DefaultRetryPolicy(),
+		sleep:       sleepContext,
+		rateLimiter: flowcontrol.NewTokenBucketRateLimiter(DefaultQPS, DefaultBurst),
+	}
+}
+
+// SetRetryPolicy 设置该客户端发出的所有请求使用的重试策略。
+// 传入 RetryPolicy{} 可以完全关闭重试。
+func (c *RESTClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// SetRateLimiter 设置该客户端发出的所有请求共享的限流器，传入 nil 可以关闭客户端限流。
+func (c *RESTClient) SetRateLimiter(rateLimiter flowcontrol.RateLimiter) {
+	c.rateLimiter = rateLimiter
+}
+
+// GetRateLimiter 返回该客户端使用的限流器，没有限流时返回 nil。
+func (c *RESTClient) GetRateLimiter() flowcontrol.RateLimiter {
+	return c.rateLimiter
+}
+
+// throttle 在发送请求前等待限流器放行，ctx 先结束时返回错误。
+func (c *RESTClient) throttle(ctx context.Context, verb string, u *url.URL) error {
+	if c.rateLimiter == nil {
+		return nil
+	}
+	start := time.Now()
+	err := c.rateLimiter.Wait(ctx)
+	latency := time.Since(start)
+	RateLimiterLatency.Observe(ctx, verb, *u, latency)
+	if latency > longThrottleLatency {
+		klog.V(3).InfoS("Waited before sending request due to client-side throttling", "method", verb, "url", u, "latency", latency)
+	}
+	return err
 }
 
 func (c *RESTClient) Verb(verb string) *Request {
@@ -6,6 +6,8 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
 	"strconv"
 	"testing"
 	"time"
@@ -132,16 +134,32 @@ func TestRESTClient_GetServices(t *testing.T) {
 	}
 }
 
-// TestRESTClient_RealAPI 测试真实的 ECSM API（需要真实的服务器运行）
-// 这个测试默认跳过，可以通过 go test -v -run TestRESTClient_RealAPI 单独运行
-func TestRESTClient_RealAPI(t *testing.T) {
-	//t.Skip("Skipping real API test - uncomment to test against real ECSM server")
+// liveServerEnv 是连接真实 ECSM API 的测试所用的服务器地址的环境变量，例如 http://192.168.31.129:3001。
+const liveServerEnv = "ECSM_TEST_SERVER"
 
-	// 创建连接到真实 ECSM API 的客户端
-	client, err := NewRESTClient("http", "192.168.31.129", "3001", &http.Client{Timeout: 10 * time.Second})
+// newLiveRESTClient 创建连接到 ECSM_TEST_SERVER 的客户端，没有设置时跳过测试。
+func newLiveRESTClient(t *testing.T) *RESTClient {
+	server := os.Getenv(liveServerEnv)
+	if server == "" {
+		t.Skipf("%s is not set, skipping test against a live ECSM server", liveServerEnv)
+	}
+	u, err := url.Parse(server)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", liveServerEnv, err)
+	}
+	client, err := NewRESTClient(u.Scheme, u.Hostname(), u.Port(), &http.Client{Timeout: 10 * time.Second})
 	if err != nil {
 		t.Fatalf("Failed to create REST client: %v", err)
 	}
+	return client
+}
+
+// TestRESTClient_RealAPI 测试真实的 ECSM API（需要真实的服务器运行）
+// 这个测试默认跳过，设置 ECSM_TEST_SERVER 后运行：
+// ECSM_TEST_SERVER=http://192.168.31.129:3001 go test -v -run TestRESTClient_RealAPI
+func TestRESTClient_RealAPI(t *testing.T) {
+	// 创建连接到真实 ECSM API 的客户端
+	client := newLiveRESTClient(t)
 
 	// 执行真实的 GET 请求
 	ctx := context.Background()
@@ -153,7 +171,7 @@ func TestRESTClient_RealAPI(t *testing.T) {
 
 	// 解析响应
 	var serviceList ServiceListResponse
-	err = result.Into(&serviceList)
+	err := result.Into(&serviceList)
 	if err != nil {
 		t.Fatalf("Failed to parse response: %v", err)
 	}
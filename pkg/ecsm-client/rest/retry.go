@@ -0,0 +1,140 @@
+// file: pkg/ecsm-client/rest/retry.go
+
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// RetryPolicy 决定 Request.Do 在遇到暂时性错误时是否以及如何重试。
+// 只有网络错误以及 429、502、503、504 响应会被重试，500 不会被重试（见 retryableStatusCodes）；
+// 非幂等的请求（默认是 POST）即使遇到网络错误也不会重试，因为无法确定服务端是否已经执行了它。
+type RetryPolicy struct {
+	// MaxAttempts 是包括第一次在内的最大尝试次数，小于等于 1 表示不重试。
+	MaxAttempts int
+	// BaseDelay 是第一次重试前的等待时间，之后每次翻倍，最长为 MaxDelay。
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jitter 为每次等待随机增加最多 Jitter 倍的时间，避免多个客户端同时重试。
+	Jitter float64
+	// Verbs 是允许重试的 HTTP 方法，为空时使用 GET、PUT 和 DELETE。
+	Verbs []string
+}
+
+// DefaultRetryPolicy 返回 NewRESTClient 默认使用的重试策略。
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Jitter:      0.2,
+		Verbs:       []string{http.MethodGet, http.MethodPut, http.MethodDelete},
+	}
+}
+
+// retryableStatusCodes 是表示服务端暂时不可用、可以重试的 HTTP 状态码。
+//
+// 即使是幂等的请求也不重试 500：ECSM 把镜像不存在、名称非法等业务错误也以 500 返回
+// （响应体中的 status 同样是 500），这些错误重试多少次结果都一样，只会让调用者多等几个退避周期。
+// 网关在 ECSM 不可用时返回的是 502/503/504，这些才是真正暂时性的错误。
+var retryableStatusCodes = sets.New(
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+)
+
+// allowsVerb 判断 verb 的请求是否可以重试。
+func (p RetryPolicy) allowsVerb(verb string) bool {
+	if p.MaxAttempts <= 1 {
+		return false
+	}
+	verbs := p.Verbs
+	if len(verbs) == 0 {
+		verbs = DefaultRetryPolicy().Verbs
+	}
+	for _, v := range verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// delay 返回第 attempt 次尝试（从 1 开始）失败后、下一次尝试前的等待时间。
+// 响应中带有 Retry-After 时以它为准，但同样不超过 MaxDelay，以免服务端让调用者一直等下去。
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if d, ok := retryAfter(resp); ok {
+		if p.MaxDelay > 0 && d > p.MaxDelay {
+			d = p.MaxDelay
+		}
+		return d
+	}
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d = wait.Jitter(d, p.Jitter)
+	}
+	return d
+}
+
+// shouldRetry 判断一次尝试的结果是否值得重试。ctx 已经结束时不会重试。
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		// RoundTripper 返回的 API 错误（例如登录失败）按状态码判断
+		var aerr *Aerror
+		if errors.As(err, &aerr) {
+			return retryableStatusCodes.Has(aerr.Code())
+		}
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return retryableStatusCodes.Has(resp.StatusCode)
+}
+
+// retryAfter 解析响应的 Retry-After 头，支持秒数和 HTTP 日期两种格式。
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// sleepContext 等待 d，ctx 先结束时返回 ctx 的错误。
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
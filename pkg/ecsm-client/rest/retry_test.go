@@ -0,0 +1,241 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyHandler 在前 failures 次请求时调用 fail，之后返回一个成功的响应，并记录收到的请求体。
+type flakyHandler struct {
+	mu       sync.Mutex
+	failures int
+	fail     func(w http.ResponseWriter)
+	requests int
+	bodies   []string
+}
+
+func (h *flakyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	h.mu.Lock()
+	h.requests++
+	h.bodies = append(h.bodies, string(body))
+	failing := h.requests <= h.failures
+	h.mu.Unlock()
+
+	if failing {
+		h.fail(w)
+		return
+	}
+	fmt.Fprint(w, `{"status": 200, "message": "success", "data": {"id": "svc-1"}}`)
+}
+
+func (h *flakyHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.requests
+}
+
+// newRetryTestClient 创建一个不真正等待、而是记录每次重试等待时间的客户端。
+func newRetryTestClient(t *testing.T, h *flakyHandler) (*RESTClient, *[]time.Duration) {
+	client := newTestClient(t, h.ServeHTTP)
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond})
+	var delays []time.Duration
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	return client, &delays
+}
+
+func unavailable(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+// resetConnection 不返回任何响应直接关闭连接，客户端会得到一个网络错误。
+func resetConnection(w http.ResponseWriter) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+// TestRequest_RetriesTransientFailures 测试幂等请求在暂时性错误后按指数退避重试
+func TestRequest_RetriesTransientFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		fail func(w http.ResponseWriter)
+	}{
+		{name: "service unavailable", fail: unavailable},
+		{name: "bad gateway page", fail: func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, "<html><body>502 Bad Gateway</body></html>")
+		}},
+		{name: "connection reset", fail: resetConnection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &flakyHandler{failures: 3, fail: tt.fail}
+			client, delays := newRetryTestClient(t, h)
+
+			var result struct {
+				ID string `json:"id"`
+			}
+			if err := client.Get().Resource("service").Do(context.Background()).Into(&result); err != nil {
+				t.Fatalf("Expected success after retries, got %v", err)
+			}
+			if result.ID != "svc-1" {
+				t.Errorf("Expected id svc-1, got %q", result.ID)
+			}
+			if h.count() != 4 {
+				t.Errorf("Expected 4 attempts, got %d", h.count())
+			}
+			want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+			if fmt.Sprint(*delays) != fmt.Sprint(want) {
+				t.Errorf("Expected delays %v, got %v", want, *delays)
+			}
+		})
+	}
+}
+
+// TestRequest_ReplaysBody 测试重试时重新发送同样的请求体
+func TestRequest_ReplaysBody(t *testing.T) {
+	h := &flakyHandler{failures: 2, fail: unavailable}
+	client, _ := newRetryTestClient(t, h)
+
+	body := map[string]string{"name": "web"}
+	if err := client.Put().Resource("service").Name("svc-1").Body(body).Do(context.Background()).Into(nil); err != nil {
+		t.Fatalf("Expected success after retries, got %v", err)
+	}
+	if len(h.bodies) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(h.bodies))
+	}
+	for i, b := range h.bodies {
+		if b != `{"name":"web"}` {
+			t.Errorf("Attempt %d sent body %q", i+1, b)
+		}
+	}
+}
+
+// TestRequest_GivesUpAfterMaxAttempts 测试重试次数用完之后返回最后一次的错误
+func TestRequest_GivesUpAfterMaxAttempts(t *testing.T) {
+	h := &flakyHandler{failures: 10, fail: unavailable}
+	client, _ := newRetryTestClient(t, h)
+
+	err := client.Delete().Resource("service").Name("svc-1").Do(context.Background()).Into(nil)
+	if !IsServiceUnavailable(err) {
+		t.Fatalf("Expected service unavailable error, got %v", err)
+	}
+	if h.count() != 4 {
+		t.Errorf("Expected 4 attempts, got %d", h.count())
+	}
+}
+
+// TestRequest_DoesNotRetry 测试非幂等请求、不可重试的错误和显式关闭重试的请求都只执行一次
+func TestRequest_DoesNotRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		fail    func(w http.ResponseWriter)
+		request func(c *RESTClient) *Request
+	}{
+		{
+			name:    "POST",
+			fail:    unavailable,
+			request: func(c *RESTClient) *Request { return c.Post().Resource("service") },
+		},
+		{
+			name:    "POST with connection reset",
+			fail:    resetConnection,
+			request: func(c *RESTClient) *Request { return c.Post().Resource("service") },
+		},
+		{
+			name:    "NoRetry",
+			fail:    unavailable,
+			request: func(c *RESTClient) *Request { return c.Get().Resource("service").NoRetry() },
+		},
+		{
+			// ECSM 的业务错误以 500 返回，重试也不会成功
+			name: "internal server error",
+			fail: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `{"status": 500, "message": "invalid image"}`)
+			},
+			request: func(c *RESTClient) *Request { return c.Get().Resource("service") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &flakyHandler{failures: 1, fail: tt.fail}
+			client, _ := newRetryTestClient(t, h)
+
+			if err := tt.request(client).Do(context.Background()).Into(nil); err == nil {
+				t.Fatal("Expected error, but got nil")
+			}
+			if h.count() != 1 {
+				t.Errorf("Expected 1 attempt, got %d", h.count())
+			}
+		})
+	}
+}
+
+// TestRequest_RespectsRetryAfter 测试 Retry-After 头优先于退避策略
+func TestRequest_RespectsRetryAfter(t *testing.T) {
+	h := &flakyHandler{failures: 1, fail: func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}}
+	client, delays := newRetryTestClient(t, h)
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: 10 * time.Second})
+
+	if err := client.Get().Resource("service").Do(context.Background()).Into(nil); err != nil {
+		t.Fatalf("Expected success after retry, got %v", err)
+	}
+	if len(*delays) != 1 || (*delays)[0] != 7*time.Second {
+		t.Errorf("Expected a single 7s delay, got %v", *delays)
+	}
+}
+
+// TestRequest_RetryAfterIsCappedByMaxDelay 测试 Retry-After 同样不超过 MaxDelay
+func TestRequest_RetryAfterIsCappedByMaxDelay(t *testing.T) {
+	h := &flakyHandler{failures: 2, fail: func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}}
+	client, delays := newRetryTestClient(t, h)
+
+	if err := client.Get().Resource("service").Do(context.Background()).Into(nil); err != nil {
+		t.Fatalf("Expected success after retry, got %v", err)
+	}
+	want := []time.Duration{300 * time.Millisecond, 300 * time.Millisecond}
+	if fmt.Sprint(*delays) != fmt.Sprint(want) {
+		t.Errorf("Expected delays %v, got %v", want, *delays)
+	}
+}
+
+// TestRequest_StopsRetryingWhenContextIsDone 测试等待重试期间 ctx 结束时立即返回
+func TestRequest_StopsRetryingWhenContextIsDone(t *testing.T) {
+	h := &flakyHandler{failures: 10, fail: unavailable}
+	client := newTestClient(t, h.ServeHTTP)
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 4, BaseDelay: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := client.Get().Resource("service").Do(ctx).Into(nil)
+	if err == nil {
+		t.Fatal("Expected error, but got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected to return soon after the context is done, took %v", elapsed)
+	}
+	if h.count() != 1 {
+		t.Errorf("Expected 1 attempt, got %d", h.count())
+	}
+}
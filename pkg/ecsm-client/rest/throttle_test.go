@@ -0,0 +1,137 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingLatency 记录所有观察到的耗时
+type recordingLatency struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+}
+
+func (r *recordingLatency) Observe(ctx context.Context, verb string, u url.URL, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies = append(r.latencies, latency)
+}
+
+// newThrottleTestServer 返回一个计数请求的服务器
+func newThrottleTestServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		writeOK(w)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// TestRESTClient_Throttle 测试超过 burst 的请求按 QPS 放行，并上报等待时间
+func TestRESTClient_Throttle(t *testing.T) {
+	metric := &recordingLatency{}
+	original := RateLimiterLatency
+	RateLimiterLatency = metric
+	t.Cleanup(func() { RateLimiterLatency = original })
+
+	server, requests := newThrottleTestServer(t)
+	client, err := RESTClientFor(&Config{Host: server.URL, QPS: 20, Burst: 2})
+	if err != nil {
+		t.Fatalf("Failed to create REST client: %v", err)
+	}
+
+	// 前 2 个请求消耗 burst，之后的 4 个请求每 50ms 放行一个
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.Get().Resource("service").Do(context.Background()).Into(nil); err != nil {
+				t.Errorf("Request failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("Expected requests to be throttled, finished in %v", elapsed)
+	}
+	if got := requests.Load(); got != 6 {
+		t.Errorf("Expected 6 requests, got %d", got)
+	}
+
+	metric.mu.Lock()
+	defer metric.mu.Unlock()
+	if len(metric.latencies) != 6 {
+		t.Fatalf("Expected 6 observations, got %d", len(metric.latencies))
+	}
+	var total time.Duration
+	for _, l := range metric.latencies {
+		total += l
+	}
+	if total < 150*time.Millisecond {
+		t.Errorf("Expected the throttled time to be reported, got %v in total", total)
+	}
+}
+
+// TestRESTClient_ThrottleHonoursContext 测试等待限流器时 ctx 结束会立即返回，请求不会被发送
+func TestRESTClient_ThrottleHonoursContext(t *testing.T) {
+	server, requests := newThrottleTestServer(t)
+	client, err := RESTClientFor(&Config{Host: server.URL, QPS: 0.1, Burst: 1})
+	if err != nil {
+		t.Fatalf("Failed to create REST client: %v", err)
+	}
+
+	if err := client.Get().Resource("service").Do(context.Background()).Into(nil); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = client.Get().Resource("service").Do(ctx).Into(nil)
+	if err == nil {
+		t.Fatal("Expected the throttled request to fail")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the request to give up with its context, waited %v", elapsed)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected the throttled request not to be sent, got %d requests", got)
+	}
+}
+
+// TestRESTClientFor_RateLimiterOptions 测试关闭限流以及共享限流器
+func TestRESTClientFor_RateLimiterOptions(t *testing.T) {
+	client, err := RESTClientFor(&Config{Host: "http://localhost:3001", QPS: -1})
+	if err != nil {
+		t.Fatalf("Failed to create REST client: %v", err)
+	}
+	if client.GetRateLimiter() != nil {
+		t.Error("Expected no rate limiter for negative QPS")
+	}
+
+	shared, err := RESTClientFor(&Config{Host: "http://localhost:3001"})
+	if err != nil {
+		t.Fatalf("Failed to create REST client: %v", err)
+	}
+	if qps := shared.GetRateLimiter().QPS(); qps != DefaultQPS {
+		t.Errorf("Expected default QPS %v, got %v", DefaultQPS, qps)
+	}
+	other, err := RESTClientFor(&Config{Host: "http://localhost:3002", RateLimiter: shared.GetRateLimiter()})
+	if err != nil {
+		t.Fatalf("Failed to create REST client: %v", err)
+	}
+	if other.GetRateLimiter() != shared.GetRateLimiter() {
+		t.Error("Expected the rate limiter to be shared")
+	}
+}
@@ -0,0 +1,123 @@
+// file: pkg/ecsm-client/simulator/configs.go
+
+package simulator
+
+import (
+	"net/http"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+)
+
+type configItem struct {
+	object
+	key   string
+	typ   clientset.ConfigItemType
+	value any
+}
+
+func (c *configItem) toAPI() clientset.ConfigItem {
+	return clientset.ConfigItem{ID: c.id, Key: c.key, Type: c.typ, Value: c.value}
+}
+
+func (s *Simulator) installConfigRoutes() {
+	s.handle(http.MethodPost, "configmap", s.createConfig)
+	s.handle(http.MethodPut, "configmap", s.updateConfig)
+	s.handle(http.MethodGet, "configmap", s.listConfigs)
+	s.handle(http.MethodDelete, "configmap/{id}", s.deleteConfig)
+	s.handle(http.MethodGet, "configmap/key", s.getConfigValue)
+}
+
+// validateConfig 检查 key 是否为空或者与 excludeID 之外的配置项冲突，以及 value 是否与 typ 匹配。
+func (s *Simulator) validateConfig(key string, typ clientset.ConfigItemType, value any, excludeID string) error {
+	if key == "" {
+		return errInvalidField("key", "must not be empty")
+	}
+	var ok bool
+	switch typ {
+	case clientset.ConfigItemTypeString:
+		_, ok = value.(string)
+	case clientset.ConfigItemTypeNumber:
+		_, ok = value.(float64)
+	case clientset.ConfigItemTypeJSON:
+		switch value.(type) {
+		case map[string]any, []any:
+			ok = true
+		}
+	default:
+		return errInvalidField("type", "unsupported type %q", typ)
+	}
+	if !ok {
+		return errInvalidField("value", "does not match type %q", typ)
+	}
+	for _, c := range s.configs {
+		if c.id != excludeID && c.key == key {
+			return errConflict("config key %q already exists", key)
+		}
+	}
+	return nil
+}
+
+func (s *Simulator) createConfig(r *http.Request) (any, error) {
+	var req clientset.CreateConfigRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if err := s.validateConfig(req.Key, req.Type, req.Value, ""); err != nil {
+		return nil, err
+	}
+	c := &configItem{object: s.newObject(), key: req.Key, typ: req.Type, value: req.Value}
+	s.configs[c.id] = c
+	return nil, nil
+}
+
+func (s *Simulator) updateConfig(r *http.Request) (any, error) {
+	var req clientset.ConfigItem
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	c, ok := s.configs[req.ID]
+	if !ok {
+		return nil, errNotFound("config %q not found", req.ID)
+	}
+	if err := s.validateConfig(req.Key, req.Type, req.Value, c.id); err != nil {
+		return nil, err
+	}
+	c.key, c.typ, c.value = req.Key, req.Type, req.Value
+	c.updated = s.clock.Now()
+	return nil, nil
+}
+
+// listConfigs 返回配置项的数组，与其他列表接口不同，响应中没有 total 等分页信息。
+func (s *Simulator) listConfigs(r *http.Request) (any, error) {
+	pageNum, pageSize, err := pageParams(r)
+	if err != nil {
+		return nil, err
+	}
+	key := r.URL.Query().Get("key")
+	items := []clientset.ConfigItem{}
+	for _, c := range sorted(s.configs) {
+		if key == "" || contains(c.key, key) {
+			items = append(items, c.toAPI())
+		}
+	}
+	return paginate(items, pageNum, pageSize), nil
+}
+
+func (s *Simulator) deleteConfig(r *http.Request) (any, error) {
+	id := r.PathValue("id")
+	if _, ok := s.configs[id]; !ok {
+		return nil, errNotFound("config %q not found", id)
+	}
+	delete(s.configs, id)
+	return nil, nil
+}
+
+func (s *Simulator) getConfigValue(r *http.Request) (any, error) {
+	key := r.URL.Query().Get("key")
+	for _, c := range s.configs {
+		if c.key == key {
+			return c.value, nil
+		}
+	}
+	return nil, errNotFound("config key %q not found", key)
+}
@@ -0,0 +1,175 @@
+// file: pkg/ecsm-client/simulator/images.go
+
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+)
+
+// localRegistry 是本地镜像仓库的 ID，Simulator 只模拟本地仓库。
+const localRegistry = "local"
+
+type image struct {
+	object
+	name   string
+	tag    string
+	os     string
+	arch   string
+	config *clientset.EcsImageConfig
+}
+
+func (img *image) ref() string {
+	return img.name + "@" + img.tag + "#" + img.os
+}
+
+func (s *Simulator) installImageRoutes() {
+	s.handle(http.MethodGet, "image", s.listImages)
+	s.handle(http.MethodGet, "image/summary", s.imageSummary)
+	s.handle(http.MethodGet, "image/config", s.imageConfig)
+	s.handle(http.MethodGet, "image/count", s.repositoryInfo)
+	s.handle(http.MethodGet, "registry/{registryId}/image/{id}", s.getImage)
+}
+
+// AddImage 向本地仓库添加一个镜像并返回它的 ID。ref 的格式为 name@tag[#os]，os 默认为 sylixos；
+// config 可以为 nil，它的 Platform 决定了镜像的架构。
+// 服务使用的镜像不要求事先添加，但只有添加过的镜像才能通过镜像接口查询到。
+func (s *Simulator) AddImage(ref string, config *clientset.EcsImageConfig) (string, error) {
+	name, tag, os := parseRef(ref)
+	if name == "" || tag == "" {
+		return "", fmt.Errorf("invalid image ref %q, expected format name@tag[#os]", ref)
+	}
+	if os == "" {
+		os = "sylixos"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	img := &image{object: s.newObject(), name: name, tag: tag, os: os, arch: "x86-64", config: config}
+	if config != nil && config.Platform != nil && config.Platform.Arch != "" {
+		img.arch = config.Platform.Arch
+	}
+	s.images[img.id] = img
+	return img.id, nil
+}
+
+// imageByRef 返回与 ref 匹配的镜像，ref 中没有 os 时匹配第一个 name@tag 相同的镜像。
+func (s *Simulator) imageByRef(ref string) *image {
+	name, tag, os := parseRef(ref)
+	for _, img := range sorted(s.images) {
+		if img.name == name && img.tag == tag && (os == "" || img.os == os) {
+			return img
+		}
+	}
+	return nil
+}
+
+// filterImages 按 name、os 和 author 过滤镜像。模拟的镜像没有作者，指定 author 时没有匹配的镜像。
+func (s *Simulator) filterImages(r *http.Request) []*image {
+	q := r.URL.Query()
+	var images []*image
+	for _, img := range sorted(s.images) {
+		if name := q.Get("name"); name != "" && !contains(img.name, name) {
+			continue
+		}
+		if os := q.Get("os"); os != "" && img.os != os {
+			continue
+		}
+		if q.Get("author") != "" {
+			continue
+		}
+		images = append(images, img)
+	}
+	return images
+}
+
+func (s *Simulator) listImages(r *http.Request) (any, error) {
+	if id := r.URL.Query().Get("registryId"); id != localRegistry {
+		return nil, errNotFound("registry %q not found", id)
+	}
+	pageNum, pageSize, err := pageParams(r)
+	if err != nil {
+		return nil, err
+	}
+	items := []clientset.ImageListItem{}
+	for _, img := range s.filterImages(r) {
+		items = append(items, clientset.ImageListItem{
+			ID:          img.id,
+			Name:        img.name,
+			OS:          img.os,
+			CreatedTime: formatTime(img.created),
+			Tag:         img.tag,
+			Arch:        img.arch,
+			Pulled:      true,
+			OCIVersion:  "1.0.2",
+		})
+	}
+	return &clientset.ImageList{
+		Total:    len(items),
+		PageNum:  pageNum,
+		PageSize: pageSize,
+		Items:    paginate(items, pageNum, pageSize),
+	}, nil
+}
+
+func (s *Simulator) imageSummary(r *http.Request) (any, error) {
+	return &clientset.ImageStatistics{Local: len(s.images)}, nil
+}
+
+func (s *Simulator) imageConfig(r *http.Request) (any, error) {
+	ref := r.URL.Query().Get("ref")
+	img := s.imageByRef(ref)
+	if img == nil {
+		return nil, errNotFound("image %q not found", ref)
+	}
+	config := img.config
+	if config == nil {
+		config = &clientset.EcsImageConfig{}
+	}
+	return map[string]any{"config": config}, nil
+}
+
+func (s *Simulator) repositoryInfo(r *http.Request) (any, error) {
+	return []clientset.RepositoryInfo{{
+		Count:        len(s.filterImages(r)),
+		RegistryID:   localRegistry,
+		RegistryName: localRegistry,
+	}}, nil
+}
+
+func (s *Simulator) getImage(r *http.Request) (any, error) {
+	if id := r.PathValue("registryId"); id != localRegistry {
+		return nil, errNotFound("registry %q not found", id)
+	}
+	img, ok := s.images[r.PathValue("id")]
+	if !ok {
+		return nil, errNotFound("image %q not found", r.PathValue("id"))
+	}
+	details := &clientset.ImageDetails{
+		ID:          img.id,
+		Name:        img.name,
+		Path:        img.ref(),
+		OS:          img.os,
+		Arch:        img.arch,
+		CreatedTime: formatTime(img.created),
+		Config:      img.config,
+		OCIVersion:  "1.0.2",
+		Tag:         img.tag,
+		Pulled:      true,
+	}
+	if img.config != nil {
+		raw, err := json.Marshal(img.config)
+		if err != nil {
+			return nil, err
+		}
+		details.RawConfig = string(raw)
+		if img.config.Hostname != "" {
+			hostname := img.config.Hostname
+			details.Hostname = &hostname
+		}
+	}
+	return details, nil
+}
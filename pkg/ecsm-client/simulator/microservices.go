@@ -0,0 +1,127 @@
+// file: pkg/ecsm-client/simulator/microservices.go
+
+package simulator
+
+import (
+	"net/http"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+)
+
+// 微服务的负载均衡策略
+const (
+	loadBalanceRoundRobin  = "roundRobin"
+	loadBalanceMasterSlave = "masterSlave"
+)
+
+func (s *Simulator) installMicroServiceRoutes() {
+	s.handle(http.MethodGet, "micro-service", s.listMicroServices)
+	s.handle(http.MethodGet, "micro-service/{id}", s.getMicroService)
+	s.handle(http.MethodPut, "micro-service", s.updateMicroService)
+}
+
+// microService 返回 id 对应的微服务。微服务就是镜像启用了 VSOA 的服务，二者的 ID 相同。
+func (s *Simulator) microService(id string) (*service, error) {
+	svc, ok := s.services[id]
+	if !ok || svc.image.VSOA == nil {
+		return nil, errNotFound("micro service %q not found", id)
+	}
+	return svc, nil
+}
+
+// loadBalance 返回微服务的负载均衡设置，没有设置过时为轮询。
+func (s *Simulator) loadBalance(svc *service) *clientset.UpdateMicroServiceRequest {
+	if lb, ok := s.loadBalances[svc.id]; ok {
+		return lb
+	}
+	return &clientset.UpdateMicroServiceRequest{ID: svc.id, LoadBalance: loadBalanceRoundRobin}
+}
+
+func (s *Simulator) microServiceRow(svc *service) clientset.MicroServiceListRow {
+	name, _, _ := parseRef(svc.image.Ref)
+	_, _, online := s.serviceInstances(svc)
+	return clientset.MicroServiceListRow{
+		ID:             svc.id,
+		Name:           svc.name,
+		ImageName:      name,
+		HealthInstance: online,
+		Instance:       len(s.serviceContainers(svc.id)),
+		LoadBalance:    s.loadBalance(svc).LoadBalance,
+	}
+}
+
+func (s *Simulator) listMicroServices(r *http.Request) (any, error) {
+	pageNum, pageSize, err := pageParams(r)
+	if err != nil {
+		return nil, err
+	}
+	q := r.URL.Query()
+	items := []clientset.MicroServiceListRow{}
+	for _, svc := range sorted(s.services) {
+		if svc.image.VSOA == nil {
+			continue
+		}
+		if name := q.Get("name"); name != "" && !contains(svc.name, name) {
+			continue
+		}
+		if nodeID := q.Get("nodeId"); nodeID != "" && !s.serviceOnNode(svc, nodeID) {
+			continue
+		}
+		if label := q.Get("label"); label != "" && (svc.pathLabel == "" || !underPath(svc.pathLabel, label)) {
+			continue
+		}
+		items = append(items, s.microServiceRow(svc))
+	}
+	return &clientset.MicroServiceList{
+		Total:    len(items),
+		PageNum:  pageNum,
+		PageSize: pageSize,
+		Items:    paginate(items, pageNum, pageSize),
+	}, nil
+}
+
+func (s *Simulator) getMicroService(r *http.Request) (any, error) {
+	svc, err := s.microService(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+	row := s.microServiceRow(svc)
+	return &clientset.MicroServiceGet{
+		BoDYnamic:         svc.policy == policyDynamic,
+		ID:                row.ID,
+		Name:              row.Name,
+		ImageName:         row.ImageName,
+		HealthInstance:    row.HealthInstance,
+		Instance:          row.Instance,
+		LoadBalance:       row.LoadBalance,
+		LoadBalanceDetail: s.loadBalance(svc).LoadBalanceDetail,
+	}, nil
+}
+
+func (s *Simulator) updateMicroService(r *http.Request) (any, error) {
+	var req clientset.UpdateMicroServiceRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	svc, err := s.microService(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	switch req.LoadBalance {
+	case loadBalanceRoundRobin:
+		req.LoadBalanceDetail = nil
+	case loadBalanceMasterSlave:
+		if len(req.LoadBalanceDetail) == 0 {
+			return nil, errInvalidField("loadBalanceDetail", "must not be empty for %s", loadBalanceMasterSlave)
+		}
+		for _, detail := range req.LoadBalanceDetail {
+			if c := s.containerByTaskID(detail.TaskID); c == nil || c.serviceID != svc.id {
+				return nil, errInvalidField("loadBalanceDetail", "task %q is not an instance of micro service %q", detail.TaskID, svc.id)
+			}
+		}
+	default:
+		return nil, errInvalidField("loadBalance", "unsupported load balance %q", req.LoadBalance)
+	}
+	s.loadBalances[svc.id] = &req
+	return nil, nil
+}
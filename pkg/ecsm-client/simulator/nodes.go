@@ -0,0 +1,382 @@
+// file: pkg/ecsm-client/simulator/nodes.go
+
+package simulator
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+)
+
+// 节点的状态
+const (
+	nodeOnline  = "online"
+	nodeOffline = "offline"
+)
+
+// defaultNodePort 是节点地址中没有端口时使用的端口
+const defaultNodePort = 3000
+
+type node struct {
+	object
+	name     string
+	address  string
+	password string
+	tls      bool
+	arch     string
+	typ      string
+	status   string
+}
+
+func (s *Simulator) installNodeRoutes() {
+	s.handle(http.MethodPost, "node", s.registerNode)
+	s.handle(http.MethodPut, "node", s.updateNode)
+	s.handle(http.MethodGet, "node", s.listNodes)
+	s.handle(http.MethodDelete, "node", s.deleteNodes)
+	s.handle(http.MethodGet, "node/{id}", s.getNode)
+	s.handle(http.MethodGet, "node/name/{name}", s.getNodeByName)
+	s.handle(http.MethodGet, "node/name/check", s.checkNodeName)
+	s.handle(http.MethodGet, "node/address/check", s.checkNodeAddress)
+	s.handle(http.MethodGet, "node/status", s.nodeStatus)
+	s.handle(http.MethodPut, "node/type", s.refreshNodeTypes)
+	s.handle(http.MethodGet, "node/type/check", s.checkNodeTypes)
+	s.handle(http.MethodGet, "overview/platform/node-view/{id}", s.nodeView)
+	s.handle(http.MethodGet, "overview/node", s.nodeMetrics)
+}
+
+// AddNode 注册一个在线的节点并返回它的 ID，效果与调用 POST node 相同。
+func (s *Simulator) AddNode(name, address string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addNode(name, address, "", false).id
+}
+
+// SetNodeStatus 修改节点的状态，例如设置为 "offline" 后 dynamic 策略的服务不会再被调度到它上面。
+func (s *Simulator) SetNodeStatus(name, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.nodeByName(name)
+	if n == nil {
+		return fmt.Errorf("node %q not found", name)
+	}
+	n.status = status
+	return nil
+}
+
+func (s *Simulator) addNode(name, address, password string, tls bool) *node {
+	n := &node{
+		object:   s.newObject(),
+		name:     name,
+		address:  address,
+		password: password,
+		tls:      tls,
+		arch:     "x86-64",
+		typ:      "sylixos",
+		status:   nodeOnline,
+	}
+	s.nodes[n.id] = n
+	return n
+}
+
+func (s *Simulator) nodeByName(name string) *node {
+	for _, n := range s.nodes {
+		if n.name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+// validateNode 检查名称和地址是否为空或者与 excludeID 之外的节点冲突。
+func (s *Simulator) validateNode(name, address, excludeID string) error {
+	switch {
+	case name == "":
+		return errInvalidField("name", "must not be empty")
+	case address == "":
+		return errInvalidField("address", "must not be empty")
+	}
+	for _, n := range s.nodes {
+		if n.id == excludeID {
+			continue
+		}
+		if n.name == name {
+			return errConflict("node name %q already exists", name)
+		}
+		if n.address == address {
+			return errConflict("node address %q already exists", address)
+		}
+	}
+	return nil
+}
+
+func (s *Simulator) registerNode(r *http.Request) (any, error) {
+	var req clientset.NodeRegisterRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if err := s.validateNode(req.Name, req.Address, ""); err != nil {
+		return nil, err
+	}
+	s.addNode(req.Name, req.Address, req.Password, req.TLS != nil && *req.TLS)
+	return nil, nil
+}
+
+func (s *Simulator) updateNode(r *http.Request) (any, error) {
+	var req clientset.NodeUpdateRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	n, ok := s.nodes[req.ID]
+	if !ok {
+		return nil, errNotFound("node %q not found", req.ID)
+	}
+	if err := s.validateNode(req.Name, req.Address, n.id); err != nil {
+		return nil, err
+	}
+	n.name, n.address, n.password, n.tls = req.Name, req.Address, req.Password, req.TLS
+	n.updated = s.clock.Now()
+	return nil, nil
+}
+
+func (s *Simulator) listNodes(r *http.Request) (any, error) {
+	pageNum, pageSize, err := pageParams(r)
+	if err != nil {
+		return nil, err
+	}
+	name := r.URL.Query().Get("name")
+
+	items := []clientset.NodeInfo{}
+	for _, n := range sorted(s.nodes) {
+		if name != "" && !contains(n.name, name) {
+			continue
+		}
+		total, running := s.nodeContainers(n.id)
+		items = append(items, clientset.NodeInfo{
+			ID:                   n.id,
+			Address:              n.address,
+			Name:                 n.name,
+			Password:             n.password,
+			Status:               n.status,
+			Type:                 n.typ,
+			TLS:                  n.tls,
+			ContainerTotal:       total,
+			ContainerRunning:     running,
+			ContainerEcsmTotal:   total,
+			ContainerEcsmRunning: running,
+			UpTime:               s.clock.Since(n.created).Seconds(),
+			CreatedTime:          formatTime(n.created),
+			Arch:                 n.arch,
+		})
+	}
+	return &clientset.NodeList{
+		Total:    len(items),
+		PageNum:  pageNum,
+		PageSize: pageSize,
+		Items:    paginate(items, pageNum, pageSize),
+	}, nil
+}
+
+// nodeContainers 返回节点上的容器总数和运行中的容器数量。
+func (s *Simulator) nodeContainers(nodeID string) (total, running int) {
+	for _, c := range s.containers {
+		if c.nodeID != nodeID {
+			continue
+		}
+		total++
+		if c.status == statusRunning {
+			running++
+		}
+	}
+	return total, running
+}
+
+// deleteNodes 删除没有被服务占用的节点。所有节点都被删除时返回 "success"，否则返回被占用的节点。
+func (s *Simulator) deleteNodes(r *http.Request) (any, error) {
+	var req clientset.NodeDeleteRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	for _, id := range req.IDs {
+		if _, ok := s.nodes[id]; !ok {
+			return nil, errNotFound("node %q not found", id)
+		}
+	}
+
+	var conflicts []clientset.NodeDeleteConflict
+	for _, id := range req.IDs {
+		n := s.nodes[id]
+		var serves []clientset.ConflictingService
+		for _, svc := range sorted(s.services) {
+			if s.serviceOnNode(svc, id) {
+				serves = append(serves, clientset.ConflictingService{ID: svc.id, Name: svc.name})
+			}
+		}
+		if len(serves) > 0 {
+			conflicts = append(conflicts, clientset.NodeDeleteConflict{ID: n.id, Name: n.name, Serves: serves})
+			continue
+		}
+		delete(s.nodes, id)
+	}
+	if len(conflicts) > 0 {
+		return conflicts, nil
+	}
+	return "success", nil
+}
+
+func (s *Simulator) getNode(r *http.Request) (any, error) {
+	n, ok := s.nodes[r.PathValue("id")]
+	if !ok {
+		return nil, errNotFound("node %q not found", r.PathValue("id"))
+	}
+	return &clientset.NodeDetailsByID{
+		ID:          n.id,
+		Address:     n.address,
+		Name:        n.name,
+		Password:    n.password,
+		TLS:         n.tls,
+		Type:        n.typ,
+		CreatedTime: formatTime(n.created),
+		Arch:        n.arch,
+		EcsdVersion: "simulator",
+	}, nil
+}
+
+func (s *Simulator) getNodeByName(r *http.Request) (any, error) {
+	n := s.nodeByName(r.PathValue("name"))
+	if n == nil {
+		return nil, errNotFound("node %q not found", r.PathValue("name"))
+	}
+	ip, port := n.address, defaultNodePort
+	if host, p, err := net.SplitHostPort(n.address); err == nil {
+		ip = host
+		if port, err = strconv.Atoi(p); err != nil {
+			port = defaultNodePort
+		}
+	}
+	details := &clientset.NodeDetailsByName{
+		ID:          n.id,
+		IP:          ip,
+		Port:        port,
+		Name:        n.name,
+		Password:    n.password,
+		Type:        n.typ,
+		CreatedTime: formatTime(n.created),
+		Arch:        n.arch,
+	}
+	if n.tls {
+		details.TLS = 1
+	}
+	return details, nil
+}
+
+func (s *Simulator) checkNodeName(r *http.Request) (any, error) {
+	q := r.URL.Query()
+	for _, n := range s.nodes {
+		if n.name == q.Get("name") && n.id != q.Get("id") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Simulator) checkNodeAddress(r *http.Request) (any, error) {
+	q := r.URL.Query()
+	for _, n := range s.nodes {
+		if n.address == q.Get("address") && n.id != q.Get("id") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Simulator) nodeStatus(r *http.Request) (any, error) {
+	resp := &clientset.NodeStatusResponse{Nodes: []clientset.NodeStatus{}}
+	for _, id := range r.URL.Query()["ids[]"] {
+		n, ok := s.nodes[id]
+		if !ok {
+			continue
+		}
+		total, running := s.nodeContainers(n.id)
+		uptime := s.clock.Since(n.created).Seconds()
+		resp.Nodes = append(resp.Nodes, clientset.NodeStatus{
+			ID:                   n.id,
+			Status:               n.status,
+			CPUUsage:             clientset.NodeCPUUsage{Cores: []float64{}},
+			Uptime:               uptime,
+			ContainerTotal:       total,
+			ContainerRunning:     running,
+			ContainerEcsmTotal:   total,
+			ContainerEcsmRunning: running,
+			Net:                  []clientset.NodeNetInfo{},
+			Time:                 clientset.NodeTimeInfo{Current: s.clock.Now().UnixMilli(), Uptime: uptime, Timezone: "UTC"},
+		})
+	}
+	return resp, nil
+}
+
+func (s *Simulator) refreshNodeTypes(r *http.Request) (any, error) {
+	return nil, nil
+}
+
+// checkNodeTypes 返回类型需要更新的节点。模拟的节点类型不会变化，所以总是返回空列表。
+func (s *Simulator) checkNodeTypes(r *http.Request) (any, error) {
+	return []clientset.NodeTypeUpdateInfo{}, nil
+}
+
+func (s *Simulator) nodeView(r *http.Request) (any, error) {
+	n, ok := s.nodes[r.PathValue("id")]
+	if !ok {
+		return nil, errNotFound("node %q not found", r.PathValue("id"))
+	}
+	view := &clientset.NodeView{ID: n.id, Status: n.status, Type: n.typ, Name: n.name, Children: []clientset.NodeViewContainer{}}
+	for _, c := range sorted(s.containers) {
+		if c.nodeID != n.id {
+			continue
+		}
+		vc := clientset.NodeViewContainer{
+			ID:        c.id,
+			Name:      c.name,
+			NodeID:    n.id,
+			ServiceID: c.serviceID,
+			Type:      "container",
+			Status:    c.status,
+			Children:  []clientset.NodeViewProvision{},
+		}
+		if svc, ok := s.services[c.serviceID]; ok {
+			vc.Children = append(vc.Children, clientset.NodeViewProvision{
+				ID:     svc.id,
+				Status: s.serviceStatus(svc),
+				Name:   svc.name,
+				Type:   "service",
+				Health: s.serviceHealthy(svc),
+			})
+		}
+		view.Children = append(view.Children, vc)
+	}
+	return view, nil
+}
+
+func (s *Simulator) nodeMetrics(r *http.Request) (any, error) {
+	id := r.URL.Query().Get("nodeId")
+	n, ok := s.nodes[id]
+	if !ok {
+		return nil, errNotFound("node %q not found", id)
+	}
+	total, running := s.nodeContainers(n.id)
+	return []clientset.NodeMetrics{{
+		Timestamp: s.clock.Now().UnixMilli(),
+		Type:      n.typ,
+		CPU:       clientset.MetricValue{Percent: "0"},
+		ROM:       clientset.MetricValueWithSize{Percent: "0"},
+		RAM:       clientset.MetricValueWithSize{Percent: "0"},
+		Processes: []clientset.ProcessMetrics{},
+		UpNet:     []clientset.NetMetrics{},
+		DownNet:   []clientset.NetMetrics{},
+		Uptime:    s.clock.Since(n.created).Seconds(),
+		Running:   running,
+		Stop:      total - running,
+	}}, nil
+}
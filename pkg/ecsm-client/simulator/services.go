@@ -0,0 +1,974 @@
+// file: pkg/ecsm-client/simulator/services.go
+
+package simulator
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/google/uuid"
+)
+
+// 服务处于部署、删除或失败阶段时，状态就是阶段本身；否则由容器的状态推导。
+const (
+	phaseDeploying = "deploying"
+	phaseDeleting  = "deleting"
+	phaseFailed    = "failed"
+)
+
+// 容器的状态
+const (
+	statusCreating = "creating"
+	statusRunning  = "running"
+	statusStopped  = "stopped"
+	statusPaused   = "paused"
+	statusExited   = "exited"
+)
+
+const (
+	policyDynamic = "dynamic"
+	policyStatic  = "static"
+)
+
+// serviceActions 是 service/{action}/ids 和 service/{action}/path-label 支持的操作
+var serviceActions = map[string]bool{
+	"start": true, "stop": true, "restart": true, "pause": true, "unpause": true, "destroy": true,
+}
+
+type service struct {
+	object
+	name       string
+	phase      string
+	image      clientset.ImageSpec
+	node       clientset.NodeSpec
+	factor     int
+	policy     string
+	prepull    bool
+	pathLabel  string
+	templateID string
+	deployNum  int
+}
+
+type container struct {
+	object
+	taskID        string
+	name          string
+	serviceID     string
+	nodeID        string
+	status        string
+	deployStatus  string
+	failedMessage string
+	restartCount  int
+	deployNum     int
+	started       time.Time
+	history       []clientset.ContainerHistory
+}
+
+type record struct {
+	object
+	serviceID string
+	name      string
+	action    string
+	image     clientset.ImageSpec
+	node      clientset.NodeSpec
+	factor    int
+	policy    string
+}
+
+func (s *Simulator) installServiceRoutes() {
+	s.handle(http.MethodPost, "service", s.createService)
+	s.handle(http.MethodPut, "service", s.updateService)
+	s.handle(http.MethodGet, "service", s.listServices)
+	s.handle(http.MethodGet, "service/{id}", s.getService)
+	s.handle(http.MethodDelete, "service/{id}", s.deleteService)
+	s.handle(http.MethodDelete, "service/path", s.deleteServicesByPath)
+	s.handle(http.MethodPost, "service/{action}/templates-path-label", s.createServicesByPath)
+	s.handle(http.MethodPost, "service/{action}/ids", s.controlServicesByID)
+	s.handle(http.MethodPost, "service/{action}/path-label", s.controlServicesByLabel)
+	s.handle(http.MethodPut, "service/deployment/restart", s.redeployService)
+	s.handle(http.MethodPut, "service/rollback", s.rollbackService)
+	s.handle(http.MethodPut, "service/container", s.controlServiceContainers)
+	s.handle(http.MethodGet, "service/name/check", s.checkServiceName)
+	s.handle(http.MethodGet, "service/summary", s.serviceSummary)
+
+	s.handle(http.MethodGet, "service/record", s.listRecords)
+	s.handle(http.MethodGet, "service/record/{id}", s.getRecord)
+	s.handle(http.MethodDelete, "service/record", s.deleteRecord)
+
+	s.handle(http.MethodGet, "container/{taskId}", s.getContainer)
+	s.handle(http.MethodGet, "container/service", s.listContainersByService)
+	s.handle(http.MethodGet, "container/node", s.listContainersByNode)
+	s.handle(http.MethodGet, "container/action/history", s.containerHistory)
+	s.handle(http.MethodPut, "container", s.controlContainer)
+}
+
+// FailContainer 让 ID 为 containerID 的容器以 message 失败退出，所属的服务随之变为不健康。
+// 已经到期的事务会先完成，避免刚提交的部署事务覆盖这里的状态。
+func (s *Simulator) FailContainer(containerID, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance()
+	c, ok := s.containers[containerID]
+	if !ok {
+		return fmt.Errorf("container %q not found", containerID)
+	}
+	c.status = statusExited
+	c.deployStatus = "failed"
+	c.failedMessage = message
+	c.updated = s.clock.Now()
+	return nil
+}
+
+// --- 服务 ---
+
+func (s *Simulator) createService(r *http.Request) (any, error) {
+	var req clientset.CreateServiceRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	svc := &service{
+		object:  s.newObject(),
+		name:    req.Name,
+		image:   req.Image,
+		node:    req.Node,
+		policy:  req.Policy,
+		prepull: req.Prepull != nil && *req.Prepull,
+	}
+	if req.Factor != nil {
+		svc.factor = *req.Factor
+	}
+	_, ids, err := s.saveService(svc, req.Image.Action)
+	if err != nil {
+		return nil, err
+	}
+	return &clientset.ServiceCreateResponse{ID: svc.id, Containers: ids}, nil
+}
+
+func (s *Simulator) updateService(r *http.Request) (any, error) {
+	var req clientset.UpdateServiceRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	old, ok := s.services[req.ID]
+	if !ok {
+		return nil, errNotFound("service %q not found", req.ID)
+	}
+	svc := *old
+	svc.name, svc.image, svc.node, svc.policy = req.Name, req.Image, req.Node, req.Policy
+	if req.Factor != nil {
+		svc.factor = *req.Factor
+	}
+	_, ids, err := s.saveService(&svc, req.Image.Action)
+	if err != nil {
+		return nil, err
+	}
+	return &clientset.ServiceCreateResponse{ID: svc.id, Containers: ids}, nil
+}
+
+// saveService 校验 svc 并为它调度容器，成功后保存 svc、记录一条部署记录并开始部署。
+// svc 可以是新的服务，也可以是已有服务修改后的副本。
+func (s *Simulator) saveService(svc *service, action string) (*transaction, []string, error) {
+	if svc.policy == "" {
+		svc.policy = policyDynamic
+	}
+	if err := s.validateService(svc); err != nil {
+		return nil, nil, err
+	}
+	placements, err := s.schedule(svc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if svc.factor == 0 {
+		svc.factor = len(placements)
+	}
+
+	svc.updated = s.clock.Now()
+	s.services[svc.id] = svc
+	s.addRecord(svc, action)
+	tx, ids := s.deploy(svc, placements, action)
+	return tx, ids, nil
+}
+
+func (s *Simulator) validateService(svc *service) error {
+	switch {
+	case svc.name == "":
+		return errInvalidField("name", "must not be empty")
+	case svc.image.Ref == "":
+		return errInvalidField("image.ref", "must not be empty")
+	case svc.policy != policyDynamic && svc.policy != policyStatic:
+		return errInvalidField("policy", "unknown policy %q", svc.policy)
+	case svc.factor < 0:
+		return errInvalidField("factor", "must not be negative")
+	}
+	if name, tag, _ := parseRef(svc.image.Ref); name == "" || tag == "" {
+		return errInvalidField("image.ref", "%q is not of the form name@tag[#os]", svc.image.Ref)
+	}
+	for _, other := range s.services {
+		if other.id != svc.id && other.name == svc.name {
+			return errConflict("service name %q already exists", svc.name)
+		}
+	}
+	return nil
+}
+
+// schedule 返回 svc 的每个容器所在的节点。
+// static 策略在列出的每个节点上运行一个容器；dynamic 策略把 factor 个容器轮流放在列出的节点上，
+// 没有列出节点时使用所有在线的节点。
+func (s *Simulator) schedule(svc *service) ([]*node, error) {
+	var pool []*node
+	for _, name := range svc.node.Names {
+		n := s.nodeByName(name)
+		if n == nil {
+			return nil, errInvalidField("node.names", "node %q not found", name)
+		}
+		pool = append(pool, n)
+	}
+	if svc.policy == policyStatic {
+		if len(pool) == 0 {
+			return nil, errInvalidField("node.names", "static policy requires at least one node")
+		}
+		return pool, nil
+	}
+
+	if len(pool) == 0 {
+		for _, n := range sorted(s.nodes) {
+			if n.status == nodeOnline {
+				pool = append(pool, n)
+			}
+		}
+	}
+	if len(pool) == 0 {
+		return nil, errBadRequest("no online node is available")
+	}
+	placements := make([]*node, max(svc.factor, 1))
+	for i := range placements {
+		placements[i] = pool[i%len(pool)]
+	}
+	return placements, nil
+}
+
+// deploy 用新的容器替换 svc 现有的容器，并提交一个完成部署的事务。
+// action 为 "load" 时只预部署，容器在部署完成后处于停止状态。
+func (s *Simulator) deploy(svc *service, placements []*node, action string) (*transaction, []string) {
+	s.removeContainers(svc.id)
+	svc.deployNum++
+	svc.phase = phaseDeploying
+
+	var ids []string
+	for _, n := range placements {
+		c := &container{
+			object:       s.newObject(),
+			taskID:       uuid.NewString(),
+			serviceID:    svc.id,
+			nodeID:       n.id,
+			status:       statusCreating,
+			deployStatus: "deploying",
+			deployNum:    svc.deployNum,
+		}
+		c.name = fmt.Sprintf("%s-%s", svc.name, c.id[:8])
+		s.containers[c.id] = c
+		ids = append(ids, c.id)
+	}
+
+	id, deployNum := svc.id, svc.deployNum
+	// 服务在事务完成前被重新部署或删除时，这次部署不再生效
+	current := func() *service {
+		svc, ok := s.services[id]
+		if !ok || svc.deployNum != deployNum || svc.phase != phaseDeploying {
+			return nil
+		}
+		return svc
+	}
+	tx := s.newTransaction(map[string]any{"serviceId": id, "containers": ids}, func() {
+		svc := current()
+		if svc == nil {
+			return
+		}
+		svc.phase = ""
+		now := s.clock.Now()
+		for _, c := range s.serviceContainers(id) {
+			c.status, c.deployStatus, c.started = statusRunning, "success", now
+			if action == "load" {
+				c.status = statusStopped
+			}
+		}
+	}, func(message string) {
+		svc := current()
+		if svc == nil {
+			return
+		}
+		svc.phase = phaseFailed
+		for _, c := range s.serviceContainers(id) {
+			c.status, c.deployStatus, c.failedMessage = statusExited, "failed", message
+		}
+	})
+	return tx, ids
+}
+
+// destroyService 提交一个删除 svc 及其容器和部署记录的事务。
+func (s *Simulator) destroyService(svc *service) *transaction {
+	svc.phase = phaseDeleting
+	id := svc.id
+	return s.newTransaction(map[string]string{"serviceId": id}, func() {
+		s.removeContainers(id)
+		for rid, rec := range s.records {
+			if rec.serviceID == id {
+				delete(s.records, rid)
+			}
+		}
+		delete(s.loadBalances, id)
+		delete(s.services, id)
+	}, func(string) {
+		if svc, ok := s.services[id]; ok && svc.phase == phaseDeleting {
+			svc.phase = ""
+		}
+	})
+}
+
+func (s *Simulator) getService(r *http.Request) (any, error) {
+	svc, ok := s.services[r.PathValue("id")]
+	if !ok {
+		return nil, errNotFound("service %q not found", r.PathValue("id"))
+	}
+	return s.serviceGet(svc), nil
+}
+
+func (s *Simulator) deleteService(r *http.Request) (any, error) {
+	svc, ok := s.services[r.PathValue("id")]
+	if !ok {
+		return nil, errNotFound("service %q not found", r.PathValue("id"))
+	}
+	tx := s.destroyService(svc)
+	return &clientset.ServiceDeleteResponse{ID: tx.id}, nil
+}
+
+func (s *Simulator) deleteServicesByPath(r *http.Request) (any, error) {
+	var req struct {
+		Path string `json:"path"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	results := []clientset.DeleteByPathResult{}
+	for _, svc := range sorted(s.services) {
+		if svc.pathLabel == "" || !underPath(svc.pathLabel, req.Path) {
+			continue
+		}
+		tx := s.destroyService(svc)
+		results = append(results, clientset.DeleteByPathResult{ProvisionID: svc.id, Result: "success", TransactionID: tx.id})
+	}
+	return results, nil
+}
+
+func (s *Simulator) createServicesByPath(r *http.Request) (any, error) {
+	action := r.PathValue("action")
+	if action != "run" && action != "load" {
+		return nil, errBadRequest("unsupported action %q", action)
+	}
+	var req clientset.CreateByPathOptions
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	force := req.Force != nil && *req.Force
+
+	var templates []*template
+	for _, p := range req.Paths {
+		found, err := s.serviceTemplatesUnder(p)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, found...)
+	}
+	if len(templates) == 0 {
+		return nil, errNotFound("no service templates found under %v", req.Paths)
+	}
+
+	results := []clientset.ServiceCreateResponse{}
+	for _, t := range templates {
+		svc, _, ids, err := s.deployTemplate(t, action, force)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, clientset.ServiceCreateResponse{ID: svc.id, Containers: ids})
+	}
+	return results, nil
+}
+
+func (s *Simulator) controlServicesByID(r *http.Request) (any, error) {
+	action := r.PathValue("action")
+	if !serviceActions[action] {
+		return nil, errBadRequest("unsupported action %q", action)
+	}
+	var req clientset.ControlServicesResponse
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	var targets []*service
+	for _, id := range req.IDs {
+		svc, ok := s.services[id]
+		if !ok {
+			return nil, errNotFound("service %q not found", id)
+		}
+		targets = append(targets, svc)
+	}
+	return s.controlServices(targets, action), nil
+}
+
+func (s *Simulator) controlServicesByLabel(r *http.Request) (any, error) {
+	action := r.PathValue("action")
+	if !serviceActions[action] {
+		return nil, errBadRequest("unsupported action %q", action)
+	}
+	var req struct {
+		Path string `json:"path"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	var targets []*service
+	for _, svc := range sorted(s.services) {
+		if svc.pathLabel != "" && underPath(svc.pathLabel, req.Path) {
+			targets = append(targets, svc)
+		}
+	}
+	return s.controlServices(targets, action), nil
+}
+
+func (s *Simulator) controlServices(targets []*service, action string) *clientset.ControlServicesResponse {
+	result := &clientset.ControlServicesResponse{IDs: []string{}}
+	for _, svc := range targets {
+		if action == "destroy" {
+			s.destroyService(svc)
+		} else {
+			s.controlContainers(s.serviceContainers(svc.id), clientset.ContainerAction(action))
+		}
+		result.IDs = append(result.IDs, svc.id)
+	}
+	return result
+}
+
+func (s *Simulator) redeployService(r *http.Request) (any, error) {
+	var req clientset.RedeployRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	svc, ok := s.services[req.ID]
+	if !ok {
+		return nil, errNotFound("service %q not found", req.ID)
+	}
+	placements, err := s.schedule(svc)
+	if err != nil {
+		return nil, err
+	}
+	s.deploy(svc, placements, svc.image.Action)
+	return nil, nil
+}
+
+func (s *Simulator) rollbackService(r *http.Request) (any, error) {
+	var req clientset.RollBackRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	old, ok := s.services[req.ID]
+	if !ok {
+		return nil, errNotFound("service %q not found", req.ID)
+	}
+	rec, ok := s.records[req.RecordID]
+	if !ok {
+		return nil, errNotFound("record %q not found", req.RecordID)
+	}
+	if rec.serviceID != old.id {
+		return nil, errBadRequest("record %q does not belong to service %q", rec.id, old.id)
+	}
+
+	svc := *old
+	svc.name, svc.image, svc.node, svc.factor, svc.policy = rec.name, rec.image, rec.node, rec.factor, rec.policy
+	tx, _, err := s.saveService(&svc, rec.action)
+	if err != nil {
+		return nil, err
+	}
+	return tx.toAPI(), nil
+}
+
+func (s *Simulator) checkServiceName(r *http.Request) (any, error) {
+	q := r.URL.Query()
+	for _, svc := range s.services {
+		if svc.name == q.Get("name") && svc.id != q.Get("id") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Simulator) serviceSummary(r *http.Request) (any, error) {
+	stats := &clientset.ServiceStatistics{Total: len(s.services)}
+	for _, svc := range s.services {
+		if s.serviceHealthy(svc) {
+			stats.Health++
+		}
+	}
+	return stats, nil
+}
+
+func (s *Simulator) listServices(r *http.Request) (any, error) {
+	pageNum, pageSize, err := pageParams(r)
+	if err != nil {
+		return nil, err
+	}
+	q := r.URL.Query()
+
+	var rows []clientset.ProvisionListRow
+	for _, svc := range sorted(s.services) {
+		if name := q.Get("name"); name != "" && !contains(svc.name, name) {
+			continue
+		}
+		if imageID := q.Get("id"); imageID != "" {
+			if img := s.images[imageID]; img == nil || s.imageByRef(svc.image.Ref) != img {
+				continue
+			}
+		}
+		if nodeID := q.Get("nodeId"); nodeID != "" && !s.serviceOnNode(svc, nodeID) {
+			continue
+		}
+		if label := q.Get("label"); label != "" && (svc.pathLabel == "" || !underPath(svc.pathLabel, label)) {
+			continue
+		}
+		rows = append(rows, s.serviceRow(svc))
+	}
+	return &clientset.ServiceList{
+		Total:    len(rows),
+		PageNum:  pageNum,
+		PageSize: pageSize,
+		Items:    paginate(rows, pageNum, pageSize),
+	}, nil
+}
+
+// serviceStatus 返回服务的状态：部署、删除或失败阶段之外，由容器的状态推导。
+func (s *Simulator) serviceStatus(svc *service) string {
+	if svc.phase != "" {
+		return svc.phase
+	}
+	status := statusStopped
+	for _, c := range s.serviceContainers(svc.id) {
+		switch c.status {
+		case statusRunning:
+			return statusRunning
+		case statusPaused:
+			status = statusPaused
+		}
+	}
+	return status
+}
+
+func (s *Simulator) serviceHealthy(svc *service) bool {
+	containers := s.serviceContainers(svc.id)
+	if svc.phase != "" || len(containers) == 0 {
+		return false
+	}
+	for _, c := range containers {
+		if c.status != statusRunning || c.failedMessage != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Simulator) serviceOnNode(svc *service, nodeID string) bool {
+	for _, c := range s.serviceContainers(svc.id) {
+		if c.nodeID == nodeID {
+			return true
+		}
+	}
+	if n, ok := s.nodes[nodeID]; ok {
+		for _, name := range svc.node.Names {
+			if name == n.name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Simulator) serviceGet(svc *service) *clientset.ServiceGet {
+	image, node := svc.image, svc.node
+	get := &clientset.ServiceGet{
+		ID:          svc.id,
+		Name:        svc.name,
+		Status:      s.serviceStatus(svc),
+		Healthy:     s.serviceHealthy(svc),
+		Factor:      svc.factor,
+		Policy:      svc.policy,
+		CreatedTime: formatTime(svc.created),
+		UpdatedTime: formatTime(svc.updated),
+		Image:       &image,
+		Node:        &node,
+	}
+	get.ContainerStatusGroup, get.NodeList, get.InstanceOnline = s.serviceInstances(svc)
+	for _, c := range s.serviceContainers(svc.id) {
+		if c.deployStatus == "success" {
+			get.InstanceActive++
+		}
+	}
+	return get
+}
+
+func (s *Simulator) serviceRow(svc *service) clientset.ProvisionListRow {
+	name, tag, os := parseRef(svc.image.Ref)
+	row := clientset.ProvisionListRow{
+		ID:             svc.id,
+		Name:           svc.name,
+		Status:         s.serviceStatus(svc),
+		UpdatedTime:    formatTime(svc.updated),
+		CreatedTime:    formatTime(svc.created),
+		ImageList:      []clientset.ImageListEntry{{Name: name, OS: os, Tag: tag}},
+		Factor:         svc.factor,
+		Policy:         svc.policy,
+		ErrorInstances: []clientset.ErrorInstance{},
+		DefaultLabels:  []string{},
+		PathLabel:      svc.pathLabel,
+	}
+	row.ContainerStatusGroup, row.NodeList, row.InstanceOnline = s.serviceInstances(svc)
+	for _, c := range s.serviceContainers(svc.id) {
+		if c.failedMessage == "" {
+			continue
+		}
+		ei := clientset.ErrorInstance{ContainerID: c.id, NodeID: c.nodeID, Message: c.failedMessage}
+		if n, ok := s.nodes[c.nodeID]; ok {
+			ei.NodeName = n.name
+		}
+		row.ErrorInstances = append(row.ErrorInstances, ei)
+	}
+	return row
+}
+
+// serviceInstances 返回服务的容器状态、容器所在的节点以及运行中的容器数量。
+func (s *Simulator) serviceInstances(svc *service) (statuses []string, nodes []clientset.ServiceNodeInfo, online int) {
+	statuses, nodes = []string{}, []clientset.ServiceNodeInfo{}
+	seen := make(map[string]bool)
+	for _, c := range s.serviceContainers(svc.id) {
+		statuses = append(statuses, c.status)
+		if c.status == statusRunning {
+			online++
+		}
+		if n, ok := s.nodes[c.nodeID]; ok && !seen[n.id] {
+			seen[n.id] = true
+			nodes = append(nodes, clientset.ServiceNodeInfo{NodeID: n.id, NodeName: n.name, Address: n.address})
+		}
+	}
+	return statuses, nodes, online
+}
+
+// --- 部署记录 ---
+
+func (s *Simulator) addRecord(svc *service, action string) {
+	rec := &record{
+		object:    s.newObject(),
+		serviceID: svc.id,
+		name:      svc.name,
+		action:    action,
+		image:     svc.image,
+		node:      svc.node,
+		factor:    svc.factor,
+		policy:    svc.policy,
+	}
+	s.records[rec.id] = rec
+}
+
+// recordGet 是 GET service/record/{id} 的响应，其中的 node 与创建服务时的 node 相同。
+type recordGet struct {
+	clientset.RecordGet
+	Node clientset.NodeSpec `json:"node"`
+}
+
+func (s *Simulator) listRecords(r *http.Request) (any, error) {
+	pageNum, pageSize, err := pageParams(r)
+	if err != nil {
+		return nil, err
+	}
+	serviceID := r.URL.Query().Get("id")
+
+	// 最新的记录在前
+	records := sorted(s.records)
+	items := []clientset.DeployRecord{}
+	for i := len(records) - 1; i >= 0; i-- {
+		rec := records[i]
+		if serviceID != "" && rec.serviceID != serviceID {
+			continue
+		}
+		node := rec.node
+		item := clientset.DeployRecord{
+			ID:          rec.id,
+			Name:        rec.name,
+			CreatedTime: formatTime(rec.created),
+			Image:       rec.image.Ref,
+			Node:        &node,
+		}
+		if cfg := rec.image.Config; cfg != nil && cfg.Process != nil {
+			item.CMD = strings.Join(cfg.Process.Args, " ")
+		}
+		items = append(items, item)
+	}
+	return &clientset.RecordList{
+		Total:    len(items),
+		PageNum:  pageNum,
+		PageSize: pageSize,
+		Items:    paginate(items, pageNum, pageSize),
+	}, nil
+}
+
+func (s *Simulator) getRecord(r *http.Request) (any, error) {
+	rec, ok := s.records[r.PathValue("id")]
+	if !ok {
+		return nil, errNotFound("record %q not found", r.PathValue("id"))
+	}
+	factor := rec.factor
+	get := &recordGet{
+		RecordGet: clientset.RecordGet{
+			Name: rec.name,
+			Image: clientset.ImageInfo{
+				Ref:         rec.image.Ref,
+				PullPolicy:  rec.image.PullPolicy,
+				AutoUpgrade: rec.image.AutoUpgrade,
+			},
+			Action:      rec.action,
+			Policy:      rec.policy,
+			Factor:      &factor,
+			VSOA:        rec.image.VSOA,
+			Config:      rec.image.Config,
+			CreatedTime: formatTime(rec.created),
+		},
+		Node: rec.node,
+	}
+	if cfg := rec.image.Config; cfg != nil && cfg.Process != nil {
+		get.Cmd = cfg.Process.Args
+	}
+	return get, nil
+}
+
+func (s *Simulator) deleteRecord(r *http.Request) (any, error) {
+	id := r.URL.Query().Get("id")
+	if _, ok := s.records[id]; !ok {
+		return nil, errNotFound("record %q not found", id)
+	}
+	delete(s.records, id)
+	return nil, nil
+}
+
+// --- 容器 ---
+
+// serviceContainers 按创建顺序返回服务的容器。
+func (s *Simulator) serviceContainers(serviceID string) []*container {
+	var containers []*container
+	for _, c := range sorted(s.containers) {
+		if c.serviceID == serviceID {
+			containers = append(containers, c)
+		}
+	}
+	return containers
+}
+
+func (s *Simulator) removeContainers(serviceID string) {
+	for id, c := range s.containers {
+		if c.serviceID == serviceID {
+			delete(s.containers, id)
+		}
+	}
+}
+
+// controlContainers 记录操作历史，并提交一个对 containers 执行 action 的事务。
+func (s *Simulator) controlContainers(containers []*container, action clientset.ContainerAction) *transaction {
+	var ids []string
+	for _, c := range containers {
+		c.history = append(c.history, clientset.ContainerHistory{
+			ID:   uuid.NewString(),
+			Cmd:  string(action),
+			User: "admin",
+			Time: formatTime(s.clock.Now()),
+		})
+		ids = append(ids, c.id)
+	}
+	return s.newTransaction(map[string]any{"containers": ids, "action": action}, func() {
+		now := s.clock.Now()
+		for _, id := range ids {
+			c, ok := s.containers[id]
+			if !ok {
+				continue
+			}
+			switch action {
+			case clientset.ActionStart, clientset.ActionUnpause:
+				if c.status != statusRunning && c.status != statusPaused {
+					c.started = now
+				}
+				c.status = statusRunning
+			case clientset.ActionRestart:
+				c.status, c.started = statusRunning, now
+				c.restartCount++
+			case clientset.ActionStop:
+				c.status = statusStopped
+			case clientset.ActionPause:
+				c.status = statusPaused
+			}
+			if c.status == statusRunning {
+				c.failedMessage = ""
+			}
+			c.updated = now
+		}
+	}, nil)
+}
+
+func validContainerAction(action clientset.ContainerAction) error {
+	switch action {
+	case clientset.ActionStart, clientset.ActionStop, clientset.ActionRestart, clientset.ActionPause, clientset.ActionUnpause:
+		return nil
+	}
+	return errInvalidField("action", "unsupported action %q", action)
+}
+
+func (s *Simulator) controlContainer(r *http.Request) (any, error) {
+	var req clientset.ContainerControlByNameRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if err := validContainerAction(req.Action); err != nil {
+		return nil, err
+	}
+	for _, c := range s.containers {
+		if c.name == req.Name {
+			return s.controlContainers([]*container{c}, req.Action).toAPI(), nil
+		}
+	}
+	return nil, errNotFound("container %q not found", req.Name)
+}
+
+func (s *Simulator) controlServiceContainers(r *http.Request) (any, error) {
+	var req clientset.ServiceControlContainerRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if err := validContainerAction(req.Action); err != nil {
+		return nil, err
+	}
+	if _, ok := s.services[req.ID]; !ok {
+		return nil, errNotFound("service %q not found", req.ID)
+	}
+	return s.controlContainers(s.serviceContainers(req.ID), req.Action).toAPI(), nil
+}
+
+func (s *Simulator) containerByTaskID(taskID string) *container {
+	for _, c := range s.containers {
+		if c.taskID == taskID {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *Simulator) getContainer(r *http.Request) (any, error) {
+	c := s.containerByTaskID(r.PathValue("taskId"))
+	if c == nil {
+		return nil, errNotFound("container with task %q not found", r.PathValue("taskId"))
+	}
+	return s.containerInfo(c), nil
+}
+
+func (s *Simulator) listContainersByService(r *http.Request) (any, error) {
+	ids := r.URL.Query()["serviceIds[]"]
+	if len(ids) == 0 {
+		return nil, errInvalidField("serviceIds", "must not be empty")
+	}
+	return s.listContainers(r, func(c *container) bool { return slices.Contains(ids, c.serviceID) })
+}
+
+func (s *Simulator) listContainersByNode(r *http.Request) (any, error) {
+	ids := r.URL.Query()["nodeIds[]"]
+	if len(ids) == 0 {
+		return nil, errInvalidField("nodeIds", "must not be empty")
+	}
+	return s.listContainers(r, func(c *container) bool { return slices.Contains(ids, c.nodeID) })
+}
+
+func (s *Simulator) listContainers(r *http.Request, match func(*container) bool) (any, error) {
+	pageNum, pageSize, err := pageParams(r)
+	if err != nil {
+		return nil, err
+	}
+	key := r.URL.Query().Get("key")
+
+	items := []clientset.ContainerInfo{}
+	for _, c := range sorted(s.containers) {
+		if match(c) && (key == "" || contains(c.name, key)) {
+			items = append(items, s.containerInfo(c))
+		}
+	}
+	return &clientset.ContainerList{
+		Total:    len(items),
+		PageNum:  pageNum,
+		PageSize: pageSize,
+		Items:    paginate(items, pageNum, pageSize),
+	}, nil
+}
+
+func (s *Simulator) containerHistory(r *http.Request) (any, error) {
+	pageNum, pageSize, err := pageParams(r)
+	if err != nil {
+		return nil, err
+	}
+	c := s.containerByTaskID(r.URL.Query().Get("id"))
+	if c == nil {
+		return nil, errNotFound("container with task %q not found", r.URL.Query().Get("id"))
+	}
+	// 最新的操作在前
+	items := make([]clientset.ContainerHistory, 0, len(c.history))
+	for i := len(c.history) - 1; i >= 0; i-- {
+		items = append(items, c.history[i])
+	}
+	return &clientset.ContainerHistoryList{
+		Total:    len(items),
+		PageNum:  pageNum,
+		PageSize: pageSize,
+		Items:    paginate(items, pageNum, pageSize),
+	}, nil
+}
+
+func (s *Simulator) containerInfo(c *container) clientset.ContainerInfo {
+	info := clientset.ContainerInfo{
+		ID:              c.id,
+		TaskID:          c.taskID,
+		Name:            c.name,
+		Status:          c.status,
+		StartedTime:     formatTime(c.started),
+		CreatedTime:     formatTime(c.created),
+		TaskCreatedTime: formatTime(c.created),
+		DeployStatus:    c.deployStatus,
+		RestartCount:    c.restartCount,
+		DeployNum:       c.deployNum,
+		CPUUsage:        clientset.CPUUsage{Cores: []float64{}},
+		ServiceID:       c.serviceID,
+		NodeID:          c.nodeID,
+	}
+	if c.failedMessage != "" {
+		message := c.failedMessage
+		info.FailedMessage = &message
+	}
+	if c.status == statusRunning && !c.started.IsZero() {
+		info.Uptime = int(s.clock.Since(c.started).Seconds())
+	}
+	if svc, ok := s.services[c.serviceID]; ok {
+		info.ServiceName = svc.name
+		info.ImageName, info.ImageVersion, info.ImageOS = parseRef(svc.image.Ref)
+		if img := s.imageByRef(svc.image.Ref); img != nil {
+			info.ImageID, info.ImageArch = img.id, img.arch
+		}
+		if cfg := svc.image.Config; cfg != nil && cfg.SylixOS != nil && cfg.SylixOS.Resources != nil && cfg.SylixOS.Resources.Memory != nil {
+			info.MemoryLimit = int64(cfg.SylixOS.Resources.Memory.MemoryLimitMB) << 20
+		}
+	}
+	if n, ok := s.nodes[c.nodeID]; ok {
+		info.NodeName, info.Address, info.NodeArch = n.name, n.address, n.arch
+	}
+	return info
+}
@@ -0,0 +1,468 @@
+// file: pkg/ecsm-client/simulator/simulator.go
+
+// Package simulator 实现了一个内存中的 ECSM API Server。
+//
+// Simulator 是一个 http.Handler，实现了 clientset 调用的服务、容器、节点、镜像、资源模板、配置、
+// 部署记录和微服务接口，并在多次调用之间保持一致的状态。与真实的 ECSM 一样，部署、删除和控制操作是异步的：
+// 接口先返回，操作在对应的事务完成时才生效，事务的状态可以通过 transaction 接口查询。配合 httptest.NewServer 或 Transport，
+// 可以在没有真实 ECSM 的环境（例如 CI）中测试 ecsm-client、ecsm-cli 和控制器，并通过 InjectFault 模拟故障。
+package simulator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/rest"
+	"github.com/google/uuid"
+	"k8s.io/utils/clock"
+)
+
+// APIPrefix 是 Simulator 提供 ECSM API 的路径前缀。
+const APIPrefix = "/api/v1/"
+
+// Simulator 是一个内存中的 ECSM API Server。它的所有方法都可以并发调用。
+type Simulator struct {
+	mux *http.ServeMux
+
+	mu      sync.Mutex
+	clock   clock.PassiveClock
+	txDelay time.Duration
+	seq     int
+
+	nodes        map[string]*node
+	images       map[string]*image
+	services     map[string]*service
+	containers   map[string]*container
+	records      map[string]*record
+	templates    map[string]*template
+	configs      map[string]*configItem
+	loadBalances map[string]*clientset.UpdateMicroServiceRequest
+	transactions map[string]*transaction
+
+	faults   []*Fault
+	failNext []string
+}
+
+// New 创建一个空的 Simulator。节点和镜像可以通过 AddNode、AddImage 预置，也可以通过 API 注册。
+func New() *Simulator {
+	s := &Simulator{
+		mux:          http.NewServeMux(),
+		clock:        clock.RealClock{},
+		nodes:        make(map[string]*node),
+		images:       make(map[string]*image),
+		services:     make(map[string]*service),
+		containers:   make(map[string]*container),
+		records:      make(map[string]*record),
+		templates:    make(map[string]*template),
+		configs:      make(map[string]*configItem),
+		loadBalances: make(map[string]*clientset.UpdateMicroServiceRequest),
+		transactions: make(map[string]*transaction),
+	}
+	s.installServiceRoutes()
+	s.installNodeRoutes()
+	s.installImageRoutes()
+	s.installTemplateRoutes()
+	s.installConfigRoutes()
+	s.installMicroServiceRoutes()
+	s.handle(http.MethodGet, "transaction/{id}", s.getTransaction)
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, errNotFound("no route for %s %s", r.Method, r.URL.Path))
+	})
+	return s
+}
+
+// SetClock 替换 Simulator 使用的时钟，测试中可以使用 k8s.io/utils/clock/testing 的 FakeClock 控制事务完成的时间。
+func (s *Simulator) SetClock(c clock.PassiveClock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = c
+}
+
+// SetTransactionDelay 设置异步事务从提交到完成的时间。
+// 默认为 0：事务在提交它的请求返回之后、下一个请求被处理之前完成。
+func (s *Simulator) SetTransactionDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txDelay = d
+}
+
+// ServeHTTP 实现了 http.Handler。
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f := s.matchFault(r); f != nil {
+		if done := serveFault(w, r, f); done {
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// Transport 返回一个直接调用 Simulator 的 http.RoundTripper，不需要监听端口。
+func (s *Simulator) Transport() http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		resp := rec.Result()
+		resp.Request = req
+		return resp, nil
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// --- 故障注入 ---
+
+// Fault 描述了一个注入的故障：匹配的请求会先等待 Delay，然后以 StatusCode 失败。
+type Fault struct {
+	// Method 为空时匹配所有方法。
+	Method string
+	// Path 是相对 APIPrefix 的路径前缀，按路径段匹配，例如 "service" 匹配 "service" 和 "service/summary"，
+	// 但不匹配 "services"。为空时匹配所有路径。
+	Path string
+	// StatusCode 为 0 时请求在 Delay 之后被正常处理，可以用于模拟慢响应。
+	StatusCode int
+	// Message 是错误响应信封中的 message，为空时使用状态码的描述。
+	Message string
+	// RetryAfter 不为 0 时在错误响应中设置 Retry-After 头。
+	RetryAfter time.Duration
+	// Delay 是响应前的等待时间，请求的 ctx 结束时停止等待。
+	Delay time.Duration
+	// Times 是故障生效的次数，0 表示一直生效。
+	Times int
+}
+
+// InjectFault 注入一个故障。多个故障同时匹配时使用最先注入的那个。
+func (s *Simulator) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults 移除所有注入的故障。
+func (s *Simulator) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// FailNextTransaction 让下一个提交的异步事务以 message 失败，例如模拟镜像拉取失败。
+// 多次调用会依次作用于之后的多个事务。
+func (s *Simulator) FailNextTransaction(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failNext = append(s.failNext, message)
+}
+
+// matchFault 返回匹配 r 的故障，并扣减它的剩余次数。
+func (s *Simulator) matchFault(r *http.Request) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := strings.TrimPrefix(r.URL.Path, APIPrefix)
+	for i, f := range s.faults {
+		if f.Method != "" && f.Method != r.Method {
+			continue
+		}
+		if f.Path != "" && p != f.Path && !strings.HasPrefix(p, strings.TrimSuffix(f.Path, "/")+"/") {
+			continue
+		}
+		matched := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return &matched
+	}
+	return nil
+}
+
+// serveFault 执行故障，返回 true 表示已经写入了响应。
+func serveFault(w http.ResponseWriter, r *http.Request, f *Fault) bool {
+	if f.Delay > 0 {
+		t := time.NewTimer(f.Delay)
+		defer t.Stop()
+		select {
+		case <-r.Context().Done():
+			return true
+		case <-t.C:
+		}
+	}
+	if f.StatusCode == 0 {
+		return false
+	}
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(f.RetryAfter.Seconds()))))
+	}
+	message := f.Message
+	if message == "" {
+		message = http.StatusText(f.StatusCode)
+	}
+	writeError(w, &apiError{status: f.StatusCode, message: message})
+	return true
+}
+
+// --- 异步事务 ---
+
+type transaction struct {
+	object
+	status      string
+	data        any
+	doneAt      time.Time
+	failMessage string
+	apply       func()
+	fail        func(message string)
+}
+
+func (tx *transaction) toAPI() *clientset.Transaction {
+	return &clientset.Transaction{
+		ID:        tx.id,
+		Status:    tx.status,
+		Data:      tx.data,
+		Timestamp: tx.created.UnixMilli(),
+	}
+}
+
+// newTransaction 提交一个异步事务。事务成功时调用 apply，失败时调用 fail（可以为 nil）。
+// apply 和 fail 在持有锁时被调用，它们引用的对象可能已经被其他事务删除，需要重新查找。
+func (s *Simulator) newTransaction(data any, apply func(), fail func(message string)) *transaction {
+	tx := &transaction{
+		object: s.newObject(),
+		status: clientset.TransactionStatusRunning,
+		data:   data,
+		apply:  apply,
+		fail:   fail,
+	}
+	tx.doneAt = tx.created.Add(s.txDelay)
+	if len(s.failNext) > 0 {
+		tx.failMessage, s.failNext = s.failNext[0], s.failNext[1:]
+	}
+	s.transactions[tx.id] = tx
+	return tx
+}
+
+func (s *Simulator) getTransaction(r *http.Request) (any, error) {
+	id := r.PathValue("id")
+	tx, ok := s.transactions[id]
+	if !ok {
+		return nil, errNotFound("transaction %q not found", id)
+	}
+	return tx.toAPI(), nil
+}
+
+// advance 完成所有到期的事务。每个请求被处理之前都会调用它。
+func (s *Simulator) advance() {
+	now := s.clock.Now()
+	for _, tx := range sorted(s.transactions) {
+		if tx.status == clientset.TransactionStatusRunning && !now.Before(tx.doneAt) {
+			s.complete(tx)
+		}
+	}
+}
+
+func (s *Simulator) complete(tx *transaction) {
+	if tx.failMessage != "" {
+		tx.status = clientset.TransactionStatusFailure
+		tx.data = map[string]string{"message": tx.failMessage}
+		if tx.fail != nil {
+			tx.fail(tx.failMessage)
+		}
+	} else {
+		tx.status = clientset.TransactionStatusSuccess
+		if tx.apply != nil {
+			tx.apply()
+		}
+	}
+	tx.updated = s.clock.Now()
+}
+
+// Settle 立即完成所有正在执行的事务，不论 SetTransactionDelay 设置的延迟。
+func (s *Simulator) Settle() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tx := range sorted(s.transactions) {
+		if tx.status == clientset.TransactionStatusRunning {
+			s.complete(tx)
+		}
+	}
+}
+
+// --- 对象与排序 ---
+
+// object 是所有资源共有的字段，seq 决定了列表的顺序。
+type object struct {
+	seq     int
+	id      string
+	created time.Time
+	updated time.Time
+}
+
+func (o *object) meta() *object { return o }
+
+func (s *Simulator) newObject() object {
+	s.seq++
+	now := s.clock.Now()
+	return object{seq: s.seq, id: uuid.NewString(), created: now, updated: now}
+}
+
+// sorted 按创建顺序返回 m 中的对象。
+func sorted[T interface{ meta() *object }](m map[string]T) []T {
+	items := make([]T, 0, len(m))
+	for _, v := range m {
+		items = append(items, v)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].meta().seq < items[j].meta().seq })
+	return items
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// --- 请求处理 ---
+
+// handlerFunc 处理一个请求，返回响应信封中的 data。它在持有锁时被调用。
+type handlerFunc func(r *http.Request) (any, error)
+
+// handle 注册一个处理函数，pattern 是相对 APIPrefix 的路径，例如 "GET service/{id}"。
+func (s *Simulator) handle(method, pattern string, h handlerFunc) {
+	s.mux.HandleFunc(method+" "+APIPrefix+pattern, func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.advance()
+		data, err := h(r)
+		s.mu.Unlock()
+
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeData(w, data)
+	})
+}
+
+// apiError 是以 ECSM 响应信封返回的错误，HTTP 状态码与信封中的 status 相同。
+type apiError struct {
+	status      int
+	message     string
+	fieldErrors string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%d: %s", e.status, e.message)
+}
+
+func errNotFound(format string, args ...any) error {
+	return &apiError{status: http.StatusNotFound, message: fmt.Sprintf(format, args...)}
+}
+
+func errBadRequest(format string, args ...any) error {
+	return &apiError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func errConflict(format string, args ...any) error {
+	return &apiError{status: http.StatusConflict, message: fmt.Sprintf(format, args...)}
+}
+
+// errInvalidField 返回一个带有 fieldErrors 的校验错误。
+func errInvalidField(field, format string, args ...any) error {
+	return &apiError{
+		status:      http.StatusBadRequest,
+		message:     "Bad Request",
+		fieldErrors: field + ": " + fmt.Sprintf(format, args...),
+	}
+}
+
+func writeData(w http.ResponseWriter, data any) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to encode response: %w", err))
+		return
+	}
+	writeEnvelope(w, http.StatusOK, rest.Response{Status: http.StatusOK, Message: "success", Data: raw})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var aerr *apiError
+	if !errors.As(err, &aerr) {
+		aerr = &apiError{status: http.StatusInternalServerError, message: err.Error()}
+	}
+	writeEnvelope(w, aerr.status, rest.Response{
+		Status:      aerr.status,
+		Message:     aerr.message,
+		Data:        json.RawMessage("null"),
+		FieldErrors: rest.FieldErrors(aerr.fieldErrors),
+	})
+}
+
+func writeEnvelope(w http.ResponseWriter, code int, resp rest.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// decodeBody 把请求体解码到 v 中。
+func decodeBody(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errBadRequest("invalid request body: %v", err)
+	}
+	return nil
+}
+
+// pageParams 读取 pageNum 和 pageSize 查询参数。
+func pageParams(r *http.Request) (pageNum, pageSize int, err error) {
+	q := r.URL.Query()
+	if pageNum, err = strconv.Atoi(q.Get("pageNum")); err != nil {
+		return 0, 0, errInvalidField("pageNum", "must be an integer")
+	}
+	if pageSize, err = strconv.Atoi(q.Get("pageSize")); err != nil {
+		return 0, 0, errInvalidField("pageSize", "must be an integer")
+	}
+	return pageNum, pageSize, nil
+}
+
+// paginate 返回 items 中的第 pageNum 页。pageNum 为 -1 或 pageSize 不大于 0 时返回全部。
+func paginate[T any](items []T, pageNum, pageSize int) []T {
+	if pageNum == -1 || pageSize <= 0 {
+		return items
+	}
+	if pageNum < 1 {
+		pageNum = 1
+	}
+	start := (pageNum - 1) * pageSize
+	if start >= len(items) {
+		return []T{}
+	}
+	return items[start:min(start+pageSize, len(items))]
+}
+
+// contains 是 ECSM 列表接口使用的模糊匹配。
+func contains(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// parseRef 解析 name@tag#os 格式的镜像引用。
+func parseRef(ref string) (name, tag, os string) {
+	rest, os, _ := strings.Cut(ref, "#")
+	name, tag, _ = strings.Cut(rest, "@")
+	return name, tag, os
+}
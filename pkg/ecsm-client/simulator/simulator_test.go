@@ -0,0 +1,673 @@
+// file: pkg/ecsm-client/simulator/simulator_test.go
+
+package simulator_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/rest"
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+// newTestClientset 启动一个由 sim 处理请求的 httptest 服务器，返回连接它的、不限流也不重试的 Clientset。
+func newTestClientset(t *testing.T, sim *simulator.Simulator) *clientset.Clientset {
+	t.Helper()
+	server := httptest.NewServer(sim)
+	t.Cleanup(server.Close)
+
+	cs, err := clientset.NewClientsetForConfig(&rest.Config{
+		Host:        server.URL,
+		QPS:         -1,
+		RetryPolicy: &rest.RetryPolicy{},
+	})
+	require.NoError(t, err)
+	return cs
+}
+
+func intPtr(i int) *int { return &i }
+
+func TestServiceLifecycle(t *testing.T) {
+	ctx := context.Background()
+	sim := simulator.New()
+	clk := testingclock.NewFakeClock(time.Now())
+	sim.SetClock(clk)
+	sim.SetTransactionDelay(time.Minute)
+	sim.AddNode("worker1", "10.0.0.1")
+	sim.AddNode("worker2", "10.0.0.2")
+	cs := newTestClientset(t, sim)
+
+	created, err := cs.Services().Create(ctx, &clientset.CreateServiceRequest{
+		Name:   "web",
+		Image:  clientset.ImageSpec{Ref: "nginx@1.0#sylixos", Action: "run"},
+		Node:   clientset.NodeSpec{Names: []string{"worker1", "worker2"}},
+		Factor: intPtr(2),
+		Policy: "dynamic",
+	})
+	require.NoError(t, err)
+	require.Len(t, created.Containers, 2)
+
+	t.Run("DeploymentIsAsynchronous", func(t *testing.T) {
+		svc, err := cs.Services().Get(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "deploying", svc.Status)
+		assert.False(t, svc.Healthy)
+		assert.Zero(t, svc.InstanceOnline)
+
+		clk.Step(time.Minute)
+		svc, err = cs.Services().Get(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "running", svc.Status)
+		assert.True(t, svc.Healthy)
+		assert.Equal(t, 2, svc.InstanceOnline)
+		assert.Equal(t, "nginx@1.0#sylixos", svc.Image.Ref)
+		assert.Equal(t, []string{"worker1", "worker2"}, svc.Node.Names)
+		assert.Len(t, svc.NodeList, 2)
+	})
+
+	t.Run("NameValidation", func(t *testing.T) {
+		result, err := cs.Services().ValidateName(ctx, &clientset.ValidateNameOptions{Name: "web"})
+		require.NoError(t, err)
+		assert.False(t, result.IsValid)
+
+		_, err = cs.Services().Create(ctx, &clientset.CreateServiceRequest{
+			Name:  "web",
+			Image: clientset.ImageSpec{Ref: "nginx@1.0#sylixos"},
+		})
+		assert.True(t, rest.IsConflict(err), "expected conflict, got %v", err)
+
+		_, err = cs.Services().Create(ctx, &clientset.CreateServiceRequest{Name: "bad"})
+		assert.True(t, rest.IsInvalid(err), "expected invalid, got %v", err)
+	})
+
+	t.Run("UpdateAndRollback", func(t *testing.T) {
+		_, err := cs.Services().Update(ctx, created.ID, &clientset.UpdateServiceRequest{
+			ID:     created.ID,
+			Name:   "web",
+			Image:  clientset.ImageSpec{Ref: "nginx@2.0#sylixos", Action: "run"},
+			Node:   clientset.NodeSpec{Names: []string{"worker1"}},
+			Factor: intPtr(1),
+			Policy: "dynamic",
+		})
+		require.NoError(t, err)
+		clk.Step(time.Minute)
+
+		svc, err := cs.Services().Get(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "nginx@2.0#sylixos", svc.Image.Ref)
+		assert.Equal(t, 1, svc.InstanceOnline)
+
+		records, err := cs.Records().ListAllRecord(ctx, clientset.ListRecordOptions{ServiceID: created.ID})
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		// 最新的记录在前
+		assert.Equal(t, "nginx@2.0#sylixos", records[0].Image)
+		assert.Equal(t, "nginx@1.0#sylixos", records[1].Image)
+
+		tx, err := cs.Services().RollBack(ctx, &clientset.RollBackRequest{ID: created.ID, RecordID: records[1].ID})
+		require.NoError(t, err)
+		assert.Equal(t, clientset.TransactionStatusRunning, tx.Status)
+		clk.Step(time.Minute)
+
+		svc, err = cs.Services().Get(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "nginx@1.0#sylixos", svc.Image.Ref)
+		assert.Equal(t, 2, svc.InstanceOnline)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		resp, err := cs.Services().Delete(ctx, created.ID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.ID)
+
+		svc, err := cs.Services().Get(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "deleting", svc.Status)
+
+		clk.Step(time.Minute)
+		_, err = cs.Services().Get(ctx, created.ID)
+		assert.True(t, rest.IsNotFound(err), "expected not found, got %v", err)
+
+		stats, err := cs.Services().GetStatistics(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, stats.Total)
+	})
+}
+
+func TestContainers(t *testing.T) {
+	ctx := context.Background()
+	sim := simulator.New()
+	nodeID := sim.AddNode("worker1", "10.0.0.1")
+	cs := newTestClientset(t, sim)
+
+	created, err := cs.Services().Create(ctx, &clientset.CreateServiceRequest{
+		Name:   "db",
+		Image:  clientset.ImageSpec{Ref: "redis@7.0#sylixos"},
+		Node:   clientset.NodeSpec{Names: []string{"worker1"}},
+		Policy: "static",
+	})
+	require.NoError(t, err)
+
+	containers, err := cs.Containers().ListAllByService(ctx, clientset.ListContainersByServiceOptions{ServiceIDs: []string{created.ID}})
+	require.NoError(t, err)
+	require.Len(t, containers, 1)
+	c := containers[0]
+	assert.Equal(t, "running", c.Status)
+	assert.Equal(t, "db", c.ServiceName)
+	assert.Equal(t, "worker1", c.NodeName)
+
+	byNode, err := cs.Containers().ListAllByNode(ctx, clientset.ListContainersByNodeOptions{NodeIDs: []string{nodeID}})
+	require.NoError(t, err)
+	assert.Len(t, byNode, 1)
+
+	tx, err := cs.Containers().SubmitControlActionByName(ctx, c.Name, clientset.ActionStop)
+	require.NoError(t, err)
+	assert.Equal(t, clientset.TransactionStatusRunning, tx.Status)
+
+	got, err := cs.Containers().GetByTaskID(ctx, c.TaskID)
+	require.NoError(t, err)
+	assert.Equal(t, "stopped", got.Status)
+
+	svc, err := cs.Services().Get(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "stopped", svc.Status)
+	assert.False(t, svc.Healthy)
+
+	_, err = cs.Containers().SubmitControlActionByService(ctx, created.ID, clientset.ActionRestart)
+	require.NoError(t, err)
+	got, err = cs.Containers().GetByTaskID(ctx, c.TaskID)
+	require.NoError(t, err)
+	assert.Equal(t, "running", got.Status)
+	assert.Equal(t, 1, got.RestartCount)
+
+	history, err := cs.Containers().GetHistory(ctx, clientset.ContainerHistoryOptions{PageNum: 1, PageSize: 10, TaskID: c.TaskID})
+	require.NoError(t, err)
+	require.Equal(t, 2, history.Total)
+	assert.Equal(t, "restart", history.Items[0].Cmd)
+	assert.Equal(t, "stop", history.Items[1].Cmd)
+
+	_, err = cs.Containers().GetByTaskID(ctx, "missing")
+	assert.True(t, rest.IsNotFound(err), "expected not found, got %v", err)
+}
+
+func TestFailures(t *testing.T) {
+	ctx := context.Background()
+	sim := simulator.New()
+	sim.AddNode("worker1", "10.0.0.1")
+	cs := newTestClientset(t, sim)
+
+	t.Run("FailedTransaction", func(t *testing.T) {
+		sim.FailNextTransaction("failed to pull image")
+		created, err := cs.Services().Create(ctx, &clientset.CreateServiceRequest{
+			Name:  "broken",
+			Image: clientset.ImageSpec{Ref: "missing@1.0#sylixos"},
+		})
+		require.NoError(t, err)
+
+		svc, err := cs.Services().Get(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "failed", svc.Status)
+		assert.False(t, svc.Healthy)
+
+		rows, err := cs.Services().ListAll(ctx, clientset.ListServicesOptions{Name: "broken"})
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Len(t, rows[0].ErrorInstances, 1)
+		assert.Equal(t, "failed to pull image", rows[0].ErrorInstances[0].Message)
+
+		// 重新部署之后恢复
+		require.NoError(t, cs.Services().Redeploy(ctx, created.ID))
+		svc, err = cs.Services().Get(ctx, created.ID)
+		require.NoError(t, err)
+		assert.True(t, svc.Healthy)
+	})
+
+	t.Run("FailedContainer", func(t *testing.T) {
+		created, err := cs.Services().Create(ctx, &clientset.CreateServiceRequest{
+			Name:  "crashy",
+			Image: clientset.ImageSpec{Ref: "app@1.0#sylixos"},
+		})
+		require.NoError(t, err)
+		require.NoError(t, sim.FailContainer(created.Containers[0], "segmentation fault"))
+
+		svc, err := cs.Services().Get(ctx, created.ID)
+		require.NoError(t, err)
+		assert.False(t, svc.Healthy)
+		assert.Equal(t, []string{"exited"}, svc.ContainerStatusGroup)
+	})
+
+	t.Run("StaticPolicyOnUnknownNode", func(t *testing.T) {
+		_, err := cs.Services().Create(ctx, &clientset.CreateServiceRequest{
+			Name:   "pinned",
+			Image:  clientset.ImageSpec{Ref: "app@1.0#sylixos"},
+			Node:   clientset.NodeSpec{Names: []string{"nowhere"}},
+			Policy: "static",
+		})
+		require.True(t, rest.IsInvalid(err), "expected invalid, got %v", err)
+		causes := err.(*rest.Aerror).Causes()
+		require.Len(t, causes, 1)
+		assert.Equal(t, "node.names", causes[0].Field)
+	})
+}
+
+func TestFaultInjection(t *testing.T) {
+	ctx := context.Background()
+	sim := simulator.New()
+	server := httptest.NewServer(sim)
+	t.Cleanup(server.Close)
+
+	cs, err := clientset.NewClientsetForConfig(&rest.Config{
+		Host:        server.URL,
+		QPS:         -1,
+		RetryPolicy: &rest.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	t.Run("TransientErrorIsRetried", func(t *testing.T) {
+		sim.InjectFault(simulator.Fault{Method: http.MethodGet, Path: "service", StatusCode: http.StatusServiceUnavailable, Times: 2})
+		_, err := cs.Services().GetStatistics(ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("PersistentError", func(t *testing.T) {
+		sim.InjectFault(simulator.Fault{Path: "node", StatusCode: http.StatusInternalServerError, Message: "database is locked"})
+		defer sim.ClearFaults()
+
+		_, err := cs.Nodes().ListAll(ctx, clientset.NodeListOptions{})
+		assert.True(t, rest.IsInternalError(err), "expected internal error, got %v", err)
+		assert.Contains(t, err.Error(), "database is locked")
+
+		// 路径按段匹配，其他资源不受影响
+		_, err = cs.Services().GetStatistics(ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("SlowResponse", func(t *testing.T) {
+		sim.InjectFault(simulator.Fault{Path: "service/summary", Delay: time.Minute})
+		defer sim.ClearFaults()
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err := cs.Services().GetStatistics(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestNodes(t *testing.T) {
+	ctx := context.Background()
+	sim := simulator.New()
+	cs := newTestClientset(t, sim)
+
+	require.NoError(t, cs.Nodes().Register(ctx, &clientset.NodeRegisterRequest{Name: "edge1", Address: "10.0.0.1:3000", Password: "secret"}))
+	err := cs.Nodes().Register(ctx, &clientset.NodeRegisterRequest{Name: "edge1", Address: "10.0.0.2"})
+	assert.True(t, rest.IsConflict(err), "expected conflict, got %v", err)
+
+	result, err := cs.Nodes().ValidateAddress(ctx, clientset.NodeValidateAddressOptions{Address: "10.0.0.1:3000"})
+	require.NoError(t, err)
+	assert.False(t, result.IsValid)
+
+	details, err := cs.Nodes().GetByName(ctx, "edge1")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", details.IP)
+	assert.Equal(t, 3000, details.Port)
+
+	_, err = cs.Services().Create(ctx, &clientset.CreateServiceRequest{
+		Name:  "app",
+		Image: clientset.ImageSpec{Ref: "app@1.0#sylixos"},
+	})
+	require.NoError(t, err)
+
+	statuses, err := cs.Nodes().ListStatus(ctx, []string{details.ID})
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, 1, statuses[0].ContainerRunning)
+
+	view, err := cs.Nodes().GetNodeView(ctx, details.ID)
+	require.NoError(t, err)
+	require.Len(t, view.Children, 1)
+	assert.Equal(t, "app", view.Children[0].Children[0].Name)
+
+	// 被服务占用的节点不能删除
+	conflicts, err := cs.Nodes().Delete(ctx, []string{details.ID})
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "app", conflicts[0].Serves[0].Name)
+
+	rows, err := cs.Services().ListAll(ctx, clientset.ListServicesOptions{})
+	require.NoError(t, err)
+	_, err = cs.Services().Delete(ctx, rows[0].ID)
+	require.NoError(t, err)
+
+	conflicts, err = cs.Nodes().Delete(ctx, []string{details.ID})
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+	nodes, err := cs.Nodes().ListAll(ctx, clientset.NodeListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, nodes)
+}
+
+func TestImages(t *testing.T) {
+	ctx := context.Background()
+	sim := simulator.New()
+	cs := newTestClientset(t, sim)
+
+	config := &clientset.EcsImageConfig{
+		Platform: &clientset.Platform{OS: "sylixos", Arch: "arm64"},
+		Process:  &clientset.Process{Args: []string{"/apps/web"}},
+	}
+	_, err := sim.AddImage("web@1.0#sylixos", config)
+	require.NoError(t, err)
+	_, err = sim.AddImage("db@1.0", nil)
+	require.NoError(t, err)
+
+	images, err := cs.Images().ListAll(ctx, clientset.ImageListOptions{RegistryID: "local", Name: "web"})
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.Equal(t, "arm64", images[0].Arch)
+
+	got, err := cs.Images().GetConfig(ctx, "web@1.0#sylixos")
+	require.NoError(t, err)
+	assert.Equal(t, config, got)
+
+	details, err := cs.Images().GetDetailsByRef(ctx, "local", "db@1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "sylixos", details.OS)
+
+	stats, err := cs.Images().GetStatistics(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Local)
+
+	_, err = cs.Images().GetConfig(ctx, "missing@1.0")
+	assert.True(t, rest.IsNotFound(err), "expected not found, got %v", err)
+}
+
+func TestTemplates(t *testing.T) {
+	ctx := context.Background()
+	sim := simulator.New()
+	sim.AddNode("worker1", "10.0.0.1")
+	cs := newTestClientset(t, sim)
+
+	_, err := sim.AddTemplate("/edge/camera", clientset.TemplateSpec{
+		Image:  clientset.ImageForTmpl{Ref: "camera@1.0#sylixos"},
+		Node:   clientset.NodeSpec{Names: []string{"worker1"}},
+		Policy: "static",
+	})
+	require.NoError(t, err)
+
+	folder, err := cs.Templates().CreateDictory(ctx, &clientset.CreateDictoryRequest{DictoryName: "sensors", DictoryPath: "/edge"})
+	require.NoError(t, err)
+	assert.Equal(t, "/edge/sensors", folder.DictoryPath)
+
+	created, err := cs.Templates().CreateTemplate(ctx, &clientset.CreateTemplateRequest{
+		ImageRefs: []string{"thermo@1.0#sylixos", "lidar@2.0#sylixos"},
+		Path:      "/edge/sensors",
+	})
+	require.NoError(t, err)
+	require.Len(t, created.ProvsionTmplList, 2)
+
+	tree, err := cs.Templates().GetTemplateTree(ctx, clientset.GetTemplateTreeOptions{Path: "/edge", Model: "full"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, tree.ChildCount)
+	require.Contains(t, tree.Children, "sensors")
+	assert.Equal(t, 2, tree.Children["sensors"].ChildCount)
+	assert.Equal(t, "/edge/sensors/lidar", tree.Children["sensors"].Children["lidar"].RealPath)
+
+	got, err := cs.Templates().GetTemplateByPath(ctx, "/edge/sensors/thermo")
+	require.NoError(t, err)
+	assert.Equal(t, "thermo@1.0#sylixos", got.Spec.Image.Ref)
+
+	found, err := cs.Templates().SearchTempOrDict(ctx, clientset.SearchTemplateOptions{Key: "cam", Path: "/edge"})
+	require.NoError(t, err)
+	assert.Equal(t, "/edge/camera", found.Realpath)
+
+	t.Run("DeployByPath", func(t *testing.T) {
+		services, err := cs.Services().CreateByPath(ctx, clientset.CreateByPathOptions{Paths: []string{"/edge"}, Action: "run"})
+		require.NoError(t, err)
+		assert.Len(t, services, 3)
+
+		// 已经部署过的模板需要 force
+		_, err = cs.Services().CreateByPath(ctx, clientset.CreateByPathOptions{Paths: []string{"/edge/camera"}, Action: "run"})
+		assert.True(t, rest.IsConflict(err), "expected conflict, got %v", err)
+
+		rows, err := cs.Services().ListAll(ctx, clientset.ListServicesOptions{Label: "/edge/sensors"})
+		require.NoError(t, err)
+		assert.Len(t, rows, 2)
+
+		stopped, err := cs.Services().ControlByLabel(ctx, "/edge/sensors", "stop")
+		require.NoError(t, err)
+		assert.Len(t, stopped.IDs, 2)
+		svc, err := cs.Services().Get(ctx, stopped.IDs[0])
+		require.NoError(t, err)
+		assert.Equal(t, "stopped", svc.Status)
+
+		results, err := cs.Services().DeleteByPath(ctx, "/edge")
+		require.NoError(t, err)
+		assert.Len(t, results, 3)
+		stats, err := cs.Services().GetStatistics(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, stats.Total)
+	})
+
+	t.Run("UpdateAndDeploy", func(t *testing.T) {
+		results, err := cs.Templates().UpdateTemplatesByName(ctx, &clientset.UpdateTemplatesBatchRequest{
+			Templates: []clientset.TemplateUpdateBatchSpec{{Name: "camera", ImageRef: "camera@1.1#sylixos"}, {Name: "missing", ImageRef: "x@1"}},
+			Action:    "run",
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.True(t, results[0].Result)
+		require.NotNil(t, results[0].DeployResult)
+		assert.Equal(t, "created", results[0].DeployResult.Result)
+		assert.Len(t, results[0].DeployResult.Tasks, 1)
+		assert.False(t, results[1].Result)
+
+		svc, err := cs.Services().Get(ctx, *results[0].DeployResult.ProvisionID)
+		require.NoError(t, err)
+		assert.Equal(t, "camera@1.1#sylixos", svc.Image.Ref)
+	})
+
+	t.Run("MoveAndDelete", func(t *testing.T) {
+		_, err := cs.Templates().MoveTempOrDict(ctx, &clientset.MoveRequest{Src: "/edge/sensors", Dst: "/"})
+		require.NoError(t, err)
+		_, err = cs.Templates().GetTemplateByPath(ctx, "/sensors/lidar")
+		require.NoError(t, err)
+
+		_, err = cs.Templates().MoveTempOrDict(ctx, &clientset.MoveRequest{Src: "/edge", Dst: "/edge"})
+		assert.Error(t, err)
+
+		_, err = cs.Templates().DeleteTempOrDict(ctx, "/sensors")
+		require.NoError(t, err)
+		_, err = cs.Templates().GetTemplateByPath(ctx, "/sensors/lidar")
+		assert.True(t, rest.IsNotFound(err), "expected not found, got %v", err)
+	})
+}
+
+func TestConfigs(t *testing.T) {
+	ctx := context.Background()
+	cs := newTestClientset(t, simulator.New())
+
+	require.NoError(t, cs.Configs().CreateConfig(ctx, &clientset.CreateConfigRequest{Key: "mode", Type: clientset.ConfigItemTypeString, Value: "edge"}))
+	require.NoError(t, cs.Configs().CreateConfig(ctx, &clientset.CreateConfigRequest{Key: "limits", Type: clientset.ConfigItemTypeJSON, Value: map[string]any{"cpu": 2}}))
+	err := cs.Configs().CreateConfig(ctx, &clientset.CreateConfigRequest{Key: "mode", Type: clientset.ConfigItemTypeString, Value: "cloud"})
+	assert.True(t, rest.IsConflict(err), "expected conflict, got %v", err)
+
+	value, err := cs.Configs().GetConfig(ctx, "limits")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"cpu": float64(2)}, value)
+
+	items, err := cs.Configs().ListAllConfig(ctx, clientset.ListConfigsOptions{})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	mode := items[0]
+	mode.Value = "cloud"
+	require.NoError(t, cs.Configs().UpdateConfig(ctx, &mode))
+	value, err = cs.Configs().GetConfig(ctx, "mode")
+	require.NoError(t, err)
+	assert.Equal(t, "cloud", value)
+
+	require.NoError(t, cs.Configs().DeleteConfig(ctx, mode.ID))
+	_, err = cs.Configs().GetConfig(ctx, "mode")
+	assert.True(t, rest.IsNotFound(err), "expected not found, got %v", err)
+}
+
+func TestMicroServices(t *testing.T) {
+	ctx := context.Background()
+	sim := simulator.New()
+	sim.AddNode("worker1", "10.0.0.1")
+	sim.AddNode("worker2", "10.0.0.2")
+	cs := newTestClientset(t, sim)
+
+	created, err := cs.Services().Create(ctx, &clientset.CreateServiceRequest{
+		Name:   "gateway",
+		Image:  clientset.ImageSpec{Ref: "gateway@1.0#sylixos", VSOA: &clientset.ImageVSOA{Port: intPtr(3001)}},
+		Factor: intPtr(2),
+	})
+	require.NoError(t, err)
+	// 没有启用 VSOA 的服务不是微服务
+	_, err = cs.Services().Create(ctx, &clientset.CreateServiceRequest{Name: "plain", Image: clientset.ImageSpec{Ref: "plain@1.0"}})
+	require.NoError(t, err)
+
+	rows, err := cs.MicroServices().ListAllMicroService(ctx, clientset.ListMicroServicesOptions{})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, created.ID, rows[0].ID)
+	assert.Equal(t, 2, rows[0].HealthInstance)
+	assert.Equal(t, "roundRobin", rows[0].LoadBalance)
+
+	containers, err := cs.Containers().ListAllByService(ctx, clientset.ListContainersByServiceOptions{ServiceIDs: []string{created.ID}})
+	require.NoError(t, err)
+	require.Len(t, containers, 2)
+
+	require.NoError(t, cs.MicroServices().UpdateMicroService(ctx, &clientset.UpdateMicroServiceRequest{
+		ID:                created.ID,
+		LoadBalance:       "masterSlave",
+		LoadBalanceDetail: []clientset.LoadBalanceDetailSpec{{Master: containers[0].TaskID, TaskID: containers[1].TaskID}},
+	}))
+	got, err := cs.MicroServices().GetMicroService(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "masterSlave", got.LoadBalance)
+	assert.True(t, got.BoDYnamic)
+	require.Len(t, got.LoadBalanceDetail, 1)
+
+	err = cs.MicroServices().UpdateMicroService(ctx, &clientset.UpdateMicroServiceRequest{
+		ID:                created.ID,
+		LoadBalance:       "masterSlave",
+		LoadBalanceDetail: []clientset.LoadBalanceDetailSpec{{TaskID: "unknown"}},
+	})
+	assert.True(t, rest.IsInvalid(err), "expected invalid, got %v", err)
+}
+
+func TestTransactions(t *testing.T) {
+	ctx := context.Background()
+	sim := simulator.New()
+	clk := testingclock.NewFakeClock(time.Now())
+	sim.SetClock(clk)
+	sim.SetTransactionDelay(time.Minute)
+	sim.AddNode("worker1", "10.0.0.1")
+	cs := newTestClientset(t, sim)
+
+	created, err := cs.Services().Create(ctx, &clientset.CreateServiceRequest{
+		Name:  "web",
+		Image: clientset.ImageSpec{Ref: "nginx@1.0#sylixos"},
+	})
+	require.NoError(t, err)
+	clk.Step(time.Minute)
+
+	t.Run("Success", func(t *testing.T) {
+		resp, err := cs.Services().Delete(ctx, created.ID)
+		require.NoError(t, err)
+
+		tx, err := cs.Transactions().Get(ctx, resp.ID)
+		require.NoError(t, err)
+		assert.Equal(t, clientset.TransactionStatusRunning, tx.Status)
+
+		// 事务没有完成之前，等待直到 ctx 超时
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = cs.Transactions().WaitForTransaction(waitCtx, resp.ID, time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		clk.Step(time.Minute)
+		tx, err = cs.Transactions().WaitForTransaction(ctx, resp.ID, time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, clientset.TransactionStatusSuccess, tx.Status)
+		_, err = cs.Services().Get(ctx, created.ID)
+		assert.True(t, rest.IsNotFound(err), "expected not found, got %v", err)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		created, err := cs.Services().Create(ctx, &clientset.CreateServiceRequest{
+			Name:  "db",
+			Image: clientset.ImageSpec{Ref: "redis@7.0#sylixos"},
+		})
+		require.NoError(t, err)
+		clk.Step(time.Minute)
+
+		sim.FailNextTransaction("container is busy")
+		tx, err := cs.Containers().SubmitControlActionByService(ctx, created.ID, clientset.ActionStop)
+		require.NoError(t, err)
+		clk.Step(time.Minute)
+
+		final, err := cs.Transactions().WaitForTransaction(ctx, tx.ID, time.Millisecond)
+		require.True(t, clientset.IsTransactionFailed(err), "expected transaction failure, got %v", err)
+		assert.Equal(t, clientset.TransactionStatusFailure, final.Status)
+		var failed *clientset.TransactionFailedError
+		require.ErrorAs(t, err, &failed)
+		assert.Equal(t, "container is busy", failed.Message())
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := cs.Transactions().WaitForTransaction(ctx, "missing", time.Millisecond)
+		assert.True(t, rest.IsNotFound(err), "expected not found, got %v", err)
+		assert.False(t, clientset.IsTransactionFailed(err))
+	})
+}
+
+func TestPagers(t *testing.T) {
+	ctx := context.Background()
+	sim := simulator.New()
+	sim.AddNode("worker1", "10.0.0.1")
+	cs := newTestClientset(t, sim)
+
+	created, err := cs.Services().Create(ctx, &clientset.CreateServiceRequest{
+		Name:   "batch",
+		Image:  clientset.ImageSpec{Ref: "worker@1.0#sylixos"},
+		Factor: intPtr(7),
+	})
+	require.NoError(t, err)
+
+	opts := clientset.ListContainersByServiceOptions{ServiceIDs: []string{created.ID}, PageSize: 2}
+	all, err := cs.Containers().ListAllByService(ctx, opts)
+	require.NoError(t, err)
+	assert.Len(t, all, 7)
+
+	p := clientset.NewContainerByServicePager(cs.Containers(), opts)
+	p.PrefetchPages = 2
+	p.Limit = 5
+	var names []string
+	for c, err := range p.All(ctx) {
+		require.NoError(t, err)
+		names = append(names, c.Name)
+	}
+	require.Len(t, names, 5)
+	for i, name := range names {
+		assert.Equal(t, all[i].Name, name)
+	}
+
+	for i := range 3 {
+		require.NoError(t, cs.Configs().CreateConfig(ctx, &clientset.CreateConfigRequest{Key: fmt.Sprintf("key-%d", i), Type: clientset.ConfigItemTypeNumber, Value: float64(i)}))
+	}
+	// 配置接口不返回总数，依靠不满一页来结束
+	configs, err := cs.Configs().ListAllConfig(ctx, clientset.ListConfigsOptions{PageSize: 2})
+	require.NoError(t, err)
+	assert.Len(t, configs, 3)
+}
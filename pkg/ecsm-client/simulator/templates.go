@@ -0,0 +1,558 @@
+// file: pkg/ecsm-client/simulator/templates.go
+
+package simulator
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/fx147/ecsm-operator/pkg/ecsm-client/clientset"
+)
+
+// 资源模板的类型
+const (
+	kindFolder  = "folder"
+	kindService = "service"
+)
+
+// template 是模板树中的一个文件夹或服务模板，parentID 为空表示位于根目录 "/" 下。
+type template struct {
+	object
+	name     string
+	parentID string
+	kind     string
+	spec     clientset.TemplateSpec
+}
+
+func (s *Simulator) installTemplateRoutes() {
+	s.handle(http.MethodPost, "provision-template/path-label/service/batch", s.createTemplates)
+	s.handle(http.MethodPost, "provision-template/path-label/folder", s.createFolder)
+	s.handle(http.MethodPut, "provision-template/path-label/move", s.moveTemplate)
+	s.handle(http.MethodGet, "provision-template/path-label/tree", s.templateTree)
+	s.handle(http.MethodGet, "provision-template/path-label/search", s.searchTemplate)
+	s.handle(http.MethodGet, "provision-template/path-label", s.getTemplateByPath)
+	s.handle(http.MethodGet, "provision-template/{id}", s.getTemplate)
+	s.handle(http.MethodDelete, "provision-template/path-label", s.deleteTemplates)
+	s.handle(http.MethodDelete, "provision-template/path-label/{id}", s.deleteTemplateByID)
+	s.handle(http.MethodPut, "provision-templates/{id}", s.updateTemplate)
+	s.handle(http.MethodPut, "provision-templates", s.updateTemplatesByID)
+	s.handle(http.MethodPut, "provision-templates/images", s.updateTemplatesByName)
+}
+
+// AddTemplate 在 templatePath 处创建一个服务模板并返回它的 ID，不存在的上级文件夹会被自动创建。
+// 例如 AddTemplate("/edge/camera", spec) 会创建文件夹 /edge 和其中的模板 camera。
+func (s *Simulator) AddTemplate(templatePath string, spec clientset.TemplateSpec) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, name := path.Split(path.Clean("/" + templatePath))
+	if name == "" {
+		return "", fmt.Errorf("invalid template path %q", templatePath)
+	}
+	parentID := ""
+	for _, segment := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		folder := s.childNamed(parentID, segment)
+		if folder == nil {
+			folder = s.addTemplate(segment, parentID, kindFolder, clientset.TemplateSpec{})
+		}
+		if folder.kind != kindFolder {
+			return "", fmt.Errorf("%q is not a folder", s.templatePath(folder))
+		}
+		parentID = folder.id
+	}
+	if s.childNamed(parentID, name) != nil {
+		return "", fmt.Errorf("template %q already exists", templatePath)
+	}
+	return s.addTemplate(name, parentID, kindService, spec).id, nil
+}
+
+func (s *Simulator) addTemplate(name, parentID, kind string, spec clientset.TemplateSpec) *template {
+	t := &template{object: s.newObject(), name: name, parentID: parentID, kind: kind, spec: spec}
+	s.templates[t.id] = t
+	return t
+}
+
+func (s *Simulator) templatePath(t *template) string {
+	if t.parentID == "" {
+		return "/" + t.name
+	}
+	return path.Join(s.templatePath(s.templates[t.parentID]), t.name)
+}
+
+// templateByPath 返回位于 p 的模板或文件夹，p 为根目录或者不存在时返回 nil。
+func (s *Simulator) templateByPath(p string) *template {
+	p = path.Clean("/" + p)
+	for _, t := range s.templates {
+		if s.templatePath(t) == p {
+			return t
+		}
+	}
+	return nil
+}
+
+// resolveFolder 返回文件夹 p 的 ID，根目录的 ID 为空。
+func (s *Simulator) resolveFolder(p string) (string, error) {
+	if path.Clean("/"+p) == "/" {
+		return "", nil
+	}
+	t := s.templateByPath(p)
+	if t == nil {
+		return "", errNotFound("folder %q not found", p)
+	}
+	if t.kind != kindFolder {
+		return "", errBadRequest("%q is not a folder", p)
+	}
+	return t.id, nil
+}
+
+func (s *Simulator) children(parentID string) []*template {
+	var children []*template
+	for _, t := range sorted(s.templates) {
+		if t.parentID == parentID {
+			children = append(children, t)
+		}
+	}
+	return children
+}
+
+func (s *Simulator) childNamed(parentID, name string) *template {
+	for _, t := range s.templates {
+		if t.parentID == parentID && t.name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// descendants 返回 parentID 之下（不包括它自己）的所有模板和文件夹，父节点在子节点之前。
+func (s *Simulator) descendants(parentID string) []*template {
+	var all []*template
+	for _, t := range s.children(parentID) {
+		all = append(all, t)
+		all = append(all, s.descendants(t.id)...)
+	}
+	return all
+}
+
+func (s *Simulator) deleteTemplate(t *template) {
+	for _, d := range s.descendants(t.id) {
+		delete(s.templates, d.id)
+	}
+	delete(s.templates, t.id)
+}
+
+// serviceTemplatesUnder 返回 p 本身（如果它是服务模板）或者 p 之下的所有服务模板。
+func (s *Simulator) serviceTemplatesUnder(p string) ([]*template, error) {
+	parentID, err := s.resolveFolder(p)
+	if err != nil {
+		if t := s.templateByPath(p); t != nil && t.kind == kindService {
+			return []*template{t}, nil
+		}
+		return nil, err
+	}
+	var templates []*template
+	for _, t := range s.descendants(parentID) {
+		if t.kind == kindService {
+			templates = append(templates, t)
+		}
+	}
+	return templates, nil
+}
+
+// deployTemplate 根据模板创建或更新服务并开始部署。模板已经部署过而 force 为 false 时返回冲突错误。
+func (s *Simulator) deployTemplate(t *template, action string, force bool) (*service, bool, []string, error) {
+	var svc service
+	created := true
+	for _, existing := range s.services {
+		if existing.templateID == t.id {
+			if !force {
+				return nil, false, nil, errConflict("service for template %q already exists", s.templatePath(t))
+			}
+			svc, created = *existing, false
+		}
+	}
+	if created {
+		svc = service{object: s.newObject(), templateID: t.id}
+	}
+
+	spec := t.spec
+	svc.name = t.name
+	svc.pathLabel = s.templatePath(t)
+	svc.image = clientset.ImageSpec{
+		Ref:        spec.Image.Ref,
+		Action:     action,
+		Config:     spec.Image.Config,
+		VSOA:       spec.Image.VSOA,
+		PullPolicy: spec.Image.PullPolicy,
+	}
+	svc.node, svc.policy, svc.factor = spec.Node, spec.Policy, 0
+	if spec.Factor != nil {
+		svc.factor = *spec.Factor
+	}
+	svc.prepull = spec.Prepull != nil && *spec.Prepull
+
+	_, ids, err := s.saveService(&svc, action)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return s.services[svc.id], created, ids, nil
+}
+
+// deployResult 部署模板 t 并把结果转换为模板更新接口返回的 DeployResult。
+func (s *Simulator) deployResult(t *template, action string) *clientset.DeployResult {
+	result := &clientset.DeployResult{ProvisionTmpld: t.id, Tasks: []clientset.DeployTask{}}
+	svc, created, ids, err := s.deployTemplate(t, action, true)
+	if err != nil {
+		message := err.Error()
+		result.Result, result.Error = "failed", &message
+		return result
+	}
+	result.Result = "updated"
+	if created {
+		result.Result = "created"
+	}
+	id := svc.id
+	result.ProvisionID = &id
+	for _, cid := range ids {
+		result.Tasks = append(result.Tasks, clientset.DeployTask{TaskID: s.containers[cid].taskID})
+	}
+	return result
+}
+
+func (s *Simulator) createTemplates(r *http.Request) (any, error) {
+	var req clientset.CreateTemplateRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	parentID, err := s.resolveFolder(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先检查所有镜像，避免只创建了一部分模板
+	names := make(map[string]bool)
+	for _, ref := range req.ImageRefs {
+		name, tag, _ := parseRef(ref)
+		if name == "" || tag == "" {
+			return nil, errInvalidField("imageRefs", "%q is not of the form name@tag[#os]", ref)
+		}
+		if names[name] || s.childNamed(parentID, name) != nil {
+			return nil, errConflict("template %q already exists", path.Join("/", req.Path, name))
+		}
+		names[name] = true
+	}
+
+	resp := &clientset.CreateTemplateResponse{ProvsionTmplList: []clientset.ProvisonTmplRow{}}
+	for _, ref := range req.ImageRefs {
+		name, _, _ := parseRef(ref)
+		factor := 1
+		spec := clientset.TemplateSpec{
+			Image:  clientset.ImageForTmpl{Ref: ref},
+			Factor: &factor,
+			Policy: policyDynamic,
+		}
+		if img := s.imageByRef(ref); img != nil {
+			spec.Image.Config = img.config
+		}
+		t := s.addTemplate(name, parentID, kindService, spec)
+		resp.ProvsionTmplList = append(resp.ProvsionTmplList, clientset.ProvisonTmplRow{ID: t.id, Name: t.name})
+	}
+	return resp, nil
+}
+
+func (s *Simulator) createFolder(r *http.Request) (any, error) {
+	var req clientset.CreateDictoryRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if req.DictoryName == "" || strings.Contains(req.DictoryName, "/") {
+		return nil, errInvalidField("name", "must be a non-empty name without '/'")
+	}
+	parentID, err := s.resolveFolder(req.DictoryPath)
+	if err != nil {
+		return nil, err
+	}
+	if s.childNamed(parentID, req.DictoryName) != nil {
+		return nil, errConflict("%q already exists", path.Join("/", req.DictoryPath, req.DictoryName))
+	}
+	t := s.addTemplate(req.DictoryName, parentID, kindFolder, clientset.TemplateSpec{})
+	return &clientset.CreateDictoryResponse{DictoryID: t.id, DictoryPath: s.templatePath(t)}, nil
+}
+
+func (s *Simulator) moveTemplate(r *http.Request) (any, error) {
+	var req clientset.MoveRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	t := s.templateByPath(req.Src)
+	if t == nil {
+		return nil, errNotFound("%q not found", req.Src)
+	}
+	parentID, err := s.resolveFolder(req.Dst)
+	if err != nil {
+		return nil, err
+	}
+	if parentID != "" && underPath(s.templatePath(s.templates[parentID]), s.templatePath(t)) {
+		return nil, errBadRequest("cannot move %q into itself", req.Src)
+	}
+	if other := s.childNamed(parentID, t.name); other != nil && other != t {
+		return nil, errConflict("%q already exists", path.Join("/", req.Dst, t.name))
+	}
+	t.parentID = parentID
+	t.updated = s.clock.Now()
+	return &clientset.MoveResponse{ID: t.id}, nil
+}
+
+func (s *Simulator) templateTree(r *http.Request) (any, error) {
+	q := r.URL.Query()
+	level := 0
+	if v := q.Get("level"); v != "" {
+		var err error
+		if level, err = strconv.Atoi(v); err != nil {
+			return nil, errInvalidField("level", "must be an integer")
+		}
+	}
+	full := q.Get("model") == "full"
+
+	p := q.Get("path")
+	if path.Clean("/"+p) == "/" {
+		return s.tree(nil, level, full), nil
+	}
+	t := s.templateByPath(p)
+	if t == nil {
+		return nil, errNotFound("%q not found", p)
+	}
+	return s.tree(t, level, full), nil
+}
+
+// tree 返回以 t 为根的模板树，t 为 nil 表示根目录。level 不大于 0 时返回所有层级。
+func (s *Simulator) tree(t *template, level int, full bool) *clientset.ProvisionTmplTree {
+	node := &clientset.ProvisionTmplTree{Name: "/", RealPath: "/", Children: map[string]*clientset.ProvisionTmplTree{}}
+	parentID := ""
+	if t != nil {
+		node.Name, node.RealPath, parentID = t.name, s.templatePath(t), t.id
+		if full {
+			node.Data = s.templateDetail(t)
+		}
+	}
+	children := s.children(parentID)
+	node.ChildCount = len(children)
+	if level != 1 {
+		for _, child := range children {
+			node.Children[child.name] = s.tree(child, level-1, full)
+		}
+	}
+	return node
+}
+
+func (s *Simulator) templateDetail(t *template) *clientset.ProvisionTmplDetail {
+	return &clientset.ProvisionTmplDetail{
+		ID:          t.id,
+		Name:        t.name,
+		Kind:        t.kind,
+		Hostname:    templateHostname(t),
+		Node:        t.spec.Node,
+		CreatedTime: formatTime(t.created),
+		UpdatedTime: formatTime(t.updated),
+	}
+}
+
+func templateHostname(t *template) string {
+	if t.spec.Image.Config != nil {
+		return t.spec.Image.Config.Hostname
+	}
+	return ""
+}
+
+func (s *Simulator) templateGet(t *template) *clientset.TemplateGet {
+	return &clientset.TemplateGet{
+		ID:          t.id,
+		Name:        t.name,
+		Kind:        t.kind,
+		Spec:        t.spec,
+		CreatedTime: formatTime(t.created),
+		UpdatedTime: formatTime(t.updated),
+	}
+}
+
+func (s *Simulator) getTemplate(r *http.Request) (any, error) {
+	t, ok := s.templates[r.PathValue("id")]
+	if !ok {
+		return nil, errNotFound("template %q not found", r.PathValue("id"))
+	}
+	return s.templateGet(t), nil
+}
+
+func (s *Simulator) getTemplateByPath(r *http.Request) (any, error) {
+	p := r.URL.Query().Get("path")
+	t := s.templateByPath(p)
+	if t == nil {
+		return nil, errNotFound("%q not found", p)
+	}
+	return s.templateGet(t), nil
+}
+
+// searchTemplate 返回 path 之下第一个名称包含 key 的模板或文件夹。
+func (s *Simulator) searchTemplate(r *http.Request) (any, error) {
+	q := r.URL.Query()
+	parentID := ""
+	if p := q.Get("path"); p != "" {
+		var err error
+		if parentID, err = s.resolveFolder(p); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range s.descendants(parentID) {
+		if !contains(t.name, q.Get("key")) || (q.Get("kind") != "" && t.kind != q.Get("kind")) {
+			continue
+		}
+		return &clientset.SearchTemplateResult{
+			ID:          t.id,
+			Name:        t.name,
+			Kind:        t.kind,
+			Hostname:    templateHostname(t),
+			Realpath:    s.templatePath(t),
+			Node:        t.spec.Node,
+			CreatedTime: formatTime(t.created),
+			UpdatedTime: formatTime(t.updated),
+		}, nil
+	}
+	return nil, errNotFound("no template matches %q", q.Get("key"))
+}
+
+// deleteTemplates 按路径删除一个模板或文件夹（连同其中的内容），或者按 ID 批量删除。
+func (s *Simulator) deleteTemplates(r *http.Request) (any, error) {
+	var req struct {
+		Path string   `json:"path"`
+		IDs  []string `json:"id"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	switch {
+	case req.Path != "":
+		t := s.templateByPath(req.Path)
+		if t == nil {
+			return nil, errNotFound("%q not found", req.Path)
+		}
+		s.deleteTemplate(t)
+		return &clientset.DeleteTempalteResult{ID: t.id}, nil
+	case len(req.IDs) > 0:
+		for _, id := range req.IDs {
+			if _, ok := s.templates[id]; !ok {
+				return nil, errNotFound("template %q not found", id)
+			}
+		}
+		for _, id := range req.IDs {
+			// 前面删除的文件夹可能已经包含了这个模板
+			if t, ok := s.templates[id]; ok {
+				s.deleteTemplate(t)
+			}
+		}
+		return &clientset.DeleteTempaltesResult{IDs: req.IDs}, nil
+	}
+	return nil, errInvalidField("path", "either path or id must be specified")
+}
+
+func (s *Simulator) deleteTemplateByID(r *http.Request) (any, error) {
+	t, ok := s.templates[r.PathValue("id")]
+	if !ok {
+		return nil, errNotFound("template %q not found", r.PathValue("id"))
+	}
+	s.deleteTemplate(t)
+	return &clientset.DeleteTempalteResult{ID: t.id}, nil
+}
+
+func (s *Simulator) updateTemplate(r *http.Request) (any, error) {
+	var req clientset.UpdateTemplatesRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	t, ok := s.templates[r.PathValue("id")]
+	if !ok || t.kind != kindService {
+		return nil, errNotFound("template %q not found", r.PathValue("id"))
+	}
+	if req.Name != "" && req.Name != t.name {
+		if s.childNamed(t.parentID, req.Name) != nil {
+			return nil, errConflict("template %q already exists", req.Name)
+		}
+		t.name = req.Name
+	}
+	if req.Templates.Image.Ref != "" {
+		t.spec = req.Templates
+	}
+	t.updated = s.clock.Now()
+
+	result := &clientset.UpdateTemplateResult{ID: t.id}
+	if req.Action != "" {
+		result.DeployResult = s.deployResult(t, req.Action)
+	}
+	return result, nil
+}
+
+func (s *Simulator) updateTemplatesByID(r *http.Request) (any, error) {
+	return s.updateTemplateImages(r, func(spec clientset.TemplateUpdateBatchSpec) *template {
+		if t, ok := s.templates[spec.ID]; ok && t.kind == kindService {
+			return t
+		}
+		return nil
+	})
+}
+
+func (s *Simulator) updateTemplatesByName(r *http.Request) (any, error) {
+	return s.updateTemplateImages(r, func(spec clientset.TemplateUpdateBatchSpec) *template {
+		for _, t := range sorted(s.templates) {
+			if t.kind == kindService && t.name == spec.Name {
+				return t
+			}
+		}
+		return nil
+	})
+}
+
+// updateTemplateImages 批量修改模板的镜像，lookup 根据请求中的一项找到要修改的模板。
+func (s *Simulator) updateTemplateImages(r *http.Request, lookup func(clientset.TemplateUpdateBatchSpec) *template) (any, error) {
+	var req clientset.UpdateTemplatesBatchRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	results := []clientset.UpdateTemplateBatchResult{}
+	for _, spec := range req.Templates {
+		result := clientset.UpdateTemplateBatchResult{ID: spec.ID, Name: spec.Name}
+		t := lookup(spec)
+		if t == nil {
+			result.Message = "template not found"
+			results = append(results, result)
+			continue
+		}
+		result.ID = t.id
+		t.spec.Image.Ref = spec.ImageRef
+		if img := s.imageByRef(spec.ImageRef); img != nil {
+			t.spec.Image.Config = img.config
+		}
+		t.updated = s.clock.Now()
+		result.Result, result.Message = true, "success"
+
+		action := spec.Action
+		if action == "" {
+			action = req.Action
+		}
+		if action != "" {
+			result.DeployResult = s.deployResult(t, action)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// underPath 判断 p 是否等于 prefix 或位于 prefix 之下。
+func underPath(p, prefix string) bool {
+	p, prefix = path.Clean("/"+p), path.Clean("/"+prefix)
+	return prefix == "/" || p == prefix || strings.HasPrefix(p, prefix+"/")
+}
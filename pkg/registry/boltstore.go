@@ -0,0 +1,623 @@
+// file: pkg/registry/boltstore.go
+
+package registry
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fx147/ecsm-operator/pkg/util"
+	bolt "go.etcd.io/bbolt"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+)
+
+// DefaultBoltStorePath 是 BoltStore 默认的数据库文件路径。
+const DefaultBoltStorePath = "/var/lib/ecsm-operator/registry.db"
+
+const (
+	// boltOpenTimeout 是等待其他进程释放数据库文件锁的最长时间
+	boltOpenTimeout = 5 * time.Second
+	// boltWatchPollInterval 是正在 Watch 的进程读取其他进程提交的事件的间隔
+	boltWatchPollInterval = 500 * time.Millisecond
+)
+
+var (
+	// objectsBucket 下按 "group/version/kinds" -> namespace -> name 嵌套存放对象
+	objectsBucket = []byte("objects")
+	// eventsBucket 按 resourceVersion 存放最近的事件，供其他进程产生 watch 事件
+	eventsBucket = []byte("events")
+	// metaBucket 存放 resourceVersion 计数器等存储自身的元数据
+	metaBucket         = []byte("meta")
+	resourceVersionKey = []byte("resourceVersion")
+)
+
+// BoltStore 实现了 Store 接口，使用单个 bbolt 数据库文件作为后端。
+// 与 FileStore 相比，它支持多对象的原子事务，并且 resourceVersion 是整个数据库共享的单调递增计数器，
+// 对象的 resourceVersion 和 List 返回的 resourceVersion 属于同一个序列。
+//
+// bbolt 同一时刻只允许一个进程以读写方式打开数据库文件，所以 BoltStore 不长期持有它：
+// 每次读取以共享锁、每个事务以排他锁打开数据库，用完立即关闭，等待其他进程释放锁最多 boltOpenTimeout。
+// 这样 ecsm-operator 和 ecsm-cli 可以同时使用同一个数据库文件。
+// 每个事务产生的事件也写入数据库（保留最近 watchHistoryLength 个），
+// 正在 Watch 的进程定期读取其他进程提交的事件，再与本进程的事件按 resourceVersion 的顺序发出。
+type BoltStore struct {
+	path   string
+	scheme *runtime.Scheme
+
+	// dbMu 让本进程内的读取共享数据库文件、事务独占数据库文件。
+	// bbolt 的文件锁属于每一次打开，同一进程内的两次打开之间同样会互相等待。
+	dbMu sync.RWMutex
+
+	// mu 保证事件按照事务提交的顺序发出，同时保护 rv 和 events。
+	// 同时持有 mu 和 dbMu 时必须先获取 mu。
+	mu     sync.Mutex
+	rv     uint64
+	events *watchCache
+
+	pollOnce  sync.Once
+	stopCh    chan struct{}
+	closeOnce sync.Once
+}
+
+var _ TransactionalStore = &BoltStore{}
+
+// NewBoltStore 打开（必要时创建）path 上的数据库文件。
+func NewBoltStore(path string, scheme *runtime.Scheme) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for boltstore: %w", err)
+	}
+
+	bs := &BoltStore{path: path, scheme: scheme, stopCh: make(chan struct{})}
+	err := bs.update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{objectsBucket, eventsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		mb, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		bs.rv = decodeUint64(mb.Get(resourceVersionKey))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize boltstore: %w", err)
+	}
+	bs.events = newWatchCache(bs.rv)
+	return bs, nil
+}
+
+// Close 停止读取其他进程的事件并关闭所有 watcher。
+func (bs *BoltStore) Close() error {
+	bs.closeOnce.Do(func() {
+		close(bs.stopCh)
+		bs.mu.Lock()
+		defer bs.mu.Unlock()
+		bs.events.shutdown()
+	})
+	return nil
+}
+
+// view 以共享锁打开数据库文件，在一个只读事务中执行 fn。
+func (bs *BoltStore) view(fn func(*bolt.Tx) error) error {
+	bs.dbMu.RLock()
+	defer bs.dbMu.RUnlock()
+	db, err := bolt.Open(bs.path, 0644, &bolt.Options{Timeout: boltOpenTimeout, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to open boltstore %s: %w", bs.path, err)
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+// update 以排他锁打开数据库文件，在一个读写事务中执行 fn。
+func (bs *BoltStore) update(fn func(*bolt.Tx) error) error {
+	bs.dbMu.Lock()
+	defer bs.dbMu.Unlock()
+	db, err := bolt.Open(bs.path, 0644, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return fmt.Errorf("failed to open boltstore %s: %w", bs.path, err)
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
+
+func (bs *BoltStore) Create(obj runtime.Object) error {
+	return bs.Transaction(func(tx Store) error { return tx.Create(obj) })
+}
+
+// Update 更新一个已存在的对象。
+// 如果 obj 的 resourceVersion 非空，它必须与存储中的版本一致，否则返回 Conflict 错误；
+// 为空则表示无条件更新。
+func (bs *BoltStore) Update(obj runtime.Object) error {
+	return bs.Transaction(func(tx Store) error { return tx.Update(obj) })
+}
+
+// Delete 删除一个对象，objToDelete 的 resourceVersion 非空时只在它与存储中的版本一致时删除。
+func (bs *BoltStore) Delete(namespace, name string, objToDelete runtime.Object) error {
+	return bs.Transaction(func(tx Store) error { return tx.Delete(namespace, name, objToDelete) })
+}
+
+func (bs *BoltStore) Get(namespace, name string, objInto runtime.Object) error {
+	return bs.view(func(btx *bolt.Tx) error {
+		return (&boltTx{store: bs, tx: btx}).Get(namespace, name, objInto)
+	})
+}
+
+// List 列出指定命名空间下的对象，namespace 为空时列出所有命名空间。
+// 返回的 metadata.resourceVersion 可以直接用于 Watch。
+func (bs *BoltStore) List(namespace string, listInto runtime.Object) error {
+	return bs.view(func(btx *bolt.Tx) error {
+		return (&boltTx{store: bs, tx: btx}).List(namespace, listInto)
+	})
+}
+
+// Transaction 在一个读写事务中执行 fn，fn 通过传入的 Store 进行的所有读写要么全部生效，要么全部不生效。
+// fn 返回错误时事务回滚，写入对象上被修改的 resourceVersion 也会被恢复。
+// 事务提交之后才会产生 watch 事件。传入的 Store 不支持 Watch，且不能在 fn 返回后继续使用。
+//
+// 事务不能嵌套：fn 只能通过传入的 Store 读写，在 fn 中调用 bs 的任何方法（包括 Transaction）都会因为
+// bs.mu 和数据库文件的锁已经被当前事务持有而死锁。
+func (bs *BoltStore) Transaction(fn func(tx Store) error) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	t := &boltTx{store: bs}
+	var external []historyEvent
+	var expired bool
+	err := bs.update(func(btx *bolt.Tx) error {
+		// 其他进程在本进程上一次观测之后提交的事件，要在本事务的事件之前发出
+		var err error
+		if external, expired, err = bs.readEvents(btx); err != nil {
+			return err
+		}
+		t.tx = btx
+		t.rv = decodeUint64(btx.Bucket(metaBucket).Get(resourceVersionKey))
+		if err := fn(t); err != nil {
+			return err
+		}
+		if err := t.logEvents(); err != nil {
+			return err
+		}
+		return btx.Bucket(metaBucket).Put(resourceVersionKey, encodeUint64(t.rv))
+	})
+	if err != nil {
+		for i := len(t.undo) - 1; i >= 0; i-- {
+			t.undo[i]()
+		}
+		return err
+	}
+
+	bs.emitLocked(external, expired)
+	bs.emitLocked(t.pending, false)
+	return nil
+}
+
+// Watch 监听指定命名空间下某一类型对象的变更，listInto 用于确定对象类型（例如 &ECSMServiceList{}）。
+// resourceVersion 为空时，会先为当前存在的每个对象发送一个 ADDED 事件；
+// 否则它应该来自 List 返回的 metadata.resourceVersion，Watch 会补发该版本之后发生的事件。
+// 其他进程的写入每隔 boltWatchPollInterval 被读取一次。
+func (bs *BoltStore) Watch(namespace string, listInto runtime.Object, resourceVersion string) (watch.Interface, error) {
+	listGVK, err := util.GetGVK(listInto, bs.scheme)
+	if err != nil {
+		return nil, err
+	}
+	itemGVK := listGVK.GroupVersion().WithKind(strings.TrimSuffix(listGVK.Kind, "List"))
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	// 在同一个只读事务中追上其他进程的写入并读取初始对象，两者对应同一个 resourceVersion
+	var initial []watch.Event
+	err = bs.view(func(btx *bolt.Tx) error {
+		events, expired, err := bs.readEvents(btx)
+		if err != nil {
+			return err
+		}
+		bs.emitLocked(events, expired)
+		if resourceVersion != "" {
+			return nil
+		}
+		return (&boltTx{store: bs, tx: btx}).forEach(itemGVK, namespace, func(data []byte) error {
+			obj, err := bs.scheme.New(itemGVK)
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal(data, obj); err != nil {
+				return err
+			}
+			initial = append(initial, watch.Event{Type: watch.Added, Object: obj})
+			return nil
+		})
+	})
+	if err == nil && resourceVersion != "" {
+		initial, err = bs.events.since(resourceVersion, bs.rv)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bs.pollOnce.Do(func() {
+		go wait.Until(bs.poll, boltWatchPollInterval, bs.stopCh)
+	})
+	return bs.events.watch(initial, func(e watch.Event) bool {
+		return matchesKind(bs.scheme, e.Object, itemGVK, namespace)
+	}), nil
+}
+
+// poll 读取其他进程提交的、本进程还没有发出的事件并发出它们。
+func (bs *BoltStore) poll() {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	err := bs.view(func(btx *bolt.Tx) error {
+		events, expired, err := bs.readEvents(btx)
+		if err != nil {
+			return err
+		}
+		bs.emitLocked(events, expired)
+		return nil
+	})
+	if err != nil {
+		klog.ErrorS(err, "Failed to read events from boltstore", "path", bs.path)
+	}
+}
+
+// loggedEvent 是 eventsBucket 中保存的一个事件，键是事件的 resourceVersion。
+type loggedEvent struct {
+	Type       watch.EventType `json:"type"`
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Object     json.RawMessage `json:"object"`
+}
+
+// readEvents 返回 btx 中 resourceVersion 大于 bs.rv 的事件，调用者必须持有 bs.mu。
+// 如果其中一部分已经因为超出保留的历史被删除，expired 为 true，返回的事件只有最新的那一个，用于推进 bs.rv。
+func (bs *BoltStore) readEvents(btx *bolt.Tx) (events []historyEvent, expired bool, err error) {
+	current := decodeUint64(btx.Bucket(metaBucket).Get(resourceVersionKey))
+	if current <= bs.rv {
+		return nil, false, nil
+	}
+
+	c := btx.Bucket(eventsBucket).Cursor()
+	k, v := c.Seek(encodeUint64(bs.rv + 1))
+	if k == nil || decodeUint64(k) != bs.rv+1 {
+		return []historyEvent{{seq: current}}, true, nil
+	}
+	for ; k != nil; k, v = c.Next() {
+		var logged loggedEvent
+		if err := json.Unmarshal(v, &logged); err != nil {
+			return nil, false, fmt.Errorf("failed to decode stored event: %w", err)
+		}
+		obj, err := bs.scheme.New(schema.FromAPIVersionAndKind(logged.APIVersion, logged.Kind))
+		if err != nil {
+			return nil, false, err
+		}
+		if err := json.Unmarshal(logged.Object, obj); err != nil {
+			return nil, false, fmt.Errorf("failed to decode stored event: %w", err)
+		}
+		events = append(events, historyEvent{seq: decodeUint64(k), event: watch.Event{Type: logged.Type, Object: obj}})
+	}
+	return events, false, nil
+}
+
+// emitLocked 按顺序发出 events 并推进 bs.rv，调用者必须持有 bs.mu。
+// expired 为 true 时说明本进程错过了一部分事件，所有 watcher 都会被关闭，由使用者重新 List 和 Watch。
+func (bs *BoltStore) emitLocked(events []historyEvent, expired bool) {
+	if len(events) == 0 {
+		return
+	}
+	last := events[len(events)-1].seq
+	if expired {
+		klog.InfoS("Missed events from other processes, closing all watchers", "path", bs.path, "from", bs.rv, "to", last)
+		bs.events.reset(last)
+		bs.rv = last
+		return
+	}
+	for _, e := range events {
+		bs.events.emit(e.seq, e.event.Type, e.event.Object)
+	}
+	bs.rv = last
+}
+
+// boltTx 是绑定在一个 bbolt 事务上的 Store 视图。
+type boltTx struct {
+	store *BoltStore
+	tx    *bolt.Tx
+
+	// rv 是事务内的 resourceVersion 计数器，提交时写回 metaBucket
+	rv      uint64
+	pending []historyEvent
+	undo    []func()
+}
+
+var _ Store = &boltTx{}
+
+func (t *boltTx) Create(obj runtime.Object) error {
+	gvk, objMeta, err := t.objectInfo(obj)
+	if err != nil {
+		return err
+	}
+	b, err := t.namespaceBucket(gvk, objMeta.Namespace, true)
+	if err != nil {
+		return err
+	}
+	if b.Get([]byte(objMeta.Name)) != nil {
+		return errors.NewAlreadyExists(resourceFor(gvk), objMeta.Name)
+	}
+	return t.put(b, obj, objMeta, watch.Added)
+}
+
+func (t *boltTx) Update(obj runtime.Object) error {
+	gvk, objMeta, err := t.objectInfo(obj)
+	if err != nil {
+		return err
+	}
+	b, err := t.namespaceBucket(gvk, objMeta.Namespace, false)
+	if err != nil {
+		return err
+	}
+	var existing []byte
+	if b != nil {
+		existing = b.Get([]byte(objMeta.Name))
+	}
+	if existing == nil {
+		return errors.NewNotFound(resourceFor(gvk), objMeta.Name)
+	}
+
+	current, err := resourceVersionOf(existing)
+	if err != nil {
+		return fmt.Errorf("failed to decode stored object: %w", err)
+	}
+	if objMeta.ResourceVersion != "" && objMeta.ResourceVersion != current {
+		return errors.NewConflict(resourceFor(gvk), objMeta.Name, fmt.Errorf(
+			"the object has been modified; please apply your changes to the latest version and try again"))
+	}
+	return t.put(b, obj, objMeta, watch.Modified)
+}
+
+func (t *boltTx) Get(namespace, name string, objInto runtime.Object) error {
+	gvk, err := util.GetGVK(objInto, t.store.scheme)
+	if err != nil {
+		return err
+	}
+	b, err := t.namespaceBucket(gvk, namespace, false)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if b != nil {
+		data = b.Get([]byte(name))
+	}
+	if data == nil {
+		return errors.NewNotFound(resourceFor(gvk), name)
+	}
+	return json.Unmarshal(data, objInto)
+}
+
+func (t *boltTx) List(namespace string, listInto runtime.Object) error {
+	listGVK, err := util.GetGVK(listInto, t.store.scheme)
+	if err != nil {
+		return err
+	}
+	itemGVK := listGVK.GroupVersion().WithKind(strings.TrimSuffix(listGVK.Kind, "List"))
+
+	listMeta, err := meta.ListAccessor(listInto)
+	if err != nil {
+		return err
+	}
+	rv := t.rv
+	if !t.tx.Writable() {
+		rv = decodeUint64(t.tx.Bucket(metaBucket).Get(resourceVersionKey))
+	}
+	listMeta.SetResourceVersion(strconv.FormatUint(rv, 10))
+
+	itemsField := reflect.ValueOf(listInto).Elem().FieldByName("Items")
+	itemType := itemsField.Type().Elem()
+	itemsField.Set(reflect.MakeSlice(itemsField.Type(), 0, 0))
+	return t.forEach(itemGVK, namespace, func(data []byte) error {
+		newItem := reflect.New(itemType).Interface().(runtime.Object)
+		if err := json.Unmarshal(data, newItem); err != nil {
+			return fmt.Errorf("failed to unmarshal stored object: %w", err)
+		}
+		itemsField.Set(reflect.Append(itemsField, reflect.ValueOf(newItem).Elem()))
+		return nil
+	})
+}
+
+func (t *boltTx) Delete(namespace, name string, objToDelete runtime.Object) error {
+	gvk, err := util.GetGVK(objToDelete, t.store.scheme)
+	if err != nil {
+		return err
+	}
+	b, err := t.namespaceBucket(gvk, namespace, false)
+	if err != nil || b == nil {
+		return err
+	}
+	data := b.Get([]byte(name))
+	if data == nil {
+		return nil
+	}
+
+	// 删除前解码对象，作为 DELETED 事件的内容
+	deleted, err := t.store.scheme.New(gvk)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, deleted); err != nil {
+		return fmt.Errorf("failed to decode stored object: %w", err)
+	}
+	if objMeta, err := util.GetObjectMeta(objToDelete); err == nil && objMeta.ResourceVersion != "" {
+		if deletedMeta, err := util.GetObjectMeta(deleted); err == nil && deletedMeta.ResourceVersion != objMeta.ResourceVersion {
+			return errors.NewConflict(resourceFor(gvk), name, fmt.Errorf(
+				"the object has been modified; please apply your changes to the latest version and try again"))
+		}
+	}
+	if err := b.Delete([]byte(name)); err != nil {
+		return err
+	}
+
+	t.rv++
+	if deletedMeta, err := util.GetObjectMeta(deleted); err == nil {
+		deletedMeta.ResourceVersion = strconv.FormatUint(t.rv, 10)
+	}
+	t.pending = append(t.pending, historyEvent{seq: t.rv, event: watch.Event{Type: watch.Deleted, Object: deleted}})
+	return nil
+}
+
+func (t *boltTx) Watch(namespace string, listInto runtime.Object, resourceVersion string) (watch.Interface, error) {
+	return nil, fmt.Errorf("watch is not supported inside a transaction")
+}
+
+// logEvents 把事务产生的事件写入 eventsBucket，并删除超出保留范围的旧事件。
+func (t *boltTx) logEvents() error {
+	b := t.tx.Bucket(eventsBucket)
+	for _, e := range t.pending {
+		gvk, err := util.GetGVK(e.event.Object, t.store.scheme)
+		if err != nil {
+			return err
+		}
+		obj, err := json.Marshal(e.event.Object)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event to json: %w", err)
+		}
+		data, err := json.Marshal(loggedEvent{Type: e.event.Type, APIVersion: gvk.GroupVersion().String(), Kind: gvk.Kind, Object: obj})
+		if err != nil {
+			return fmt.Errorf("failed to marshal event to json: %w", err)
+		}
+		if err := b.Put(encodeUint64(e.seq), data); err != nil {
+			return err
+		}
+	}
+
+	if t.rv <= watchHistoryLength {
+		return nil
+	}
+	cutoff := t.rv - watchHistoryLength
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil && decodeUint64(k) <= cutoff; k, _ = c.First() {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// put 为对象分配新的 resourceVersion 并写入 bucket，objMeta 是 obj 的 ObjectMeta。
+func (t *boltTx) put(b *bolt.Bucket, obj runtime.Object, objMeta *metav1.ObjectMeta, eventType watch.EventType) error {
+	oldVersion := objMeta.ResourceVersion
+	objMeta.ResourceVersion = strconv.FormatUint(t.rv+1, 10)
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		objMeta.ResourceVersion = oldVersion
+		return fmt.Errorf("failed to marshal object to json: %w", err)
+	}
+	if err := b.Put([]byte(objMeta.Name), data); err != nil {
+		objMeta.ResourceVersion = oldVersion
+		return err
+	}
+
+	t.rv++
+	t.undo = append(t.undo, func() { objMeta.ResourceVersion = oldVersion })
+	t.pending = append(t.pending, historyEvent{seq: t.rv, event: watch.Event{Type: eventType, Object: obj.DeepCopyObject()}})
+	return nil
+}
+
+// forEach 对指定类型、命名空间（为空表示所有命名空间）下的每个对象调用 fn。
+func (t *boltTx) forEach(gvk schema.GroupVersionKind, namespace string, fn func(data []byte) error) error {
+	kb := t.tx.Bucket(objectsBucket).Bucket(kindKey(gvk))
+	if kb == nil {
+		return nil
+	}
+	eachObject := func(nb *bolt.Bucket) error {
+		return nb.ForEach(func(_, v []byte) error { return fn(v) })
+	}
+	if namespace != "" {
+		if nb := kb.Bucket(namespaceKey(namespace)); nb != nil {
+			return eachObject(nb)
+		}
+		return nil
+	}
+	return kb.ForEachBucket(func(k []byte) error {
+		return eachObject(kb.Bucket(k))
+	})
+}
+
+// namespaceBucket 返回存放指定类型、命名空间对象的 bucket。
+// create 为 false 且 bucket 不存在时返回 (nil, nil)。
+func (t *boltTx) namespaceBucket(gvk schema.GroupVersionKind, namespace string, create bool) (*bolt.Bucket, error) {
+	objects := t.tx.Bucket(objectsBucket)
+	if !create {
+		kb := objects.Bucket(kindKey(gvk))
+		if kb == nil {
+			return nil, nil
+		}
+		return kb.Bucket(namespaceKey(namespace)), nil
+	}
+
+	kb, err := objects.CreateBucketIfNotExists(kindKey(gvk))
+	if err != nil {
+		return nil, err
+	}
+	return kb.CreateBucketIfNotExists(namespaceKey(namespace))
+}
+
+func (t *boltTx) objectInfo(obj runtime.Object) (schema.GroupVersionKind, *metav1.ObjectMeta, error) {
+	gvk, err := util.GetGVK(obj, t.store.scheme)
+	if err != nil {
+		return schema.GroupVersionKind{}, nil, err
+	}
+	objMeta, err := util.GetObjectMeta(obj)
+	if err != nil {
+		return schema.GroupVersionKind{}, nil, err
+	}
+	return gvk, objMeta, nil
+}
+
+// kindKey 返回某一类型对象所在 bucket 的名字，例如 "ecsm.sh/v1/ecsmservices"。
+func kindKey(gvk schema.GroupVersionKind) []byte {
+	return []byte(gvk.Group + "/" + gvk.Version + "/" + strings.ToLower(gvk.Kind) + "s")
+}
+
+// namespaceKey 返回命名空间对应的 bucket 名。
+// bbolt 不允许空的 bucket 名，没有命名空间的对象使用一个不可能是合法命名空间的固定名字。
+func namespaceKey(namespace string) []byte {
+	if namespace == "" {
+		return []byte("\x00")
+	}
+	return []byte(namespace)
+}
+
+// resourceFor 返回某一类型对象对应的 GroupResource，用于构造 API 错误。
+func resourceFor(gvk schema.GroupVersionKind) schema.GroupResource {
+	return schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind) + "s"}
+}
+
+func encodeUint64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func decodeUint64(b []byte) uint64 {
+	if len(b) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
@@ -0,0 +1,295 @@
+// file: pkg/registry/boltstore_test.go
+
+package registry
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func newTestBoltStore(t *testing.T, path string) *BoltStore {
+	t.Helper()
+	store, err := NewBoltStore(path, newTestScheme())
+	if err != nil {
+		t.Fatalf("Failed to create BoltStore: %v", err)
+	}
+	return store
+}
+
+func TestBoltStore(t *testing.T) {
+	store := newTestBoltStore(t, filepath.Join(t.TempDir(), "registry.db"))
+	defer store.Close()
+
+	svc1 := newTestService("default", "app-one")
+	svc2 := newTestService("default", "app-two")
+	svc3 := newTestService("production", "app-one")
+
+	for _, svc := range []*ecsmv1.ECSMService{svc1, svc2, svc3} {
+		if err := store.Create(svc); err != nil {
+			t.Fatalf("Create(%s/%s) failed: %v", svc.Namespace, svc.Name, err)
+		}
+	}
+	if err := store.Create(newTestService("default", "app-one")); !errors.IsAlreadyExists(err) {
+		t.Errorf("Expected 'AlreadyExists' error, but got: %v", err)
+	}
+
+	got := &ecsmv1.ECSMService{}
+	if err := store.Get("default", "app-one", got); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Name != "app-one" || got.Namespace != "default" || got.ResourceVersion != svc1.ResourceVersion {
+		t.Errorf("Get returned unexpected object: %s/%s at %q", got.Namespace, got.Name, got.ResourceVersion)
+	}
+	if err := store.Get("default", "missing", &ecsmv1.ECSMService{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected 'NotFound' error, but got: %v", err)
+	}
+
+	list := &ecsmv1.ECSMServiceList{}
+	if err := store.List("default", list); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list.Items) != 2 {
+		t.Errorf("Expected 2 items in namespace 'default', but got %d", len(list.Items))
+	}
+	if err := store.List("", list); err != nil {
+		t.Fatalf("List across namespaces failed: %v", err)
+	}
+	if len(list.Items) != 3 {
+		t.Errorf("Expected 3 items across namespaces, but got %d", len(list.Items))
+	}
+	if list.ResourceVersion != "3" {
+		t.Errorf("Expected list resourceVersion \"3\", but got %q", list.ResourceVersion)
+	}
+
+	stale := svc1.DeepCopy()
+	svc1.Labels["updated"] = "true"
+	if err := store.Update(svc1); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if svc1.ResourceVersion != "4" {
+		t.Errorf("Expected resourceVersion \"4\" after update, but got %q", svc1.ResourceVersion)
+	}
+	if err := store.Update(stale); !errors.IsConflict(err) {
+		t.Errorf("Expected 'Conflict' error for a stale resourceVersion, but got: %v", err)
+	}
+	if err := store.Update(newTestService("default", "missing")); !errors.IsNotFound(err) {
+		t.Errorf("Expected 'NotFound' error, but got: %v", err)
+	}
+
+	if err := store.Delete("default", "app-one", &ecsmv1.ECSMService{}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Get("default", "app-one", &ecsmv1.ECSMService{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected 'NotFound' error after delete, but got: %v", err)
+	}
+	// 删除不存在的对象不是错误
+	if err := store.Delete("default", "app-one", &ecsmv1.ECSMService{}); err != nil {
+		t.Errorf("Deleting a missing object should succeed, but got: %v", err)
+	}
+}
+
+func TestBoltStore_TransactionRollback(t *testing.T) {
+	store := newTestBoltStore(t, filepath.Join(t.TempDir(), "registry.db"))
+	defer store.Close()
+
+	existing := newTestService("default", "existing")
+	if err := store.Create(existing); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	rv := existing.ResourceVersion
+
+	created := newTestService("default", "created")
+	err := store.Transaction(func(tx Store) error {
+		if err := tx.Create(created); err != nil {
+			return err
+		}
+		existing.Labels["updated"] = "true"
+		if err := tx.Update(existing); err != nil {
+			return err
+		}
+		return fmt.Errorf("abort")
+	})
+	if err == nil || err.Error() != "abort" {
+		t.Fatalf("Expected the transaction to fail with 'abort', but got: %v", err)
+	}
+
+	// 回滚后没有任何写入生效，对象上的 resourceVersion 也被恢复
+	if err := store.Get("default", "created", &ecsmv1.ECSMService{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected 'NotFound' for an object created in a rolled back transaction, but got: %v", err)
+	}
+	got := &ecsmv1.ECSMService{}
+	if err := store.Get("default", "existing", got); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Labels["updated"] != "" || got.ResourceVersion != rv {
+		t.Errorf("Rolled back update is visible: labels %v, resourceVersion %q", got.Labels, got.ResourceVersion)
+	}
+	if existing.ResourceVersion != rv || created.ResourceVersion != "" {
+		t.Errorf("Expected resourceVersions to be restored, got %q and %q", existing.ResourceVersion, created.ResourceVersion)
+	}
+
+	// 恢复后的对象可以直接重试
+	if err := store.Update(existing); err != nil {
+		t.Errorf("Retrying the update after rollback failed: %v", err)
+	}
+}
+
+func TestBoltStore_TransactionCommit(t *testing.T) {
+	store := newTestBoltStore(t, filepath.Join(t.TempDir(), "registry.db"))
+	defer store.Close()
+
+	w, err := store.Watch("default", &ecsmv1.ECSMServiceList{}, "")
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer w.Stop()
+
+	err = store.Transaction(func(tx Store) error {
+		for _, name := range []string{"a", "b"} {
+			if err := tx.Create(newTestService("default", name)); err != nil {
+				return err
+			}
+		}
+		// 事务内可以读到自己的写入
+		list := &ecsmv1.ECSMServiceList{}
+		if err := tx.List("default", list); err != nil {
+			return err
+		}
+		if len(list.Items) != 2 {
+			return fmt.Errorf("expected 2 items inside the transaction, got %d", len(list.Items))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	// 事务提交之后才产生事件，并且按写入顺序
+	expectEvent(t, w, watch.Added, "a")
+	expectEvent(t, w, watch.Added, "b")
+	expectNoEvent(t, w)
+}
+
+func TestBoltStore_Watch(t *testing.T) {
+	store := newTestBoltStore(t, filepath.Join(t.TempDir(), "registry.db"))
+	defer store.Close()
+
+	if err := store.Create(newTestService("default", "before")); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	list := &ecsmv1.ECSMServiceList{}
+	if err := store.List("default", list); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+
+	svc := newTestService("default", "after")
+	if err := store.Create(svc); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	w, err := store.Watch("default", &ecsmv1.ECSMServiceList{}, list.ResourceVersion)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer w.Stop()
+	expectEvent(t, w, watch.Added, "after")
+
+	if err := store.Create(newTestService("other", "ignored")); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := store.Delete("default", "after", &ecsmv1.ECSMService{}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	got := expectEvent(t, w, watch.Deleted, "after")
+	if got.ResourceVersion != "4" {
+		t.Errorf("Expected DELETED event at resourceVersion \"4\", but got %q", got.ResourceVersion)
+	}
+	expectNoEvent(t, w)
+
+	if _, err := store.Watch("default", &ecsmv1.ECSMServiceList{}, "100"); !errors.IsBadRequest(err) {
+		t.Errorf("Expected 'BadRequest' error for a future resourceVersion, but got: %v", err)
+	}
+}
+
+func TestBoltStore_ResourceVersionSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.db")
+
+	store := newTestBoltStore(t, path)
+	svc := newTestService("default", "app")
+	if err := store.Create(svc); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := store.Delete("default", "app", &ecsmv1.ECSMService{}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	store = newTestBoltStore(t, path)
+	defer store.Close()
+
+	// 重新打开后计数器继续递增，不会复用已经分配过的 resourceVersion
+	svc = newTestService("default", "app")
+	if err := store.Create(svc); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if svc.ResourceVersion != "3" {
+		t.Errorf("Expected resourceVersion \"3\" after reopen, but got %q", svc.ResourceVersion)
+	}
+}
+
+func TestBoltStore_SharedDatabaseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.db")
+	// 两个 BoltStore 打开同一个数据库文件，相当于同时运行的 ecsm-operator 和 ecsm-cli
+	operator := newTestBoltStore(t, path)
+	defer operator.Close()
+	cli := newTestBoltStore(t, path)
+	defer cli.Close()
+
+	if err := operator.Create(newTestService("default", "existing")); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	list := &ecsmv1.ECSMServiceList{}
+	if err := cli.List("default", list); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list.Items) != 1 {
+		t.Fatalf("Expected the other store's object to be listed, but got %d items", len(list.Items))
+	}
+
+	// 从另一个进程 List 返回的版本开始 Watch，之后另一个进程的写入同样会产生事件
+	w, err := operator.Watch("default", &ecsmv1.ECSMServiceList{}, list.ResourceVersion)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer w.Stop()
+
+	svc := newTestService("default", "app")
+	if err := cli.Create(svc); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if svc.ResourceVersion != "2" {
+		t.Errorf("Expected the counter to be shared, but got resourceVersion %q", svc.ResourceVersion)
+	}
+	expectEvent(t, w, watch.Added, "app")
+
+	// 本进程的写入与其他进程的写入按 resourceVersion 的顺序发出
+	if err := cli.Delete("default", "existing", &ecsmv1.ECSMService{}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := operator.Update(svc); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	expectEvent(t, w, watch.Deleted, "existing")
+	got := expectEvent(t, w, watch.Modified, "app")
+	if got.ResourceVersion != "4" {
+		t.Errorf("Expected MODIFIED event at resourceVersion \"4\", but got %q", got.ResourceVersion)
+	}
+	expectNoEvent(t, w)
+}
@@ -0,0 +1,41 @@
+// file: pkg/registry/conformance_test.go
+
+package registry_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/registry"
+	"github.com/fx147/ecsm-operator/pkg/registry/storetest"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newConformanceScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := ecsmv1.AddToScheme(s); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	return s
+}
+
+func TestFileStore_Conformance(t *testing.T) {
+	storetest.RunConformance(t, func() registry.Store {
+		store, err := registry.NewFileStore(t.TempDir(), newConformanceScheme(t))
+		if err != nil {
+			t.Fatalf("Failed to create FileStore: %v", err)
+		}
+		return store
+	})
+}
+
+func TestBoltStore_Conformance(t *testing.T) {
+	storetest.RunConformance(t, func() registry.Store {
+		store, err := registry.NewBoltStore(filepath.Join(t.TempDir(), "registry.db"), newConformanceScheme(t))
+		if err != nil {
+			t.Fatalf("Failed to create BoltStore: %v", err)
+		}
+		return store
+	})
+}
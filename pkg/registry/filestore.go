@@ -8,18 +8,34 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/fx147/ecsm-operator/pkg/util"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
+// DefaultFileStorePath 是 ecsm-operator 和 ecsm-cli 共享的默认 FileStore 根目录。
+const DefaultFileStorePath = "/var/lib/ecsm-operator/registry"
+
+// resourceVersionFile 是 basePath 下保存 resourceVersion 计数器的文件
+const resourceVersionFile = ".resourceVersion"
+
 // FileStore 实现了 Store 接口，使用本地文件系统作为后端。
+// 同一个 basePath 下的所有对象共享一个保存在磁盘上的 resourceVersion 计数器，每次写入（包括删除）都会递增它，
+// 所以对象的 resourceVersion、List 返回的 resourceVersion 和 watch 事件属于同一个序列，进程重启后也不会重置。
+// Update 时如果传入对象的 resourceVersion 非空且与存储中的不一致，会返回 Conflict 错误。
 type FileStore struct {
 	basePath string
 	scheme   *runtime.Scheme
+
+	// mu 保护 watchState。跨进程的 "读取版本-比较-写入" 的原子性由对象和计数器的文件锁保证。
+	mu sync.Mutex
+	watchState
 }
 
 var _ Store = &FileStore{}
@@ -28,7 +44,11 @@ func NewFileStore(basePath string, scheme *runtime.Scheme) (*FileStore, error) {
 	if err := os.MkdirAll(basePath, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create base path for filestore: %w", err)
 	}
-	return &FileStore{basePath: basePath, scheme: scheme}, nil
+	rv, err := readResourceVersion(filepath.Join(basePath, resourceVersionFile))
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{basePath: basePath, scheme: scheme, watchState: newWatchState(rv)}, nil
 }
 
 func (fs *FileStore) getPathForObject(obj runtime.Object) (string, error) {
@@ -66,45 +86,230 @@ func (fs *FileStore) Create(obj runtime.Object) error {
 		return err
 	}
 
-	if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+	dir := filepath.Dir(path)
+	if mkdirErr := os.MkdirAll(dir, 0755); mkdirErr != nil {
+		return fmt.Errorf("failed to create directory for object: %w", mkdirErr)
+	}
+
+	unlock, err := lockObjectFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	unlockRV, err := fs.lockResourceVersion()
+	if err != nil {
+		return err
+	}
+	defer unlockRV()
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	// 是否已存在由 writeWithResourceVersion 以原子方式判断，这里不再单独 Stat
+	err = fs.writeWithResourceVersion(path, obj, "", watch.Added)
+	if os.IsExist(err) {
 		meta, _ := util.GetObjectMeta(obj)
 		gvk, _ := util.GetGVK(obj, fs.scheme)
 		gr := schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind) + "s"}
 		return errors.NewAlreadyExists(gr, meta.Name)
 	}
+	return err
+}
 
-	dir := filepath.Dir(path)
-	if mkdirErr := os.MkdirAll(dir, 0755); mkdirErr != nil {
-		return fmt.Errorf("failed to create directory for object: %w", mkdirErr)
+// Update 更新一个已存在的对象。
+// 如果 obj 的 resourceVersion 非空，它必须与存储中的版本一致，否则返回 Conflict 错误；
+// 为空则表示无条件更新。
+func (fs *FileStore) Update(obj runtime.Object) error {
+	path, err := fs.getPathForObject(obj)
+	if err != nil {
+		return err
 	}
 
-	data, marshalErr := json.MarshalIndent(obj, "", "  ")
-	if marshalErr != nil {
-		return fmt.Errorf("failed to marshal object to json: %w", marshalErr)
+	meta, err := util.GetObjectMeta(obj)
+	if err != nil {
+		return err
 	}
+	gvk, _ := util.GetGVK(obj, fs.scheme)
+	gr := schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind) + "s"}
 
-	return os.WriteFile(path, data, 0644)
+	// 文件锁保证跨进程（例如 CLI 和 operator 共享同一个 basePath）的 "读取版本-比较-写入" 是原子的
+	unlock, err := lockObjectFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// 命名空间目录都不存在，对象自然也不存在
+			return errors.NewNotFound(gr, meta.Name)
+		}
+		return err
+	}
+	defer unlock()
+	unlockRV, err := fs.lockResourceVersion()
+	if err != nil {
+		return err
+	}
+	defer unlockRV()
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	existing, readErr := os.ReadFile(path)
+	if readErr != nil {
+		if os.IsNotExist(readErr) {
+			return errors.NewNotFound(gr, meta.Name)
+		}
+		return fmt.Errorf("failed to read object file: %w", readErr)
+	}
+
+	current, err := resourceVersionOf(existing)
+	if err != nil {
+		return fmt.Errorf("failed to decode stored object: %w", err)
+	}
+	if meta.ResourceVersion != "" && meta.ResourceVersion != current {
+		return errors.NewConflict(gr, meta.Name, fmt.Errorf(
+			"the object has been modified; please apply your changes to the latest version and try again"))
+	}
+
+	return fs.writeWithResourceVersion(path, obj, current, watch.Modified)
 }
 
-func (fs *FileStore) Update(obj runtime.Object) error {
-	path, err := fs.getPathForObject(obj)
+// writeWithResourceVersion 为 obj 分配一个新的 resourceVersion 并写入 path，current 是对象当前的版本，
+// 成功后产生一个 eventType 类型的 watch 事件。写入失败时 obj 的 resourceVersion 会被恢复。
+// eventType 为 ADDED 时，如果 path 已经存在则返回一个满足 os.IsExist 的错误。
+// 调用者必须持有 path 的文件锁、计数器的文件锁和 fs.mu。
+func (fs *FileStore) writeWithResourceVersion(path string, obj runtime.Object, current string, eventType watch.EventType) error {
+	meta, err := util.GetObjectMeta(obj)
 	if err != nil {
 		return err
 	}
 
-	if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
-		meta, _ := util.GetObjectMeta(obj)
-		gvk, _ := util.GetGVK(obj, fs.scheme)
-		gr := schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind) + "s"}
-		return errors.NewNotFound(gr, meta.Name)
+	rv, err := fs.allocateResourceVersion(current)
+	if err != nil {
+		return err
 	}
+	oldVersion := meta.ResourceVersion
+	meta.ResourceVersion = strconv.FormatUint(rv, 10)
 
 	data, marshalErr := json.MarshalIndent(obj, "", "  ")
 	if marshalErr != nil {
+		meta.ResourceVersion = oldVersion
 		return fmt.Errorf("failed to marshal object to json: %w", marshalErr)
 	}
 
-	return os.WriteFile(path, data, 0644)
+	if writeErr := writeFileAtomic(path, data, 0644, eventType == watch.Added); writeErr != nil {
+		meta.ResourceVersion = oldVersion
+		return writeErr
+	}
+
+	fs.notifyLocked(eventType, path, data, obj, rv)
+	return nil
+}
+
+// lockResourceVersion 获取计数器的文件锁，返回的函数用于释放锁。
+// 它与对象的文件锁一样跨进程有效，持有它的写入在同一个 basePath 下是串行的。
+func (fs *FileStore) lockResourceVersion() (func(), error) {
+	return lockObjectFile(filepath.Join(fs.basePath, resourceVersionFile))
+}
+
+// allocateResourceVersion 递增计数器并返回新的 resourceVersion，调用者必须持有计数器的文件锁。
+// 新版本同时大于 current，兼容在引入计数器之前写入的、带有逐对象版本号的文件。
+// 计数器先于对象写入，之后写入对象失败只会跳过一个版本号，不会导致版本号被重复使用。
+func (fs *FileStore) allocateResourceVersion(current string) (uint64, error) {
+	path := filepath.Join(fs.basePath, resourceVersionFile)
+	rv, err := readResourceVersion(path)
+	if err != nil {
+		return 0, err
+	}
+	if v, err := strconv.ParseUint(current, 10, 64); err == nil && v > rv {
+		rv = v
+	}
+	rv++
+	if err := writeFileAtomic(path, []byte(strconv.FormatUint(rv, 10)), 0644, false); err != nil {
+		return 0, fmt.Errorf("failed to write resourceVersion counter: %w", err)
+	}
+	return rv, nil
+}
+
+// readResourceVersion 读取 path 上的计数器，文件不存在时为 0。
+func readResourceVersion(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read resourceVersion counter: %w", err)
+	}
+	rv, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid resourceVersion counter %s: %w", path, err)
+	}
+	return rv, nil
+}
+
+// writeFileAtomic 把 data 写入同目录下的临时文件并 fsync，然后再放到 path 上，
+// 这样即使进程在写入过程中崩溃，path 上也不会出现写了一半的文件。
+// exclusive 为 true 时使用 link 放置文件，path 已存在则失败（满足 os.IsExist）；否则使用 rename 覆盖。
+func writeFileAtomic(path string, data []byte, perm os.FileMode, exclusive bool) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		// rename 成功后临时文件已经不存在，这里的删除只在失败或 link 之后起作用
+		os.Remove(tmpPath)
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to chmod temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if exclusive {
+		if err := os.Link(tmpPath, path); err != nil {
+			return err
+		}
+	} else if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+
+	return syncDir(dir)
+}
+
+// syncDir 对目录执行 fsync，使 rename/link 产生的目录项变更持久化。
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil && !isSyncNotSupported(err) {
+		return fmt.Errorf("failed to sync directory: %w", err)
+	}
+	return nil
+}
+
+// resourceVersionOf 从序列化后的对象中读取 metadata.resourceVersion。
+func resourceVersionOf(data []byte) (string, error) {
+	var partial struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &partial); err != nil {
+		return "", err
+	}
+	return partial.Metadata.ResourceVersion, nil
 }
 
 func (fs *FileStore) Get(namespace, name string, objInto runtime.Object) error {
@@ -126,45 +331,104 @@ func (fs *FileStore) Get(namespace, name string, objInto runtime.Object) error {
 	return json.Unmarshal(data, objInto)
 }
 
+// List 列出指定命名空间下的对象。
+// namespace 为空 (metav1.NamespaceAll) 时，会遍历该类型下所有命名空间的目录。
+//
+// 只有 resourceVersion 和文件名是在 fs.mu 内取得的，文件内容在锁外读取，避免列出大量对象时阻塞写入。
+// 因此列出的对象可能比返回的 resourceVersion 更新，从这个 resourceVersion 开始的 Watch 会再收到一次这些变更，
+// 与 Kubernetes 的 watch 语义一样，这对使用者是无害的；读取时已经被删除的对象会被跳过。
 func (fs *FileStore) List(namespace string, listInto runtime.Object) error {
 	dirPath, err := fs.getDirForKind(namespace, listInto)
 	if err != nil {
 		return err
 	}
 
-	if _, statErr := os.Stat(dirPath); os.IsNotExist(statErr) {
-		return nil
-	}
-
 	listValue := reflect.ValueOf(listInto).Elem()
 	itemsField := listValue.FieldByName("Items")
 	itemType := itemsField.Type().Elem()
+	// 复用的 listInto 中可能还留有上一次 List 的结果
+	itemsField.Set(reflect.MakeSlice(itemsField.Type(), 0, 0))
 
-	entries, err := os.ReadDir(dirPath)
+	paths, err := fs.listObjectPaths(namespace, dirPath, listInto)
 	if err != nil {
-		return fmt.Errorf("failed to read directory: %w", err)
+		return err
 	}
 
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
-			path := filepath.Join(dirPath, entry.Name())
-			data, readErr := os.ReadFile(path)
-			if readErr != nil {
+	for _, path := range paths {
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			if !os.IsNotExist(readErr) {
 				fmt.Fprintf(os.Stderr, "warning: failed to read file %s: %v\n", path, readErr)
-				continue
 			}
+			continue
+		}
 
-			newItem := reflect.New(itemType).Interface().(runtime.Object)
-			if umErr := json.Unmarshal(data, newItem); umErr != nil {
-				fmt.Fprintf(os.Stderr, "warning: failed to unmarshal file %s: %v\n", path, umErr)
-				continue
-			}
-			itemsField.Set(reflect.Append(itemsField, reflect.ValueOf(newItem).Elem()))
+		newItem := reflect.New(itemType).Interface().(runtime.Object)
+		if umErr := json.Unmarshal(data, newItem); umErr != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to unmarshal file %s: %v\n", path, umErr)
+			continue
 		}
+		itemsField.Set(reflect.Append(itemsField, reflect.ValueOf(newItem).Elem()))
 	}
 	return nil
 }
 
+// listObjectPaths 在 fs.mu 内设置 listInto 的 resourceVersion，并返回此时 dirPath 下所有对象文件的路径。
+func (fs *FileStore) listObjectPaths(namespace, dirPath string, listInto runtime.Object) ([]string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.setListResourceVersionLocked(listInto); err != nil {
+		return nil, err
+	}
+
+	if _, statErr := os.Stat(dirPath); os.IsNotExist(statErr) {
+		return nil, nil
+	}
+
+	if namespace != "" {
+		return appendObjectPaths(nil, dirPath)
+	}
+
+	// NamespaceAll: kind 目录下的每个子目录都是一个命名空间
+	nsEntries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var paths []string
+	for _, nsEntry := range nsEntries {
+		if !nsEntry.IsDir() {
+			continue
+		}
+		if paths, err = appendObjectPaths(paths, filepath.Join(dirPath, nsEntry.Name())); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// appendObjectPaths 把单个命名空间目录中所有对象文件的路径追加到 paths 中。
+func appendObjectPaths(paths []string, dirPath string) ([]string, error) {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		// 以 "." 开头的是临时文件和锁文件
+		if !entry.IsDir() && isObjectFile(entry.Name()) {
+			paths = append(paths, filepath.Join(dirPath, entry.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// isObjectFile 判断目录项是否是对象文件，以 "." 开头的临时文件和锁文件会被排除。
+func isObjectFile(name string) bool {
+	return strings.HasSuffix(name, ".json") && !strings.HasPrefix(name, ".")
+}
+
+// Delete 删除一个对象，objToDelete 的 resourceVersion 非空时只在它与存储中的版本一致时删除。
 func (fs *FileStore) Delete(namespace, name string, objToDelete runtime.Object) error {
 	dir, err := fs.getDirForKind(namespace, objToDelete)
 	if err != nil {
@@ -172,10 +436,75 @@ func (fs *FileStore) Delete(namespace, name string, objToDelete runtime.Object)
 	}
 	path := filepath.Join(dir, name+".json")
 
+	if _, statErr := os.Stat(dir); os.IsNotExist(statErr) {
+		return nil
+	}
+	unlock, err := lockObjectFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+		return nil
+	}
+	unlockRV, err := fs.lockResourceVersion()
+	if err != nil {
+		return err
+	}
+	defer unlockRV()
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if objMeta, err := util.GetObjectMeta(objToDelete); err == nil && objMeta.ResourceVersion != "" {
+		existing, readErr := os.ReadFile(path)
+		if readErr != nil {
+			if os.IsNotExist(readErr) {
+				return nil
+			}
+			return fmt.Errorf("failed to read object file: %w", readErr)
+		}
+		current, err := resourceVersionOf(existing)
+		if err != nil {
+			return fmt.Errorf("failed to decode stored object: %w", err)
+		}
+		if objMeta.ResourceVersion != current {
+			gvk, _ := util.GetGVK(objToDelete, fs.scheme)
+			gr := schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind) + "s"}
+			return errors.NewConflict(gr, name, fmt.Errorf(
+				"the object has been modified; please apply your changes to the latest version and try again"))
+		}
+	}
+
+	// 删除前读取对象，作为 DELETED 事件的内容
+	deleted, known := fs.contents[path]
+	if !known {
+		if gvk, gvkErr := util.GetGVK(objToDelete, fs.scheme); gvkErr == nil {
+			if data, obj, readErr := fs.readObjectFile(path, gvk); readErr == nil {
+				deleted, known = observedFile{data: data, obj: obj}, true
+			}
+		}
+	}
+
+	// 删除同样占用一个 resourceVersion，DELETED 事件中的对象带有这个版本
+	rv, err := fs.allocateResourceVersion("")
+	if err != nil {
+		return err
+	}
 	err = os.Remove(path)
-	if err != nil && !os.IsNotExist(err) {
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
 		return fmt.Errorf("failed to delete object file: %w", err)
 	}
 
+	if known {
+		obj := deleted.obj.DeepCopyObject()
+		if objMeta, err := util.GetObjectMeta(obj); err == nil {
+			objMeta.ResourceVersion = strconv.FormatUint(rv, 10)
+		}
+		fs.notifyLocked(watch.Deleted, path, nil, obj, rv)
+	}
 	return nil
 }
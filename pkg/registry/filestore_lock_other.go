@@ -0,0 +1,26 @@
+// file: pkg/registry/filestore_lock_other.go
+
+//go:build !unix
+
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// objectLocks 是不支持 flock 的平台上的进程内对象锁。
+var objectLocks sync.Map
+
+// lockObjectFile 获取 path 对应对象的排他锁，返回的函数用于释放锁。
+// 这些平台上没有 flock，锁只在同一进程内有效，跨进程的并发写入仍然依赖原子的 rename/link。
+func lockObjectFile(path string) (func(), error) {
+	if _, err := os.Stat(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	v, _ := objectLocks.LoadOrStore(path, &sync.Mutex{})
+	mu := v.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock, nil
+}
@@ -0,0 +1,44 @@
+// file: pkg/registry/filestore_lock_unix.go
+
+//go:build unix
+
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lockObjectFile 获取 path 对应对象的排他文件锁，返回的函数用于释放锁。
+// 锁文件与对象文件位于同一目录，名为 ".<name>.json.lock"。
+// flock 锁属于打开的文件描述，所以它既能互斥不同进程，也能互斥同一进程中的不同 goroutine。
+// 锁文件在对象删除后会保留下来，删除它会让正在等待锁的进程锁住一个已经被替换的文件。
+func lockObjectFile(path string) (func(), error) {
+	lockPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lock")
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if !errors.Is(err, syscall.EINTR) {
+			break
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", lockPath, err)
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// isSyncNotSupported 判断对目录 fsync 的错误是否只是因为文件系统不支持。
+func isSyncNotSupported(err error) bool {
+	return errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTSUP)
+}
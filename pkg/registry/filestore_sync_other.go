@@ -0,0 +1,15 @@
+// file: pkg/registry/filestore_sync_other.go
+
+//go:build !unix && !windows
+
+package registry
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isSyncNotSupported 判断对目录 fsync 的错误是否只是因为文件系统不支持。
+func isSyncNotSupported(err error) bool {
+	return errors.Is(err, errors.ErrUnsupported) || errors.Is(err, syscall.EINVAL)
+}
@@ -0,0 +1,19 @@
+// file: pkg/registry/filestore_sync_windows.go
+
+//go:build windows
+
+package registry
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorInvalidHandle 是 Windows 的 ERROR_INVALID_HANDLE，syscall 包没有导出它。
+const errorInvalidHandle syscall.Errno = 6
+
+// isSyncNotSupported 判断对目录 fsync 的错误是否只是因为文件系统不支持。
+// Windows 上只读打开的目录句柄无法 FlushFileBuffers，返回 ERROR_ACCESS_DENIED 或 ERROR_INVALID_HANDLE。
+func isSyncNotSupported(err error) bool {
+	return errors.Is(err, syscall.ERROR_ACCESS_DENIED) || errors.Is(err, errorInvalidHandle)
+}
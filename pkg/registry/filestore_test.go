@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
+	"sync"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/runtime"
@@ -183,3 +185,187 @@ func TestFileStore(t *testing.T) {
 		}
 	})
 }
+
+func TestFileStore_ResourceVersion(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), newTestScheme())
+	if err != nil {
+		t.Fatalf("Failed to create FileStore: %v", err)
+	}
+
+	svc := newTestService("default", "app")
+	if err := store.Create(svc); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if svc.ResourceVersion != "1" {
+		t.Errorf("Expected resourceVersion '1' after create, but got %q", svc.ResourceVersion)
+	}
+
+	// 基于最新版本的更新应该成功，并递增版本号
+	stale := svc.DeepCopy()
+	svc.Labels["step"] = "2"
+	if err := store.Update(svc); err != nil {
+		t.Fatalf("Update with current resourceVersion failed: %v", err)
+	}
+	if svc.ResourceVersion != "2" {
+		t.Errorf("Expected resourceVersion '2' after update, but got %q", svc.ResourceVersion)
+	}
+
+	// 基于旧版本的更新应该返回 Conflict，且不修改存储中的对象
+	stale.Labels["step"] = "stale"
+	if err := store.Update(stale); !errors.IsConflict(err) {
+		t.Errorf("Expected 'Conflict' error for stale update, but got: %v", err)
+	}
+	if stale.ResourceVersion != "1" {
+		t.Errorf("Stale object's resourceVersion should be left untouched, but got %q", stale.ResourceVersion)
+	}
+	got := &ecsmv1.ECSMService{}
+	if err := store.Get("default", "app", got); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Labels["step"] != "2" || got.ResourceVersion != "2" {
+		t.Errorf("Stale update must not be persisted, got labels %v at resourceVersion %q", got.Labels, got.ResourceVersion)
+	}
+
+	// resourceVersion 为空表示无条件更新
+	stale.ResourceVersion = ""
+	if err := store.Update(stale); err != nil {
+		t.Fatalf("Unconditional update failed: %v", err)
+	}
+	if stale.ResourceVersion != "3" {
+		t.Errorf("Expected resourceVersion '3' after unconditional update, but got %q", stale.ResourceVersion)
+	}
+}
+
+// TestFileStore_ConcurrentWritersAcrossStores 模拟多个进程（例如 CLI 和 operator）共享同一个 basePath，
+// 每个 FileStore 实例都有自己的进程内锁，只能依靠文件锁和 resourceVersion 保证不丢失更新。
+func TestFileStore_ConcurrentWritersAcrossStores(t *testing.T) {
+	tempDir := t.TempDir()
+	seed, err := NewFileStore(tempDir, newTestScheme())
+	if err != nil {
+		t.Fatalf("Failed to create FileStore: %v", err)
+	}
+	svc := newTestService("default", "counter")
+	svc.Annotations = map[string]string{"count": "0"}
+	if err := seed.Create(svc); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	const writers, incrementsPerWriter = 4, 10
+	var wg sync.WaitGroup
+	errCh := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		store, err := NewFileStore(tempDir, newTestScheme())
+		if err != nil {
+			t.Fatalf("Failed to create FileStore: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < incrementsPerWriter; {
+				current := &ecsmv1.ECSMService{}
+				if err := store.Get("default", "counter", current); err != nil {
+					errCh <- err
+					return
+				}
+				count, _ := strconv.Atoi(current.Annotations["count"])
+				current.Annotations["count"] = strconv.Itoa(count + 1)
+				err := store.Update(current)
+				if errors.IsConflict(err) {
+					continue
+				}
+				if err != nil {
+					errCh <- err
+					return
+				}
+				n++
+			}
+		}()
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		t.Fatalf("Concurrent writer failed: %v", err)
+	}
+
+	got := &ecsmv1.ECSMService{}
+	if err := seed.Get("default", "counter", got); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if want := strconv.Itoa(writers * incrementsPerWriter); got.Annotations["count"] != want {
+		t.Errorf("Lost updates: expected count %s, but got %s", want, got.Annotations["count"])
+	}
+}
+
+func TestFileStore_ConcurrentCreate(t *testing.T) {
+	tempDir := t.TempDir()
+
+	const creators = 8
+	var wg sync.WaitGroup
+	results := make(chan error, creators)
+	for i := 0; i < creators; i++ {
+		store, err := NewFileStore(tempDir, newTestScheme())
+		if err != nil {
+			t.Fatalf("Failed to create FileStore: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- store.Create(newTestService("default", "app"))
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	created := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			created++
+		case !errors.IsAlreadyExists(err):
+			t.Errorf("Expected 'AlreadyExists' error from losing creators, but got: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("Expected exactly one successful Create, but got %d", created)
+	}
+}
+
+func TestFileStore_NoPartialFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	store, err := NewFileStore(tempDir, newTestScheme())
+	if err != nil {
+		t.Fatalf("Failed to create FileStore: %v", err)
+	}
+
+	svc := newTestService("default", "app")
+	if err := store.Create(svc); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := store.Update(svc); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// 写入完成后不应该留下临时文件，只有对象文件和锁文件
+	nsDir := filepath.Join(tempDir, "ecsm.sh", "v1", "ecsmservices", "default")
+	entries, err := os.ReadDir(nsDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	for _, entry := range entries {
+		if name := entry.Name(); name != "app.json" && name != ".app.json.lock" {
+			t.Errorf("Unexpected leftover file %q", name)
+		}
+	}
+
+	// 残留的临时文件（例如写入过程中崩溃）不应该被 List 读到
+	if err := os.WriteFile(filepath.Join(nsDir, ".app.json.tmp-123"), []byte(`{"metadata":`), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	list := &ecsmv1.ECSMServiceList{}
+	if err := store.List("default", list); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list.Items) != 1 {
+		t.Errorf("Expected 1 item, but got %d", len(list.Items))
+	}
+}
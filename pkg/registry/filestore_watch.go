@@ -0,0 +1,339 @@
+// file: pkg/registry/filestore_watch.go
+
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/fx147/ecsm-operator/pkg/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+)
+
+// observedFile 记录了一个对象文件最近一次被观测到的内容。
+// 经由 store 的写入和 fsnotify 报告的磁盘变更都会与它比较，内容相同的变更不会重复产生事件。
+type observedFile struct {
+	data []byte
+	obj  runtime.Object
+}
+
+// watchState 是 FileStore 中与 Watch 相关的状态，都由 FileStore.mu 保护。
+type watchState struct {
+	events *watchCache
+
+	// rv 是本进程已知的最新 resourceVersion，也是最近一个事件的序号。
+	// 其他进程的写入通过 fsnotify 观测到，它们的事件按观测到的顺序发出，序号不会小于之前的事件。
+	rv uint64
+
+	// contents 只记录被监听的类型目录中当前存在的对象，对象被删除时随之移除
+	contents map[string]observedFile
+
+	// fsWatcher 在第一次 Watch 时才启动，kindDirs 记录了已经被监听的类型目录及其对象类型
+	fsWatcher *fsnotify.Watcher
+	kindDirs  map[string]schema.GroupVersionKind
+	closeOnce sync.Once
+}
+
+func newWatchState(rv uint64) watchState {
+	return watchState{
+		events:   newWatchCache(rv),
+		rv:       rv,
+		contents: make(map[string]observedFile),
+		kindDirs: make(map[string]schema.GroupVersionKind),
+	}
+}
+
+// Watch 监听指定命名空间下某一类型对象的变更，listInto 用于确定对象类型（例如 &ECSMServiceList{}）。
+//
+// resourceVersion 为空时，会先为当前存在的每个对象发送一个 ADDED 事件；
+// 否则它应该来自 List 返回的 metadata.resourceVersion，Watch 会补发该版本之后发生的事件。
+// 如果该版本已经太旧（超出了保留的历史），返回 ResourceExpired 错误，调用者需要重新 List。
+//
+// 除了经由 FileStore 的写入，直接修改磁盘上 JSON 文件造成的变更也会通过 fsnotify 被观测到。
+func (fs *FileStore) Watch(namespace string, listInto runtime.Object, resourceVersion string) (watch.Interface, error) {
+	listGVK, err := util.GetGVK(listInto, fs.scheme)
+	if err != nil {
+		return nil, err
+	}
+	itemGVK := listGVK.GroupVersion().WithKind(strings.TrimSuffix(listGVK.Kind, "List"))
+
+	kindDir, err := fs.getDirForKind("", listInto)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(kindDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for watch: %w", err)
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.watchKindDirLocked(kindDir, itemGVK); err != nil {
+		// fsnotify 不可用时仍然可以观测到经由 store 的写入
+		klog.ErrorS(err, "Failed to watch directory, direct edits on disk will not be observed", "dir", kindDir)
+	}
+
+	var initial []watch.Event
+	if resourceVersion == "" {
+		initial = fs.snapshotEventsLocked(kindDir, namespace)
+	} else {
+		initial, err = fs.events.since(resourceVersion, fs.currentResourceVersionLocked())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return fs.events.watch(initial, func(e watch.Event) bool {
+		return matchesKind(fs.scheme, e.Object, itemGVK, namespace)
+	}), nil
+}
+
+// Close 停止 fsnotify 并关闭所有 watcher。Close 之后 FileStore 仍然可以读写，但不再产生事件。
+func (fs *FileStore) Close() error {
+	var err error
+	fs.closeOnce.Do(func() {
+		fs.mu.Lock()
+		w := fs.fsWatcher
+		fs.mu.Unlock()
+		if w != nil {
+			err = w.Close()
+		}
+		fs.events.shutdown()
+	})
+	return err
+}
+
+// currentResourceVersionLocked 返回当前的 resourceVersion，调用者必须持有 fs.mu。
+// 其他进程可能已经递增了磁盘上的计数器，这里取两者中较大的一个，
+// 这样 List 返回的版本总是能用于 Watch。
+func (fs *FileStore) currentResourceVersionLocked() uint64 {
+	if rv, err := readResourceVersion(filepath.Join(fs.basePath, resourceVersionFile)); err == nil && rv > fs.rv {
+		fs.rv = rv
+	}
+	return fs.rv
+}
+
+// notifyLocked 记录 path 的最新内容，并广播一个 resourceVersion 为 rv 的事件。调用者必须持有 fs.mu。
+func (fs *FileStore) notifyLocked(eventType watch.EventType, path string, data []byte, obj runtime.Object, rv uint64) {
+	obj = obj.DeepCopyObject()
+	if eventType == watch.Deleted {
+		delete(fs.contents, path)
+	} else if _, watched := fs.kindDirs[filepath.Dir(filepath.Dir(path))]; watched {
+		fs.contents[path] = observedFile{data: data, obj: obj}
+	}
+
+	if rv > fs.rv {
+		fs.rv = rv
+	}
+	fs.events.emit(fs.rv, eventType, obj)
+}
+
+// snapshotEventsLocked 为 kindDir 下（指定命名空间中）当前已知的每个对象生成一个 ADDED 事件。
+func (fs *FileStore) snapshotEventsLocked(kindDir, namespace string) []watch.Event {
+	prefix := kindDir + string(filepath.Separator)
+	if namespace != "" {
+		prefix = filepath.Join(kindDir, namespace) + string(filepath.Separator)
+	}
+
+	paths := make([]string, 0, len(fs.contents))
+	for path := range fs.contents {
+		if strings.HasPrefix(path, prefix) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	events := make([]watch.Event, 0, len(paths))
+	for _, path := range paths {
+		events = append(events, watch.Event{Type: watch.Added, Object: fs.contents[path].obj.DeepCopyObject()})
+	}
+	return events
+}
+
+// matchesKind 判断事件中的对象是否属于 watcher 关心的类型和命名空间。
+func matchesKind(scheme *runtime.Scheme, obj runtime.Object, gvk schema.GroupVersionKind, namespace string) bool {
+	objGVK, err := util.GetGVK(obj, scheme)
+	if err != nil || objGVK != gvk {
+		return false
+	}
+	if namespace == "" {
+		return true
+	}
+	objMeta, err := util.GetObjectMeta(obj)
+	return err == nil && objMeta.Namespace == namespace
+}
+
+// watchKindDirLocked 启动 fsnotify（如果还没有启动），并开始监听 kindDir 及其下的所有命名空间目录。
+// 目录中已有的文件会被记录为基线内容，不会产生事件。
+// 即使 fsnotify 不可用，基线内容也会被读取，返回的错误只表示磁盘上的直接修改无法被观测到。
+func (fs *FileStore) watchKindDirLocked(kindDir string, gvk schema.GroupVersionKind) error {
+	if _, ok := fs.kindDirs[kindDir]; ok {
+		return nil
+	}
+	fs.kindDirs[kindDir] = gvk
+
+	var watchErr error
+	if fs.fsWatcher == nil {
+		w, err := fsnotify.NewWatcher()
+		if err == nil {
+			fs.fsWatcher = w
+			go fs.runFSWatcher(w)
+		}
+		watchErr = err
+	}
+	if fs.fsWatcher != nil {
+		watchErr = fs.fsWatcher.Add(kindDir)
+	}
+
+	nsEntries, err := os.ReadDir(kindDir)
+	if err != nil {
+		return err
+	}
+	for _, nsEntry := range nsEntries {
+		if nsEntry.IsDir() {
+			fs.watchNamespaceDirLocked(filepath.Join(kindDir, nsEntry.Name()), gvk, false)
+		}
+	}
+	return watchErr
+}
+
+// watchNamespaceDirLocked 监听一个命名空间目录，并读取其中已有的对象文件。
+// emit 为 true 时，之前未知的文件会产生 ADDED 事件（用于监听开始之后才出现的目录）。
+func (fs *FileStore) watchNamespaceDirLocked(nsDir string, gvk schema.GroupVersionKind, emit bool) {
+	if fs.fsWatcher != nil {
+		if err := fs.fsWatcher.Add(nsDir); err != nil {
+			klog.ErrorS(err, "Failed to watch namespace directory", "dir", nsDir)
+		}
+	}
+	entries, err := os.ReadDir(nsDir)
+	if err != nil {
+		klog.ErrorS(err, "Failed to read namespace directory", "dir", nsDir)
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !isObjectFile(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(nsDir, entry.Name())
+		if emit {
+			fs.syncFileLocked(path, gvk)
+			continue
+		}
+		if _, known := fs.contents[path]; known {
+			continue
+		}
+		if data, obj, err := fs.readObjectFile(path, gvk); err == nil {
+			fs.contents[path] = observedFile{data: data, obj: obj}
+		}
+	}
+}
+
+// runFSWatcher 处理 fsnotify 报告的磁盘变更，直到 watcher 被关闭。
+func (fs *FileStore) runFSWatcher(w *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			fs.handleFSEvent(event)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			klog.ErrorS(err, "Filesystem watcher error")
+		}
+	}
+}
+
+func (fs *FileStore) handleFSEvent(event fsnotify.Event) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	// 类型目录下新建的命名空间目录
+	if gvk, ok := fs.kindDirs[filepath.Dir(event.Name)]; ok {
+		if event.Has(fsnotify.Create) {
+			if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+				fs.watchNamespaceDirLocked(event.Name, gvk, true)
+			}
+		}
+		return
+	}
+
+	gvk, ok := fs.kindDirs[filepath.Dir(filepath.Dir(event.Name))]
+	if !ok || !isObjectFile(filepath.Base(event.Name)) {
+		return
+	}
+	fs.syncFileLocked(event.Name, gvk)
+}
+
+// syncFileLocked 把磁盘上 path 的当前内容与上一次观测到的内容比较，并在发生变化时产生相应事件。
+func (fs *FileStore) syncFileLocked(path string, gvk schema.GroupVersionKind) {
+	known, wasKnown := fs.contents[path]
+
+	data, obj, err := fs.readObjectFile(path, gvk)
+	switch {
+	case os.IsNotExist(err):
+		if wasKnown {
+			// 删除对象的进程已经递增了计数器，但无法知道它为这次删除分配的版本
+			fs.notifyLocked(watch.Deleted, path, nil, known.obj, fs.currentResourceVersionLocked())
+		}
+	case err != nil:
+		// 文件可能正在被编辑器写入，等待下一次变更事件
+		klog.V(2).InfoS("Ignoring unreadable object file", "path", path, "err", err)
+	case wasKnown && bytes.Equal(known.data, data):
+		// 经由 store 的写入已经产生过事件
+	case wasKnown:
+		fs.notifyLocked(watch.Modified, path, data, obj, objectResourceVersion(obj))
+	default:
+		fs.notifyLocked(watch.Added, path, data, obj, objectResourceVersion(obj))
+	}
+}
+
+// readObjectFile 读取并解码一个对象文件。
+func (fs *FileStore) readObjectFile(path string, gvk schema.GroupVersionKind) ([]byte, runtime.Object, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	obj, err := fs.scheme.New(gvk)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return nil, nil, err
+	}
+	return data, obj, nil
+}
+
+// objectResourceVersion 返回对象的 resourceVersion，无法解析时返回 0。
+func objectResourceVersion(obj runtime.Object) uint64 {
+	objMeta, err := util.GetObjectMeta(obj)
+	if err != nil {
+		return 0
+	}
+	rv, _ := strconv.ParseUint(objMeta.ResourceVersion, 10, 64)
+	return rv
+}
+
+// setListResourceVersionLocked 把 List 结果的 resourceVersion 设为当前的 resourceVersion，调用者必须持有 fs.mu。
+func (fs *FileStore) setListResourceVersionLocked(listInto runtime.Object) error {
+	listMeta, err := meta.ListAccessor(listInto)
+	if err != nil {
+		return err
+	}
+	listMeta.SetResourceVersion(strconv.FormatUint(fs.currentResourceVersionLocked(), 10))
+	return nil
+}
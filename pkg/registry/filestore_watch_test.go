@@ -0,0 +1,355 @@
+// file: pkg/registry/filestore_watch_test.go
+
+package registry
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// nextEvent 从 watcher 中读取下一个事件，超时则测试失败。
+func nextEvent(t *testing.T, w watch.Interface) (watch.EventType, *ecsmv1.ECSMService) {
+	t.Helper()
+	select {
+	case e, ok := <-w.ResultChan():
+		if !ok {
+			t.Fatalf("Watch channel closed unexpectedly")
+		}
+		svc, ok := e.Object.(*ecsmv1.ECSMService)
+		if !ok {
+			t.Fatalf("Expected *ECSMService in event, but got %T", e.Object)
+		}
+		return e.Type, svc
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for watch event")
+	}
+	return "", nil
+}
+
+// expectEvent 读取下一个事件，并检查其类型和对象名称。
+func expectEvent(t *testing.T, w watch.Interface, wantType watch.EventType, wantName string) *ecsmv1.ECSMService {
+	t.Helper()
+	gotType, svc := nextEvent(t, w)
+	if gotType != wantType || svc.Name != wantName {
+		t.Fatalf("Expected %s event for %q, but got %s for %q", wantType, wantName, gotType, svc.Name)
+	}
+	return svc
+}
+
+// expectNoEvent 确认在短时间内没有更多事件。
+func expectNoEvent(t *testing.T, w watch.Interface) {
+	t.Helper()
+	select {
+	case e := <-w.ResultChan():
+		t.Fatalf("Expected no event, but got %s %+v", e.Type, e.Object)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func newWatchTestStore(t *testing.T) (*FileStore, string) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, newTestScheme())
+	if err != nil {
+		t.Fatalf("Failed to create FileStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store, dir
+}
+
+func TestFileStore_WatchStoreWrites(t *testing.T) {
+	store, _ := newWatchTestStore(t)
+
+	existing := newTestService("default", "existing")
+	if err := store.Create(existing); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	w, err := store.Watch("default", &ecsmv1.ECSMServiceList{}, "")
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer w.Stop()
+
+	// resourceVersion 为空时先收到现有对象的 ADDED 事件
+	expectEvent(t, w, watch.Added, "existing")
+
+	svc := newTestService("default", "app")
+	if err := store.Create(svc); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	expectEvent(t, w, watch.Added, "app")
+
+	// 其他命名空间中的变更不应该被看到
+	if err := store.Create(newTestService("other", "app")); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	svc.Labels["updated"] = "true"
+	if err := store.Update(svc); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	// existing、app、other/app 依次占用了 1 到 3，所有对象共享同一个 resourceVersion 序列
+	got := expectEvent(t, w, watch.Modified, "app")
+	if got.Labels["updated"] != "true" || got.ResourceVersion != "4" {
+		t.Errorf("MODIFIED event carries a stale object: labels %v, resourceVersion %q", got.Labels, got.ResourceVersion)
+	}
+
+	if err := store.Delete("default", "app", &ecsmv1.ECSMService{}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	expectEvent(t, w, watch.Deleted, "app")
+
+	// fsnotify 观测到的、经由 store 的写入不应该产生重复事件
+	expectNoEvent(t, w)
+}
+
+func TestFileStore_WatchDiskEdits(t *testing.T) {
+	store, dir := newWatchTestStore(t)
+
+	svc := newTestService("default", "app")
+	if err := store.Create(svc); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	w, err := store.Watch("", &ecsmv1.ECSMServiceList{}, "")
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer w.Stop()
+	expectEvent(t, w, watch.Added, "app")
+
+	kindDir := filepath.Join(dir, "ecsm.sh", "v1", "ecsmservices")
+	writeFile := func(path string, obj *ecsmv1.ECSMService) {
+		t.Helper()
+		data, err := json.Marshal(obj)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	// 直接修改磁盘上的文件
+	svc.Labels["edited"] = "by-hand"
+	writeFile(filepath.Join(kindDir, "default", "app.json"), svc)
+	got := expectEvent(t, w, watch.Modified, "app")
+	if got.Labels["edited"] != "by-hand" {
+		t.Errorf("Expected MODIFIED event to carry the edited labels, got %v", got.Labels)
+	}
+
+	// 在新的命名空间目录中直接创建文件
+	writeFile(filepath.Join(kindDir, "staging", "new.json"), newTestService("staging", "new"))
+	expectEvent(t, w, watch.Added, "new")
+
+	// 直接删除文件
+	if err := os.Remove(filepath.Join(kindDir, "default", "app.json")); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	expectEvent(t, w, watch.Deleted, "app")
+}
+
+func TestFileStore_WatchFromResourceVersion(t *testing.T) {
+	store, _ := newWatchTestStore(t)
+
+	if err := store.Create(newTestService("default", "before")); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	list := &ecsmv1.ECSMServiceList{}
+	if err := store.List("default", list); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if list.ResourceVersion == "" {
+		t.Fatalf("Expected List to return a resourceVersion")
+	}
+
+	// List 和 Watch 之间发生的写入应该被补发，而 List 之前的对象不应该再次出现
+	if err := store.Create(newTestService("default", "after")); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	w, err := store.Watch("default", &ecsmv1.ECSMServiceList{}, list.ResourceVersion)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer w.Stop()
+	expectEvent(t, w, watch.Added, "after")
+	expectNoEvent(t, w)
+
+	if _, err := store.Watch("default", &ecsmv1.ECSMServiceList{}, "not-a-number"); !errors.IsBadRequest(err) {
+		t.Errorf("Expected 'BadRequest' error for an invalid resourceVersion, but got: %v", err)
+	}
+}
+
+func TestFileStore_WatchExpiredResourceVersion(t *testing.T) {
+	store, _ := newWatchTestStore(t)
+
+	svc := newTestService("default", "app")
+	if err := store.Create(svc); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for i := 0; i < watchHistoryLength+1; i++ {
+		if err := store.Update(svc); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+	}
+
+	if _, err := store.Watch("default", &ecsmv1.ECSMServiceList{}, "1"); !errors.IsResourceExpired(err) {
+		t.Errorf("Expected 'ResourceExpired' error for a compacted resourceVersion, but got: %v", err)
+	}
+}
+
+func TestFileStore_ListAndObjectsShareResourceVersion(t *testing.T) {
+	store, dir := newWatchTestStore(t)
+
+	a, b := newTestService("default", "a"), newTestService("other", "b")
+	for _, svc := range []*ecsmv1.ECSMService{a, b} {
+		if err := store.Create(svc); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	if err := store.Update(a); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if a.ResourceVersion != "3" || b.ResourceVersion != "2" {
+		t.Errorf("Expected resourceVersions 3 and 2, but got %q and %q", a.ResourceVersion, b.ResourceVersion)
+	}
+
+	list := &ecsmv1.ECSMServiceList{}
+	if err := store.List("", list); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if list.ResourceVersion != "3" {
+		t.Errorf("Expected List resourceVersion to be the latest object resourceVersion \"3\", but got %q", list.ResourceVersion)
+	}
+
+	// 重新打开之后计数器继续递增，List 返回的版本也不会回到 0
+	reopened, err := NewFileStore(dir, newTestScheme())
+	if err != nil {
+		t.Fatalf("Failed to reopen FileStore: %v", err)
+	}
+	defer reopened.Close()
+	if err := reopened.List("", list); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if list.ResourceVersion != "3" {
+		t.Errorf("Expected List resourceVersion \"3\" after reopen, but got %q", list.ResourceVersion)
+	}
+	if err := reopened.Delete("other", "b", &ecsmv1.ECSMService{}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	c := newTestService("default", "c")
+	if err := reopened.Create(c); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if c.ResourceVersion != "5" {
+		t.Errorf("Expected resourceVersion \"5\" after a delete in the reopened store, but got %q", c.ResourceVersion)
+	}
+
+	// 另一个进程的写入同样推进 List 返回的版本，并且可以从这个版本开始 Watch
+	if err := store.List("", list); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if list.ResourceVersion != "5" {
+		t.Errorf("Expected List resourceVersion to include writes by another store, but got %q", list.ResourceVersion)
+	}
+	w, err := store.Watch("", &ecsmv1.ECSMServiceList{}, list.ResourceVersion)
+	if err != nil {
+		t.Fatalf("Watch from another store's resourceVersion failed: %v", err)
+	}
+	w.Stop()
+}
+
+func TestFileStore_SlowWatcherDoesNotBlockWrites(t *testing.T) {
+	store, _ := newWatchTestStore(t)
+
+	// 这个 watcher 从不读取事件
+	slow, err := store.Watch("default", &ecsmv1.ECSMServiceList{}, "")
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer slow.Stop()
+
+	svc := newTestService("default", "app")
+	done := make(chan error, 1)
+	go func() {
+		if err := store.Create(svc); err != nil {
+			done <- err
+			return
+		}
+		for i := 0; i < watchQueueLength+10; i++ {
+			if err := store.Update(svc); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatalf("Writes blocked on a watcher that does not read its events")
+	}
+
+	// 缓冲区满了之后 watcher 被关闭，读完缓冲区中的事件之后通道关闭
+	received := 0
+	for range slow.ResultChan() {
+		received++
+	}
+	if received != watchQueueLength {
+		t.Errorf("Expected the slow watcher to receive %d buffered events before being closed, but got %d", watchQueueLength, received)
+	}
+}
+
+func TestFileStore_ContentsDropDeletedObjects(t *testing.T) {
+	store, _ := newWatchTestStore(t)
+
+	// 没有被监听的类型不需要记录对象内容
+	if err := store.Create(newTestService("default", "unwatched")); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if n := len(store.contents); n != 0 {
+		t.Errorf("Expected no cached contents before anything is watched, but got %d", n)
+	}
+
+	w, err := store.Watch("", &ecsmv1.ECSMServiceList{}, "")
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer w.Stop()
+	expectEvent(t, w, watch.Added, "unwatched")
+
+	for _, name := range []string{"unwatched", "app"} {
+		if name == "app" {
+			if err := store.Create(newTestService("default", name)); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			expectEvent(t, w, watch.Added, name)
+		}
+		if err := store.Delete("default", name, &ecsmv1.ECSMService{}); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		expectEvent(t, w, watch.Deleted, name)
+	}
+
+	store.mu.Lock()
+	n := len(store.contents)
+	store.mu.Unlock()
+	if n != 0 {
+		t.Errorf("Expected deleted objects to be dropped from the cache, but %d remain", n)
+	}
+}
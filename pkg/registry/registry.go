@@ -7,3 +7,16 @@ type Registry struct {
 func NewRegistry(store Store) *Registry {
 	return &Registry{store: store}
 }
+
+// atomically 执行 fn。底层存储支持事务时，fn 在一个事务中执行：它的读取和写入要么全部生效，要么全部不生效，
+// 读取之后也不会有其他写入插进来；否则 fn 直接在存储上执行，依靠写入时的 resourceVersion 比较检测并发修改。
+// fn 只能通过传入的 Registry 读写存储。
+func (r *Registry) atomically(fn func(r *Registry) error) error {
+	ts, ok := r.store.(TransactionalStore)
+	if !ok {
+		return fn(r)
+	}
+	return ts.Transaction(func(tx Store) error {
+		return fn(&Registry{store: tx})
+	})
+}
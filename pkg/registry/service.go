@@ -2,13 +2,16 @@ package registry
 
 import (
 	"context"
+	"fmt"
+	"slices"
 
 	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
 	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 // GetServiceWithNamespace 是一个类型安全的方法，可以用于获取 ECSMService 对象。
@@ -31,21 +34,31 @@ func (r *Registry) ListServices(ctx context.Context, namespace string) (*ecsmv1.
 	return services, nil
 }
 
+// WatchServices 监听指定命名空间中 ECSMService 对象的变更。
+// resourceVersion 通常来自 ListServices 返回的 metadata.resourceVersion，为空表示从当前状态开始。
+func (r *Registry) WatchServices(ctx context.Context, namespace, resourceVersion string) (watch.Interface, error) {
+	return r.store.Watch(namespace, &ecsmv1.ECSMServiceList{}, resourceVersion)
+}
+
 func (r *Registry) CreateService(ctx context.Context, service *ecsmv1.ECSMService) (*ecsmv1.ECSMService, error) {
 	// 业务逻辑1 设置默认值
 	setServiceDefaults(service)
 
 	// 业务逻辑2 验证对象
 	if errs := validateService(service); len(errs) > 0 {
-		// 一般会返回一个包含所有错误的聚合错误
-		// 这里为了简化，只返回第一个
+		// 返回一个包含所有字段错误的 Invalid 错误
 		return nil, errors.NewInvalid(ecsmv1.Kind("ECSMService"), service.Name, errs)
 	}
 
 	// 业务逻辑3 填充系统管理的字段
+	// 控制器维护的注解只能由控制器写入，否则用户可以让控制器删除任意一个 ECSM 服务
+	for _, key := range controllerAnnotations {
+		delete(service.ObjectMeta.Annotations, key)
+	}
 	uidString := uuid.New().String()
 	service.ObjectMeta.UID = types.UID(uidString)
 	service.ObjectMeta.CreationTimestamp = metav1.Now()
+	service.ObjectMeta.Generation = 1
 
 	// 调用底层存储
 	if err := r.store.Create(service); err != nil {
@@ -57,7 +70,19 @@ func (r *Registry) CreateService(ctx context.Context, service *ecsmv1.ECSMServic
 
 // UpdateService 封装了更新一个 ECSMService 的业务逻辑。
 // 这是一个“读取-修改-写入”的原子操作，以防止更新冲突。
+// service 的 resourceVersion 为空时表示基于最新版本更新：写入仍然会检查读取到的版本，
+// 与其他写入冲突时重新读取并重试，而不会覆盖掉并发写入的 status 或 metadata。
 func (r *Registry) UpdateService(ctx context.Context, service *ecsmv1.ECSMService) (*ecsmv1.ECSMService, error) {
+	var result *ecsmv1.ECSMService
+	err := r.retryUpdate(service, func(r *Registry) (err error) {
+		result, err = r.updateService(ctx, service)
+		return err
+	})
+	return result, err
+}
+
+// updateService 执行一次 UpdateService 的“读取-修改-写入”。
+func (r *Registry) updateService(ctx context.Context, service *ecsmv1.ECSMService) (*ecsmv1.ECSMService, error) {
 	// --- 第一步：获取当前存储中的老对象 ---
 	// 这是保证所有更新都在最新版本上进行的关键。
 	oldService, err := r.GetService(ctx, service.Namespace, service.Name)
@@ -66,10 +91,11 @@ func (r *Registry) UpdateService(ctx context.Context, service *ecsmv1.ECSMServic
 		return nil, err
 	}
 
-	// (未来优化点): 在这里进行 ResourceVersion 的检查，实现乐观锁。
-	// if oldService.ResourceVersion != service.ResourceVersion {
-	//     return nil, errors.NewConflict(...)
-	// }
+	// 在这里进行 ResourceVersion 的检查，实现乐观锁。
+	// resourceVersion 为空表示基于刚刚读取到的版本更新。
+	if err := checkResourceVersion(oldService, service); err != nil {
+		return nil, err
+	}
 
 	// --- 第二步：准备要写入的新对象 ---
 	// 我们不能直接修改传入的 service 对象，因为它可能不完整。
@@ -87,7 +113,12 @@ func (r *Registry) UpdateService(ctx context.Context, service *ecsmv1.ECSMServic
 	//    我们只允许更新 labels 和 annotations。
 	serviceToUpdate.ObjectMeta.Labels = service.ObjectMeta.Labels
 	serviceToUpdate.ObjectMeta.Annotations = service.ObjectMeta.Annotations
+	// 控制器维护的注解不能被用户修改或删除，始终沿用存储中的值
+	serviceToUpdate.ObjectMeta.Annotations = withControllerAnnotations(serviceToUpdate.ObjectMeta.Annotations, oldService.ObjectMeta.Annotations)
 	// 注意：serviceToUpdate 的 Name, Namespace, UID, CreationTimestamp 等都继承自 oldService，不会被覆盖。
+	// resourceVersion 使用调用者看到的版本，交给底层存储在写入时再做一次原子的比较。
+	// 调用者没有指定时使用刚刚读取到的版本，这样在读取之后发生的写入同样会导致 Conflict。
+	serviceToUpdate.ObjectMeta.ResourceVersion = resourceVersionFor(oldService, service)
 
 	// 3. **Status 的处理**: UpdateService 方法 *不应该* 修改 Status。
 	//    Status 的更新应该由一个独立的 UpdateStatus 方法来完成。
@@ -101,6 +132,19 @@ func (r *Registry) UpdateService(ctx context.Context, service *ecsmv1.ECSMServic
 		return nil, errors.NewInvalid(ecsmv1.SchemeGroupVersion.WithKind("ECSMService").GroupKind(), service.Name, errs)
 	}
 
+	// 没有任何变化时不写入存储，resourceVersion 保持不变
+	specChanged := !equality.Semantic.DeepEqual(oldService.Spec, serviceToUpdate.Spec)
+	if !specChanged &&
+		equality.Semantic.DeepEqual(oldService.Labels, serviceToUpdate.Labels) &&
+		equality.Semantic.DeepEqual(oldService.Annotations, serviceToUpdate.Annotations) {
+		return oldService, nil
+	}
+
+	// 只有 spec 发生变化时才递增 generation，metadata 的变更不影响它
+	if specChanged {
+		serviceToUpdate.ObjectMeta.Generation = oldService.ObjectMeta.Generation + 1
+	}
+
 	// --- 第五步：调用底层存储 ---
 	if err := r.store.Update(serviceToUpdate); err != nil {
 		return nil, err
@@ -109,20 +153,35 @@ func (r *Registry) UpdateService(ctx context.Context, service *ecsmv1.ECSMServic
 	return serviceToUpdate, nil
 }
 
-// 新增一个专门用于更新 Status 的方法
+// UpdateServiceStatus 只更新 ECSMService 的 status，resourceVersion 的处理与 UpdateService 相同。
 func (r *Registry) UpdateServiceStatus(ctx context.Context, service *ecsmv1.ECSMService) (*ecsmv1.ECSMService, error) {
+	var result *ecsmv1.ECSMService
+	err := r.retryUpdate(service, func(r *Registry) (err error) {
+		result, err = r.updateServiceStatus(ctx, service)
+		return err
+	})
+	return result, err
+}
+
+// updateServiceStatus 执行一次 UpdateServiceStatus 的“读取-修改-写入”。
+func (r *Registry) updateServiceStatus(ctx context.Context, service *ecsmv1.ECSMService) (*ecsmv1.ECSMService, error) {
 	oldService, err := r.GetService(ctx, service.Namespace, service.Name)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := checkResourceVersion(oldService, service); err != nil {
+		return nil, err
+	}
+
 	serviceToUpdate := oldService.DeepCopy()
 
 	// 只用新的 status 覆盖旧的 status
 	serviceToUpdate.Status = service.Status
+	serviceToUpdate.ObjectMeta.ResourceVersion = resourceVersionFor(oldService, service)
 
-	// 可以在这里对 Status 的某些字段进行验证
-	if errs := validateService(serviceToUpdate); len(errs) > 0 {
+	// 只验证 status，spec 由 UpdateService 负责验证
+	if errs := validateServiceStatus(&serviceToUpdate.Status); len(errs) > 0 {
 		return nil, errors.NewInvalid(ecsmv1.SchemeGroupVersion.WithKind("ECSMService").GroupKind(), service.Name, errs)
 	}
 
@@ -134,15 +193,180 @@ func (r *Registry) UpdateServiceStatus(ctx context.Context, service *ecsmv1.ECSM
 	return serviceToUpdate, nil
 }
 
+// DeleteService 删除一个 ECSMService。
+// 对象上没有 finalizer 时立即从存储中移除；否则只设置 DeletionTimestamp，
+// 等到所有 finalizer 都被移除（见 RemoveServiceFinalizer）之后才真正删除。
+// 删除一个不存在的对象不是错误。
 func (r *Registry) DeleteService(ctx context.Context, namespace, name string) error {
-	return r.store.Delete(namespace, name, &ecsmv1.ECSMService{})
+	return r.retryOnConflict(func(r *Registry) error {
+		service, err := r.GetService(ctx, namespace, name)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(service.Finalizers) == 0 {
+			// 只删除刚刚读取到的版本：读取之后被并发添加了 finalizer 的对象会返回 Conflict，重新读取后改为设置 DeletionTimestamp
+			return r.store.Delete(namespace, name, service)
+		}
+		if service.DeletionTimestamp != nil {
+			// 已经在删除中，等待 finalizer 被移除
+			return nil
+		}
+
+		now := metav1.Now()
+		var gracePeriod int64
+		service.DeletionTimestamp = &now
+		service.DeletionGracePeriodSeconds = &gracePeriod
+		return r.store.Update(service)
+	})
 }
 
-func setServiceDefaults(service *ecsmv1.ECSMService) {
-	// 填充默认值
+// AddServiceFinalizer 为 ECSMService 添加一个 finalizer，已经存在时什么也不做。
+// 正在被删除的对象不能再添加新的 finalizer。
+func (r *Registry) AddServiceFinalizer(ctx context.Context, namespace, name, finalizer string) (*ecsmv1.ECSMService, error) {
+	var result *ecsmv1.ECSMService
+	err := r.retryOnConflict(func(r *Registry) error {
+		service, err := r.GetService(ctx, namespace, name)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(service.Finalizers, finalizer) {
+			result = service
+			return nil
+		}
+		if service.DeletionTimestamp != nil {
+			return errors.NewForbidden(ecsmv1.Resource("ecsmservices"), name,
+				fmt.Errorf("no new finalizers can be added if the object is being deleted"))
+		}
+
+		service.Finalizers = append(service.Finalizers, finalizer)
+		if err := r.store.Update(service); err != nil {
+			return err
+		}
+		result = service
+		return nil
+	})
+	return result, err
 }
 
-func validateService(service *ecsmv1.ECSMService) field.ErrorList {
-	// 验证对象
-	return nil
+// RemoveServiceFinalizer 从 ECSMService 上移除一个 finalizer。
+// 如果对象正在被删除并且这是最后一个 finalizer，对象会被真正从存储中删除。
+func (r *Registry) RemoveServiceFinalizer(ctx context.Context, namespace, name, finalizer string) error {
+	return r.retryOnConflict(func(r *Registry) error {
+		service, err := r.GetService(ctx, namespace, name)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(service.Finalizers, finalizer) {
+			return nil
+		}
+
+		service.Finalizers = slices.DeleteFunc(service.Finalizers, func(f string) bool { return f == finalizer })
+		if len(service.Finalizers) == 0 && service.DeletionTimestamp != nil {
+			// 与 DeleteService 一样以读取到的版本为前提，读取之后发生的写入会导致 Conflict 并重试
+			return r.store.Delete(namespace, name, service)
+		}
+		return r.store.Update(service)
+	})
+}
+
+// SetServiceAnnotation 设置 ECSMService 上的一个注解，值没有变化时什么也不做。
+// 它供控制器记录 ECSMServiceIDAnnotation 这类由系统维护的注解，不会经过 UpdateService 对注解的限制。
+func (r *Registry) SetServiceAnnotation(ctx context.Context, namespace, name, key, value string) (*ecsmv1.ECSMService, error) {
+	var result *ecsmv1.ECSMService
+	err := r.retryOnConflict(func(r *Registry) error {
+		service, err := r.GetService(ctx, namespace, name)
+		if err != nil {
+			return err
+		}
+		if v, ok := service.Annotations[key]; ok && v == value {
+			result = service
+			return nil
+		}
+
+		if service.Annotations == nil {
+			service.Annotations = make(map[string]string)
+		}
+		service.Annotations[key] = value
+		if err := r.store.Update(service); err != nil {
+			return err
+		}
+		result = service
+		return nil
+	})
+	return result, err
+}
+
+// controllerAnnotations 是由控制器维护、用户不能通过 CreateService 和 UpdateService 写入的注解
+var controllerAnnotations = []string{ecsmv1.ECSMServiceIDAnnotation, ecsmv1.ECSMServiceDeleteTransactionAnnotation}
+
+// withControllerAnnotations 返回 annotations 的一个副本，其中由控制器维护的注解被替换为 old 中的值。
+func withControllerAnnotations(annotations, old map[string]string) map[string]string {
+	result, copied := annotations, false
+	for _, key := range controllerAnnotations {
+		v, ok := old[key]
+		if current, set := result[key]; ok == set && current == v {
+			continue
+		}
+		if !copied {
+			result = make(map[string]string, len(annotations)+len(controllerAnnotations))
+			for k, val := range annotations {
+				result[k] = val
+			}
+			copied = true
+		}
+		delete(result, key)
+		if ok {
+			result[key] = v
+		}
+	}
+	return result
+}
+
+// conflictRetries 是 Registry 内部的“读取-修改-写入”操作在遇到 Conflict 时的最大尝试次数
+const conflictRetries = 5
+
+// retryOnConflict 通过 r.atomically 执行 fn，在它返回 Conflict 错误时重新执行，最多 conflictRetries 次。
+// fn 每次都必须通过传入的 Registry 重新读取对象。
+func (r *Registry) retryOnConflict(fn func(r *Registry) error) error {
+	var err error
+	for i := 0; i < conflictRetries; i++ {
+		if err = r.atomically(fn); !errors.IsConflict(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// resourceVersionFor 返回写入时应该校验的 resourceVersion：调用者指定的版本，没有指定时为读取到的版本。
+func resourceVersionFor(oldService, service *ecsmv1.ECSMService) string {
+	if service.ResourceVersion != "" {
+		return service.ResourceVersion
+	}
+	return oldService.ResourceVersion
+}
+
+// retryUpdate 执行 update。service 没有指定 resourceVersion 时，Conflict 只说明读取之后有并发写入，
+// 按 retryOnConflict 重新读取并重试；指定了 resourceVersion 时 Conflict 说明调用者看到的对象已经过时，直接返回。
+func (r *Registry) retryUpdate(service *ecsmv1.ECSMService, update func(r *Registry) error) error {
+	if service.ResourceVersion != "" {
+		return r.atomically(update)
+	}
+	return r.retryOnConflict(update)
+}
+
+// checkResourceVersion 检查调用者提交的对象是否基于存储中的最新版本。
+// 这只是一次提前的检查，真正保证原子性的是底层存储在写入时的比较。
+func checkResourceVersion(oldService, service *ecsmv1.ECSMService) error {
+	if service.ResourceVersion == "" || service.ResourceVersion == oldService.ResourceVersion {
+		return nil
+	}
+	return errors.NewConflict(ecsmv1.Resource("ecsmservices"), service.Name, fmt.Errorf(
+		"the object has been modified; please apply your changes to the latest version and try again"))
 }
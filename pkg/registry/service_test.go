@@ -0,0 +1,542 @@
+// file: pkg/registry/service_test.go
+
+package registry
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newTestRegistry(t *testing.T) *Registry {
+	store, err := NewFileStore(t.TempDir(), newTestScheme())
+	if err != nil {
+		t.Fatalf("Failed to create FileStore: %v", err)
+	}
+	return NewRegistry(store)
+}
+
+// newValidTestService 返回一个能够通过 Registry 校验的 ECSMService。
+func newValidTestService(namespace, name string) *ecsmv1.ECSMService {
+	svc := newTestService(namespace, name)
+	replicas := int32(1)
+	svc.Spec = ecsmv1.ECSMServiceSpec{
+		DeploymentStrategy: ecsmv1.DeploymentStrategy{
+			Type:     ecsmv1.DeploymentStrategyTypeDynamic,
+			Replicas: &replicas,
+			NodePool: []string{"node-1"},
+		},
+		Template: ecsmv1.ContainerTemplateSpec{Image: "nginx@1.0"},
+	}
+	return svc
+}
+
+func TestRegistry_GenerationAndConflict(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRegistry(t)
+
+	created, err := r.CreateService(ctx, newValidTestService("default", "app"))
+	if err != nil {
+		t.Fatalf("CreateService failed: %v", err)
+	}
+	if created.Generation != 1 {
+		t.Errorf("Expected generation 1 after create, but got %d", created.Generation)
+	}
+
+	// 只修改 metadata 不应该递增 generation
+	svc := created.DeepCopy()
+	svc.Labels["tier"] = "web"
+	updated, err := r.UpdateService(ctx, svc)
+	if err != nil {
+		t.Fatalf("UpdateService (labels) failed: %v", err)
+	}
+	if updated.Generation != 1 {
+		t.Errorf("Expected generation to stay 1 after a metadata-only update, but got %d", updated.Generation)
+	}
+	if updated.ResourceVersion == created.ResourceVersion {
+		t.Errorf("Expected resourceVersion to change after update, but it stayed %q", updated.ResourceVersion)
+	}
+
+	// 修改 spec 应该递增 generation
+	svc = updated.DeepCopy()
+	svc.Spec.Template.Image = "nginx@2.0"
+	updated, err = r.UpdateService(ctx, svc)
+	if err != nil {
+		t.Fatalf("UpdateService (spec) failed: %v", err)
+	}
+	if updated.Generation != 2 {
+		t.Errorf("Expected generation 2 after a spec update, but got %d", updated.Generation)
+	}
+
+	// status 的更新不影响 generation
+	svc = updated.DeepCopy()
+	svc.Status.ObservedGeneration = updated.Generation
+	updated, err = r.UpdateServiceStatus(ctx, svc)
+	if err != nil {
+		t.Fatalf("UpdateServiceStatus failed: %v", err)
+	}
+	if updated.Generation != 2 {
+		t.Errorf("Expected generation to stay 2 after a status update, but got %d", updated.Generation)
+	}
+
+	// 基于旧版本的 Update 和 UpdateStatus 都应该返回 Conflict
+	stale := created.DeepCopy()
+	stale.Spec.Template.Image = "nginx@3.0"
+	if _, err := r.UpdateService(ctx, stale); !errors.IsConflict(err) {
+		t.Errorf("Expected 'Conflict' error from UpdateService, but got: %v", err)
+	}
+	stale.Status.Replicas = 5
+	if _, err := r.UpdateServiceStatus(ctx, stale); !errors.IsConflict(err) {
+		t.Errorf("Expected 'Conflict' error from UpdateServiceStatus, but got: %v", err)
+	}
+
+	got, err := r.GetService(ctx, "default", "app")
+	if err != nil {
+		t.Fatalf("GetService failed: %v", err)
+	}
+	if got.Spec.Template.Image != "nginx@2.0" || got.Status.Replicas != 0 {
+		t.Errorf("Stale writes must not be persisted, got image %q and replicas %d", got.Spec.Template.Image, got.Status.Replicas)
+	}
+}
+
+func TestRegistry_Defaults(t *testing.T) {
+	r := newTestRegistry(t)
+
+	created, err := r.CreateService(context.Background(), newValidTestService("default", "app"))
+	if err != nil {
+		t.Fatalf("CreateService failed: %v", err)
+	}
+	tmpl := created.Spec.Template
+	if tmpl.ImagePullPolicy != ecsmv1.ImagePullPolicyIfNotPresent {
+		t.Errorf("Expected imagePullPolicy to default to IfNotPresent, but got %q", tmpl.ImagePullPolicy)
+	}
+	if created.Spec.UpgradeStrategy.Type != ecsmv1.UpgradeStrategyTypeNever {
+		t.Errorf("Expected upgradeStrategy to default to Never, but got %q", created.Spec.UpgradeStrategy.Type)
+	}
+	if tmpl.Hostname != "app" {
+		t.Errorf("Expected hostname to default to the object name, but got %q", tmpl.Hostname)
+	}
+}
+
+func TestRegistry_Validation(t *testing.T) {
+	int32Ptr := func(v int32) *int32 { return &v }
+
+	tests := []struct {
+		name       string
+		mutate     func(svc *ecsmv1.ECSMService)
+		wantFields []string
+	}{
+		{
+			name:   "valid dynamic",
+			mutate: func(svc *ecsmv1.ECSMService) {},
+		},
+		{
+			name: "valid static with os and resources",
+			mutate: func(svc *ecsmv1.ECSMService) {
+				svc.Spec.DeploymentStrategy = ecsmv1.DeploymentStrategy{
+					Type:  ecsmv1.DeploymentStrategyTypeStatic,
+					Nodes: []string{"node-1", "node-2"},
+				}
+				svc.Spec.Template.Image = "nginx@1.0#sylixos"
+				svc.Spec.Template.Resources = &ecsmv1.ResourceRequirements{
+					Limits: map[ecsmv1.ResourceType]string{"memory": "512Mi", "disk": "1Gi"},
+				}
+			},
+		},
+		{
+			name: "static without nodes",
+			mutate: func(svc *ecsmv1.ECSMService) {
+				svc.Spec.DeploymentStrategy = ecsmv1.DeploymentStrategy{Type: ecsmv1.DeploymentStrategyTypeStatic}
+			},
+			wantFields: []string{"spec.deploymentStrategy.nodes"},
+		},
+		{
+			name: "dynamic without replicas and node pool",
+			mutate: func(svc *ecsmv1.ECSMService) {
+				svc.Spec.DeploymentStrategy = ecsmv1.DeploymentStrategy{Type: ecsmv1.DeploymentStrategyTypeDynamic}
+			},
+			wantFields: []string{"spec.deploymentStrategy.replicas", "spec.deploymentStrategy.nodePool"},
+		},
+		{
+			name: "dynamic with zero replicas",
+			mutate: func(svc *ecsmv1.ECSMService) {
+				svc.Spec.DeploymentStrategy.Replicas = int32Ptr(0)
+			},
+			wantFields: []string{"spec.deploymentStrategy.replicas"},
+		},
+		{
+			name: "unknown deployment type",
+			mutate: func(svc *ecsmv1.ECSMService) {
+				svc.Spec.DeploymentStrategy.Type = "Rolling"
+			},
+			wantFields: []string{"spec.deploymentStrategy.type"},
+		},
+		{
+			name: "image without tag",
+			mutate: func(svc *ecsmv1.ECSMService) {
+				svc.Spec.Template.Image = "nginx"
+			},
+			wantFields: []string{"spec.template.image"},
+		},
+		{
+			name: "image with empty os",
+			mutate: func(svc *ecsmv1.ECSMService) {
+				svc.Spec.Template.Image = "nginx@1.0#"
+			},
+			wantFields: []string{"spec.template.image"},
+		},
+		{
+			name: "bad resource limits",
+			mutate: func(svc *ecsmv1.ECSMService) {
+				svc.Spec.Template.Resources = &ecsmv1.ResourceRequirements{
+					Limits: map[ecsmv1.ResourceType]string{"memory": "lots", "cpu": "1"},
+				}
+			},
+			wantFields: []string{"spec.template.resources.limits[memory]", "spec.template.resources.limits[cpu]"},
+		},
+		{
+			name: "negative health check",
+			mutate: func(svc *ecsmv1.ECSMService) {
+				svc.Spec.Template.VSOA = &ecsmv1.VSOASpec{
+					HealthCheck: &ecsmv1.HealthCheckSpec{TimeoutSeconds: -1, FailureThreshold: -3},
+				}
+			},
+			wantFields: []string{"spec.template.vsoa.healthCheck.timeoutSeconds", "spec.template.vsoa.healthCheck.failureThreshold"},
+		},
+		{
+			name: "unknown image pull policy",
+			mutate: func(svc *ecsmv1.ECSMService) {
+				svc.Spec.Template.ImagePullPolicy = "Sometimes"
+			},
+			wantFields: []string{"spec.template.imagePullPolicy"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRegistry(t)
+			svc := newValidTestService("default", "app")
+			tt.mutate(svc)
+
+			_, err := r.CreateService(context.Background(), svc)
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("Expected CreateService to succeed, but got: %v", err)
+				}
+				return
+			}
+			if !errors.IsInvalid(err) {
+				t.Fatalf("Expected 'Invalid' error, but got: %v", err)
+			}
+
+			// 所有字段错误都应该被返回，而不只是第一个
+			var gotFields []string
+			for _, cause := range err.(errors.APIStatus).Status().Details.Causes {
+				gotFields = append(gotFields, cause.Field)
+			}
+			for _, want := range tt.wantFields {
+				if !slices.Contains(gotFields, want) {
+					t.Errorf("Expected an error for field %q, but got errors for %v", want, gotFields)
+				}
+			}
+
+			// 无效的对象不应该被存储
+			if _, err := r.GetService(context.Background(), "default", "app"); !errors.IsNotFound(err) {
+				t.Errorf("Invalid object must not be stored, but Get returned: %v", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_UpdateWithoutChanges(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRegistry(t)
+
+	created, err := r.CreateService(ctx, newValidTestService("default", "app"))
+	if err != nil {
+		t.Fatalf("CreateService failed: %v", err)
+	}
+
+	// 与存储中完全相同的对象（包括未填写、需要默认值的字段）不应该产生写入
+	svc := newValidTestService("default", "app")
+	updated, err := r.UpdateService(ctx, svc)
+	if err != nil {
+		t.Fatalf("UpdateService failed: %v", err)
+	}
+	if updated.ResourceVersion != created.ResourceVersion || updated.Generation != created.Generation {
+		t.Errorf("Expected a no-op update to keep resourceVersion %q and generation %d, but got %q and %d",
+			created.ResourceVersion, created.Generation, updated.ResourceVersion, updated.Generation)
+	}
+}
+
+func TestRegistry_DeleteWithFinalizers(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRegistry(t)
+	const finalizer = "test.ecsm.sh/cleanup"
+
+	if _, err := r.CreateService(ctx, newValidTestService("default", "plain")); err != nil {
+		t.Fatalf("CreateService failed: %v", err)
+	}
+	if _, err := r.CreateService(ctx, newValidTestService("default", "app")); err != nil {
+		t.Fatalf("CreateService failed: %v", err)
+	}
+	if _, err := r.AddServiceFinalizer(ctx, "default", "app", finalizer); err != nil {
+		t.Fatalf("AddServiceFinalizer failed: %v", err)
+	}
+
+	// 没有 finalizer 的对象被立即删除
+	if err := r.DeleteService(ctx, "default", "plain"); err != nil {
+		t.Fatalf("DeleteService failed: %v", err)
+	}
+	if _, err := r.GetService(ctx, "default", "plain"); !errors.IsNotFound(err) {
+		t.Errorf("Expected 'NotFound' error, but got: %v", err)
+	}
+
+	// 有 finalizer 的对象只被标记为删除中
+	if err := r.DeleteService(ctx, "default", "app"); err != nil {
+		t.Fatalf("DeleteService failed: %v", err)
+	}
+	got, err := r.GetService(ctx, "default", "app")
+	if err != nil {
+		t.Fatalf("Expected the object to survive until its finalizers are removed, but got: %v", err)
+	}
+	if got.DeletionTimestamp == nil {
+		t.Errorf("Expected DeletionTimestamp to be set")
+	}
+	if _, err := r.AddServiceFinalizer(ctx, "default", "app", "another"); !errors.IsForbidden(err) {
+		t.Errorf("Expected 'Forbidden' error when adding a finalizer during deletion, but got: %v", err)
+	}
+
+	// 移除最后一个 finalizer 之后对象被真正删除
+	if err := r.RemoveServiceFinalizer(ctx, "default", "app", finalizer); err != nil {
+		t.Fatalf("RemoveServiceFinalizer failed: %v", err)
+	}
+	if _, err := r.GetService(ctx, "default", "app"); !errors.IsNotFound(err) {
+		t.Errorf("Expected 'NotFound' error after the last finalizer is removed, but got: %v", err)
+	}
+}
+
+// interceptDeleteStore 在每次 Delete 之前调用 beforeDelete，用于在 Registry 读取对象之后、删除之前插入并发写入。
+type interceptDeleteStore struct {
+	Store
+	beforeDelete func()
+}
+
+func (s *interceptDeleteStore) Delete(namespace, name string, objToDelete runtime.Object) error {
+	if s.beforeDelete != nil {
+		s.beforeDelete()
+	}
+	return s.Store.Delete(namespace, name, objToDelete)
+}
+
+func TestRegistry_DeleteRacesWithAddFinalizer(t *testing.T) {
+	ctx := context.Background()
+	const finalizer = "test.ecsm.sh/cleanup"
+	fileStore, err := NewFileStore(t.TempDir(), newTestScheme())
+	if err != nil {
+		t.Fatalf("Failed to create FileStore: %v", err)
+	}
+	store := &interceptDeleteStore{Store: fileStore}
+	r := NewRegistry(store)
+
+	if _, err := r.CreateService(ctx, newValidTestService("default", "app")); err != nil {
+		t.Fatalf("CreateService failed: %v", err)
+	}
+
+	// DeleteService 读到没有 finalizer 的对象之后，控制器抢先添加了 finalizer（随后会在 ECSM 上创建服务）。
+	// 删除必须因为版本变化而失败并重试，改为标记删除中，否则 ECSM 上的服务再也不会被清理。
+	store.beforeDelete = func() {
+		store.beforeDelete = nil
+		if _, err := r.AddServiceFinalizer(ctx, "default", "app", finalizer); err != nil {
+			t.Errorf("AddServiceFinalizer failed: %v", err)
+		}
+	}
+	if err := r.DeleteService(ctx, "default", "app"); err != nil {
+		t.Fatalf("DeleteService failed: %v", err)
+	}
+	got, err := r.GetService(ctx, "default", "app")
+	if err != nil {
+		t.Fatalf("Expected the object to survive until its finalizers are removed, but got: %v", err)
+	}
+	if got.DeletionTimestamp == nil || !slices.Contains(got.Finalizers, finalizer) {
+		t.Errorf("Expected the object to be marked for deletion with finalizer %q, got deletionTimestamp %v and finalizers %v",
+			finalizer, got.DeletionTimestamp, got.Finalizers)
+	}
+
+	// 移除最后一个 finalizer 时对象同样只在没有被并发修改的情况下删除
+	store.beforeDelete = func() {
+		store.beforeDelete = nil
+		if _, err := r.SetServiceAnnotation(ctx, "default", "app", "touched", "true"); err != nil {
+			t.Errorf("SetServiceAnnotation failed: %v", err)
+		}
+	}
+	if err := r.RemoveServiceFinalizer(ctx, "default", "app", finalizer); err != nil {
+		t.Fatalf("RemoveServiceFinalizer failed: %v", err)
+	}
+	if _, err := r.GetService(ctx, "default", "app"); !errors.IsNotFound(err) {
+		t.Errorf("Expected 'NotFound' error after the last finalizer is removed, but got: %v", err)
+	}
+}
+
+func TestRegistry_ControllerAnnotationsAreControllerOwned(t *testing.T) {
+	for _, key := range []string{ecsmv1.ECSMServiceIDAnnotation, ecsmv1.ECSMServiceDeleteTransactionAnnotation} {
+		t.Run(key, func(t *testing.T) {
+			ctx := context.Background()
+			r := newTestRegistry(t)
+
+			// 用户不能在创建时写入这个注解
+			svc := newValidTestService("default", "app")
+			svc.Annotations = map[string]string{key: "foreign", "team": "a"}
+			created, err := r.CreateService(ctx, svc)
+			if err != nil {
+				t.Fatalf("CreateService failed: %v", err)
+			}
+			if _, ok := created.Annotations[key]; ok {
+				t.Errorf("Expected %s to be dropped on create, but got annotations %v", key, created.Annotations)
+			}
+
+			// 控制器写入之后，UpdateService 既不能修改也不能删除它
+			if _, err := r.SetServiceAnnotation(ctx, "default", "app", key, "svc-1"); err != nil {
+				t.Fatalf("SetServiceAnnotation failed: %v", err)
+			}
+			for _, annotations := range []map[string]string{
+				{key: "foreign"},
+				nil,
+			} {
+				svc := newValidTestService("default", "app")
+				svc.Annotations = annotations
+				updated, err := r.UpdateService(ctx, svc)
+				if err != nil {
+					t.Fatalf("UpdateService failed: %v", err)
+				}
+				if got := updated.Annotations[key]; got != "svc-1" {
+					t.Errorf("Expected UpdateService with annotations %v to keep %s=svc-1, but got %q", annotations, key, got)
+				}
+			}
+		})
+	}
+}
+
+// interleavingStore 在第一次 Update 之前执行 beforeUpdate，模拟在“读取”和“写入”之间发生的并发写入。
+type interleavingStore struct {
+	Store
+	beforeUpdate func()
+}
+
+func (s *interleavingStore) Update(obj runtime.Object) error {
+	if f := s.beforeUpdate; f != nil {
+		s.beforeUpdate = nil
+		f()
+	}
+	return s.Store.Update(obj)
+}
+
+func TestRegistry_UpdateWithoutResourceVersionKeepsConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir(), newTestScheme())
+	if err != nil {
+		t.Fatalf("Failed to create FileStore: %v", err)
+	}
+	is := &interleavingStore{Store: store}
+	r := NewRegistry(is)
+
+	if _, err := r.CreateService(ctx, newValidTestService("default", "app")); err != nil {
+		t.Fatalf("CreateService failed: %v", err)
+	}
+
+	// spec 更新读取对象之后、写入之前，控制器写入了 status
+	is.beforeUpdate = func() {
+		current, err := r.GetService(ctx, "default", "app")
+		if err != nil {
+			t.Fatalf("GetService failed: %v", err)
+		}
+		current.Status.Replicas = 3
+		if _, err := r.UpdateServiceStatus(ctx, current); err != nil {
+			t.Fatalf("UpdateServiceStatus failed: %v", err)
+		}
+	}
+	svc := newValidTestService("default", "app")
+	svc.Spec.Template.Image = "nginx@2.0"
+	if _, err := r.UpdateService(ctx, svc); err != nil {
+		t.Fatalf("UpdateService without resourceVersion failed: %v", err)
+	}
+
+	got, err := r.GetService(ctx, "default", "app")
+	if err != nil {
+		t.Fatalf("GetService failed: %v", err)
+	}
+	if got.Spec.Template.Image != "nginx@2.0" || got.Status.Replicas != 3 {
+		t.Errorf("Expected both the spec and the status update to survive, got image %q and replicas %d",
+			got.Spec.Template.Image, got.Status.Replicas)
+	}
+
+	// 反过来，status 更新读取对象之后发生的 spec 更新也不能被覆盖
+	is.beforeUpdate = func() {
+		svc := newValidTestService("default", "app")
+		svc.Spec.Template.Image = "nginx@3.0"
+		if _, err := r.UpdateService(ctx, svc); err != nil {
+			t.Fatalf("UpdateService failed: %v", err)
+		}
+	}
+	status := got.DeepCopy()
+	status.ResourceVersion = ""
+	status.Status.Replicas = 4
+	if _, err := r.UpdateServiceStatus(ctx, status); err != nil {
+		t.Fatalf("UpdateServiceStatus without resourceVersion failed: %v", err)
+	}
+
+	got, err = r.GetService(ctx, "default", "app")
+	if err != nil {
+		t.Fatalf("GetService failed: %v", err)
+	}
+	if got.Spec.Template.Image != "nginx@3.0" || got.Status.Replicas != 4 {
+		t.Errorf("Expected both the spec and the status update to survive, got image %q and replicas %d",
+			got.Spec.Template.Image, got.Status.Replicas)
+	}
+}
+
+func TestRegistry_BoltStoreTransactions(t *testing.T) {
+	ctx := context.Background()
+	store := newTestBoltStore(t, filepath.Join(t.TempDir(), "registry.db"))
+	defer store.Close()
+	r := NewRegistry(store)
+	const finalizer = "test.ecsm.sh/cleanup"
+
+	// Registry 的“读取-修改-写入”在 BoltStore 的一个事务中完成
+	if _, err := r.CreateService(ctx, newValidTestService("default", "app")); err != nil {
+		t.Fatalf("CreateService failed: %v", err)
+	}
+	if _, err := r.AddServiceFinalizer(ctx, "default", "app", finalizer); err != nil {
+		t.Fatalf("AddServiceFinalizer failed: %v", err)
+	}
+	if err := r.DeleteService(ctx, "default", "app"); err != nil {
+		t.Fatalf("DeleteService failed: %v", err)
+	}
+	got, err := r.GetService(ctx, "default", "app")
+	if err != nil {
+		t.Fatalf("Expected the object to survive until its finalizers are removed, but got: %v", err)
+	}
+	if got.DeletionTimestamp == nil {
+		t.Errorf("Expected DeletionTimestamp to be set")
+	}
+
+	// 事务中返回的错误不会留下部分写入
+	stale := got.DeepCopy()
+	stale.ResourceVersion = "1"
+	stale.Status.ReadyReplicas = 1
+	if _, err := r.UpdateServiceStatus(ctx, stale); !errors.IsConflict(err) {
+		t.Errorf("Expected 'Conflict' error for a stale resourceVersion, but got: %v", err)
+	}
+
+	if err := r.RemoveServiceFinalizer(ctx, "default", "app", finalizer); err != nil {
+		t.Fatalf("RemoveServiceFinalizer failed: %v", err)
+	}
+	if _, err := r.GetService(ctx, "default", "app"); !errors.IsNotFound(err) {
+		t.Errorf("Expected 'NotFound' error after the last finalizer is removed, but got: %v", err)
+	}
+}
@@ -0,0 +1,268 @@
+// file: pkg/registry/service_validation.go
+
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// setServiceDefaults 为用户没有填写的可选字段填充默认值。
+func setServiceDefaults(service *ecsmv1.ECSMService) {
+	spec := &service.Spec
+
+	if spec.UpgradeStrategy.Type == "" {
+		spec.UpgradeStrategy.Type = ecsmv1.UpgradeStrategyTypeNever
+	}
+	if spec.Template.ImagePullPolicy == "" {
+		spec.Template.ImagePullPolicy = ecsmv1.ImagePullPolicyIfNotPresent
+	}
+	if spec.Template.Hostname == "" {
+		spec.Template.Hostname = service.Name
+	}
+}
+
+// validateService 检查一个（已经填充过默认值的）ECSMService，返回发现的所有错误。
+func validateService(service *ecsmv1.ECSMService) field.ErrorList {
+	allErrs := validateServiceMeta(service, field.NewPath("metadata"))
+
+	specPath := field.NewPath("spec")
+	allErrs = append(allErrs, validateDeploymentStrategy(&service.Spec.DeploymentStrategy, specPath.Child("deploymentStrategy"))...)
+	allErrs = append(allErrs, validateUpgradeStrategy(&service.Spec.UpgradeStrategy, specPath.Child("upgradeStrategy"))...)
+	allErrs = append(allErrs, validateTemplate(&service.Spec.Template, specPath.Child("template"))...)
+	return allErrs
+}
+
+// validateServiceStatus 只检查 status，用于 UpdateServiceStatus。
+func validateServiceStatus(status *ecsmv1.ECSMServiceStatus) field.ErrorList {
+	var allErrs field.ErrorList
+	statusPath := field.NewPath("status")
+
+	if status.Replicas < 0 {
+		allErrs = append(allErrs, field.Invalid(statusPath.Child("replicas"), status.Replicas, "must be greater than or equal to 0"))
+	}
+	if status.ReadyReplicas < 0 {
+		allErrs = append(allErrs, field.Invalid(statusPath.Child("readyReplicas"), status.ReadyReplicas, "must be greater than or equal to 0"))
+	}
+	if status.ObservedGeneration < 0 {
+		allErrs = append(allErrs, field.Invalid(statusPath.Child("observedGeneration"), status.ObservedGeneration, "must be greater than or equal to 0"))
+	}
+	return allErrs
+}
+
+func validateServiceMeta(service *ecsmv1.ECSMService, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if service.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), ""))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(service.Name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), service.Name, msg))
+		}
+	}
+	if service.Namespace != "" {
+		for _, msg := range validation.IsDNS1123Label(service.Namespace) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("namespace"), service.Namespace, msg))
+		}
+	}
+	return allErrs
+}
+
+func validateDeploymentStrategy(ds *ecsmv1.DeploymentStrategy, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	switch ds.Type {
+	case ecsmv1.DeploymentStrategyTypeStatic:
+		if len(ds.Nodes) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("nodes"), "must list at least one node for Static deployment"))
+		}
+		allErrs = append(allErrs, validateNodeNames(ds.Nodes, fldPath.Child("nodes"))...)
+	case ecsmv1.DeploymentStrategyTypeDynamic:
+		if ds.Replicas == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("replicas"), "must be set for Dynamic deployment"))
+		} else if *ds.Replicas < 1 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("replicas"), *ds.Replicas, "must be greater than or equal to 1"))
+		}
+		if len(ds.NodePool) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("nodePool"), "must list at least one node for Dynamic deployment"))
+		}
+		allErrs = append(allErrs, validateNodeNames(ds.NodePool, fldPath.Child("nodePool"))...)
+	case "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("type"), ""))
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), ds.Type, []string{
+			string(ecsmv1.DeploymentStrategyTypeStatic),
+			string(ecsmv1.DeploymentStrategyTypeDynamic),
+		}))
+	}
+	return allErrs
+}
+
+// validateNodeNames 检查节点名非空且不重复。
+func validateNodeNames(nodes []string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := sets.New[string]()
+	for i, node := range nodes {
+		switch {
+		case node == "":
+			allErrs = append(allErrs, field.Required(fldPath.Index(i), ""))
+		case seen.Has(node):
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i), node))
+		}
+		seen.Insert(node)
+	}
+	return allErrs
+}
+
+func validateUpgradeStrategy(us *ecsmv1.UpgradeStrategy, fldPath *field.Path) field.ErrorList {
+	switch us.Type {
+	case ecsmv1.UpgradeStrategyTypeNever, ecsmv1.UpgradeStrategyTypeLarger, ecsmv1.UpgradeStrategyTypeAlways:
+		return nil
+	}
+	return field.ErrorList{field.NotSupported(fldPath.Child("type"), us.Type, []string{
+		string(ecsmv1.UpgradeStrategyTypeNever),
+		string(ecsmv1.UpgradeStrategyTypeLarger),
+		string(ecsmv1.UpgradeStrategyTypeAlways),
+	})}
+}
+
+func validateTemplate(t *ecsmv1.ContainerTemplateSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := validateImageRef(t.Image, fldPath.Child("image"))
+
+	switch t.ImagePullPolicy {
+	case ecsmv1.ImagePullPolicyAlways, ecsmv1.ImagePullPolicyIfNotPresent, ecsmv1.ImagePullPolicyNever:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("imagePullPolicy"), t.ImagePullPolicy, []string{
+			string(ecsmv1.ImagePullPolicyAlways),
+			string(ecsmv1.ImagePullPolicyIfNotPresent),
+			string(ecsmv1.ImagePullPolicyNever),
+		}))
+	}
+
+	envNames := sets.New[string]()
+	for i, env := range t.Env {
+		namePath := fldPath.Child("env").Index(i).Child("name")
+		switch {
+		case env.Name == "":
+			allErrs = append(allErrs, field.Required(namePath, ""))
+		case strings.Contains(env.Name, "="):
+			allErrs = append(allErrs, field.Invalid(namePath, env.Name, "must not contain '='"))
+		case envNames.Has(env.Name):
+			allErrs = append(allErrs, field.Duplicate(namePath, env.Name))
+		}
+		envNames.Insert(env.Name)
+	}
+
+	if t.Resources != nil {
+		allErrs = append(allErrs, validateResources(t.Resources, fldPath.Child("resources"))...)
+	}
+
+	for i, vm := range t.VolumeMounts {
+		vmPath := fldPath.Child("volumeMounts").Index(i)
+		if vm.HostPath == "" {
+			allErrs = append(allErrs, field.Required(vmPath.Child("hostPath"), ""))
+		}
+		if vm.ContainerPath == "" {
+			allErrs = append(allErrs, field.Required(vmPath.Child("containerPath"), ""))
+		}
+	}
+
+	if t.VSOA != nil {
+		allErrs = append(allErrs, validateVSOA(t.VSOA, fldPath.Child("vsoa"))...)
+	}
+
+	if ps := t.PlatformSpecific; ps != nil {
+		switch ps.Action {
+		case "", ecsmv1.ActionTypeRun, ecsmv1.ActionTypeLoad:
+		default:
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("platformSpecific", "action"), ps.Action, []string{
+				string(ecsmv1.ActionTypeRun),
+				string(ecsmv1.ActionTypeLoad),
+			}))
+		}
+	}
+	return allErrs
+}
+
+// validateImageRef 检查镜像引用是否符合 "name@tag" 或 "name@tag#os" 格式。
+func validateImageRef(image string, fldPath *field.Path) field.ErrorList {
+	if image == "" {
+		return field.ErrorList{field.Required(fldPath, "")}
+	}
+
+	const format = "must be in the form 'name@tag' or 'name@tag#os'"
+	if strings.ContainsAny(image, " \t\r\n") {
+		return field.ErrorList{field.Invalid(fldPath, image, "must not contain whitespace")}
+	}
+	nameAndTag, os, hasOS := strings.Cut(image, "#")
+	name, tag, hasTag := strings.Cut(nameAndTag, "@")
+	if !hasTag || name == "" || tag == "" || strings.Contains(tag, "@") {
+		return field.ErrorList{field.Invalid(fldPath, image, format)}
+	}
+	if hasOS && (os == "" || strings.Contains(os, "#")) {
+		return field.ErrorList{field.Invalid(fldPath, image, format)}
+	}
+	return nil
+}
+
+func validateResources(r *ecsmv1.ResourceRequirements, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	limitsPath := fldPath.Child("limits")
+	for name, value := range r.Limits {
+		keyPath := limitsPath.Key(string(name))
+		switch name {
+		case ecsmv1.ResourceTypeMemory, ecsmv1.ResourceTypeDisk:
+		default:
+			allErrs = append(allErrs, field.NotSupported(keyPath, name, []string{
+				string(ecsmv1.ResourceTypeMemory),
+				string(ecsmv1.ResourceTypeDisk),
+			}))
+			continue
+		}
+
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(keyPath, value, fmt.Sprintf("must be a valid quantity: %v", err)))
+			continue
+		}
+		if q.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(keyPath, value, "must be greater than or equal to 0"))
+		}
+	}
+	return allErrs
+}
+
+func validateVSOA(v *ecsmv1.VSOASpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	// 端口为 0 表示由 ECSM 动态分配
+	if v.Port != nil && *v.Port != 0 {
+		for _, msg := range validation.IsValidPortNum(int(*v.Port)) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("port"), *v.Port, msg))
+		}
+	}
+
+	if hc := v.HealthCheck; hc != nil {
+		hcPath := fldPath.Child("healthCheck")
+		for _, f := range []struct {
+			name  string
+			value int32
+		}{
+			{"initialDelaySeconds", hc.InitialDelaySeconds},
+			{"timeoutSeconds", hc.TimeoutSeconds},
+			{"periodSeconds", hc.PeriodSeconds},
+			{"failureThreshold", hc.FailureThreshold},
+		} {
+			if f.value < 0 {
+				allErrs = append(allErrs, field.Invalid(hcPath.Child(f.name), f.value, "must be greater than or equal to 0"))
+			}
+		}
+	}
+	return allErrs
+}
@@ -1,7 +1,10 @@
 package registry
 
 import (
+	"fmt"
+
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 // Store 是我们对持久化层的核心抽象接口。
@@ -21,6 +24,47 @@ type Store interface {
 	// List 列出指定命名空间下某一类型的所有对象，并填充到 listInto 中。
 	List(namespace string, listInto runtime.Object) error
 
-	// Delete 删除一个指定类型的对象。
+	// Delete 删除一个指定类型的对象，objToDelete 用于确定对象类型。
+	// 如果 objToDelete 的 resourceVersion 非空，它必须与存储中的版本一致，否则返回 Conflict 错误；
+	// 为空则表示无条件删除。删除一个不存在的对象不是错误。
 	Delete(namespace, name string, objToDelete runtime.Object) error
+
+	// Watch 监听指定命名空间下某一类型对象的变更，listInto 用于确定对象类型。
+	// resourceVersion 为空时先为现有的每个对象发送 ADDED 事件；
+	// 否则从 List 返回的该版本之后开始发送事件。
+	Watch(namespace string, listInto runtime.Object, resourceVersion string) (watch.Interface, error)
+}
+
+// TransactionalStore 是支持多对象原子事务的 Store。
+type TransactionalStore interface {
+	Store
+
+	// Transaction 在一个事务中执行 fn，fn 通过传入的 Store 进行的所有写入要么全部生效，要么全部不生效。
+	// fn 返回错误时事务回滚，并原样返回该错误。
+	Transaction(fn func(tx Store) error) error
+}
+
+// 可选的存储后端
+const (
+	StoreBackendFile = "file"
+	StoreBackendBolt = "bolt"
+)
+
+// NewStore 根据后端类型创建 Store。path 为空时使用该后端的默认路径：
+// file 后端的 path 是根目录，bolt 后端的 path 是数据库文件。
+func NewStore(backend, path string, scheme *runtime.Scheme) (Store, error) {
+	switch backend {
+	case StoreBackendFile, "":
+		if path == "" {
+			path = DefaultFileStorePath
+		}
+		return NewFileStore(path, scheme)
+	case StoreBackendBolt:
+		if path == "" {
+			path = DefaultBoltStorePath
+		}
+		return NewBoltStore(path, scheme)
+	default:
+		return nil, fmt.Errorf("unknown store backend %q, must be %q or %q", backend, StoreBackendFile, StoreBackendBolt)
+	}
 }
@@ -0,0 +1,404 @@
+// file: pkg/registry/storetest/storetest.go
+
+// Package storetest 提供了 registry.Store 的一致性测试。
+// 每个新的存储后端或包装了 Store 的缓存层，都应该通过 RunConformance 证明它的行为与 FileStore 一致。
+package storetest
+
+import (
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	ecsmv1 "github.com/fx147/ecsm-operator/pkg/apis/ecsm/v1"
+	"github.com/fx147/ecsm-operator/pkg/registry"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// RunConformance 对 newStore 创建的 Store 运行一致性测试。
+//
+// 每个子测试都会调用一次 newStore，返回的 Store 必须是空的，并且它的 Scheme 中注册了 ecsm.sh/v1 的类型。
+// 如果 Store 实现了 io.Closer，子测试结束时会关闭它。
+func RunConformance(t *testing.T, newStore func() registry.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store registry.Store)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"AlreadyExists", testAlreadyExists},
+		{"NotFound", testNotFound},
+		{"DeleteIsIdempotent", testDeleteIsIdempotent},
+		{"NamespaceIsolation", testNamespaceIsolation},
+		{"ListEmptyNamespace", testListEmptyNamespace},
+		{"ResourceVersionConflict", testResourceVersionConflict},
+		{"DeleteResourceVersionConflict", testDeleteResourceVersionConflict},
+		{"ConcurrentWriters", testConcurrentWriters},
+		{"ConcurrentCreate", testConcurrentCreate},
+		{"Watch", testWatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newStore()
+			if closer, ok := store.(io.Closer); ok {
+				t.Cleanup(func() { closer.Close() })
+			}
+			tt.fn(t, store)
+		})
+	}
+}
+
+// newService 创建一个测试用的 ECSMService 对象。
+func newService(namespace, name string) *ecsmv1.ECSMService {
+	return &ecsmv1.ECSMService{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: ecsmv1.SchemeGroupVersion.String(),
+			Kind:       "ECSMService",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name,
+			Labels:      map[string]string{"app": name},
+			Annotations: map[string]string{},
+		},
+	}
+}
+
+func mustCreate(t *testing.T, store registry.Store, svc *ecsmv1.ECSMService) {
+	t.Helper()
+	if err := store.Create(svc); err != nil {
+		t.Fatalf("Create(%s/%s) failed: %v", svc.Namespace, svc.Name, err)
+	}
+}
+
+func testCreateAndGet(t *testing.T, store registry.Store) {
+	svc := newService("default", "app")
+	mustCreate(t, store, svc)
+	if svc.ResourceVersion == "" {
+		t.Errorf("Expected Create to set a resourceVersion on the object")
+	}
+
+	got := &ecsmv1.ECSMService{}
+	if err := store.Get("default", "app", got); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Namespace != "default" || got.Name != "app" || got.Labels["app"] != "app" {
+		t.Errorf("Get returned unexpected object: %s/%s with labels %v", got.Namespace, got.Name, got.Labels)
+	}
+	if got.ResourceVersion != svc.ResourceVersion {
+		t.Errorf("Expected resourceVersion %q from Get, but got %q", svc.ResourceVersion, got.ResourceVersion)
+	}
+}
+
+func testAlreadyExists(t *testing.T, store registry.Store) {
+	mustCreate(t, store, newService("default", "app"))
+
+	if err := store.Create(newService("default", "app")); !errors.IsAlreadyExists(err) {
+		t.Errorf("Expected 'AlreadyExists' error, but got: %v", err)
+	}
+	// 同名对象可以存在于不同的命名空间
+	if err := store.Create(newService("other", "app")); err != nil {
+		t.Errorf("Create in another namespace failed: %v", err)
+	}
+}
+
+func testNotFound(t *testing.T, store registry.Store) {
+	if err := store.Get("default", "missing", &ecsmv1.ECSMService{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected 'NotFound' error from Get, but got: %v", err)
+	}
+	if err := store.Update(newService("default", "missing")); !errors.IsNotFound(err) {
+		t.Errorf("Expected 'NotFound' error from Update, but got: %v", err)
+	}
+
+	mustCreate(t, store, newService("default", "app"))
+	if err := store.Delete("default", "app", &ecsmv1.ECSMService{}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Get("default", "app", &ecsmv1.ECSMService{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected 'NotFound' error after Delete, but got: %v", err)
+	}
+}
+
+func testDeleteIsIdempotent(t *testing.T, store registry.Store) {
+	// 从未存在过的对象、甚至从未使用过的命名空间
+	if err := store.Delete("default", "missing", &ecsmv1.ECSMService{}); err != nil {
+		t.Errorf("Deleting a missing object should succeed, but got: %v", err)
+	}
+
+	mustCreate(t, store, newService("default", "app"))
+	for i := 0; i < 2; i++ {
+		if err := store.Delete("default", "app", &ecsmv1.ECSMService{}); err != nil {
+			t.Errorf("Delete #%d failed: %v", i+1, err)
+		}
+	}
+}
+
+func testNamespaceIsolation(t *testing.T, store registry.Store) {
+	mustCreate(t, store, newService("default", "one"))
+	mustCreate(t, store, newService("default", "two"))
+	mustCreate(t, store, newService("production", "one"))
+
+	list := &ecsmv1.ECSMServiceList{}
+	if err := store.List("default", list); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list.Items) != 2 {
+		t.Errorf("Expected 2 items in namespace 'default', but got %d", len(list.Items))
+	}
+	for _, item := range list.Items {
+		if item.Namespace != "default" {
+			t.Errorf("List of namespace 'default' returned %s/%s", item.Namespace, item.Name)
+		}
+	}
+
+	// 复用同一个 list 对象时，结果不应该包含上一次 List 的元素
+	if err := store.List("", list); err != nil {
+		t.Fatalf("List across namespaces failed: %v", err)
+	}
+	if len(list.Items) != 3 {
+		t.Errorf("Expected 3 items across namespaces, but got %d", len(list.Items))
+	}
+
+	// 删除一个命名空间中的对象不影响另一个命名空间中的同名对象
+	if err := store.Delete("default", "one", &ecsmv1.ECSMService{}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Get("production", "one", &ecsmv1.ECSMService{}); err != nil {
+		t.Errorf("Object in namespace 'production' should survive, but got: %v", err)
+	}
+}
+
+func testListEmptyNamespace(t *testing.T, store registry.Store) {
+	list := &ecsmv1.ECSMServiceList{}
+	if err := store.List("empty", list); err != nil {
+		t.Fatalf("List of an unused namespace failed: %v", err)
+	}
+	if len(list.Items) != 0 {
+		t.Errorf("Expected no items, but got %d", len(list.Items))
+	}
+	if err := store.List("", list); err != nil {
+		t.Fatalf("List of an empty store failed: %v", err)
+	}
+	if len(list.Items) != 0 {
+		t.Errorf("Expected no items, but got %d", len(list.Items))
+	}
+
+	mustCreate(t, store, newService("default", "app"))
+	if err := store.Delete("default", "app", &ecsmv1.ECSMService{}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.List("default", list); err != nil {
+		t.Fatalf("List of an emptied namespace failed: %v", err)
+	}
+	if len(list.Items) != 0 {
+		t.Errorf("Expected no items after Delete, but got %d", len(list.Items))
+	}
+}
+
+func testResourceVersionConflict(t *testing.T, store registry.Store) {
+	svc := newService("default", "app")
+	mustCreate(t, store, svc)
+	created := svc.ResourceVersion
+
+	stale := svc.DeepCopy()
+	svc.Labels["step"] = "current"
+	if err := store.Update(svc); err != nil {
+		t.Fatalf("Update with the current resourceVersion failed: %v", err)
+	}
+	if svc.ResourceVersion == "" || svc.ResourceVersion == created {
+		t.Errorf("Expected Update to change the resourceVersion, but got %q", svc.ResourceVersion)
+	}
+
+	// 基于旧版本的更新返回 Conflict，既不写入存储，也不修改传入的对象
+	stale.Labels["step"] = "stale"
+	if err := store.Update(stale); !errors.IsConflict(err) {
+		t.Errorf("Expected 'Conflict' error for a stale resourceVersion, but got: %v", err)
+	}
+	if stale.ResourceVersion != created {
+		t.Errorf("Stale object's resourceVersion should be left untouched, but got %q", stale.ResourceVersion)
+	}
+	got := &ecsmv1.ECSMService{}
+	if err := store.Get("default", "app", got); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Labels["step"] != "current" || got.ResourceVersion != svc.ResourceVersion {
+		t.Errorf("Stale update must not be persisted, got labels %v at resourceVersion %q", got.Labels, got.ResourceVersion)
+	}
+
+	// resourceVersion 为空表示无条件更新
+	stale.ResourceVersion = ""
+	if err := store.Update(stale); err != nil {
+		t.Errorf("Unconditional update failed: %v", err)
+	}
+}
+
+func testDeleteResourceVersionConflict(t *testing.T, store registry.Store) {
+	svc := newService("default", "app")
+	mustCreate(t, store, svc)
+	stale := svc.DeepCopy()
+	svc.Labels["step"] = "current"
+	if err := store.Update(svc); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// 基于旧版本的删除返回 Conflict，对象保持不变
+	if err := store.Delete("default", "app", stale); !errors.IsConflict(err) {
+		t.Errorf("Expected 'Conflict' error for a stale resourceVersion, but got: %v", err)
+	}
+	if err := store.Get("default", "app", &ecsmv1.ECSMService{}); err != nil {
+		t.Fatalf("Expected the object to survive a stale delete, but got: %v", err)
+	}
+
+	if err := store.Delete("default", "app", svc); err != nil {
+		t.Fatalf("Delete with the current resourceVersion failed: %v", err)
+	}
+	if err := store.Get("default", "app", &ecsmv1.ECSMService{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected 'NotFound' error after Delete, but got: %v", err)
+	}
+}
+
+func testConcurrentWriters(t *testing.T, store registry.Store) {
+	mustCreate(t, store, newService("default", "counter"))
+
+	const writers, incrementsPerWriter = 4, 10
+	var wg sync.WaitGroup
+	errCh := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < incrementsPerWriter; {
+				current := &ecsmv1.ECSMService{}
+				if err := store.Get("default", "counter", current); err != nil {
+					errCh <- err
+					return
+				}
+				if current.Annotations == nil {
+					current.Annotations = map[string]string{}
+				}
+				count, _ := strconv.Atoi(current.Annotations["count"])
+				current.Annotations["count"] = strconv.Itoa(count + 1)
+				err := store.Update(current)
+				if errors.IsConflict(err) {
+					continue
+				}
+				if err != nil {
+					errCh <- err
+					return
+				}
+				n++
+			}
+		}()
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		t.Fatalf("Concurrent writer failed: %v", err)
+	}
+
+	got := &ecsmv1.ECSMService{}
+	if err := store.Get("default", "counter", got); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if want := strconv.Itoa(writers * incrementsPerWriter); got.Annotations["count"] != want {
+		t.Errorf("Lost updates: expected count %s, but got %s", want, got.Annotations["count"])
+	}
+}
+
+func testConcurrentCreate(t *testing.T, store registry.Store) {
+	const creators = 8
+	var wg sync.WaitGroup
+	results := make(chan error, creators)
+	for i := 0; i < creators; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- store.Create(newService("default", "app"))
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	created := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			created++
+		case !errors.IsAlreadyExists(err):
+			t.Errorf("Expected 'AlreadyExists' error from losing creators, but got: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("Expected exactly one successful Create, but got %d", created)
+	}
+}
+
+func testWatch(t *testing.T, store registry.Store) {
+	mustCreate(t, store, newService("default", "before"))
+	list := &ecsmv1.ECSMServiceList{}
+	if err := store.List("default", list); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+
+	svc := newService("default", "app")
+	mustCreate(t, store, svc)
+
+	// 从 List 返回的 resourceVersion 开始 watch，补发之后的写入，但不包括 List 已经看到的对象
+	w, err := store.Watch("default", &ecsmv1.ECSMServiceList{}, list.ResourceVersion)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer w.Stop()
+	expectEvent(t, w, watch.Added, "app")
+
+	mustCreate(t, store, newService("other", "ignored"))
+	svc.Labels["updated"] = "true"
+	if err := store.Update(svc); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	got := expectEvent(t, w, watch.Modified, "app")
+	if got.Labels["updated"] != "true" || got.ResourceVersion != svc.ResourceVersion {
+		t.Errorf("MODIFIED event carries a stale object: labels %v, resourceVersion %q", got.Labels, got.ResourceVersion)
+	}
+	if err := store.Delete("default", "app", &ecsmv1.ECSMService{}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	expectEvent(t, w, watch.Deleted, "app")
+
+	// resourceVersion 为空时，先为当前存在的每个对象发送 ADDED 事件
+	w2, err := store.Watch("default", &ecsmv1.ECSMServiceList{}, "")
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer w2.Stop()
+	expectEvent(t, w2, watch.Added, "before")
+
+	if _, err := store.Watch("default", &ecsmv1.ECSMServiceList{}, "not-a-number"); !errors.IsBadRequest(err) {
+		t.Errorf("Expected 'BadRequest' error for an invalid resourceVersion, but got: %v", err)
+	}
+}
+
+// expectEvent 读取下一个事件，并检查其类型和对象名称。
+func expectEvent(t *testing.T, w watch.Interface, wantType watch.EventType, wantName string) *ecsmv1.ECSMService {
+	t.Helper()
+	select {
+	case e, ok := <-w.ResultChan():
+		if !ok {
+			t.Fatalf("Watch channel closed unexpectedly")
+		}
+		svc, ok := e.Object.(*ecsmv1.ECSMService)
+		if !ok {
+			t.Fatalf("Expected *ECSMService in event, but got %T", e.Object)
+		}
+		if e.Type != wantType || svc.Name != wantName {
+			t.Fatalf("Expected %s event for %q, but got %s for %q", wantType, wantName, e.Type, svc.Name)
+		}
+		return svc
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for %s event for %q", wantType, wantName)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+// file: pkg/registry/watch_cache.go
+
+package registry
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// watchQueueLength 是每个 watcher 的事件缓冲区长度，缓冲区满了的 watcher 会被关闭
+	watchQueueLength = 1000
+	// watchHistoryLength 是为了支持从旧 resourceVersion 继续 watch 而保留的最近事件数量
+	watchHistoryLength = 1000
+)
+
+// historyEvent 是带有序号的 watch.Event，序号就是事件发生时的 resourceVersion
+type historyEvent struct {
+	seq   uint64
+	event watch.Event
+}
+
+// watchCache 把事件分发给所有 watcher，并保留最近的事件以便从某个 resourceVersion 继续 watch。
+// 它本身不保证事件的顺序，调用者必须在自己的锁内按写入顺序调用 emit，并在同一把锁内调用 since 和 watch，
+// 这样 watcher 收到的初始事件和之后的事件之间既不会遗漏也不会重复。
+//
+// emit 从不阻塞：每个 watcher 有自己的缓冲区，缓冲区满了的 watcher 会被关闭，由使用者重新 List 和 Watch。
+// 因此一个读得慢的 watcher 不会拖慢持有存储锁的写入。
+type watchCache struct {
+	mu sync.Mutex
+	// history 中的序号单调不减，但不一定连续（例如其他进程写入的、没有被监听的类型）
+	history []historyEvent
+	// complete 表示 complete 之后的所有事件都还在 history 中
+	complete uint64
+	watchers map[*cacheWatcher]struct{}
+	stopped  bool
+}
+
+// newWatchCache 创建一个 watchCache，rv 是创建时存储的 resourceVersion，此前的事件不可补发。
+func newWatchCache(rv uint64) *watchCache {
+	return &watchCache{
+		complete: rv,
+		watchers: make(map[*cacheWatcher]struct{}),
+	}
+}
+
+// emit 记录并广播一个序号为 seq 的事件，seq 不能小于之前的序号。
+func (c *watchCache) emit(seq uint64, eventType watch.EventType, obj runtime.Object) {
+	event := watch.Event{Type: eventType, Object: obj}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.history = append(c.history, historyEvent{seq: seq, event: event})
+	if n := len(c.history) - watchHistoryLength; n > 0 {
+		c.complete = c.history[n-1].seq
+		c.history = append([]historyEvent(nil), c.history[n:]...)
+	}
+
+	for w := range c.watchers {
+		if !w.keep(event) {
+			continue
+		}
+		select {
+		case w.result <- event:
+		default:
+			klog.InfoS("Closing watcher that is not keeping up with events", "bufferSize", cap(w.result))
+			c.removeLocked(w)
+		}
+	}
+}
+
+// since 返回 resourceVersion 之后发生的所有事件，current 是当前最新的 resourceVersion。
+// 如果 resourceVersion 之后的事件已经不在保留的历史中，返回 ResourceExpired 错误。
+func (c *watchCache) since(resourceVersion string, current uint64) ([]watch.Event, error) {
+	since, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid resourceVersion %q: %v", resourceVersion, err))
+	}
+	if since > current {
+		return nil, errors.NewBadRequest(fmt.Sprintf("resourceVersion %d is newer than the current version %d", since, current))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if since < c.complete {
+		return nil, errors.NewResourceExpired(fmt.Sprintf("too old resource version: %d (%d)", since, c.complete))
+	}
+
+	var events []watch.Event
+	for _, h := range c.history {
+		if h.seq > since {
+			events = append(events, h.event)
+		}
+	}
+	return events, nil
+}
+
+// watch 创建一个 watcher，先发送 initial 中满足 keep 的事件，之后只转发满足 keep 的事件。
+// watchCache 已经关闭时返回一个已经关闭的 watcher。
+func (c *watchCache) watch(initial []watch.Event, keep func(watch.Event) bool) watch.Interface {
+	var kept []watch.Event
+	for _, e := range initial {
+		if keep(e) {
+			kept = append(kept, e)
+		}
+	}
+
+	w := &cacheWatcher{
+		cache:  c,
+		keep:   keep,
+		result: make(chan watch.Event, len(kept)+watchQueueLength),
+	}
+	for _, e := range kept {
+		w.result <- e
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		close(w.result)
+		return w
+	}
+	c.watchers[w] = struct{}{}
+	return w
+}
+
+// shutdown 关闭所有 watcher，之后的 emit 不再产生事件。
+func (c *watchCache) shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	for w := range c.watchers {
+		c.removeLocked(w)
+	}
+}
+
+// reset 关闭所有 watcher 并丢弃保留的历史，rv 及之前的事件不再可补发。
+// 存储发现自己错过了一部分事件时调用它，让使用者重新 List 和 Watch。
+func (c *watchCache) reset(rv uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.history = nil
+	c.complete = rv
+	for w := range c.watchers {
+		c.removeLocked(w)
+	}
+}
+
+// removeLocked 关闭 w 的结果通道，调用者必须持有 c.mu。
+func (c *watchCache) removeLocked(w *cacheWatcher) {
+	if _, ok := c.watchers[w]; !ok {
+		return
+	}
+	delete(c.watchers, w)
+	close(w.result)
+}
+
+// cacheWatcher 是 watchCache 中的一个 watcher，它的结果通道带有缓冲区。
+type cacheWatcher struct {
+	cache  *watchCache
+	keep   func(watch.Event) bool
+	result chan watch.Event
+}
+
+func (w *cacheWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+func (w *cacheWatcher) Stop() {
+	w.cache.mu.Lock()
+	defer w.cache.mu.Unlock()
+	w.cache.removeLocked(w)
+}